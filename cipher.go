@@ -0,0 +1,43 @@
+package HHESoK
+
+// Encryptor is the symmetric encryption interface shared by the HE-friendly ciphers in sym/
+type Encryptor interface {
+	// Encrypt and Decrypt use the fixed DefaultNonce, they are kept for the test vectors
+	Encrypt(plaintext Plaintext) Ciphertext
+	Decrypt(ciphertext Ciphertext) Plaintext
+	// EncryptWithNonce and DecryptWithNonce start the block counter at zero
+	EncryptWithNonce(nonce []byte, plaintext Plaintext) Ciphertext
+	DecryptWithNonce(nonce []byte, ciphertext Ciphertext) Plaintext
+	// EncryptWithCounter and DecryptWithCounter start the block counter at the given block offset
+	EncryptWithCounter(nonce []byte, counter uint64, plaintext Plaintext) Ciphertext
+	DecryptWithCounter(nonce []byte, counter uint64, ciphertext Ciphertext) Plaintext
+	// EncryptRandomNonce samples a fresh nonce and prepends it to the ciphertext as field elements
+	EncryptRandomNonce(plaintext Plaintext) (Ciphertext, error)
+	// DecryptRandomNonce fails with ErrCiphertextLength on a ciphertext shorter than a nonce
+	DecryptRandomNonce(ciphertext Ciphertext) (Plaintext, error)
+}
+
+// SymmetricCipher is the common interface satisfied by every HE-friendly cipher in sym/
+type SymmetricCipher interface {
+	// GetKeySize returns the number of field elements in the secret key
+	GetKeySize() int
+	// GetKeyStreamSize returns the number of field elements produced by one KeyStream call
+	GetKeyStreamSize() int
+	// GetModulus returns the plaintext modulus of the cipher
+	GetModulus() uint64
+	// KeyStream generates one block of key stream for the given nonce and counter
+	KeyStream(nonce []byte, counter []byte) Block
+	// NewEncryptor returns a new encryptor bound to the cipher instance
+	NewEncryptor() Encryptor
+	// ShallowCopy returns a cipher sharing the key and parameters but with its own
+	// internal state, so that copies can generate key streams concurrently
+	ShallowCopy() SymmetricCipher
+}
+
+// NoisyCipher is a SymmetricCipher whose encryption adds fresh noise to the key stream, such as
+// Rubato. Its KeyStream is the noiseless part, which is deterministic
+type NoisyCipher interface {
+	SymmetricCipher
+	// AddNoise adds fresh noise to every element of vec
+	AddNoise(vec []uint64)
+}
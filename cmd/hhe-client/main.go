@@ -0,0 +1,323 @@
+// Command hhe-client runs a session of the transciphering service of hhe-server: it uploads
+// the parameters, the evaluation keys and the encrypted symmetric key, streams records of
+// random data encrypted with HERA, Rubato or PASTA, decrypts the results and reports the error.
+//
+//	hhe-client -server http://127.0.0.1:8750 -scheme rubato -records 4 -affine 0.5,0.25
+//
+// The parameters are the ones of a test case of the symmetric cipher with a fresh random key,
+// on a ring of degree 2^logn. The default -logn 11 is not secure, -logn 0 keeps the ring of
+// the RtF parameter set. PASTA takes the ring of its preset, -case indexes the presets, and
+// its results are exact, the server must run without computation.
+package main
+
+import (
+	"HHESoK"
+	"HHESoK/hhe/hera"
+	hhePasta "HHESoK/hhe/pasta"
+	"HHESoK/hhe/rtf"
+	"HHESoK/hhe/rubato"
+	"HHESoK/hhe/service"
+	"HHESoK/rtf_ckks_integration/utils"
+	symHera "HHESoK/sym/hera"
+	symRubato "HHESoK/sym/rubato"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// client is the data owner side of a pipeline
+type client interface {
+	MarshalParameters() ([]byte, error)
+	GenEvaluationKeys() ([]byte, error)
+	EncryptSymKey() ([]byte, error)
+	// EncryptRecord encrypts random data and returns its artifact and the maximum error of
+	// the result artifact of the record
+	EncryptRecord() (symCt []byte, check func(result []byte) (float64, error), err error)
+}
+
+// rtfPipeline is the data owner side of an RtF pipeline, see hera.Client and rubato.Client
+type rtfPipeline interface {
+	FVSlots() int
+	MarshalParameters() ([]byte, error)
+	GenEvaluationKeys() ([]byte, error)
+	EncryptSymKey() ([]byte, error)
+	NewNonce() []byte
+	EncryptData(nonce []byte, data [][]float64) ([]byte, error)
+	DecryptResult(data []byte) ([][]float64, error)
+}
+
+// rtfClient encrypts records of rows data vectors in [-1, 1] and checks the results against
+// the computation x -> a*x+b
+type rtfClient struct {
+	rtfPipeline
+	rows int
+	a, b float64
+}
+
+func (c *rtfClient) EncryptRecord() ([]byte, func([]byte) (float64, error), error) {
+	data := make([][]float64, c.rows)
+	for s := range data {
+		data[s] = make([]float64, c.FVSlots())
+		for i := range data[s] {
+			data[s][i] = utils.RandFloat64(-1, 1)
+		}
+	}
+	// a nonce of the current epoch, a random nonce would close the nonces below it
+	symCt, err := c.EncryptData(c.NewNonce(), data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return symCt, func(res []byte) (float64, error) {
+		values, err := c.DecryptResult(res)
+		if err != nil {
+			return 0, err
+		}
+		maxErr := 0.0
+		for s := range data {
+			for i, x := range data[s] {
+				maxErr = math.Max(maxErr, math.Abs(c.a*x+c.b-values[s][i]))
+			}
+		}
+		return maxErr, nil
+	}, nil
+}
+
+// pastaClient encrypts records of size random elements of Z_t, the results are exact
+type pastaClient struct {
+	*hhePasta.Client
+	size    int
+	modulus uint64
+}
+
+func (c *pastaClient) GenEvaluationKeys() ([]byte, error) {
+	return c.Client.GenEvaluationKeys(c.size)
+}
+
+func (c *pastaClient) EncryptRecord() ([]byte, func([]byte) (float64, error), error) {
+	data, err := randomKey(c.size, c.modulus)
+	if err != nil {
+		return nil, nil, err
+	}
+	symCt, err := c.EncryptData(HHESoK.NewNonce(), HHESoK.Plaintext(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	return symCt, func(res []byte) (float64, error) {
+		values, err := c.DecryptResult(res)
+		if err != nil {
+			return 0, err
+		}
+		if len(values) != len(data) {
+			return 0, fmt.Errorf("got %d elements, want %d", len(values), len(data))
+		}
+		// the distance in Z_t, centered
+		maxErr := 0.0
+		for i, x := range data {
+			d := (values[i] + c.modulus - x) % c.modulus
+			maxErr = math.Max(maxErr, float64(min(d, c.modulus-d)))
+		}
+		return maxErr, nil
+	}, nil
+}
+
+func main() {
+	serverURL := flag.String("server", "http://127.0.0.1:8750", "URL of hhe-server")
+	id := flag.String("id", fmt.Sprintf("hhe-client-%d", os.Getpid()), "client ID of the session")
+	scheme := flag.String("scheme", hera.Scheme, "symmetric cipher, hera, rubato or pasta")
+	testCase := flag.Int("case", -1, "test case of the cipher, -1 for the default one")
+	logN := flag.Int("logn", 11, "ring degree of the RtF parameters, 0 for the parameter set")
+	records := flag.Int("records", 2, "number of records to stream")
+	affine := flag.String("affine", "", "computation a,b configured on the server, to check the results")
+	flag.Parse()
+
+	a, b, err := parseAffine(*affine)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c, err := newClient(*scheme, *testCase, *logN, a, b)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger := HHESoK.NewLogger(HHESoK.DEBUG)
+	session := service.NewClient(*serverURL, *id)
+
+	start := time.Now()
+	for _, step := range []struct {
+		name     string
+		artifact func() ([]byte, error)
+		upload   func([]byte) error
+	}{
+		{"parameters", c.MarshalParameters, session.Open},
+		{"evaluation keys", c.GenEvaluationKeys, session.SetEvaluationKeys},
+		{"encrypted key", c.EncryptSymKey, session.SetSymKeyCiphertext},
+	} {
+		data, err := step.artifact()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = step.upload(data); err != nil {
+			log.Fatalf("cannot upload the %s: %v", step.name, err)
+		}
+		logger.PrintFormatted("uploaded the %s (%d bytes)", step.name, len(data))
+	}
+	logger.PrintFormatted("session %s set up in %s", session.ID(), time.Since(start))
+
+	symCts := make([][]byte, *records)
+	checks := make([]func([]byte) (float64, error), *records)
+	for r := range symCts {
+		if symCts[r], checks[r], err = c.EncryptRecord(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	start = time.Now()
+	results, err := session.Transcipher(symCts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger.PrintFormatted("transciphered %d records in %s", len(results), time.Since(start))
+
+	for r, res := range results {
+		maxErr, err := checks[r](res)
+		if err != nil {
+			log.Fatal(err)
+		}
+		logger.PrintFormatted("record %d: max error %e (log2 %.2f)", r, maxErr, math.Log2(maxErr))
+	}
+	if err = session.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newClient returns the client of a test case of the scheme with a random key, a and b are
+// the computation configured on the server
+func newClient(scheme string, testCase, logN int, a, b float64) (client, error) {
+	switch scheme {
+	case hera.Scheme:
+		if testCase < 0 {
+			testCase = 4 + symHera.HR128AS
+		}
+		if testCase >= len(symHera.TestVector) {
+			return nil, fmt.Errorf("no HERA test case %d", testCase)
+		}
+		tc := symHera.TestVector[testCase]
+		modDown := rtf.HeraModDown128[tc.FVParamIndex]
+		if tc.Params.Rounds == 4 {
+			modDown = rtf.HeraModDown80[tc.FVParamIndex]
+		}
+		lit, modDown, err := ring(rtf.HeraParams[tc.FVParamIndex], tc.Params.GetModulus(), modDown, tc.Radix, logN)
+		if err != nil {
+			return nil, err
+		}
+		key, err := randomKey(tc.Params.BlockSize, tc.Params.GetModulus())
+		if err != nil {
+			return nil, err
+		}
+		c, err := hera.NewClientFromLiteral(lit, tc.Params, modDown, tc.Radix, key)
+		if err != nil {
+			return nil, err
+		}
+		return &rtfClient{rtfPipeline: c, rows: tc.Params.BlockSize, a: a, b: b}, nil
+	case rubato.Scheme:
+		if testCase < 0 {
+			testCase = 0
+		}
+		if testCase >= len(symRubato.TestsVector) {
+			return nil, fmt.Errorf("no Rubato test case %d", testCase)
+		}
+		tc := symRubato.TestsVector[testCase]
+		// the Rubato mod-down presets use the radix 2
+		lit, modDown, err := ring(rtf.RubatoParams[0], tc.Params.GetModulus(), rtf.RubatoModDown[tc.FVParamIndex], 2, logN)
+		if err != nil {
+			return nil, err
+		}
+		key, err := randomKey(tc.Params.GetBlockSize(), tc.Params.GetModulus())
+		if err != nil {
+			return nil, err
+		}
+		c, err := rubato.NewClientFromLiteral(lit, tc.Params, modDown, key)
+		if err != nil {
+			return nil, err
+		}
+		return &rtfClient{rtfPipeline: c, rows: tc.Params.GetBlockSize() - 4, a: a, b: b}, nil
+	case hhePasta.Scheme:
+		if a != 1 || b != 0 {
+			return nil, fmt.Errorf("PASTA has no computation, -affine must be empty")
+		}
+		if testCase < 0 {
+			testCase = 0
+		}
+		presets := hhePasta.Presets()
+		if testCase >= len(presets) {
+			return nil, fmt.Errorf("no PASTA preset %d", testCase)
+		}
+		set := presets[testCase]
+		key, err := randomKey(set.SymParams.KeySize, set.SymParams.GetModulus())
+		if err != nil {
+			return nil, err
+		}
+		c, err := hhePasta.NewClient(set.Params, set.SymParams, key)
+		if err != nil {
+			return nil, err
+		}
+		// two blocks per record, the second one is partial
+		size := 2*set.SymParams.GetBlockSize() - 1
+		return &pastaClient{Client: c, size: size, modulus: set.SymParams.GetModulus()}, nil
+	}
+	return nil, fmt.Errorf("unknown scheme %q", scheme)
+}
+
+// ring moves lit to the ring of degree 2^logN, the slots to coefficients transform of a
+// smaller ring is shorter and drops no modulus
+func ring(lit rtf.ParametersLiteral, plainModulus uint64, modDown rtf.ModDown, radix, logN int) (rtf.ParametersLiteral, rtf.ModDown, error) {
+	lit.PlainModulus = plainModulus
+	if logN == 0 || logN == lit.LogN {
+		return lit, modDown, nil
+	}
+	lit = rtf.TestParams(lit, logN)
+	params, err := rtf.NewParametersFromLiteral(lit)
+	if err != nil {
+		return lit, modDown, err
+	}
+	modDown.StCModDown = make([]int, params.SlotsToCoeffsDepth(radix))
+	return lit, modDown, nil
+}
+
+// randomKey samples a key of size elements of Z_t
+func randomKey(size int, t uint64) (HHESoK.Key, error) {
+	key := make(HHESoK.Key, size)
+	bound := new(big.Int).SetUint64(t)
+	for i := range key {
+		k, err := rand.Int(rand.Reader, bound)
+		if err != nil {
+			return nil, err
+		}
+		key[i] = k.Uint64()
+	}
+	return key, nil
+}
+
+// parseAffine parses the flag a,b, an empty flag is the identity
+func parseAffine(s string) (a, b float64, err error) {
+	if s == "" {
+		return 1, 0, nil
+	}
+	aStr, bStr, ok := strings.Cut(s, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid -affine %q, want a,b", s)
+	}
+	if a, err = strconv.ParseFloat(aStr, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid -affine %q: %w", s, err)
+	}
+	if b, err = strconv.ParseFloat(bStr, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid -affine %q: %w", s, err)
+	}
+	return a, b, nil
+}
@@ -0,0 +1,71 @@
+// Command hhe-server runs the transciphering service of package service on localhost. The
+// sessions are kept in memory, keyed by client ID, and lost when the server stops.
+//
+//	hhe-server -addr 127.0.0.1:8750 -affine 0.5,0.25
+//
+// With -affine a,b the server returns a*x + b for every transciphered value x instead of x.
+package main
+
+import (
+	"HHESoK/hhe/rtf"
+	"HHESoK/hhe/service"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8750", "loopback address to listen on")
+	affine := flag.String("affine", "", "computation a,b evaluated on the transciphered data")
+	flag.Parse()
+
+	if err := checkLoopback(*addr); err != nil {
+		log.Fatal(err)
+	}
+	compute, err := parseAffine(*affine)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("hhe-server listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, service.NewHandler(compute)))
+}
+
+// checkLoopback rejects the addresses that are not on the loopback interface, the service
+// has no authentication and holds the sessions in memory
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("%s is not a loopback address", addr)
+	}
+	return nil
+}
+
+// parseAffine parses the flag a,b, an empty flag is no computation
+func parseAffine(s string) (rtf.Computation, error) {
+	if s == "" {
+		return nil, nil
+	}
+	aStr, bStr, ok := strings.Cut(s, ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid -affine %q, want a,b", s)
+	}
+	a, err := strconv.ParseFloat(aStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -affine %q: %w", s, err)
+	}
+	b, err := strconv.ParseFloat(bStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -affine %q: %w", s, err)
+	}
+	return rtf.Affine(a, b), nil
+}
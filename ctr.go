@@ -0,0 +1,62 @@
+package HHESoK
+
+// CTREncrypt adds the key stream of cipher to plaintext in CTR mode: block b of GetKeyStreamSize
+// elements is masked by KeyStream(nonce, CounterBytes(counter+b)) and the last block is truncated.
+// A NoisyCipher then adds fresh noise to the ciphertext
+func CTREncrypt(cipher SymmetricCipher, nonce []byte, counter uint64, plaintext Plaintext) Ciphertext {
+	modulus := cipher.GetModulus()
+	ciphertext := make(Ciphertext, len(plaintext))
+	ctrBlocks(cipher, nonce, counter, len(plaintext), func(i int, ks uint64) {
+		ciphertext[i] = (plaintext[i] + ks) % modulus
+	})
+	if noisy, ok := cipher.(NoisyCipher); ok {
+		noisy.AddNoise(ciphertext)
+	}
+	return ciphertext
+}
+
+// CTRDecrypt subtracts the key stream of cipher from ciphertext, it inverts CTREncrypt up to the
+// noise of a NoisyCipher
+func CTRDecrypt(cipher SymmetricCipher, nonce []byte, counter uint64, ciphertext Ciphertext) Plaintext {
+	modulus := cipher.GetModulus()
+	plaintext := make(Plaintext, len(ciphertext))
+	ctrBlocks(cipher, nonce, counter, len(ciphertext), func(i int, ks uint64) {
+		plaintext[i] = (ciphertext[i] + modulus - ks) % modulus
+	})
+	return plaintext
+}
+
+// CTREncryptRandomNonce encrypts plaintext with CTREncrypt under a fresh random nonce and counter 0,
+// the nonce is prepended to the ciphertext as NonceElements field elements
+func CTREncryptRandomNonce(cipher SymmetricCipher, plaintext Plaintext) (Ciphertext, error) {
+	nonce := NewNonce()
+	elements, err := NonceToElements(nonce, cipher.GetModulus())
+	if err != nil {
+		return nil, err
+	}
+	return append(Ciphertext(elements), CTREncrypt(cipher, nonce, 0, plaintext)...), nil
+}
+
+// CTRDecryptRandomNonce decrypts a ciphertext produced by CTREncryptRandomNonce, it fails with
+// ErrCiphertextLength on a ciphertext shorter than a nonce
+func CTRDecryptRandomNonce(cipher SymmetricCipher, ciphertext Ciphertext) (Plaintext, error) {
+	modulus := cipher.GetModulus()
+	nonce, err := ElementsToNonce(ciphertext, modulus)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := NonceElements(modulus)
+	return CTRDecrypt(cipher, nonce, 0, ciphertext[n:]), nil
+}
+
+// ctrBlocks generates the key stream of the size elements block by block and calls mask on
+// every element with its key stream value
+func ctrBlocks(cipher SymmetricCipher, nonce []byte, counter uint64, size int, mask func(i int, ks uint64)) {
+	ksSize := cipher.GetKeyStreamSize()
+	for b := 0; b*ksSize < size; b++ {
+		keyStream := cipher.KeyStream(nonce, CounterBytes(counter+uint64(b)))
+		for i := b * ksSize; i < (b+1)*ksSize && i < size; i++ {
+			mask(i, keyStream[i-b*ksSize])
+		}
+	}
+}
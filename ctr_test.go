@@ -0,0 +1,65 @@
+package HHESoK
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// counterCipher is a toy cipher whose key stream element i of block counter is counter*10+i
+type counterCipher struct{}
+
+func (counterCipher) GetKeySize() int       { return 0 }
+func (counterCipher) GetKeyStreamSize() int { return 3 }
+func (counterCipher) GetModulus() uint64    { return 17 }
+func (counterCipher) NewEncryptor() Encryptor {
+	return nil
+}
+func (c counterCipher) ShallowCopy() SymmetricCipher { return c }
+
+func (counterCipher) KeyStream(_ []byte, counter []byte) Block {
+	ctr := binary.BigEndian.Uint64(counter)
+	return Block{ctr * 10 % 17, (ctr*10 + 1) % 17, (ctr*10 + 2) % 17}
+}
+
+// TestCTR checks that the blocks of a partial last block use the counters from the offset on
+func TestCTR(t *testing.T) {
+	plaintext := Plaintext{16, 0, 1, 2, 3, 4, 5}
+	ciphertext := CTREncrypt(counterCipher{}, nil, 2, plaintext)
+	want := Ciphertext{(16 + 20) % 17, (0 + 21) % 17, (1 + 22) % 17, (2 + 30) % 17, (3 + 31) % 17, (4 + 32) % 17, (5 + 40) % 17}
+	for i := range want {
+		if ciphertext[i] != want[i] {
+			t.Fatalf("element %d: got %d, want %d", i, ciphertext[i], want[i])
+		}
+	}
+	decrypted := CTRDecrypt(counterCipher{}, nil, 2, ciphertext)
+	for i := range plaintext {
+		if decrypted[i] != plaintext[i] {
+			t.Fatalf("element %d decrypted to %d, want %d", i, decrypted[i], plaintext[i])
+		}
+	}
+}
+
+// unitCipher is counterCipher over Z_1, which cannot carry a nonce
+type unitCipher struct{ counterCipher }
+
+func (unitCipher) GetModulus() uint64 { return 1 }
+
+// TestCTRRandomNonceErrors checks that the random nonce helpers return errors instead of panicking
+func TestCTRRandomNonceErrors(t *testing.T) {
+	if _, err := CTREncryptRandomNonce(unitCipher{}, Plaintext{0}); !errors.Is(err, ErrInvalidParameters) {
+		t.Fatalf("CTREncryptRandomNonce over Z_1: got %v, want %v", err, ErrInvalidParameters)
+	}
+	if _, err := CTRDecryptRandomNonce(unitCipher{}, Ciphertext{0}); !errors.Is(err, ErrInvalidParameters) {
+		t.Fatalf("CTRDecryptRandomNonce over Z_1: got %v, want %v", err, ErrInvalidParameters)
+	}
+	for _, modulus := range []uint64{2, 17, 65537} {
+		n, err := NonceElements(modulus)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ElementsToNonce(make([]uint64, n-1), modulus); !errors.Is(err, ErrCiphertextLength) {
+			t.Fatalf("ElementsToNonce with %d elements mod %d: got %v, want %v", n-1, modulus, err, ErrCiphertextLength)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package encoding
+
+import (
+	"HHESoK"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// maxChunkElements bounds the number of field elements packed together by the radix packing
+const maxChunkElements = 8
+
+// paddingByte starts the ISO/IEC 7816-4 padding appended before encoding
+const paddingByte = 0x80
+
+// ByteCodec packs bytes into field elements below the modulus. Chunks of ChunkBytes bytes
+// are read as big endian integers and written in radix q to ChunkElements elements, the
+// chunk sizes are chosen to waste the fewest bits. The data is padded with 0x80 and zeros
+// to a whole chunk so that Decode recovers the exact length.
+type ByteCodec struct {
+	modulus       uint64
+	chunkBytes    int
+	chunkElements int
+}
+
+// NewByteCodec returns the byte codec for the modulus, moduli below 256 spread a byte over
+// several elements, e.g. one bit per element in Z_2
+func NewByteCodec(modulus uint64) (*ByteCodec, error) {
+	if modulus < 2 {
+		return nil, fmt.Errorf("%w: encoding: modulus %d is too small to carry a byte", HHESoK.ErrInvalidParameters, modulus)
+	}
+	codec := &ByteCodec{modulus: modulus}
+	q := new(big.Int).SetUint64(modulus)
+	qn := big.NewInt(1)
+	for n := 1; n <= maxChunkElements; n++ {
+		qn.Mul(qn, q)
+		// largest m such that 256^m <= q^n
+		m := (qn.BitLen() - 1) / 8
+		if codec.chunkBytes == 0 || m*codec.chunkElements > codec.chunkBytes*n {
+			codec.chunkBytes, codec.chunkElements = m, n
+		}
+	}
+	return codec, nil
+}
+
+// ChunkBytes returns the number of bytes packed in one chunk
+func (codec *ByteCodec) ChunkBytes() int {
+	return codec.chunkBytes
+}
+
+// ChunkElements returns the number of field elements of one chunk
+func (codec *ByteCodec) ChunkElements() int {
+	return codec.chunkElements
+}
+
+// EncodedSize returns the number of field elements produced by Encode for size bytes
+func (codec *ByteCodec) EncodedSize(size int) int {
+	return (size/codec.chunkBytes + 1) * codec.chunkElements
+}
+
+// Encode pads data and packs it into field elements
+func (codec *ByteCodec) Encode(data []byte) HHESoK.Plaintext {
+	numChunk := len(data)/codec.chunkBytes + 1
+	padded := make([]byte, numChunk*codec.chunkBytes)
+	copy(padded, data)
+	padded[len(data)] = paddingByte
+
+	plaintext := make(HHESoK.Plaintext, numChunk*codec.chunkElements)
+	if codec.chunkElements == 1 {
+		for c := 0; c < numChunk; c++ {
+			var x uint64
+			for _, b := range padded[c*codec.chunkBytes : (c+1)*codec.chunkBytes] {
+				x = x<<8 | uint64(b)
+			}
+			plaintext[c] = x
+		}
+		return plaintext
+	}
+
+	q := new(big.Int).SetUint64(codec.modulus)
+	x, digit := new(big.Int), new(big.Int)
+	for c := 0; c < numChunk; c++ {
+		x.SetBytes(padded[c*codec.chunkBytes : (c+1)*codec.chunkBytes])
+		for e := 0; e < codec.chunkElements; e++ {
+			x.QuoRem(x, q, digit)
+			plaintext[c*codec.chunkElements+e] = digit.Uint64()
+		}
+	}
+	return plaintext
+}
+
+// Decode unpacks the field elements produced by Encode and removes the padding
+func (codec *ByteCodec) Decode(plaintext HHESoK.Plaintext) ([]byte, error) {
+	if len(plaintext) == 0 || len(plaintext)%codec.chunkElements != 0 {
+		return nil, fmt.Errorf("encoding: %d elements is not a positive multiple of the chunk size %d", len(plaintext), codec.chunkElements)
+	}
+	numChunk := len(plaintext) / codec.chunkElements
+	padded := make([]byte, numChunk*codec.chunkBytes)
+
+	if codec.chunkElements == 1 {
+		for c, x := range plaintext {
+			if x >= codec.modulus || x>>(8*codec.chunkBytes) != 0 {
+				return nil, errors.New("encoding: chunk does not decode to bytes")
+			}
+			for b := codec.chunkBytes - 1; b >= 0; b-- {
+				padded[c*codec.chunkBytes+b] = byte(x)
+				x >>= 8
+			}
+		}
+		return unpad(padded)
+	}
+
+	q := new(big.Int).SetUint64(codec.modulus)
+	x, digit := new(big.Int), new(big.Int)
+	for c := 0; c < numChunk; c++ {
+		x.SetInt64(0)
+		for e := codec.chunkElements - 1; e >= 0; e-- {
+			element := plaintext[c*codec.chunkElements+e]
+			if element >= codec.modulus {
+				return nil, errors.New("encoding: element is not reduced modulo the modulus")
+			}
+			x.Mul(x, q).Add(x, digit.SetUint64(element))
+		}
+		if (x.BitLen()+7)/8 > codec.chunkBytes {
+			return nil, errors.New("encoding: chunk does not decode to bytes")
+		}
+		x.FillBytes(padded[c*codec.chunkBytes : (c+1)*codec.chunkBytes])
+	}
+	return unpad(padded)
+}
+
+// unpad strips the zeros and the 0x80 marker appended by Encode
+func unpad(padded []byte) ([]byte, error) {
+	end := len(padded) - 1
+	for end >= 0 && padded[end] == 0 {
+		end--
+	}
+	if end < 0 || padded[end] != paddingByte {
+		return nil, errors.New("encoding: invalid padding")
+	}
+	return padded[:end], nil
+}
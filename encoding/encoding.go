@@ -0,0 +1,48 @@
+// Package encoding converts application data to and from the field elements of the
+// Z_p stream ciphers in sym/, the codecs only depend on the plaintext modulus so they
+// work with any HHESoK.SymmetricCipher
+package encoding
+
+import "HHESoK"
+
+// EncryptBytes encodes data with the byte codec of the cipher and encrypts it under nonce
+func EncryptBytes(cipher HHESoK.SymmetricCipher, nonce []byte, data []byte) (HHESoK.Ciphertext, error) {
+	codec, err := NewByteCodec(cipher.GetModulus())
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewEncryptor().EncryptWithNonce(nonce, codec.Encode(data)), nil
+}
+
+// DecryptBytes decrypts a ciphertext produced by EncryptBytes and decodes the original bytes,
+// the decryption must be exact so it does not apply to Rubato, use FixedPointCodec instead
+func DecryptBytes(cipher HHESoK.SymmetricCipher, nonce []byte, ciphertext HHESoK.Ciphertext) ([]byte, error) {
+	codec, err := NewByteCodec(cipher.GetModulus())
+	if err != nil {
+		return nil, err
+	}
+	return codec.Decode(cipher.NewEncryptor().DecryptWithNonce(nonce, ciphertext))
+}
+
+// EncryptReals encodes values with a FixedPointCodec and encrypts them under nonce
+func EncryptReals(cipher HHESoK.SymmetricCipher, scale float64, nonce []byte, values []float64) (HHESoK.Ciphertext, error) {
+	codec, err := NewFixedPointCodec(cipher.GetModulus(), scale)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := codec.Encode(values)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewEncryptor().EncryptWithNonce(nonce, plaintext), nil
+}
+
+// DecryptReals decrypts a ciphertext produced by EncryptReals, for Rubato the encryption
+// noise shows up as an error of a few multiples of 1/scale
+func DecryptReals(cipher HHESoK.SymmetricCipher, scale float64, nonce []byte, ciphertext HHESoK.Ciphertext) ([]float64, error) {
+	codec, err := NewFixedPointCodec(cipher.GetModulus(), scale)
+	if err != nil {
+		return nil, err
+	}
+	return codec.Decode(cipher.NewEncryptor().DecryptWithNonce(nonce, ciphertext))
+}
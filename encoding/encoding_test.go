@@ -0,0 +1,195 @@
+package encoding
+
+import (
+	"HHESoK"
+	"HHESoK/rtf_ckks_integration/utils"
+	"HHESoK/sym"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testModuli covers the PASTA, HERA and Rubato plaintext moduli and a 64-bit prime
+var testModuli = []uint64{
+	65537,
+	25166081,
+	33292289,
+	268042241,
+	8088322049,
+	1096486890805657601,
+	0xffffffffffffffc5,
+}
+
+func randomBytes(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(utils.RandUint64())
+	}
+	return data
+}
+
+// smallModuli covers small rings such as Z_2, which spread a byte over several elements
+var smallModuli = []uint64{2, 3, 16}
+
+func TestByteCodec(t *testing.T) {
+	for _, q := range append(smallModuli, testModuli...) {
+		codec, err := NewByteCodec(q)
+		require.NoError(t, err)
+		t.Run(fmt.Sprintf("Modulus=%d/Chunk=%dB:%d", q, codec.ChunkBytes(), codec.ChunkElements()), func(t *testing.T) {
+			for _, size := range []int{0, 1, codec.ChunkBytes() - 1, codec.ChunkBytes(), codec.ChunkBytes() + 1, 1000} {
+				data := randomBytes(size)
+				plaintext := codec.Encode(data)
+				require.Len(t, plaintext, codec.EncodedSize(size))
+				for _, x := range plaintext {
+					assert.Less(t, x, q)
+				}
+				decoded, err := codec.Decode(plaintext)
+				require.NoError(t, err)
+				assert.Equal(t, data, decoded)
+			}
+
+			// all-zero and all-0x80 messages must not be confused with the padding
+			for _, b := range []byte{0, paddingByte} {
+				data := make([]byte, 2*codec.ChunkBytes())
+				for i := range data {
+					data[i] = b
+				}
+				decoded, err := codec.Decode(codec.Encode(data))
+				require.NoError(t, err)
+				assert.Equal(t, data, decoded)
+			}
+
+			_, err := codec.Decode(make(HHESoK.Plaintext, codec.ChunkElements()))
+			assert.Error(t, err)
+			_, err = codec.Decode(HHESoK.Plaintext{})
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("RadixPacking", func(t *testing.T) {
+		// a 60-bit modulus carries more than 7 bytes per element once elements are packed together
+		codec, err := NewByteCodec(1096486890805657601)
+		require.NoError(t, err)
+		assert.Greater(t, float64(codec.ChunkBytes())/float64(codec.ChunkElements()), 7.0)
+	})
+}
+
+func TestIntCodec(t *testing.T) {
+	for _, q := range testModuli {
+		codec, err := NewIntCodec(q)
+		require.NoError(t, err)
+		t.Run(fmt.Sprintf("Modulus=%d", q), func(t *testing.T) {
+			bound := int64(codec.Bound())
+			values := []int64{0, 1, -1, bound, -bound, 12345 % bound, -(12345 % bound)}
+			plaintext, err := codec.Encode(values)
+			require.NoError(t, err)
+			for _, x := range plaintext {
+				assert.Less(t, x, q)
+			}
+			decoded, err := codec.Decode(plaintext)
+			require.NoError(t, err)
+			assert.Equal(t, values, decoded)
+
+			if codec.Bound() < math.MaxInt64 {
+				_, err = codec.Encode([]int64{bound + 1})
+				assert.Error(t, err)
+				_, err = codec.Encode([]int64{-bound - 1})
+				assert.Error(t, err)
+			}
+			_, err = codec.Decode(HHESoK.Plaintext{q})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFixedPointCodec(t *testing.T) {
+	for _, q := range testModuli {
+		scale := float64(q) / 1024
+		codec, err := NewFixedPointCodec(q, scale)
+		require.NoError(t, err)
+		t.Run(fmt.Sprintf("Modulus=%d/Scale=%g", q, scale), func(t *testing.T) {
+			values := make([]float64, 100)
+			for i := range values {
+				values[i] = utils.RandFloat64(-100, 100)
+			}
+			plaintext, err := codec.Encode(values)
+			require.NoError(t, err)
+			decoded, err := codec.Decode(plaintext)
+			require.NoError(t, err)
+			for i := range values {
+				assert.InDelta(t, values[i], decoded[i], 0.5/scale+1e-12*math.Abs(values[i]))
+			}
+
+			_, err = codec.Encode([]float64{600})
+			assert.Error(t, err)
+			_, err = codec.Encode([]float64{math.NaN()})
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("RtF", func(t *testing.T) {
+		codec, err := NewRtFFixedPointCodec(33292289, 32)
+		require.NoError(t, err)
+		assert.Equal(t, float64(33292289)/32, codec.Scale())
+	})
+}
+
+func TestCodecErrors(t *testing.T) {
+	_, err := NewByteCodec(1)
+	assert.ErrorIs(t, err, HHESoK.ErrInvalidParameters)
+	_, err = NewIntCodec(2)
+	assert.ErrorIs(t, err, HHESoK.ErrInvalidParameters)
+	for _, scale := range []float64{0, -1, math.Inf(1), math.NaN()} {
+		_, err = NewFixedPointCodec(65537, scale)
+		assert.ErrorIs(t, err, HHESoK.ErrInvalidParameters)
+	}
+	_, err = NewFixedPointCodec(2, 1)
+	assert.ErrorIs(t, err, HHESoK.ErrInvalidParameters)
+}
+
+// TestCiphers encrypts bytes and reals with every registered cipher
+func TestCiphers(t *testing.T) {
+	for _, entry := range sym.Entries() {
+		t.Run(fmt.Sprintf("Cipher=%s/ParamSet=%s", entry.Name, entry.ParamSet), func(t *testing.T) {
+			probe, err := entry.New(make(HHESoK.Key, entry.KeySize))
+			require.NoError(t, err)
+			key := make(HHESoK.Key, entry.KeySize)
+			for i := range key {
+				key[i] = utils.RandUint64() % probe.GetModulus()
+			}
+			cipher, err := entry.New(key)
+			require.NoError(t, err)
+			nonce := HHESoK.NewNonce()
+
+			// the byte codec needs an exact key stream
+			if entry.NoiseBound == 0 {
+				data := randomBytes(3*cipher.GetKeyStreamSize() + 5)
+				ciphertext, err := EncryptBytes(cipher, nonce, data)
+				require.NoError(t, err)
+				decrypted, err := DecryptBytes(cipher, nonce, ciphertext)
+				require.NoError(t, err)
+				assert.Equal(t, data, decrypted)
+			}
+
+			// a fixed-point scale needs a large modulus
+			if cipher.GetModulus() < 256 {
+				return
+			}
+			scale := float64(cipher.GetModulus()) / 64
+			values := make([]float64, 2*cipher.GetKeyStreamSize()+3)
+			for i := range values {
+				values[i] = utils.RandFloat64(-1, 1)
+			}
+			ciphertext, err := EncryptReals(cipher, scale, nonce, values)
+			require.NoError(t, err)
+			decrypted, err := DecryptReals(cipher, scale, nonce, ciphertext)
+			require.NoError(t, err)
+			for i := range values {
+				assert.InDelta(t, values[i], decrypted[i], float64(entry.NoiseBound+1)/scale+1e-15)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package encoding
+
+import (
+	"HHESoK"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// IntCodec maps signed integers to field elements with the centered representation,
+// v is encoded as v mod q and decoded back into [-(q-1)/2, (q-1)/2]
+type IntCodec struct {
+	modulus uint64
+	bound   uint64
+}
+
+// NewIntCodec returns the signed integer codec for the modulus
+func NewIntCodec(modulus uint64) (*IntCodec, error) {
+	if modulus < 3 {
+		return nil, fmt.Errorf("%w: encoding: modulus %d is too small to carry a signed integer", HHESoK.ErrInvalidParameters, modulus)
+	}
+	return &IntCodec{modulus: modulus, bound: (modulus - 1) / 2}, nil
+}
+
+// Bound returns the largest absolute value accepted by Encode
+func (codec *IntCodec) Bound() uint64 {
+	return codec.bound
+}
+
+// Encode maps every value to Z_q, it fails if a value does not fit the centered range
+func (codec *IntCodec) Encode(values []int64) (HHESoK.Plaintext, error) {
+	plaintext := make(HHESoK.Plaintext, len(values))
+	for i, v := range values {
+		if v >= 0 {
+			if uint64(v) > codec.bound {
+				return nil, fmt.Errorf("encoding: value %d at index %d exceeds the bound %d", v, i, codec.bound)
+			}
+			plaintext[i] = uint64(v)
+		} else {
+			// -v overflows for math.MinInt64, compute the magnitude in uint64
+			abs := uint64(-(v + 1)) + 1
+			if abs > codec.bound {
+				return nil, fmt.Errorf("encoding: value %d at index %d exceeds the bound %d", v, i, codec.bound)
+			}
+			plaintext[i] = codec.modulus - abs
+		}
+	}
+	return plaintext, nil
+}
+
+// Decode lifts every element to the centered range
+func (codec *IntCodec) Decode(plaintext HHESoK.Plaintext) ([]int64, error) {
+	values := make([]int64, len(plaintext))
+	for i, x := range plaintext {
+		if x >= codec.modulus {
+			return nil, errors.New("encoding: element is not reduced modulo the modulus")
+		}
+		if x > codec.bound {
+			values[i] = -int64(codec.modulus - x)
+		} else {
+			values[i] = int64(x)
+		}
+	}
+	return values, nil
+}
+
+// FixedPointCodec maps reals to field elements as round(x * scale) with the centered
+// representation, so the precision is 1/scale and |x| must stay below (q-1)/(2*scale)
+type FixedPointCodec struct {
+	ints  *IntCodec
+	scale float64
+}
+
+// NewFixedPointCodec returns the fixed-point codec for the modulus and scale
+func NewFixedPointCodec(modulus uint64, scale float64) (*FixedPointCodec, error) {
+	if !(scale > 0) || math.IsInf(scale, 0) {
+		return nil, fmt.Errorf("%w: encoding: fixed-point scale %g", HHESoK.ErrInvalidParameters, scale)
+	}
+	ints, err := NewIntCodec(modulus)
+	if err != nil {
+		return nil, err
+	}
+	return &FixedPointCodec{ints: ints, scale: scale}, nil
+}
+
+// NewRtFFixedPointCodec returns the fixed-point codec used by the HERA and Rubato HHE
+// paths, the scale is modulus / messageRatio as in HEHera and HERubato
+func NewRtFFixedPointCodec(modulus uint64, messageRatio float64) (*FixedPointCodec, error) {
+	return NewFixedPointCodec(modulus, float64(modulus)/messageRatio)
+}
+
+// Scale returns the fixed-point scale
+func (codec *FixedPointCodec) Scale() float64 {
+	return codec.scale
+}
+
+// Encode rounds every value to the nearest multiple of 1/scale, halves away from zero
+func (codec *FixedPointCodec) Encode(values []float64) (HHESoK.Plaintext, error) {
+	bound := float64(codec.ints.bound)
+	scaled := make([]int64, len(values))
+	for i, v := range values {
+		s := math.Round(v * codec.scale)
+		if math.IsNaN(s) || math.Abs(s) > bound || math.Abs(s) >= math.MaxInt64 {
+			return nil, fmt.Errorf("encoding: value %g at index %d does not fit the modulus with scale %g", v, i, codec.scale)
+		}
+		scaled[i] = int64(s)
+	}
+	return codec.ints.Encode(scaled)
+}
+
+// Decode returns the reals represented by the field elements
+func (codec *FixedPointCodec) Decode(plaintext HHESoK.Plaintext) ([]float64, error) {
+	scaled, err := codec.ints.Decode(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float64, len(scaled))
+	for i, s := range scaled {
+		values[i] = float64(s) / codec.scale
+	}
+	return values, nil
+}
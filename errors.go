@@ -0,0 +1,22 @@
+package HHESoK
+
+import "errors"
+
+// Errors returned by the sym and hhe packages, the returned errors wrap one of them with the
+// details of the failure and are matched with errors.Is
+var (
+	// ErrInvalidParameters reports parameters that cannot instantiate a cipher or an HE pipeline
+	ErrInvalidParameters = errors.New("invalid parameters")
+	// ErrKeyLength reports a key of the wrong number of elements or with elements out of range
+	ErrKeyLength = errors.New("invalid key length")
+	// ErrCiphertextLength reports a ciphertext that is too short for its encoding
+	ErrCiphertextLength = errors.New("invalid ciphertext length")
+	// ErrSlotCapacity reports data or blocks that do not fit the slots of the HE ciphertexts
+	ErrSlotCapacity = errors.New("insufficient slot capacity")
+	// ErrInsufficientLevel reports a ciphertext without enough moduli left for the evaluation
+	ErrInsufficientLevel = errors.New("insufficient level")
+	// ErrMissingGaloisKey reports an evaluation that needs a Galois key that was not generated
+	ErrMissingGaloisKey = errors.New("missing Galois key")
+	// ErrKeyEpoch reports a nonce outside the epochs of the registered keys or an invalid key rotation
+	ErrKeyEpoch = errors.New("invalid key epoch")
+)
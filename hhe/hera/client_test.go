@@ -0,0 +1,223 @@
+package hera
+
+import (
+	"HHESoK"
+	"HHESoK/hhe/wire"
+	"HHESoK/rtf_ckks_integration/utils"
+	"HHESoK/sym/hera"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestClientServer runs the client and the server of the pipeline on the RtF test ring,
+// the two roles only exchange byte buffers
+func TestClientServer(t *testing.T) {
+	tc := hera.TestVector[4+hera.HR128AS]
+	params := testParams(t, tc)
+
+	// client: keys and artifacts
+	client, err := NewClientFromLiteral(params.ParametersLiteral, tc.Params, testModDown(params, tc), tc.Radix, tc.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paramsBuf := new(bytes.Buffer)
+	evkBuf := new(bytes.Buffer)
+	symKeyBuf := new(bytes.Buffer)
+	symCtBuf := new(bytes.Buffer)
+	writeArtifact(t, paramsBuf)(client.MarshalParameters())
+	writeArtifact(t, evkBuf)(client.GenEvaluationKeys())
+	writeArtifact(t, symKeyBuf)(client.EncryptSymKey())
+
+	data := make([][]float64, tc.Params.BlockSize)
+	for s := range data {
+		data[s] = make([]float64, client.FVSlots())
+		for i := range data[s] {
+			data[s][i] = utils.RandFloat64(-1, 1)
+		}
+	}
+	writeArtifact(t, symCtBuf)(client.EncryptData(HHESoK.NewNonce(), data))
+
+	// server: everything is read from the buffers
+	server, err := NewServer(paramsBuf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = server.SetEvaluationKeys(evkBuf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if err = server.SetSymKeyCiphertext(symKeyBuf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = server.Transcipher(symKeyBuf.Bytes()); !errors.Is(err, wire.ErrKind) {
+		t.Fatalf("Transcipher of the key artifact: got %v, want %v", err, wire.ErrKind)
+	}
+	resBuf := new(bytes.Buffer)
+	writeArtifact(t, resBuf)(server.Transcipher(symCtBuf.Bytes()))
+
+	// client: decrypt the CKKS result
+	values, err := client.DecryptResult(resBuf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != len(data) {
+		t.Fatalf("got %d state elements, want %d", len(values), len(data))
+	}
+	for s := range data {
+		if maxErr := maxError(data[s], values[s]); maxErr > 1e-3 {
+			t.Fatalf("state element %d: max error %e", s, maxErr)
+		}
+	}
+}
+
+// TestKeyRotation rotates the HERA key twice under the same HE keys and transciphers the data
+// of the epochs interleaved, the data of the first epoch masked under an earlier nonce included.
+// The closed epochs refuse new data and the retired ones are not transciphered anymore
+func TestKeyRotation(t *testing.T) {
+	tc := hera.TestVector[4+hera.HR128AS]
+	params := testParams(t, tc)
+	client, err := NewClientFromLiteral(params.ParametersLiteral, tc.Params, testModDown(params, tc), tc.Radix, tc.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := NewServer(mustArtifact(t)(client.MarshalParameters()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = server.SetEvaluationKeys(mustArtifact(t)(client.GenEvaluationKeys())); err != nil {
+		t.Fatal(err)
+	}
+	if err = server.SetSymKeyCiphertext(mustArtifact(t)(client.EncryptSymKey())); err != nil {
+		t.Fatal(err)
+	}
+
+	// a record is encrypted in every epoch, the last record under the first key with a nonce
+	// reserved before the one of the first record
+	const epochs = 3
+	data := make([][][]float64, epochs+1)
+	for r := range data {
+		data[r] = make([][]float64, tc.Params.BlockSize)
+		for s := range data[r] {
+			data[r][s] = make([]float64, client.FVSlots())
+			for i := range data[r][s] {
+				data[r][s][i] = utils.RandFloat64(-1, 1)
+			}
+		}
+	}
+	records := make([][]byte, len(data))
+	var rotations [][]byte
+	var reserved []byte
+	for epoch := 0; epoch < epochs; epoch++ {
+		if epoch > 0 {
+			key := make(HHESoK.Key, len(tc.Key))
+			for i := range key {
+				key[i] = (tc.Key[i] + uint64(epoch)) % tc.Params.GetModulus()
+			}
+			rotations = append(rotations, mustArtifact(t)(client.RotateKey(key)))
+		} else {
+			reserved = client.NewNonce()
+		}
+		records[epoch] = mustArtifact(t)(client.EncryptData(client.NewNonce(), data[epoch]))
+		if epoch == 0 {
+			records[epochs] = mustArtifact(t)(client.EncryptData(reserved, data[epochs]))
+		}
+	}
+	if _, err = client.EncryptData(reserved, data[epochs]); !errors.Is(err, HHESoK.ErrKeyEpoch) {
+		t.Fatalf("EncryptData under a closed epoch: got %v, want %v", err, HHESoK.ErrKeyEpoch)
+	}
+
+	for _, rotation := range rotations {
+		if err = server.SetSymKeyCiphertext(rotation); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = server.SetSymKeyCiphertext(rotations[0]); !errors.Is(err, HHESoK.ErrKeyEpoch) {
+		t.Fatalf("SetSymKeyCiphertext of a registered key: got %v, want %v", err, HHESoK.ErrKeyEpoch)
+	}
+	if _, err = client.RotateKey(tc.Key[1:]); !errors.Is(err, HHESoK.ErrKeyLength) {
+		t.Fatalf("RotateKey with a short key: got %v, want %v", err, HHESoK.ErrKeyLength)
+	}
+	if got := server.Epochs(); len(got) != epochs {
+		t.Fatalf("server has %d epochs, want %d", len(got), epochs)
+	}
+	for i, epoch := range server.Epochs() {
+		if epoch != client.epochs[i] || epoch.ID != uint64(i) {
+			t.Fatalf("epoch %d: server %+v, client %+v", i, epoch, client.epochs[i])
+		}
+	}
+
+	for _, r := range []int{1, 3, 2, 0} {
+		values, err := client.DecryptResult(mustArtifact(t)(server.Transcipher(records[r])))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for s := range data[r] {
+			if maxErr := maxError(data[r][s], values[s]); maxErr > 1e-3 {
+				t.Fatalf("record %d, element %d: max error %e", r, s, maxErr)
+			}
+		}
+	}
+
+	below := client.epochs[1].First
+	client.RetireEpochs(below)
+	server.RetireEpochs(below)
+	if len(server.symKeyCt) != epochs-1 || len(client.keys) != epochs-1 || server.Epochs()[0] != client.epochs[0] || client.epochs[0].ID != 1 {
+		t.Fatalf("epochs after the retirement: server %+v, client %+v", server.Epochs(), client.epochs)
+	}
+	for _, r := range []int{0, epochs} {
+		if _, err = server.Transcipher(records[r]); !errors.Is(err, HHESoK.ErrKeyEpoch) {
+			t.Fatalf("Transcipher of record %d of a retired epoch: got %v, want %v", r, err, HHESoK.ErrKeyEpoch)
+		}
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	tc := hera.TestVector[4+hera.HR128AS]
+	params := testParams(t, tc)
+	modDown := testModDown(params, tc)
+
+	if _, err := NewClientFromLiteral(params.ParametersLiteral, tc.Params, modDown, tc.Radix, tc.Key[1:]); !errors.Is(err, HHESoK.ErrKeyLength) {
+		t.Fatalf("NewClient with a short key: got %v, want %v", err, HHESoK.ErrKeyLength)
+	}
+	client, err := NewClientFromLiteral(params.ParametersLiteral, tc.Params, modDown, tc.Radix, tc.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.fvHera.EncKey(tc.Key[1:]); !errors.Is(err, HHESoK.ErrKeyLength) {
+		t.Fatalf("EncKey with a short key: got %v, want %v", err, HHESoK.ErrKeyLength)
+	}
+	kCt, err := client.fvHera.EncKey(tc.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonces := HHESoK.BlockNonces(HHESoK.NewNonce(), 0, client.FVSlots())
+	if _, err = client.fvHera.Crypt(nonces[1:], kCt, modDown.CipherModDown); !errors.Is(err, HHESoK.ErrSlotCapacity) {
+		t.Fatalf("Crypt with missing nonces: got %v, want %v", err, HHESoK.ErrSlotCapacity)
+	}
+	if _, err = client.fvHera.Crypt(nonces, kCt, modDown.CipherModDown[1:]); !errors.Is(err, HHESoK.ErrInvalidParameters) {
+		t.Fatalf("Crypt with a short mod-down: got %v, want %v", err, HHESoK.ErrInvalidParameters)
+	}
+	if err = client.fvHera.Reset(params.MaxLevel() + 1); !errors.Is(err, HHESoK.ErrInsufficientLevel) {
+		t.Fatalf("Reset beyond the last level: got %v, want %v", err, HHESoK.ErrInsufficientLevel)
+	}
+}
+
+// writeArtifact returns a function that writes the output of an artifact constructor to buf
+func writeArtifact(t *testing.T, buf *bytes.Buffer) func([]byte, error) {
+	return func(data []byte, err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(data)
+	}
+}
+
+// mustArtifact returns a function that returns the output of an artifact constructor
+func mustArtifact(t *testing.T) func([]byte, error) []byte {
+	return func(data []byte, err error) []byte {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+}
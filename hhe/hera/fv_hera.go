@@ -0,0 +1,368 @@
+package hera
+
+import (
+	"HHESoK"
+	"HHESoK/hhe/rtf"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+	"golang.org/x/crypto/sha3"
+)
+
+// MFVHera evaluates the HERA key stream of FVSlots blocks in the BFV slots, state element
+// st of block i is in slot i of the ciphertext st
+type MFVHera interface {
+	Crypt(nonces [][]byte, kCt []*rlwe.Ciphertext, heraModDown []int) ([]*rlwe.Ciphertext, error)
+	CryptNoModSwitch(nonces [][]byte, kCt []*rlwe.Ciphertext) ([]*rlwe.Ciphertext, error)
+	Reset(nbInitModDown int) error
+	EncKey(key []uint64) (res []*rlwe.Ciphertext, err error)
+	ShallowCopy() MFVHera
+}
+
+type mfvHera struct {
+	numRound      int
+	slots         int
+	nbInitModDown int
+
+	params    rtf.Parameters
+	encoder   *bgv.Encoder
+	encryptor *rlwe.Encryptor
+	evaluator *bgv.Evaluator
+
+	icCt []*rlwe.Ciphertext // encrypted initial states
+	stCt []*rlwe.Ciphertext
+	mkCt []*rlwe.Ciphertext
+	rc   [][][]uint64 // RoundConstants[round][state][slot]
+	xof  []sha3.ShakeHash
+}
+
+// NewMFVHera returns the HERA evaluator of the lattigo v6 RtF pipeline, the evaluator must
+// hold the relinearization key, nbInitModDown moduli are dropped from the fresh states and keys
+func NewMFVHera(numRound int, params rtf.Parameters, encoder *bgv.Encoder, encryptor *rlwe.Encryptor, evaluator *bgv.Evaluator, nbInitModDown int) (MFVHera, error) {
+	if numRound < 1 {
+		return nil, fmt.Errorf("%w: HERA with %d rounds", HHESoK.ErrInvalidParameters, numRound)
+	}
+	hera := new(mfvHera)
+
+	hera.numRound = numRound
+	hera.slots = params.FVSlots()
+
+	hera.params = params
+	hera.encoder = encoder
+	hera.encryptor = encryptor
+	hera.evaluator = evaluator
+
+	hera.allocateState()
+
+	if err := hera.Reset(nbInitModDown); err != nil {
+		return nil, err
+	}
+	return hera, nil
+}
+
+// MustNewMFVHera is NewMFVHera panicking on invalid parameters
+func MustNewMFVHera(numRound int, params rtf.Parameters, encoder *bgv.Encoder, encryptor *rlwe.Encryptor, evaluator *bgv.Evaluator, nbInitModDown int) MFVHera {
+	hera, err := NewMFVHera(numRound, params, encoder, encryptor, evaluator, nbInitModDown)
+	HHESoK.HandleError(err)
+	return hera
+}
+
+// allocateState allocates the buffers of the state, the round constants and the XOFs
+func (hera *mfvHera) allocateState() {
+	hera.stCt = make([]*rlwe.Ciphertext, 16)
+	hera.mkCt = make([]*rlwe.Ciphertext, 16)
+	hera.xof = make([]sha3.ShakeHash, hera.slots)
+
+	hera.rc = make([][][]uint64, hera.numRound+1)
+	for r := 0; r <= hera.numRound; r++ {
+		hera.rc[r] = make([][]uint64, 16)
+		for st := 0; st < 16; st++ {
+			hera.rc[r][st] = make([]uint64, hera.slots)
+		}
+	}
+}
+
+// ShallowCopy returns an evaluator sharing the parameters and the encrypted initial states of
+// hera but with its own state, encoder and evaluator buffers, so that copies can evaluate
+// key streams concurrently
+func (hera *mfvHera) ShallowCopy() MFVHera {
+	cp := &mfvHera{
+		numRound:      hera.numRound,
+		slots:         hera.slots,
+		nbInitModDown: hera.nbInitModDown,
+		params:        hera.params,
+		encoder:       hera.encoder.ShallowCopy(),
+		evaluator:     hera.evaluator.ShallowCopy(),
+		icCt:          hera.icCt,
+	}
+	if hera.encryptor != nil {
+		cp.encryptor = hera.encryptor.ShallowCopy()
+	}
+	cp.allocateState()
+	return cp
+}
+
+// Reset encrypts the initial states again and drops nbInitModDown moduli
+func (hera *mfvHera) Reset(nbInitModDown int) (err error) {
+	if nbInitModDown < 0 || nbInitModDown > hera.params.MaxLevel() {
+		return fmt.Errorf("%w: %d moduli dropped from the states, only %d available", HHESoK.ErrInsufficientLevel,
+			nbInitModDown, hera.params.MaxLevel())
+	}
+	hera.nbInitModDown = nbInitModDown
+	hera.icCt = make([]*rlwe.Ciphertext, 16)
+
+	state := make([]uint64, hera.slots)
+	for i := 0; i < 16; i++ {
+		for j := 0; j < hera.slots; j++ {
+			state[j] = uint64(i + 1) // ic = 1, ..., 16
+		}
+		if hera.icCt[i], err = hera.encryptSlots(state); err != nil {
+			return err
+		}
+	}
+	return
+}
+
+// EncKey encrypts every key element in all the slots of its own ciphertext
+func (hera *mfvHera) EncKey(key []uint64) (res []*rlwe.Ciphertext, err error) {
+	if len(key) != 16 {
+		return nil, fmt.Errorf("%w: got %d elements, want 16", HHESoK.ErrKeyLength, len(key))
+	}
+	res = make([]*rlwe.Ciphertext, 16)
+
+	dupKey := make([]uint64, hera.slots)
+	for i := 0; i < 16; i++ {
+		for j := 0; j < hera.slots; j++ {
+			dupKey[j] = key[i]
+		}
+		if res[i], err = hera.encryptSlots(dupKey); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// encryptSlots encrypts one value per BFV slot at the initial level of the states
+func (hera *mfvHera) encryptSlots(values []uint64) (*rlwe.Ciphertext, error) {
+	pt := hera.params.NewPlaintext(hera.params.MaxLevel())
+	if err := hera.encoder.Encode(rtf.EmbedVector(values, hera.slots, hera.params.N()), pt); err != nil {
+		return nil, err
+	}
+	ct, err := hera.encryptor.EncryptNew(pt)
+	if err != nil {
+		return nil, err
+	}
+	return ct, rtf.ModSwitchMany(hera.evaluator, ct, hera.nbInitModDown)
+}
+
+// init computes the round constants of every slot and brings the key to the level of the states
+func (hera *mfvHera) init(nonces [][]byte, kCt []*rlwe.Ciphertext) (err error) {
+	for st := 0; st < 16; st++ {
+		hera.stCt[st] = hera.icCt[st].CopyNew()
+		hera.mkCt[st] = kCt[st].CopyNew()
+	}
+
+	slots := hera.slots
+	for i := 0; i < slots; i++ {
+		hera.xof[i] = sha3.NewShake256()
+		_, _ = hera.xof[i].Write(nonces[i])
+	}
+
+	t := hera.params.PlainModulus
+	for r := 0; r <= hera.numRound; r++ {
+		for st := 0; st < 16; st++ {
+			for slot := 0; slot < slots; slot++ {
+				if hera.rc[r][st][slot], err = rtf.SampleZqx(hera.xof[slot], t); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for st := 0; st < 16; st++ {
+		nbSwitch := hera.mkCt[st].Level() - hera.stCt[st].Level()
+		if nbSwitch > 0 {
+			if err := rtf.ModSwitchMany(hera.evaluator, hera.mkCt[st], nbSwitch); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// check checks that there is one nonce per slot and one ciphertext per key element
+func (hera *mfvHera) check(nonces [][]byte, kCt []*rlwe.Ciphertext) error {
+	if len(nonces) != hera.slots {
+		return fmt.Errorf("%w: got %d nonces for %d slots", HHESoK.ErrSlotCapacity, len(nonces), hera.slots)
+	}
+	if len(kCt) != 16 {
+		return fmt.Errorf("%w: got %d encrypted key elements, want 16", HHESoK.ErrKeyLength, len(kCt))
+	}
+	return nil
+}
+
+// CryptNoModSwitch computes the key stream without modulus switching
+func (hera *mfvHera) CryptNoModSwitch(nonces [][]byte, kCt []*rlwe.Ciphertext) (res []*rlwe.Ciphertext, err error) {
+	if err = hera.check(nonces, kCt); err != nil {
+		return nil, err
+	}
+	if err = hera.evaluate(nonces, kCt, nil); err != nil {
+		return nil, err
+	}
+	return hera.stCt, nil
+}
+
+// Crypt computes the key stream under the homomorphically encrypted key kCt with the
+// modulus switching given in heraModDown, heraModDown[0] must be the nbInitModDown of the states
+func (hera *mfvHera) Crypt(nonces [][]byte, kCt []*rlwe.Ciphertext, heraModDown []int) (res []*rlwe.Ciphertext, err error) {
+	if err = hera.check(nonces, kCt); err != nil {
+		return nil, err
+	}
+	if err = HHESoK.CheckModDown(heraModDown, hera.numRound, hera.nbInitModDown, hera.params.MaxLevel()); err != nil {
+		return nil, err
+	}
+	if err = hera.evaluate(nonces, kCt, heraModDown); err != nil {
+		return nil, err
+	}
+	return hera.stCt, nil
+}
+
+// evaluate runs the rounds of the key stream on the states, heraModDown is nil without
+// modulus switching
+func (hera *mfvHera) evaluate(nonces [][]byte, kCt []*rlwe.Ciphertext, heraModDown []int) (err error) {
+	modSwitch := func(r int) error {
+		if heraModDown == nil {
+			return nil
+		}
+		return hera.modSwitch(heraModDown[r])
+	}
+	if err = hera.init(nonces, kCt); err != nil {
+		return err
+	}
+	if err = hera.addRoundKey(0); err != nil {
+		return err
+	}
+	for r := 1; r < hera.numRound; r++ {
+		if err = hera.linLayer(); err != nil {
+			return err
+		}
+		if err = hera.cube(); err != nil {
+			return err
+		}
+		if err = modSwitch(r); err != nil {
+			return err
+		}
+		if err = hera.addRoundKey(r); err != nil {
+			return err
+		}
+	}
+	if err = hera.linLayer(); err != nil {
+		return err
+	}
+	if err = hera.cube(); err != nil {
+		return err
+	}
+	if err = modSwitch(hera.numRound); err != nil {
+		return err
+	}
+	if err = hera.linLayer(); err != nil {
+		return err
+	}
+	return hera.addRoundKey(hera.numRound)
+}
+
+func (hera *mfvHera) modSwitch(nbSwitch int) error {
+	if nbSwitch <= 0 {
+		return nil
+	}
+	for st := 0; st < 16; st++ {
+		if err := rtf.ModSwitchMany(hera.evaluator, hera.stCt[st], nbSwitch); err != nil {
+			return err
+		}
+		if err := rtf.ModSwitchMany(hera.evaluator, hera.mkCt[st], nbSwitch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRoundKey adds key * rc to the state, the round constants are plaintexts of scale 1
+func (hera *mfvHera) addRoundKey(round int) error {
+	ev := hera.evaluator
+	n := hera.params.N()
+	for st := 0; st < 16; st++ {
+		rk, err := ev.MulNew(hera.mkCt[st], rtf.EmbedVector(hera.rc[round][st], hera.slots, n))
+		if err != nil {
+			return err
+		}
+		if err = ev.Add(hera.stCt[st], rk, hera.stCt[st]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linLayer applies MixColumns then MixRows
+func (hera *mfvHera) linLayer() error {
+	for col := 0; col < 4; col++ {
+		if err := hera.mix(col, 4); err != nil {
+			return err
+		}
+	}
+	for row := 0; row < 4; row++ {
+		if err := hera.mix(4*row, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mix multiplies the 4 states start + i*stride by the circulant matrix (2, 3, 1, 1),
+// y_i = sum + x_i + 2 * x_(i+1)
+func (hera *mfvHera) mix(start, stride int) error {
+	ev := hera.evaluator
+	var x [4]*rlwe.Ciphertext
+	for i := range x {
+		x[i] = hera.stCt[start+i*stride]
+	}
+
+	sum, err := ev.AddNew(x[0], x[1])
+	if err != nil {
+		return err
+	}
+	for _, xi := range x[2:] {
+		if err = ev.Add(sum, xi, sum); err != nil {
+			return err
+		}
+	}
+
+	for i := range x {
+		y, err := ev.AddNew(sum, x[i])
+		if err != nil {
+			return err
+		}
+		for k := 0; k < 2; k++ {
+			if err = ev.Add(y, x[(i+1)%4], y); err != nil {
+				return err
+			}
+		}
+		hera.stCt[start+i*stride] = y
+	}
+	return nil
+}
+
+// cube computes x^3 with the scale invariant multiplication
+func (hera *mfvHera) cube() error {
+	ev := hera.evaluator
+	for st := 0; st < 16; st++ {
+		x2, err := ev.MulRelinScaleInvariantNew(hera.stCt[st], hera.stCt[st])
+		if err != nil {
+			return err
+		}
+		if hera.stCt[st], err = ev.MulRelinScaleInvariantNew(x2, hera.stCt[st]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,46 +2,49 @@ package hera
 
 import (
 	"HHESoK"
-	ckks "HHESoK/rtf_ckks_integration/ckks_fv"
+	"HHESoK/hhe/rtf"
 	"HHESoK/rtf_ckks_integration/utils"
 	"HHESoK/sym/hera"
 	"crypto/rand"
-	"math"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+	"github.com/tuneinsight/lattigo/v6/schemes/ckks"
 )
 
 type HEHera struct {
 	logger           HHESoK.Logger
 	paramIndex       int
 	fullCoefficients bool
-	params           *ckks.Parameters
+	params           rtf.Parameters
 	symParams        hera.Parameter
-	hbtp             *ckks.HalfBootstrapper
-	hbtpParams       *ckks.HalfBootParameters
-	keyGenerator     ckks.KeyGenerator
-	fvEncoder        ckks.MFVEncoder
-	ckksEncoder      ckks.CKKSEncoder
-	ckksDecryptor    ckks.CKKSDecryptor
-	sk               *ckks.SecretKey
-	pk               *ckks.PublicKey
-	fvEncryptor      ckks.MFVEncryptor
-	fvEvaluator      ckks.MFVEvaluator
-	plainCKKSRingTs  []*ckks.PlaintextRingT
-	plaintexts       []*ckks.Plaintext
-
-	fvHera         ckks.MFVHera
+	hbtp             *rtf.HalfBootstrapper
+	keyGenerator     *rlwe.KeyGenerator
+	fvEncoder        *bgv.Encoder
+	ckksEncoder      *ckks.Encoder
+	ckksDecryptor    *rlwe.Decryptor
+	sk               *rlwe.SecretKey
+	pk               *rlwe.PublicKey
+	fvEncryptor      *rlwe.Encryptor
+	fvEvaluator      *bgv.Evaluator
+	plaintexts       []*rlwe.Plaintext
+
+	fvHera         MFVHera
 	messageScaling float64
 	heraModDown    []int
 	stcModDown     []int
-	pDcds          [][]*ckks.PtDiagMatrixT
-	rotkeys        *ckks.RotationKeySet
-	rlk            *ckks.RelinearizationKey
-	hbtpKey        ckks.BootstrappingKey
+	stc            *rtf.SlotsToCoeffs
+	gks            []*rlwe.GaloisKey
+	rlk            *rlwe.RelinearizationKey
+	evk            *rlwe.MemEvaluationKeySet
 
 	N            int
 	outSize      int
 	coefficients [][]float64
-	symKeyCt     []*ckks.Ciphertext
-	ciphertext   *ckks.Ciphertext
+	maskedCoeffs [][]uint64
+	symKeyCt     []*rlwe.Ciphertext
+	symCip       hera.Hera
+	ciphertext   *rlwe.Ciphertext
 }
 
 func NewHEHera() *HEHera {
@@ -49,10 +52,9 @@ func NewHEHera() *HEHera {
 		logger:           HHESoK.NewLogger(HHESoK.DEBUG),
 		paramIndex:       0,
 		fullCoefficients: true,
-		params:           nil,
+		params:           rtf.Parameters{},
 		symParams:        hera.Parameter{},
 		hbtp:             nil,
-		hbtpParams:       nil,
 		keyGenerator:     nil,
 		fvEncoder:        nil,
 		ckksEncoder:      nil,
@@ -61,100 +63,98 @@ func NewHEHera() *HEHera {
 		pk:               nil,
 		fvEncryptor:      nil,
 		fvEvaluator:      nil,
-		plainCKKSRingTs:  nil,
 		plaintexts:       nil,
 		fvHera:           nil,
 		messageScaling:   0,
 		heraModDown:      nil,
 		stcModDown:       nil,
-		pDcds:            nil,
-		rotkeys:          nil,
+		stc:              nil,
+		gks:              nil,
 		rlk:              nil,
-		hbtpKey:          ckks.BootstrappingKey{},
+		evk:              nil,
 		N:                0,
 		outSize:          0,
 		coefficients:     nil,
+		maskedCoeffs:     nil,
 		symKeyCt:         nil,
+		symCip:           nil,
 		ciphertext:       nil,
 	}
 	return hera
 }
 
-func (hH *HEHera) InitParams(paramIndex int, symParams hera.Parameter) {
-	var err error
+// InitParams sets the RtF parameters rtf.HeraParams[paramIndex] and the mod-down presets
+// of the number of rounds of symParams
+func (hH *HEHera) InitParams(paramIndex int, symParams hera.Parameter) error {
+	modDown := rtf.HeraModDown128[paramIndex]
+	if symParams.Rounds == 4 {
+		modDown = rtf.HeraModDown80[paramIndex]
+	}
 	hH.paramIndex = paramIndex
+	return hH.InitParamsFromLiteral(rtf.HeraParams[paramIndex], symParams, modDown)
+}
+
+// InitParamsFromLiteral sets the RtF parameters lit with the plaintext modulus of symParams,
+// the sets of rtf.TestParams run the pipeline on a small ring
+func (hH *HEHera) InitParamsFromLiteral(lit rtf.ParametersLiteral, symParams hera.Parameter, modDown rtf.ModDown) error {
+	var err error
 	hH.symParams = symParams
 	hH.outSize = symParams.BlockSize
-	hH.hbtpParams = ckks.RtFHeraParams[paramIndex] // set to 2, using Hera 128af
-	hH.params, err = hH.hbtpParams.Params()
-	if err != nil {
-		panic(err)
+	lit.PlainModulus = symParams.GetModulus()
+	if hH.params, err = rtf.NewParametersFromLiteral(lit); err != nil {
+		return err
 	}
-	//hH.N = int(math.Ceil(float64(plainSize / hH.outSize)))
 	hH.N = hH.params.N()
-	hH.params.SetPlainModulus(symParams.GetModulus())
-	hH.params.SetLogFVSlots(hH.params.LogN())
-	hH.messageScaling = float64(hH.params.PlainModulus()) / hH.hbtpParams.MessageRatio
-	if symParams.Rounds == 4 {
-		hH.heraModDown = ckks.HeraModDownParams80[paramIndex].CipherModDown
-		hH.stcModDown = ckks.HeraModDownParams80[paramIndex].StCModDown
-	} else {
-		hH.heraModDown = ckks.HeraModDownParams128[paramIndex].CipherModDown
-		hH.stcModDown = ckks.HeraModDownParams128[paramIndex].StCModDown
-	}
+	hH.messageScaling = hH.params.MessageScaling()
+	hH.heraModDown = modDown.CipherModDown
+	hH.stcModDown = modDown.StCModDown
 	// full Coefficients denotes whether full coefficients are used for data encoding
-	switch paramIndex {
-	case hera.HR128S, hera.HR128AS:
-		hH.fullCoefficients = false
-	case hera.HR128F, hera.HR128AF:
-		hH.fullCoefficients = true
-	}
-	if hH.fullCoefficients {
-		hH.params.SetLogFVSlots(hH.params.LogN())
-	} else {
-		hH.params.SetLogFVSlots(hH.params.LogSlots())
-	}
+	hH.fullCoefficients = hH.params.FullCoefficients()
+	return nil
+}
+
+// FVSlots returns the number of blocks transciphered at once
+func (hH *HEHera) FVSlots() int {
+	return hH.params.FVSlots()
 }
 
 func (hH *HEHera) HEKeyGen() {
-	hH.keyGenerator = ckks.NewKeyGenerator(hH.params)
-	hH.sk, hH.pk = hH.keyGenerator.GenKeyPairSparse(hH.hbtpParams.H)
+	bgvParams := hH.params.BGV()
+	hH.keyGenerator = rlwe.NewKeyGenerator(bgvParams)
+	hH.sk, hH.pk = hH.keyGenerator.GenKeyPairNew()
 
-	hH.fvEncoder = ckks.NewMFVEncoder(hH.params)
-	hH.ckksEncoder = ckks.NewCKKSEncoder(hH.params)
-	hH.fvEncryptor = ckks.NewMFVEncryptorFromPk(hH.params, hH.pk)
-	hH.ckksDecryptor = ckks.NewCKKSDecryptor(hH.params, hH.sk)
+	hH.fvEncoder = bgv.NewEncoder(bgvParams)
+	hH.ckksEncoder = ckks.NewEncoder(hH.params.CKKS())
+	hH.fvEncryptor = rlwe.NewEncryptor(bgvParams, hH.pk)
+	hH.ckksDecryptor = rlwe.NewDecryptor(hH.params.CKKS(), hH.sk)
 }
 
-func (hH *HEHera) HalfBootKeyGen(radix int) {
-	// Generating half-bootstrapping keys
-	rotationsHalfBoot := hH.keyGenerator.GenRotationIndexesForHalfBoot(hH.params.LogSlots(), hH.hbtpParams)
-	hH.pDcds = hH.fvEncoder.GenSlotToCoeffMatFV(radix)
-	rotationsStC := hH.keyGenerator.GenRotationIndexesForSlotsToCoeffsMat(hH.pDcds)
-	rotations := append(rotationsHalfBoot, rotationsStC...)
-	if !hH.fullCoefficients {
-		rotations = append(rotations, hH.params.Slots()/2)
+func (hH *HEHera) HalfBootKeyGen(radix int) error {
+	var err error
+	// Generating half-bootstrapping and slots to coefficients keys
+	if hH.stc, err = rtf.NewSlotsToCoeffs(hH.params, hH.fvEncoder, radix); err != nil {
+		return err
 	}
-	hH.rotkeys = hH.keyGenerator.GenRotationKeysForRotations(rotations, true, hH.sk)
-	hH.rlk = hH.keyGenerator.GenRelinearizationKey(hH.sk)
-	hH.hbtpKey = ckks.BootstrappingKey{Rlk: hH.rlk, Rtks: hH.rotkeys}
+	galEls := rtf.GaloisElements(hH.params.HalfBootGaloisElements(), hH.stc.GaloisElements())
+	hH.gks = hH.keyGenerator.GenGaloisKeysNew(galEls, hH.sk)
+	hH.rlk = hH.keyGenerator.GenRelinearizationKeyNew(hH.sk)
+	hH.evk = rlwe.NewMemEvaluationKeySet(hH.rlk, hH.gks...)
+	return nil
 }
 
-func (hH *HEHera) InitHalfBootstrapper() {
-	var err error
-	if hH.hbtp, err = ckks.NewHalfBootstrapper(hH.params, hH.hbtpParams, hH.hbtpKey); err != nil {
-		panic(err)
-	}
+func (hH *HEHera) InitHalfBootstrapper() (err error) {
+	hH.hbtp, err = rtf.NewHalfBootstrapper(hH.params, hH.evk)
+	return
 }
 
 func (hH *HEHera) InitEvaluator() {
-	hH.fvEvaluator = ckks.NewMFVEvaluator(hH.params, ckks.EvaluationKey{Rlk: hH.rlk, Rtks: hH.rotkeys}, hH.pDcds)
+	hH.fvEvaluator = bgv.NewEvaluator(hH.params.BGV(), hH.evk)
 }
 
 func (hH *HEHera) InitCoefficients() {
 	hH.coefficients = make([][]float64, hH.outSize)
 	for s := 0; s < hH.outSize; s++ {
-		hH.coefficients[s] = make([]float64, hH.params.N())
+		hH.coefficients[s] = make([]float64, hH.params.FVSlots())
 	}
 }
 
@@ -173,87 +173,219 @@ func (hH *HEHera) NonceGen(size int) (nonces [][]byte) {
 	nonces = make([][]byte, size)
 	for i := 0; i < size; i++ {
 		nonces[i] = make([]byte, 64)
-		rand.Read(nonces[i])
+		_, _ = rand.Read(nonces[i])
 	}
 	return
 }
 
-func (hH *HEHera) DataToCoefficients(data [][]float64, size int) {
+// DataToCoefficients sets the data matrix [output size][FVSlots], column i is the data at
+// position i of the HalfBoot output and is masked by the key stream of block params.SlotBlock(i)
+func (hH *HEHera) DataToCoefficients(data [][]float64) {
 	for s := 0; s < hH.outSize; s++ {
-		for i := 0; i < size/2; i++ {
-			j := utils.BitReverse64(uint64(i), uint64(hH.params.LogN()-1))
-			hH.coefficients[s][j] = data[s][i]
-			hH.coefficients[s][j+uint64(size/2)] = data[s][i+size/2]
-		}
+		copy(hH.coefficients[s], data[s])
 	}
 }
 
-func (hH *HEHera) EncodeEncrypt(keystream [][]uint64, size int) {
-	hH.plainCKKSRingTs = make([]*ckks.PlaintextRingT, hH.outSize)
+// EncodeEncrypt masks the data with keystream [FVSlots][output size], the key stream of one block per row
+func (hH *HEHera) EncodeEncrypt(keystream [][]uint64) error {
+	var err error
+	hH.maskedCoeffs = make([][]uint64, hH.outSize)
+	column := make([]uint64, hH.params.FVSlots())
 	for s := 0; s < hH.outSize; s++ {
-		hH.plainCKKSRingTs[s] = hH.ckksEncoder.EncodeCoeffsRingTNew(hH.coefficients[s], hH.messageScaling)
-		poly := hH.plainCKKSRingTs[s].Value()[0]
-		for i := 0; i < size; i++ {
-			j := utils.BitReverse64(uint64(i), uint64(hH.params.LogN()))
-			poly.Coeffs[0][j] = (poly.Coeffs[0][j] + keystream[i][s]) % hH.params.PlainModulus()
+		for i := range column {
+			column[i] = keystream[i][s]
+		}
+		if hH.maskedCoeffs[s], err = hH.params.MaskCoefficients(hH.coefficients[s], column); err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
-func (hH *HEHera) ScaleUp() {
-	hH.plaintexts = make([]*ckks.Plaintext, hH.outSize)
+func (hH *HEHera) ScaleUp() error {
+	var err error
+	hH.plaintexts = make([]*rlwe.Plaintext, hH.outSize)
 	for s := 0; s < hH.outSize; s++ {
-		hH.plaintexts[s] = ckks.NewPlaintextFVLvl(hH.params, 0)
-		hH.fvEncoder.FVScaleUp(hH.plainCKKSRingTs[s], hH.plaintexts[s])
+		if hH.plaintexts[s], err = hH.params.EncodeCoefficients(hH.fvEncoder, hH.maskedCoeffs[s]); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (hH *HEHera) InitFvHera() ckks.MFVHera {
-	hH.fvHera = ckks.NewMFVHera(hH.symParams.Rounds, hH.params, hH.fvEncoder, hH.fvEncryptor,
+func (hH *HEHera) InitFvHera() (MFVHera, error) {
+	fvHera, err := NewMFVHera(hH.symParams.Rounds, hH.params, hH.fvEncoder, hH.fvEncryptor,
 		hH.fvEvaluator, hH.heraModDown[0])
-	return hH.fvHera
+	if err != nil {
+		return nil, err
+	}
+	hH.fvHera = fvHera
+	return hH.fvHera, nil
 }
 
-func (hH *HEHera) EncryptSymKey(key []uint64) {
-	hH.symKeyCt = hH.fvHera.EncKey(key)
+// EncryptSymKey encrypts the symmetric key under BFV, the key is kept to mask the data of TranscipherStream
+func (hH *HEHera) EncryptSymKey(key []uint64) error {
+	symKeyCt, err := hH.fvHera.EncKey(key)
+	if err != nil {
+		return err
+	}
+	symCip, err := hera.NewHera(key, hH.symParams)
+	if err != nil {
+		return err
+	}
+	hH.symKeyCt, hH.symCip = symKeyCt, symCip
 	hH.logger.PrintMessages(">> Symmetric Key Length: ", len(hH.symKeyCt))
+	return nil
 }
 
-func (hH *HEHera) GetFvKeyStreams(nonces [][]byte) []*ckks.Ciphertext {
-	fvKeyStreams := hH.fvHera.Crypt(nonces, hH.symKeyCt, hH.heraModDown)
+func (hH *HEHera) GetFvKeyStreams(nonces [][]byte) ([]*rlwe.Ciphertext, error) {
+	fvKeyStreams, err := hH.fvHera.Crypt(nonces, hH.symKeyCt, hH.heraModDown)
+	if err != nil {
+		return nil, err
+	}
 	for i := 0; i < hH.outSize; i++ {
 		hH.logger.PrintMessages(">> index: ", i)
-		fvKeyStreams[i] = hH.fvEvaluator.SlotsToCoeffs(fvKeyStreams[i], hH.stcModDown)
-		hH.fvEvaluator.ModSwitchMany(fvKeyStreams[i], fvKeyStreams[i], fvKeyStreams[i].Level())
+		if fvKeyStreams[i], err = hH.stc.Evaluate(hH.fvEvaluator, fvKeyStreams[i], hH.stcModDown); err != nil {
+			return nil, err
+		}
+		if err = rtf.ModSwitchMany(hH.fvEvaluator, fvKeyStreams[i], fvKeyStreams[i].Level()); err != nil {
+			return nil, err
+		}
+	}
+	return fvKeyStreams, nil
+}
+
+// ScaleCiphertext removes the key stream from the masked data of the first state element
+// and returns the CKKS ciphertext at level 0
+func (hH *HEHera) ScaleCiphertext(fvKeyStreams []*rlwe.Ciphertext) (err error) {
+	hH.ciphertext, err = hH.params.ToCKKS(hH.fvEvaluator, hH.plaintexts[0], fvKeyStreams[0])
+	return
+}
+
+// HalfBoot Half-Bootstrap the ciphertext (homomorphic evaluation of ModRaise -> SubSum -> CtS -> EvalMod)
+// It takes the ciphertext at level 0 and returns ciphertexts at the residual level.
+// Difference from the bootstrapping is that the last StC is missing.
+// With full coefficients, ctReal holds the first N/2 data positions and ctImag the last N/2,
+// otherwise ctImag is nil and the FVSlots positions are in ctReal.
+func (hH *HEHera) HalfBoot() (ctReal, ctImag *rlwe.Ciphertext, err error) {
+	return hH.hbtp.HalfBoot(hH.ciphertext)
+}
+
+// HalfBootVector half-bootstraps the ciphertext like HalfBoot and returns both outputs as one
+// rtf.Vector, the transciphering of the first row of the data
+func (hH *HEHera) HalfBootVector() (*rtf.Vector, error) {
+	return hH.hbtp.HalfBootVector(hH.ciphertext)
+}
+
+// DecodeVectors decrypts vectors, the transciphering of the rows of data of layout l, and
+// returns the data [l.Rows][l.Cols]
+func (hH *HEHera) DecodeVectors(vectors []*rtf.Vector, l rtf.Layout) ([][]float64, error) {
+	return hH.params.DecodeVectors(hH.ckksEncoder, hH.ckksDecryptor, vectors, l)
+}
+
+// TranscipherStream transciphers dataset [rows][<= output size], any number of rows of the same
+// width, in batches of FVSlots rows under fresh nonces, and returns the CKKS ciphertexts of the
+// stream and its manifest, see rtf.Manifest. The symmetric key must be encrypted beforehand
+func (hH *HEHera) TranscipherStream(dataset [][]float64) ([]*rlwe.Ciphertext, rtf.Manifest, error) {
+	var cts []*rlwe.Ciphertext
+	manifest, err := hH.TranscipherBatches(dataset, func(batchCts []*rlwe.Ciphertext, _ rtf.BatchEntry) bool {
+		cts = append(cts, batchCts...)
+		return true
+	})
+	if err != nil {
+		return nil, rtf.Manifest{}, err
 	}
-	return fvKeyStreams
+	return cts, manifest, nil
 }
 
-func (hH *HEHera) ScaleCiphertext(fvKeyStreams []*ckks.Ciphertext) {
-	// Encrypt and mod switch to the lowest leve
-	hH.ciphertext = ckks.NewCiphertextFVLvl(hH.params, 1, 0)
-	hH.ciphertext.Value()[0] = hH.plaintexts[0].Value()[0].CopyNew()
-	hH.fvEvaluator.Sub(hH.ciphertext, fvKeyStreams[0], hH.ciphertext)
-	hH.fvEvaluator.TransformToNTT(hH.ciphertext, hH.ciphertext)
-	hH.ciphertext.SetScale(
-		math.Exp2(
-			math.Round(
-				math.Log2(
-					float64(hH.params.Qi()[0]) /
-						float64(hH.params.PlainModulus()) *
-						hH.messageScaling,
-				),
-			),
-		),
-	)
+// TranscipherBatches transciphers dataset like TranscipherStream, but yields the CKKS ciphertexts
+// of every batch with its manifest entry as soon as the batch is transciphered, so that the
+// stream is never held in memory. It stops after the first batch for which yield returns false
+// and returns the manifest, the nonces of the batches not transciphered are nil. It stops on the
+// first error, the batches yielded before it are valid
+func (hH *HEHera) TranscipherBatches(dataset [][]float64, yield func(cts []*rlwe.Ciphertext, entry rtf.BatchEntry) bool) (rtf.Manifest, error) {
+	var width int
+	if len(dataset) > 0 {
+		width = len(dataset[0])
+	}
+	manifest, err := hH.params.NewManifest(len(dataset), width, hH.outSize)
+	if err != nil {
+		return rtf.Manifest{}, err
+	}
+	for b := 0; b < manifest.Batches(); b++ {
+		data, err := manifest.Batch(dataset, b)
+		if err != nil {
+			return manifest, err
+		}
+		manifest.Nonces[b] = HHESoK.NewNonce()
+		vectors, err := hH.transcipherBatch(manifest.Nonces[b], data)
+		if err != nil {
+			return manifest, err
+		}
+		cts := make([]*rlwe.Ciphertext, 0, manifest.BatchCiphertexts())
+		for _, v := range vectors {
+			cts = append(cts, v.Ciphertexts()...)
+		}
+		if !yield(cts, manifest.Entry(b)) {
+			break
+		}
+	}
+	return manifest, nil
 }
 
-func (hH *HEHera) HalfBoot() *ckks.Ciphertext {
-	var ctBoot *ckks.Ciphertext
-	if hH.fullCoefficients {
-		ctBoot, _ = hH.hbtp.HalfBoot(hH.ciphertext, false)
-	} else {
-		ctBoot, _ = hH.hbtp.HalfBoot(hH.ciphertext, true)
+// transcipherBatch masks data [width][<= FVSlots] with the key streams of the blocks
+// nonce || CounterBytes(i), evaluates the HERA key streams under the encrypted key and
+// returns the Vector of every row of data
+func (hH *HEHera) transcipherBatch(nonce []byte, data [][]float64) ([]*rtf.Vector, error) {
+	fvSlots := hH.params.FVSlots()
+	keyStream := make([][]uint64, fvSlots)
+	for i := range keyStream {
+		keyStream[i] = append([]uint64{}, hH.symCip.KeyStream(nonce, HHESoK.CounterBytes(uint64(i)))...)
 	}
-	return ctBoot
+	fvKeyStreams, err := hH.fvHera.Crypt(HHESoK.BlockNonces(nonce, 0, fvSlots), hH.symKeyCt, hH.heraModDown)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make([]*rtf.Vector, len(data))
+	column := make([]uint64, fvSlots)
+	for s := range data {
+		for i := range column {
+			column[i] = keyStream[i][s]
+		}
+		coeffs, err := hH.params.MaskCoefficients(data[s], column)
+		if err != nil {
+			return nil, err
+		}
+		pt, err := hH.params.EncodeCoefficients(hH.fvEncoder, coeffs)
+		if err != nil {
+			return nil, err
+		}
+		ks, err := hH.stc.Evaluate(hH.fvEvaluator, fvKeyStreams[s], hH.stcModDown)
+		if err != nil {
+			return nil, err
+		}
+		if err = rtf.ModSwitchMany(hH.fvEvaluator, ks, ks.Level()); err != nil {
+			return nil, err
+		}
+		ct, err := hH.params.ToCKKS(hH.fvEvaluator, pt, ks)
+		if err != nil {
+			return nil, err
+		}
+		if vectors[s], err = hH.hbtp.HalfBootVector(ct); err != nil {
+			return nil, err
+		}
+	}
+	return vectors, nil
+}
+
+// DecodeStream decrypts the ciphertexts of TranscipherStream and returns the dataset of manifest
+func (hH *HEHera) DecodeStream(cts []*rlwe.Ciphertext, manifest rtf.Manifest) ([][]float64, error) {
+	return manifest.DecodeStream(hH.ckksEncoder, hH.ckksDecryptor, cts)
+}
+
+// DecodeBatch decrypts the ciphertexts of one batch of TranscipherBatches and returns the rows
+// [entry.First, entry.Last) of the dataset
+func (hH *HEHera) DecodeBatch(cts []*rlwe.Ciphertext, entry rtf.BatchEntry) ([][]float64, error) {
+	return hH.params.DecodeBatch(hH.ckksEncoder, hH.ckksDecryptor, cts, entry)
 }
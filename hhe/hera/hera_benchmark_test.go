@@ -2,10 +2,13 @@ package hera
 
 import (
 	"HHESoK"
-	"HHESoK/rtf_ckks_integration/ckks_fv"
 	"HHESoK/sym/hera"
 	"fmt"
+	"runtime"
 	"testing"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
 )
 
 func BenchmarkHera(b *testing.B) {
@@ -39,7 +42,9 @@ func benchHEHera(tc hera.TestContext, b *testing.B) {
 	var nonces [][]byte
 	var keyStream [][]uint64
 
-	heHera.InitParams(tc.FVParamIndex, tc.Params)
+	if err := heHera.InitParams(tc.FVParamIndex, tc.Params); err != nil {
+		b.Fatal(err)
+	}
 
 	b.Run("HERA/HEKeyGen", func(b *testing.B) {
 		b.ResetTimer()
@@ -51,93 +56,132 @@ func benchHEHera(tc hera.TestContext, b *testing.B) {
 	b.Run("HERA/HalfBootKeyGen", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			heHera.HalfBootKeyGen(tc.Radix)
+			if err := heHera.HalfBootKeyGen(tc.Radix); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 
-	heHera.InitHalfBootstrapper()
+	if err := heHera.InitHalfBootstrapper(); err != nil {
+		b.Fatal(err)
+	}
 
 	heHera.InitEvaluator()
 
 	heHera.InitCoefficients()
 
-	if heHera.fullCoefficients {
-		data = heHera.RandomDataGen(heHera.params.N())
+	fvSlots := heHera.FVSlots()
+	data = heHera.RandomDataGen(fvSlots)
 
-		nonces = heHera.NonceGen(heHera.params.N())
+	nonces = heHera.NonceGen(fvSlots)
 
-		keyStream = make([][]uint64, heHera.params.N())
-		b.Run("HERA/SymKeyStream", func(b *testing.B) {
-			b.ResetTimer()
-			for i := 0; i < b.N; i++ {
-				for i := 0; i < heHera.params.N(); i++ {
-					symHera := hera.NewHera(tc.Key, tc.Params)
-					keyStream[i] = symHera.KeyStream(nonces[i])
-				}
-			}
-		})
-
-		heHera.DataToCoefficients(data, heHera.params.N())
-
-		b.Run("HERA/EncryptSymData", func(b *testing.B) {
-			b.ResetTimer()
-			for i := 0; i < b.N; i++ {
-				heHera.EncodeEncrypt(keyStream, heHera.params.N())
+	keyStream = make([][]uint64, fvSlots)
+	b.Run("HERA/SymKeyStream", func(b *testing.B) {
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for i := 0; i < fvSlots; i++ {
+				symHera := hera.MustNewHera(tc.Key, tc.Params)
+				keyStream[i] = append([]uint64{}, symHera.KeyStream(nonces[i], nil)...)
 			}
-		})
-
-	} else {
-		data = heHera.RandomDataGen(heHera.params.Slots())
+		}
+	})
 
-		nonces = heHera.NonceGen(heHera.params.Slots())
+	heHera.DataToCoefficients(data)
 
-		keyStream = make([][]uint64, heHera.params.Slots())
-		b.Run("HERA/SymKeyStream", func(b *testing.B) {
-			b.ResetTimer()
-			for i := 0; i < b.N; i++ {
-				for i := 0; i < heHera.params.Slots(); i++ {
-					symHera := hera.NewHera(tc.Key, tc.Params)
-					keyStream[i] = symHera.KeyStream(nonces[i])
-				}
+	b.Run("HERA/EncryptSymData", func(b *testing.B) {
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := heHera.EncodeEncrypt(keyStream); err != nil {
+				b.Fatal(err)
 			}
-		})
-
-		heHera.DataToCoefficients(data, heHera.params.Slots())
+		}
+	})
 
-		b.Run("HERA/EncryptSymData", func(b *testing.B) {
-			b.ResetTimer()
-			for i := 0; i < b.N; i++ {
-				heHera.EncodeEncrypt(keyStream, heHera.params.Slots())
-			}
-		})
+	if err := heHera.ScaleUp(); err != nil {
+		b.Fatal(err)
 	}
 
-	heHera.ScaleUp()
-
 	// FV Key Stream, encrypts symmetric key stream using BFV on the client side
 	b.Run("HERA/EncSymKey", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_ = heHera.InitFvHera()
-			heHera.EncryptSymKey(tc.Key)
+			if _, err := heHera.InitFvHera(); err != nil {
+				b.Fatal(err)
+			}
+			if err := heHera.EncryptSymKey(tc.Key); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 
 	// get BFV key stream using encrypted symmetric key, nonce, and counter on the server side
-	var fvKeyStreams []*ckks_fv.Ciphertext
+	var fvKeyStreams []*rlwe.Ciphertext
 	b.Run("HERA/FVKeyStream", func(b *testing.B) {
 		b.ResetTimer()
+		var err error
 		for i := 0; i < b.N; i++ {
-			fvKeyStreams = heHera.GetFvKeyStreams(nonces)
+			if fvKeyStreams, err = heHera.GetFvKeyStreams(nonces); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 
-	heHera.ScaleCiphertext(fvKeyStreams)
+	if err := heHera.ScaleCiphertext(fvKeyStreams); err != nil {
+		b.Fatal(err)
+	}
 
-	b.Run("Rubato/HalfBoot", func(b *testing.B) {
+	b.Run("HERA/HalfBoot", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_ = heHera.HalfBoot()
+			if _, _, err := heHera.HalfBoot(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkHeraParallel compares the sequential key stream evaluation with the parallel driver
+// on one batch of FVSlots nonces per CPU, on the test ring
+func BenchmarkHeraParallel(b *testing.B) {
+	tc := hera.TestVector[4+hera.HR128AS]
+	params := testParams(b, tc)
+	bgvParams := params.BGV()
+	modDown := testModDown(params, tc).CipherModDown
+
+	kgen := rlwe.NewKeyGenerator(bgvParams)
+	sk, pk := kgen.GenKeyPairNew()
+	rlk := kgen.GenRelinearizationKeyNew(sk)
+	fvEvaluator := bgv.NewEvaluator(bgvParams, rlwe.NewMemEvaluationKeySet(rlk))
+	fvHera := MustNewMFVHera(tc.Params.GetRounds(), params, bgv.NewEncoder(bgvParams), rlwe.NewEncryptor(bgvParams, pk), fvEvaluator, modDown[0])
+	kCt, err := fvHera.EncKey(tc.Key)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	workers := runtime.NumCPU()
+	nonce := HHESoK.NewNonce()
+	numBlock := params.FVSlots()
+	nonces := make([][][]byte, workers)
+	for i := range nonces {
+		nonces[i] = HHESoK.BlockNonces(nonce, uint64(i*numBlock), numBlock)
+	}
+
+	b.Run("HERA/Crypt", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, batch := range nonces {
+				if _, err := fvHera.Crypt(batch, kCt, modDown); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run(fmt.Sprintf("HERA/CryptParallel/Workers=%d", workers), func(b *testing.B) {
+		par := NewParallelMFVHera(fvHera, workers)
+		for i := 0; i < b.N; i++ {
+			if _, err := par.Crypt(nonces, kCt, modDown); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 }
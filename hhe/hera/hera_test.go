@@ -1,30 +1,246 @@
 package hera
 
 import (
+	"HHESoK"
+	"HHESoK/hhe/rtf"
 	"HHESoK/rtf_ckks_integration/ckks_fv"
 	"HHESoK/sym/hera"
+	"crypto/rand"
 	"fmt"
 	"math"
+	"math/big"
 	"testing"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
 )
 
+// testLogN is the ring degree of the RtF test parameters, the sets are not secure
+const testLogN = 11
+
 func testString(opName string, p hera.Parameter) string {
 	return fmt.Sprintf("%s/BlockSize=%d/Modulus=%d/Rounds=%d",
 		opName, p.GetBlockSize(), p.GetModulus(), p.GetRounds())
 }
 
+// testParams returns the RtF parameters of tc on a ring of degree 2^testLogN
+func testParams(t testing.TB, tc hera.TestContext) rtf.Parameters {
+	lit := rtf.TestParams(rtf.HeraParams[tc.FVParamIndex], testLogN)
+	lit.PlainModulus = tc.Params.GetModulus()
+	params, err := rtf.NewParametersFromLiteral(lit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return params
+}
+
+// testModDown returns the mod-down presets of tc, the slots to coefficients transform of the
+// test ring is shorter and drops no modulus
+func testModDown(params rtf.Parameters, tc hera.TestContext) rtf.ModDown {
+	modDown := rtf.HeraModDown128[tc.FVParamIndex]
+	if tc.Params.Rounds == 4 {
+		modDown = rtf.HeraModDown80[tc.FVParamIndex]
+	}
+	modDown.StCModDown = make([]int, params.SlotsToCoeffsDepth(tc.Radix))
+	return modDown
+}
+
 func TestHera(t *testing.T) {
 	for _, tc := range hera.TestVector {
 		// skip the test for 80-bit security
 		if tc.Params.Rounds == 4 {
 			continue
 		}
-		fmt.Println(testString("HERA", tc.Params))
-		testHEHera(t, tc)
+		t.Run(testString("HERA", tc.Params), func(t *testing.T) {
+			testHEHera(t, tc, false)
+		})
+	}
+}
+
+// TestHeraSymCrossCheck encrypts a multi-block message with the symmetric HERA encryptor
+// and removes the key stream homomorphically with MFVHera, block i of the symmetric
+// ciphertext is slot i of the HHE data matrix
+func TestHeraSymCrossCheck(t *testing.T) {
+	for _, tc := range hera.TestVector {
+		t.Run(testString("HERA/SymCrossCheck", tc.Params), func(t *testing.T) {
+			testHeraSymCrossCheck(t, tc)
+		})
+	}
+}
+
+// TestHeraCKKSFVCrossCheck evaluates the key stream and the slots to coefficients transform
+// with the lattigo v6 pipeline and with ckks_fv on the same modulus chain, the decrypted
+// results must be equal
+func TestHeraCKKSFVCrossCheck(t *testing.T) {
+	for _, tc := range []hera.TestContext{hera.TestVector[hera.HR80F], hera.TestVector[4+hera.HR128AS]} {
+		t.Run(testString("HERA/CKKSFVCrossCheck", tc.Params), func(t *testing.T) {
+			testHeraCKKSFVCrossCheck(t, tc)
+		})
 	}
 }
 
-func testHEHera(t *testing.T, tc hera.TestContext) {
+func testHeraSymCrossCheck(t *testing.T, tc hera.TestContext) {
+	params := testParams(t, tc)
+	bgvParams := params.BGV()
+
+	kgen := rlwe.NewKeyGenerator(bgvParams)
+	sk, pk := kgen.GenKeyPairNew()
+	rlk := kgen.GenRelinearizationKeyNew(sk)
+	fvEncoder := bgv.NewEncoder(bgvParams)
+	fvEncryptor := rlwe.NewEncryptor(bgvParams, pk)
+	fvDecryptor := rlwe.NewDecryptor(bgvParams, sk)
+	fvEvaluator := bgv.NewEvaluator(bgvParams, rlwe.NewMemEvaluationKeySet(rlk))
+	fvHera := MustNewMFVHera(tc.Params.GetRounds(), params, fvEncoder, fvEncryptor, fvEvaluator, 0)
+
+	blockSize := tc.Params.GetBlockSize()
+	numBlock := params.FVSlots()
+	modulus := tc.Params.GetModulus()
+
+	plaintext := make(HHESoK.Plaintext, numBlock*blockSize)
+	for i := range plaintext {
+		v, _ := rand.Int(rand.Reader, new(big.Int).SetUint64(modulus))
+		plaintext[i] = v.Uint64()
+	}
+
+	// client side: CTR mode encryption starting from an arbitrary counter
+	nonce := HHESoK.NewNonce()
+	counter := uint64(42)
+	symHera := hera.MustNewHera(tc.Key, tc.Params)
+	ciphertext := symHera.NewEncryptor().EncryptWithCounter(nonce, counter, plaintext)
+
+	// server side: evaluate the key stream of every block under the encrypted key
+	kCt, err := fvHera.EncKey(tc.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fvKeyStreams, err := fvHera.CryptNoModSwitch(HHESoK.BlockNonces(nonce, counter, numBlock), kCt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	column := make([]uint64, numBlock)
+	for s := 0; s < blockSize; s++ {
+		for i := 0; i < numBlock; i++ {
+			column[i] = ciphertext[i*blockSize+s]
+		}
+		// negate in place, MulNew does not keep the scale
+		ct := fvKeyStreams[s].CopyNew()
+		if err := fvEvaluator.Mul(ct, -1, ct); err != nil {
+			t.Fatal(err)
+		}
+		if err := fvEvaluator.Add(ct, rtf.EmbedVector(column, numBlock, params.N()), ct); err != nil {
+			t.Fatal(err)
+		}
+
+		got := decodeSlots(params, fvEncoder, fvDecryptor, ct)
+		for i := 0; i < numBlock; i++ {
+			if got[i] != plaintext[i*blockSize+s] {
+				t.Fatalf("block %d, element %d: got %d, want %d", i, s, got[i], plaintext[i*blockSize+s])
+			}
+		}
+	}
+}
+
+func testHeraCKKSFVCrossCheck(t *testing.T, tc hera.TestContext) {
+	params := testParams(t, tc)
+	bgvParams := params.BGV()
+	numBlock := params.FVSlots()
+	nonces := NewHEHera().NonceGen(numBlock)
+
+	// lattigo v6
+	kgen := rlwe.NewKeyGenerator(bgvParams)
+	sk, pk := kgen.GenKeyPairNew()
+	fvEncoder := bgv.NewEncoder(bgvParams)
+	stc, err := rtf.NewSlotsToCoeffs(params, fvEncoder, tc.Radix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evk := rlwe.NewMemEvaluationKeySet(kgen.GenRelinearizationKeyNew(sk), kgen.GenGaloisKeysNew(stc.GaloisElements(), sk)...)
+	fvDecryptor := rlwe.NewDecryptor(bgvParams, sk)
+	fvEvaluator := bgv.NewEvaluator(bgvParams, evk)
+	fvHera := MustNewMFVHera(tc.Params.GetRounds(), params, fvEncoder, rlwe.NewEncryptor(bgvParams, pk), fvEvaluator, 0)
+	kCt, err := fvHera.EncKey(tc.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyStreams, err := fvHera.CryptNoModSwitch(nonces, kCt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ckks_fv on the same modulus chain and slots
+	ckksFVParams, err := ckks_fv.NewParametersFromModuli(params.LogN, &ckks_fv.Moduli{Qi: bgvParams.Q(), Pi: bgvParams.P()}, params.PlainModulus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ckksFVParams.SetLogFVSlots(params.LogFVSlots())
+	ckksFVKgen := ckks_fv.NewKeyGenerator(ckksFVParams)
+	ckksFVSk, ckksFVPk := ckksFVKgen.GenKeyPair()
+	ckksFVEncoder := ckks_fv.NewMFVEncoder(ckksFVParams)
+	pDcds := ckksFVEncoder.GenSlotToCoeffMatFV(tc.Radix)
+	rotKeys := ckksFVKgen.GenRotationKeysForRotations(ckksFVKgen.GenRotationIndexesForSlotsToCoeffsMat(pDcds), true, ckksFVSk)
+	ckksFVEvaluator := ckks_fv.NewMFVEvaluator(ckksFVParams, ckks_fv.EvaluationKey{Rlk: ckksFVKgen.GenRelinearizationKey(ckksFVSk), Rtks: rotKeys}, pDcds)
+	ckksFVDecryptor := ckks_fv.NewMFVDecryptor(ckksFVParams, ckksFVSk)
+	ckksFVHera := ckks_fv.MustNewMFVHera(tc.Params.GetRounds(), ckksFVParams, ckksFVEncoder, ckks_fv.NewMFVEncryptorFromPk(ckksFVParams, ckksFVPk), ckksFVEvaluator, 0)
+	ckksFVKCt, err := ckksFVHera.EncKey(tc.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ckksFVKeyStreams, err := ckksFVHera.CryptNoModSwitch(nonces, ckksFVKCt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	symHera := hera.MustNewHera(tc.Key, tc.Params)
+	for st := 0; st < tc.Params.GetBlockSize(); st++ {
+		got := decodeSlots(params, fvEncoder, fvDecryptor, keyStreams[st])
+		want := ckksFVEncoder.DecodeUintSmallNew(ckksFVDecryptor.DecryptNew(ckksFVKeyStreams[st]))
+		for i := 0; i < numBlock; i++ {
+			if got[i] != want[i] {
+				t.Fatalf("state %d, block %d: got %d, ckks_fv %d", st, i, got[i], want[i])
+			}
+		}
+		if ks := symHera.KeyStream(nonces[st], nil); got[st] != ks[st] {
+			t.Fatalf("state %d, block %d: got %d, symmetric %d", st, st, got[st], ks[st])
+		}
+	}
+
+	ctStC, err := stc.Evaluate(fvEvaluator, keyStreams[0], make([]int, stc.Depth()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := fvDecryptor.DecryptNew(ctStC)
+	pt.IsBatched = false
+	got := make([]uint64, params.N())
+	if err = fvEncoder.Decode(pt, got); err != nil {
+		t.Fatal(err)
+	}
+	ptRt := ckks_fv.NewPlaintextRingT(ckksFVParams)
+	ckksFVEncoder.DecodeRingT(ckksFVDecryptor.DecryptNew(ckksFVEvaluator.SlotsToCoeffsNoModSwitch(ckksFVKeyStreams[0])), ptRt)
+	want := ptRt.Value()[0].Coeffs[0]
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("SlotsToCoeffs coefficient %d: got %d, ckks_fv %d", i, got[i], want[i])
+		}
+	}
+}
+
+// TestHeraProduction runs the pipeline on the production RtF parameters with the mod-down
+// presets of InitParams, so that the presets are checked against the noise growth of lattigo v6
+func TestHeraProduction(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the production RtF parameters in short mode")
+	}
+	for _, tc := range hera.TestVector {
+		t.Run(testString("HERA/Production", tc.Params)+fmt.Sprintf("/FVParamIndex=%d", tc.FVParamIndex), func(t *testing.T) {
+			testHEHera(t, tc, true)
+		})
+	}
+}
+
+// testHEHera runs the pipeline of tc on the RtF test ring, or on the production parameters
+// rtf.HeraParams[tc.FVParamIndex] with the presets of InitParams
+func testHEHera(t *testing.T, tc hera.TestContext, production bool) {
 	heHera := NewHEHera()
 	lg := heHera.logger
 	lg.PrintDataLen(tc.Key)
@@ -33,15 +249,28 @@ func testHEHera(t *testing.T, tc hera.TestContext) {
 	var nonces [][]byte
 	var keyStream [][]uint64
 
-	heHera.InitParams(tc.FVParamIndex, tc.Params)
+	if production {
+		if err := heHera.InitParams(tc.FVParamIndex, tc.Params); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		params := testParams(t, tc)
+		if err := heHera.InitParamsFromLiteral(params.ParametersLiteral, tc.Params, testModDown(params, tc)); err != nil {
+			t.Fatal(err)
+		}
+	}
 
 	heHera.HEKeyGen()
 	lg.PrintMemUsage("HEKeyGen")
 
-	heHera.HalfBootKeyGen(tc.Radix)
+	if err := heHera.HalfBootKeyGen(tc.Radix); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("HalfBootKeyGen")
 
-	heHera.InitHalfBootstrapper()
+	if err := heHera.InitHalfBootstrapper(); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("InitHalfBootstrapper")
 
 	heHera.InitEvaluator()
@@ -50,83 +279,251 @@ func testHEHera(t *testing.T, tc hera.TestContext) {
 	heHera.InitCoefficients()
 	lg.PrintMemUsage("InitCoefficients")
 
-	if heHera.fullCoefficients {
-		data = heHera.RandomDataGen(heHera.params.N())
-		lg.PrintMemUsage("RandomDataGen")
-
-		nonces = heHera.NonceGen(heHera.params.N())
-
-		keyStream = make([][]uint64, heHera.params.N())
-		symHera := hera.NewHera(tc.Key, tc.Params)
-		for i := 0; i < heHera.params.N(); i++ {
-			keyStream[i] = symHera.KeyStream(nonces[i])
-		}
-		lg.PrintMemUsage("SymKeyStreamGen")
+	fvSlots := heHera.FVSlots()
+	data = heHera.RandomDataGen(fvSlots)
+	lg.PrintMemUsage("RandomDataGen")
 
-		heHera.DataToCoefficients(data, heHera.params.N())
-		lg.PrintMemUsage("DataToCoefficients")
+	nonces = heHera.NonceGen(fvSlots)
 
-		heHera.EncodeEncrypt(keyStream, heHera.params.N())
-		lg.PrintMemUsage("EncodeEncrypt")
-	} else {
-		data = heHera.RandomDataGen(heHera.params.Slots())
-		lg.PrintMemUsage("RandomDataGen")
-
-		nonces = heHera.NonceGen(heHera.params.Slots())
-
-		keyStream = make([][]uint64, heHera.params.Slots())
-		symHera := hera.NewHera(tc.Key, tc.Params)
-		for i := 0; i < heHera.params.Slots(); i++ {
-			keyStream[i] = symHera.KeyStream(nonces[i])
-		}
-		lg.PrintMemUsage("SymKeyStreamGen")
+	keyStream = make([][]uint64, fvSlots)
+	symHera := hera.MustNewHera(tc.Key, tc.Params)
+	for i := 0; i < fvSlots; i++ {
+		keyStream[i] = append([]uint64{}, symHera.KeyStream(nonces[i], nil)...)
+	}
+	lg.PrintMemUsage("SymKeyStreamGen")
 
-		heHera.DataToCoefficients(data, heHera.params.Slots())
-		lg.PrintMemUsage("DataToCoefficients")
+	heHera.DataToCoefficients(data)
+	lg.PrintMemUsage("DataToCoefficients")
 
-		heHera.EncodeEncrypt(keyStream, heHera.params.Slots())
-		lg.PrintMemUsage("EncodeEncrypt")
+	if err := heHera.EncodeEncrypt(keyStream); err != nil {
+		t.Fatal(err)
 	}
+	lg.PrintMemUsage("EncodeEncrypt")
 
-	heHera.ScaleUp()
+	if err := heHera.ScaleUp(); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("ScaleUp")
 
-	_ = heHera.InitFvHera()
+	if _, err := heHera.InitFvHera(); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("InitFvHera")
 
 	// encrypts symmetric master key using BFV on the client side
-	heHera.EncryptSymKey(tc.Key)
+	if err := heHera.EncryptSymKey(tc.Key); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("EncryptSymKey")
 
 	// get BFV key stream using encrypted symmetric key, nonce, and counter on the server side
-	fvKeyStreams := heHera.GetFvKeyStreams(nonces)
+	fvKeyStreams, err := heHera.GetFvKeyStreams(nonces)
+	if err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("GetFvKeyStreams")
 
-	heHera.ScaleCiphertext(fvKeyStreams)
+	if err := heHera.ScaleCiphertext(fvKeyStreams); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("ScaleCiphertext")
 
-	var ctBoot *ckks_fv.Ciphertext
-	ctBoot = heHera.HalfBoot()
+	// HalfBoot modifies the ciphertext, HalfBootVector runs on a copy
+	ct := heHera.ciphertext.CopyNew()
+	ctReal, ctImag, err := heHera.HalfBoot()
+	if err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("HalfBoot")
 
-	valuesWant := make([]complex128, heHera.params.Slots())
-	for i := 0; i < heHera.params.Slots(); i++ {
-		valuesWant[i] = complex(data[0][i], 0)
+	valuesTest := decodeHalfBoot(heHera, ctReal, ctImag)
+	checkPrecision(t, ctReal, data[0], valuesTest)
+
+	heHera.ciphertext = ct
+	vector, err := heHera.HalfBootVector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lg.PrintMemUsage("HalfBootVector")
+
+	vectorValues, err := heHera.DecodeVectors([]*rtf.Vector{vector}, rtf.Layout{Rows: 1, Cols: len(data[0])})
+	if err != nil {
+		t.Fatal(err)
 	}
+	checkPrecision(t, vector.Ciphertext(0), data[0], vectorValues[0])
+}
+
+// decodeSlots returns the FVSlots slots of a BFV ciphertext
+func decodeSlots(params rtf.Parameters, encoder *bgv.Encoder, decryptor *rlwe.Decryptor, ct *rlwe.Ciphertext) []uint64 {
+	values := make([]uint64, params.N())
+	HHESoK.HandleError(encoder.Decode(decryptor.DecryptNew(ct), values))
+	return rtf.ExtractVector(values, params.FVSlots(), params.N())
+}
 
-	fmt.Println("Precision of HalfBoot(ciphertext)")
-	printDebug(heHera.params, ctBoot, valuesWant,
-		heHera.ckksDecryptor, heHera.ckksEncoder)
+// decodeHalfBoot returns the data positions of the HalfBoot output
+func decodeHalfBoot(heHera *HEHera, ctReal, ctImag *rlwe.Ciphertext) []float64 {
+	values, err := heHera.params.DecodeHalfBoot(heHera.ckksEncoder, heHera.ckksDecryptor, ctReal, ctImag)
+	HHESoK.HandleError(err)
+	return values
 }
 
-func printDebug(params *ckks_fv.Parameters, ciphertext *ckks_fv.Ciphertext, valuesWant []complex128, decryptor ckks_fv.CKKSDecryptor, encoder ckks_fv.CKKSEncoder) {
-	valuesTest := encoder.DecodeComplex(decryptor.DecryptNew(ciphertext), params.LogSlots())
-	logSlots := params.LogSlots()
-	sigma := params.Sigma()
-	fmt.Printf("Level: %d (logQ = %d)\n", ciphertext.Level(), params.LogQLvl(ciphertext.Level()))
-	fmt.Printf("Scale: 2^%f\n", math.Log2(ciphertext.Scale()))
+func checkPrecision(t *testing.T, ct *rlwe.Ciphertext, valuesWant, valuesTest []float64) {
+	maxErr := maxError(valuesWant, valuesTest)
+	fmt.Printf("Level: %d, Scale: 2^%f\n", ct.Level(), math.Log2(ct.Scale.Float64()))
 	fmt.Printf("ValuesTest: %6.10f %6.10f %6.10f %6.10f...\n", valuesTest[0], valuesTest[1], valuesTest[2], valuesTest[3])
 	fmt.Printf("ValuesWant: %6.10f %6.10f %6.10f %6.10f...\n", valuesWant[0], valuesWant[1], valuesWant[2], valuesWant[3])
-	precisionState := ckks_fv.GetPrecisionStats(params, encoder, nil, valuesWant, valuesTest, logSlots, sigma)
-	fmt.Println(precisionState.String())
+	fmt.Printf("Precision of HalfBoot(ciphertext): max error 2^%.2f\n", math.Log2(maxErr))
+	if maxErr > 1e-3 {
+		t.Fatalf("HalfBoot precision too low: max error %e", maxErr)
+	}
+}
+
+// maxError returns the largest absolute difference between the values of want and got
+func maxError(want, got []float64) (maxErr float64) {
+	for i := range want {
+		maxErr = math.Max(maxErr, math.Abs(want[i]-got[i]))
+	}
+	return
+}
+
+// TestHeraStream transciphers a dataset of more rows than one batch and narrower than the
+// output size, and checks every row against the manifest, then transciphers it batch by batch
+// and checks the rows of the first batch against its entry. The ragged, empty and too wide
+// datasets must fail before any batch is transciphered
+func TestHeraStream(t *testing.T) {
+	tc := hera.TestVector[4+hera.HR128AS]
+	t.Run(testString("HERA/Stream", tc.Params), func(t *testing.T) {
+		heHera := NewHEHera()
+		params := testParams(t, tc)
+		if err := heHera.InitParamsFromLiteral(params.ParametersLiteral, tc.Params, testModDown(params, tc)); err != nil {
+			t.Fatal(err)
+		}
+		heHera.HEKeyGen()
+		if err := heHera.HalfBootKeyGen(tc.Radix); err != nil {
+			t.Fatal(err)
+		}
+		if err := heHera.InitHalfBootstrapper(); err != nil {
+			t.Fatal(err)
+		}
+		heHera.InitEvaluator()
+		if _, err := heHera.InitFvHera(); err != nil {
+			t.Fatal(err)
+		}
+		if err := heHera.EncryptSymKey(tc.Key); err != nil {
+			t.Fatal(err)
+		}
+
+		rows, width := params.FVSlots()+5, 3
+		dataset := make([][]float64, rows)
+		for r := range dataset {
+			dataset[r] = make([]float64, width)
+			for c := range dataset[r] {
+				dataset[r][c] = float64(r*width+c)/float64(rows*width) - 0.5
+			}
+		}
+		cts, manifest, err := heHera.TranscipherStream(dataset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if manifest.Batches() != 2 || len(cts) != manifest.Ciphertexts() {
+			t.Fatalf("%d batches and %d ciphertexts, want 2 and %d", manifest.Batches(), len(cts), manifest.Ciphertexts())
+		}
+		if string(manifest.Nonces[0]) == string(manifest.Nonces[1]) {
+			t.Fatal("the batches share a nonce")
+		}
+		got, err := heHera.DecodeStream(cts, manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for r := range dataset {
+			if maxErr := maxError(dataset[r], got[r]); maxErr > 1e-3 {
+				t.Fatalf("row %d: max error %e", r, maxErr)
+			}
+		}
+
+		var yields int
+		manifest, err = heHera.TranscipherBatches(dataset, func(batchCts []*rlwe.Ciphertext, entry rtf.BatchEntry) bool {
+			yields++
+			if len(batchCts) != manifest.BatchCiphertexts() || entry.First != 0 || entry.Last != params.FVSlots() {
+				t.Fatalf("batch %d: %d ciphertexts of rows [%d, %d)", entry.Batch, len(batchCts), entry.First, entry.Last)
+			}
+			rows, err := heHera.DecodeBatch(batchCts, entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range rows {
+				if maxErr := maxError(dataset[entry.First+i], rows[i]); maxErr > 1e-3 {
+					t.Fatalf("row %d: max error %e", entry.First+i, maxErr)
+				}
+			}
+			return false
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if yields != 1 || manifest.Nonces[0] == nil || manifest.Nonces[1] != nil {
+			t.Fatalf("%d batches transciphered after the first one stopped the stream", yields)
+		}
+
+		// the invalid datasets are rejected before any batch is yielded
+		ragged := [][]float64{make([]float64, width), make([]float64, width-1)}
+		wide := [][]float64{make([]float64, heHera.outSize+1)}
+		for name, dataset := range map[string][][]float64{"ragged": ragged, "empty": nil, "wide": wide} {
+			yields = 0
+			if _, err := heHera.TranscipherBatches(dataset, func([]*rlwe.Ciphertext, rtf.BatchEntry) bool {
+				yields++
+				return true
+			}); err == nil || yields != 0 {
+				t.Fatalf("%s dataset: got error %v after %d batches", name, err, yields)
+			}
+			if _, _, err := heHera.TranscipherStream(dataset); err == nil {
+				t.Fatalf("%s dataset: no error", name)
+			}
+		}
+	})
+}
+
+// TestHeraParallel checks that the parallel driver returns the key streams of the sequential calls
+func TestHeraParallel(t *testing.T) {
+	tc := hera.TestVector[4+hera.HR128AS]
+	t.Run(testString("HERA/Parallel", tc.Params), func(t *testing.T) {
+		params := testParams(t, tc)
+		bgvParams := params.BGV()
+		modDown := testModDown(params, tc).CipherModDown
+
+		kgen := rlwe.NewKeyGenerator(bgvParams)
+		sk, pk := kgen.GenKeyPairNew()
+		rlk := kgen.GenRelinearizationKeyNew(sk)
+		fvEncoder := bgv.NewEncoder(bgvParams)
+		fvEncryptor := rlwe.NewEncryptor(bgvParams, pk)
+		fvEvaluator := bgv.NewEvaluator(bgvParams, rlwe.NewMemEvaluationKeySet(rlk))
+		fvHera := MustNewMFVHera(tc.Params.GetRounds(), params, fvEncoder, fvEncryptor, fvEvaluator, modDown[0])
+		kCt, err := fvHera.EncKey(tc.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nonce := HHESoK.NewNonce()
+		numBlock := params.FVSlots()
+		nonces := make([][][]byte, 3)
+		for i := range nonces {
+			nonces[i] = HHESoK.BlockNonces(nonce, uint64(i*numBlock), numBlock)
+		}
+
+		got, err := NewParallelMFVHera(fvHera, 2).Crypt(nonces, kCt, modDown)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range nonces {
+			want, err := fvHera.Crypt(nonces[i], kCt, modDown)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for st := range want {
+				if !got[i][st].Equal(want[st]) {
+					t.Fatalf("batch %d, element %d differs from the sequential path", i, st)
+				}
+			}
+		}
+	})
 }
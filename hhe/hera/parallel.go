@@ -0,0 +1,46 @@
+package hera
+
+import (
+	"HHESoK"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// ParallelMFVHera evaluates batches of FVSlots nonces on a pool of workers, every worker owns
+// a ShallowCopy of the MFVHera and batch i is always Crypt(nonces[i], ...), so the output is
+// identical to the sequential calls of MFVHera.Crypt
+type ParallelMFVHera struct {
+	fvHera  MFVHera
+	workers int
+}
+
+// NewParallelMFVHera returns a driver for fvHera, workers <= 0 uses one worker per CPU
+func NewParallelMFVHera(fvHera MFVHera, workers int) *ParallelMFVHera {
+	return &ParallelMFVHera{fvHera: fvHera, workers: HHESoK.Workers(workers)}
+}
+
+// Workers returns the size of the worker pool
+func (par *ParallelMFVHera) Workers() int {
+	return par.workers
+}
+
+// Crypt computes the key streams of the nonce batches, res[i] is the key stream of nonces[i]
+func (par *ParallelMFVHera) Crypt(nonces [][][]byte, kCt []*rlwe.Ciphertext, heraModDown []int) (res [][]*rlwe.Ciphertext, err error) {
+	res = make([][]*rlwe.Ciphertext, len(nonces))
+	err = HHESoK.ParallelRangeErr(len(nonces), par.workers, func(first, last int) error {
+		fvHera := par.fvHera.ShallowCopy()
+		for i := first; i < last; i++ {
+			keyStreams, err := fvHera.Crypt(nonces[i], kCt, heraModDown)
+			if err != nil {
+				return err
+			}
+			// the returned slice is the state slice of the worker, whose entries the next batch
+			// replaces with fresh copies, so only the slice is copied and the ciphertexts are kept
+			res[i] = append([]*rlwe.Ciphertext(nil), keyStreams...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
@@ -0,0 +1,84 @@
+package pasta
+
+import (
+	"HHESoK/hhe/wire"
+	"HHESoK/sym/pasta"
+	"fmt"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// Scheme is the scheme name in the header of the PASTA artifacts
+const Scheme = "pasta"
+
+func marshalParameters(params Parameter, symParams pasta.Parameter) ([]byte, error) {
+	var enc wire.Encoder
+	enc.PutInt(params.logN)
+	enc.PutUint64(params.plainMod)
+	enc.PutUint64(params.modDegree)
+	enc.PutBool(params.UseBsGs)
+	enc.PutInt(params.bSgSN1)
+	enc.PutInt(params.bSgSN2)
+	logQ, logP := params.Moduli()
+	enc.PutInts(logQ)
+	enc.PutInts(logP)
+	enc.PutInt(symParams.KeySize)
+	enc.PutInt(symParams.BlockSize)
+	enc.PutInt(symParams.Rounds)
+	enc.PutUint64(symParams.Modulus)
+	body, err := enc.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return wire.Seal(Scheme, wire.KindParameters, body), nil
+}
+
+func unmarshalParameters(data []byte) (params Parameter, symParams pasta.Parameter, err error) {
+	body, err := wire.Open(data, Scheme, wire.KindParameters)
+	if err != nil {
+		return
+	}
+	dec := wire.NewDecoder(body)
+	params.logN = dec.Int()
+	params.plainMod = dec.Uint64()
+	params.modDegree = dec.Uint64()
+	params.UseBsGs = dec.Bool()
+	params.bSgSN1 = dec.Int()
+	params.bSgSN2 = dec.Int()
+	params.logQ = dec.Ints()
+	params.logP = dec.Ints()
+	symParams.KeySize = dec.Int()
+	symParams.BlockSize = dec.Int()
+	symParams.Rounds = dec.Int()
+	symParams.Modulus = dec.Uint64()
+	if err = dec.Finish(); err != nil {
+		return
+	}
+	if len(params.logQ) == 0 || symParams.BlockSize <= 0 || symParams.KeySize != 2*symParams.BlockSize || params.plainMod != symParams.Modulus {
+		err = fmt.Errorf("%w: invalid PASTA parameters", wire.ErrFormat)
+	}
+	return
+}
+
+// marshalResult seals the transciphered blocks and the length of the message
+func marshalResult(size int, cts []*rlwe.Ciphertext) ([]byte, error) {
+	var enc wire.Encoder
+	enc.PutInt(size)
+	enc.PutCiphertexts(cts)
+	body, err := enc.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return wire.Seal(Scheme, wire.KindResult, body), nil
+}
+
+func unmarshalResult(data []byte) (size int, cts []*rlwe.Ciphertext, err error) {
+	body, err := wire.Open(data, Scheme, wire.KindResult)
+	if err != nil {
+		return
+	}
+	dec := wire.NewDecoder(body)
+	size = dec.Int()
+	cts = dec.Ciphertexts()
+	err = dec.Finish()
+	return
+}
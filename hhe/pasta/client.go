@@ -0,0 +1,124 @@
+package pasta
+
+import (
+	"HHESoK"
+	"HHESoK/hhe/wire"
+	"HHESoK/sym/pasta"
+	"fmt"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// Client is the data owner of the PASTA pipeline: it generates the BFV keys, encrypts the
+// PASTA key under BFV, encrypts its data with PASTA and decrypts the transciphered blocks.
+// Everything it sends to the Server is a wire artifact
+type Client struct {
+	params    Parameter
+	symParams pasta.Parameter
+	bfvParams bgv.Parameters
+	key       HHESoK.Key
+	symPasta  pasta.Pasta
+
+	keyGenerator *rlwe.KeyGenerator
+	sk           *rlwe.SecretKey
+	pk           *rlwe.PublicKey
+	encoder      *bgv.Encoder
+	decryptor    *rlwe.Decryptor
+	fvPasta      MFVPasta
+}
+
+// NewClient returns the client of the given parameters, it generates a fresh key pair
+func NewClient(params Parameter, symParams pasta.Parameter, key HHESoK.Key) (c *Client, err error) {
+	c = &Client{params: params, symParams: symParams, key: key}
+	if c.bfvParams, err = params.bgvParameters(); err != nil {
+		return nil, fmt.Errorf("cannot NewClient: %w", err)
+	}
+	if c.symPasta, err = pasta.NewPasta(key, symParams); err != nil {
+		return nil, fmt.Errorf("cannot NewClient: %w", err)
+	}
+
+	c.keyGenerator = rlwe.NewKeyGenerator(c.bfvParams)
+	c.sk, c.pk = c.keyGenerator.GenKeyPairNew()
+	c.encoder = bgv.NewEncoder(c.bfvParams)
+	c.decryptor = rlwe.NewDecryptor(c.bfvParams, c.sk)
+	if c.fvPasta, err = NewMFVPasta(params, c.bfvParams, symParams, c.encoder, rlwe.NewEncryptor(c.bfvParams, c.pk), nil); err != nil {
+		return nil, fmt.Errorf("cannot NewClient: %w", err)
+	}
+	return c, nil
+}
+
+// MarshalParameters returns the parameters artifact
+func (c *Client) MarshalParameters() ([]byte, error) {
+	return marshalParameters(c.params, c.symParams)
+}
+
+// GenEvaluationKeys returns the artifact of the relinearization key and the Galois keys
+// for messages of dataSize elements
+func (c *Client) GenEvaluationKeys(dataSize int) ([]byte, error) {
+	rlk := c.keyGenerator.GenRelinearizationKeyNew(c.sk)
+	galEls := c.fvPasta.GetGaloisElements(dataSize)
+	evk := rlwe.NewMemEvaluationKeySet(rlk, c.keyGenerator.GenGaloisKeysNew(galEls, c.sk)...)
+
+	var enc wire.Encoder
+	enc.PutObject(evk)
+	body, err := enc.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenEvaluationKeys: %w", err)
+	}
+	return wire.Seal(Scheme, wire.KindEvaluationKeys, body), nil
+}
+
+// EncryptSymKey returns the artifact of the PASTA key encrypted under BFV
+func (c *Client) EncryptSymKey() ([]byte, error) {
+	kCt, err := c.fvPasta.EncKey(c.key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot EncryptSymKey: %w", err)
+	}
+	var enc wire.Encoder
+	enc.PutObject(kCt)
+	body, err := enc.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("cannot EncryptSymKey: %w", err)
+	}
+	return wire.Seal(Scheme, wire.KindSymKeyCiphertext, body), nil
+}
+
+// EncryptData encrypts plaintext with PASTA under nonce, starting from block counter 0,
+// and returns the symmetric ciphertext artifact
+func (c *Client) EncryptData(nonce []byte, plaintext HHESoK.Plaintext) ([]byte, error) {
+	modulus := c.symParams.GetModulus()
+	for _, v := range plaintext {
+		if v >= modulus {
+			return nil, fmt.Errorf("cannot EncryptData: element not reduced modulo %d", modulus)
+		}
+	}
+	var enc wire.Encoder
+	enc.PutBytes(nonce)
+	enc.PutUint64s(c.symPasta.NewEncryptor().EncryptWithNonce(nonce, plaintext))
+	body, err := enc.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("cannot EncryptData: %w", err)
+	}
+	return wire.Seal(Scheme, wire.KindSymCiphertext, body), nil
+}
+
+// DecryptResult decrypts the result artifact and returns the message
+func (c *Client) DecryptResult(data []byte) (HHESoK.Plaintext, error) {
+	size, cts, err := unmarshalResult(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot DecryptResult: %w", err)
+	}
+	blockSize := c.symParams.GetBlockSize()
+	if size < 0 || size > len(cts)*blockSize {
+		return nil, fmt.Errorf("cannot DecryptResult: %w: %d elements in %d blocks", wire.ErrFormat, size, len(cts))
+	}
+	plaintext := make(HHESoK.Plaintext, 0, len(cts)*blockSize)
+	tmp := make([]uint64, c.bfvParams.MaxSlots())
+	for _, ct := range cts {
+		if err = c.encoder.Decode(c.decryptor.DecryptNew(ct), tmp); err != nil {
+			return nil, fmt.Errorf("cannot DecryptResult: %w", err)
+		}
+		plaintext = append(plaintext, tmp[:blockSize]...)
+	}
+	return plaintext[:size], nil
+}
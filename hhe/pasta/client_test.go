@@ -0,0 +1,120 @@
+package pasta
+
+import (
+	"HHESoK"
+	"HHESoK/hhe/wire"
+	"bytes"
+	"errors"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"testing"
+)
+
+// TestClientServer runs the client and the server of the pipeline, the two roles only
+// exchange byte buffers
+func TestClientServer(t *testing.T) {
+	tc := pasta3TestVector[0]
+
+	// client: keys and artifacts
+	client, err := NewClient(tc.Params, tc.SymParams, tc.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paramsBuf := new(bytes.Buffer)
+	evkBuf := new(bytes.Buffer)
+	symKeyBuf := new(bytes.Buffer)
+	symCtBuf := new(bytes.Buffer)
+	writeArtifact(t, paramsBuf)(client.MarshalParameters())
+	writeArtifact(t, evkBuf)(client.GenEvaluationKeys(len(tc.Plaintext)))
+	writeArtifact(t, symKeyBuf)(client.EncryptSymKey())
+	writeArtifact(t, symCtBuf)(client.EncryptData(HHESoK.NewNonce(), tc.Plaintext))
+
+	// server: everything is read from the buffers
+	server, err := NewServer(paramsBuf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = server.SetEvaluationKeys(evkBuf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if err = server.SetSymKeyCiphertext(symKeyBuf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = server.Transcipher(symKeyBuf.Bytes()); !errors.Is(err, wire.ErrKind) {
+		t.Fatalf("Transcipher of the key artifact: got %v, want %v", err, wire.ErrKind)
+	}
+	resBuf := new(bytes.Buffer)
+	writeArtifact(t, resBuf)(server.Transcipher(symCtBuf.Bytes()))
+
+	// client: decrypt the BFV result
+	plaintext, err := client.DecryptResult(resBuf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plaintext) != len(tc.Plaintext) {
+		t.Fatalf("got %d elements, want %d", len(plaintext), len(tc.Plaintext))
+	}
+	for i := range plaintext {
+		if plaintext[i] != tc.Plaintext[i] {
+			t.Fatalf("element %d: got %d, want %d", i, plaintext[i], tc.Plaintext[i])
+		}
+	}
+}
+
+// writeArtifact returns a function that writes the output of an artifact constructor to buf
+func writeArtifact(t *testing.T, buf *bytes.Buffer) func([]byte, error) {
+	return func(data []byte, err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(data)
+	}
+}
+
+// TestClientServerErrors checks the typed errors of the pipeline
+func TestClientServerErrors(t *testing.T) {
+	tc := pasta3TestVector[0]
+
+	if _, err := NewClient(tc.Params, tc.SymParams, tc.Key[1:]); !errors.Is(err, HHESoK.ErrKeyLength) {
+		t.Fatalf("NewClient with a short key: got %v, want %v", err, HHESoK.ErrKeyLength)
+	}
+	client, err := NewClient(tc.Params, tc.SymParams, tc.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.fvPasta.SetModDown(make(ModDown, tc.SymParams.Rounds)); !errors.Is(err, HHESoK.ErrInvalidParameters) {
+		t.Fatalf("SetModDown with a short schedule: got %v, want %v", err, HHESoK.ErrInvalidParameters)
+	}
+	modDown := make(ModDown, tc.SymParams.Rounds+2)
+	modDown[0] = client.bfvParams.MaxLevel() + 1
+	if err = client.fvPasta.SetModDown(modDown); !errors.Is(err, HHESoK.ErrInsufficientLevel) {
+		t.Fatalf("SetModDown beyond the last level: got %v, want %v", err, HHESoK.ErrInsufficientLevel)
+	}
+
+	// evaluation keys without the Galois keys of the circuit
+	var enc wire.Encoder
+	enc.PutObject(rlwe.NewMemEvaluationKeySet(client.keyGenerator.GenRelinearizationKeyNew(client.sk)))
+	body, err := enc.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	paramsBuf := new(bytes.Buffer)
+	symKeyBuf := new(bytes.Buffer)
+	symCtBuf := new(bytes.Buffer)
+	writeArtifact(t, paramsBuf)(client.MarshalParameters())
+	writeArtifact(t, symKeyBuf)(client.EncryptSymKey())
+	writeArtifact(t, symCtBuf)(client.EncryptData(HHESoK.NewNonce(), tc.Plaintext))
+
+	server, err := NewServer(paramsBuf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = server.SetEvaluationKeys(wire.Seal(Scheme, wire.KindEvaluationKeys, body)); err != nil {
+		t.Fatal(err)
+	}
+	if err = server.SetSymKeyCiphertext(symKeyBuf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = server.Transcipher(symCtBuf.Bytes()); !errors.Is(err, HHESoK.ErrMissingGaloisKey) {
+		t.Fatalf("Transcipher without Galois keys: got %v, want %v", err, HHESoK.ErrMissingGaloisKey)
+	}
+}
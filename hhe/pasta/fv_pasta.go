@@ -2,8 +2,10 @@ package pasta
 
 import (
 	"HHESoK"
+	"HHESoK/hhe/rtf"
 	"HHESoK/sym/pasta"
 	"encoding/binary"
+	"fmt"
 	"github.com/tuneinsight/lattigo/v6/core/rlwe"
 	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
 	"golang.org/x/crypto/sha3"
@@ -12,10 +14,19 @@ import (
 )
 
 type MFVPasta interface {
-	Crypt(nonce []byte, kCt *rlwe.Ciphertext, dCt HHESoK.Ciphertext) (res []*rlwe.Ciphertext)
-	EncKey(key []uint64) (res *rlwe.Ciphertext)
+	Crypt(nonce []byte, kCt *rlwe.Ciphertext, dCt HHESoK.Ciphertext) (res []*rlwe.Ciphertext, err error)
+	CryptBlock(nonce []byte, counter uint64, kCt *rlwe.Ciphertext, symCt HHESoK.Ciphertext) (res *rlwe.Ciphertext, err error)
+	ShallowCopy() MFVPasta
+	BlockSize() int
+	SetNoiseTracer(tracer *NoiseTracer)
+	SetModDown(modDown ModDown) error
+	EncKey(key []uint64) (res *rlwe.Ciphertext, err error)
 	GetGaloisElements(dataSize int) []uint64
 	UpdateEvaluator(evaluator *bgv.Evaluator)
+	BatchSize() int
+	CryptBatch(nonce []byte, kCt *rlwe.Ciphertext, dCt HHESoK.Ciphertext) (res []*rlwe.Ciphertext, err error)
+	CryptBatchWithCounter(nonce []byte, counter uint64, kCt *rlwe.Ciphertext, dCt HHESoK.Ciphertext) (res []*rlwe.Ciphertext, err error)
+	GetBatchGaloisElements() []uint64
 }
 
 type mfvPasta struct {
@@ -49,9 +60,18 @@ type mfvPasta struct {
 
 	rcPt *rlwe.Plaintext
 	rc   []uint64
+
+	tracer *NoiseTracer
+
+	modDown ModDown
 }
 
-func NEWMFVPasta(params Parameter, fvParams bgv.Parameters, symParams pasta.Parameter, encoder *bgv.Encoder, encryptor *rlwe.Encryptor, evaluator *bgv.Evaluator) MFVPasta {
+// NewMFVPasta returns the homomorphic PASTA evaluator of the parameters, the encryptor is only
+// used by EncKey and the evaluator can be set later with UpdateEvaluator
+func NewMFVPasta(params Parameter, fvParams bgv.Parameters, symParams pasta.Parameter, encoder *bgv.Encoder, encryptor *rlwe.Encryptor, evaluator *bgv.Evaluator) (MFVPasta, error) {
+	if err := checkParameters(params, fvParams, symParams); err != nil {
+		return nil, err
+	}
 	fvPasta := new(mfvPasta)
 	fvPasta.logger = HHESoK.NewLogger(HHESoK.DEBUG)
 
@@ -76,7 +96,7 @@ func NEWMFVPasta(params Parameter, fvParams bgv.Parameters, symParams pasta.Para
 	fvPasta.evaluator = evaluator
 
 	mps := uint64(0) // max prime size
-	prime := fvPasta.modDegree
+	prime := fvPasta.modulus
 
 	// count the number of valid bits of prime number, using shift to right operation
 	for prime > 0 {
@@ -89,9 +109,40 @@ func NEWMFVPasta(params Parameter, fvParams bgv.Parameters, symParams pasta.Para
 
 	fvPasta.maxPrimeSize = mps
 
+	return fvPasta, nil
+}
+
+// MustNewMFVPasta is NewMFVPasta panicking on invalid parameters
+func MustNewMFVPasta(params Parameter, fvParams bgv.Parameters, symParams pasta.Parameter, encoder *bgv.Encoder, encryptor *rlwe.Encryptor, evaluator *bgv.Evaluator) MFVPasta {
+	fvPasta, err := NewMFVPasta(params, fvParams, symParams, encoder, encryptor, evaluator)
+	HHESoK.HandleError(err)
 	return fvPasta
 }
 
+// checkParameters checks that the BGV parameters batch the PASTA blocks, the matrix
+// multiplications need a full row or half a row per block
+func checkParameters(params Parameter, fvParams bgv.Parameters, symParams pasta.Parameter) error {
+	if fvParams.PlaintextModulus() != symParams.GetModulus() {
+		return fmt.Errorf("%w: plaintext modulus %d, PASTA modulus %d", HHESoK.ErrInvalidParameters,
+			fvParams.PlaintextModulus(), symParams.GetModulus())
+	}
+	blockSize, slots := symParams.GetBlockSize(), fvParams.MaxSlots()
+	if blockSize == 0 || blockSize*2 != slots && blockSize*4 > slots {
+		return fmt.Errorf("%w: %d slots for blocks of %d elements", HHESoK.ErrSlotCapacity, slots, blockSize)
+	}
+	if params.UseBsGs && params.bSgSN1*params.bSgSN2 != blockSize {
+		return fmt.Errorf("%w: baby-step giant-step split %d*%d of blocks of %d elements", HHESoK.ErrInvalidParameters,
+			params.bSgSN1, params.bSgSN2, blockSize)
+	}
+	return nil
+}
+
+// newEvaluator returns the evaluator of the PASTA circuit, its products are scale invariant
+// as in BFV so that the circuit runs at the top level without rescaling
+func newEvaluator(params bgv.Parameters, evk rlwe.EvaluationKeySet) *bgv.Evaluator {
+	return bgv.NewEvaluator(params, evk, true)
+}
+
 // Crypt tranciphers SYM.Enc(dCt) into HE.Enc(res)
 // Parameters:
 //
@@ -102,67 +153,196 @@ func NEWMFVPasta(params Parameter, fvParams bgv.Parameters, symParams pasta.Para
 // Returns:
 //
 //	res: homomorphically encrypted cipher
-func (pas *mfvPasta) Crypt(nonce []byte, kCt *rlwe.Ciphertext, dCt HHESoK.Ciphertext) (res []*rlwe.Ciphertext) {
+//	err: wraps HHESoK.ErrMissingGaloisKey or HHESoK.ErrInsufficientLevel when the keys or
+//	the level of kCt do not fit the circuit
+func (pas *mfvPasta) Crypt(nonce []byte, kCt *rlwe.Ciphertext, dCt HHESoK.Ciphertext) (res []*rlwe.Ciphertext, err error) {
+	if err = pas.checkEvaluation(kCt, pas.keyStreamGaloisElements()); err != nil {
+		return nil, err
+	}
+
 	size := len(dCt)
-	numBlock := uint64(math.Ceil(float64(size / int(pas.plainSize))))
+	numBlock := (uint64(size) + pas.plainSize - 1) / pas.plainSize
 
 	res = make([]*rlwe.Ciphertext, numBlock)
-
-	// state = homomorphically encrypted key
-	pas.state = kCt.CopyNew()
-
-	counter := make([]byte, 8)
 	for b := uint64(0); b < numBlock; b++ {
-		binary.BigEndian.PutUint64(counter, b)
-		pas.initShake(nonce, counter)
-		R := pas.numRound
-		for r := 1; r <= R; r++ {
-			pas.logger.PrintMessages(">>> Round: ", r, " <<<")
-			// initialize random matrices and random constant
-			pas.mat1 = pas.genRandomMatrix()
-			pas.mat2 = pas.genRandomMatrix()
-			pas.rc = pas.genRcVector(pas.halfSlots)
-
-			// PASTA key stream generation circuit
-			pas.matMul()
-			pas.addRC()
-			pas.mix()
-
-			if r == R {
-				pas.sBoxCube()
-			} else {
-				pas.sBoxFeistel()
-			}
-			//	print noise for state in each round
+		var sIndex = b * pas.plainSize
+		var eIndex = int(math.Min(float64((b+1)*pas.plainSize), float64(size)))
+		if res[b], err = pas.cryptBlock(nonce, b, kCt, dCt[sIndex:eIndex]); err != nil {
+			return nil, err
 		}
-		//	final addition
+	}
+	return
+}
+
+// CryptBlock tranciphers the block symCt of the given counter, the blocks are independent and
+// each one starts from a fresh copy of the encrypted key
+func (pas *mfvPasta) CryptBlock(nonce []byte, counter uint64, kCt *rlwe.Ciphertext, symCt HHESoK.Ciphertext) (res *rlwe.Ciphertext, err error) {
+	if err = pas.checkEvaluation(kCt, pas.keyStreamGaloisElements()); err != nil {
+		return nil, err
+	}
+	if len(symCt) > int(pas.plainSize) {
+		return nil, fmt.Errorf("%w: block of %d elements, want at most %d", HHESoK.ErrSlotCapacity, len(symCt), pas.plainSize)
+	}
+	return pas.cryptBlock(nonce, counter, kCt, symCt)
+}
+
+func (pas *mfvPasta) cryptBlock(nonce []byte, counter uint64, kCt *rlwe.Ciphertext, symCt HHESoK.Ciphertext) (*rlwe.Ciphertext, error) {
+	keyStream, err := pas.keyStream(nonce, counter, kCt)
+	if err != nil {
+		return nil, err
+	}
+
+	// converting
+	plaintext := bgv.NewPlaintext(pas.bfvParams, keyStream.Level())
+	// encoded at the scale of the key stream, the addition then does not multiply its noise by
+	// a scale matching factor of up to t
+	plaintext.Scale = keyStream.Scale
+	if err = pas.encoder.Encode([]uint64(symCt), plaintext); err != nil {
+		return nil, err
+	}
+	// res = symCt - keyStream, the in place product keeps the scale of the key stream
+	if err = pas.evaluator.Mul(keyStream, -1, keyStream); err != nil {
+		return nil, err
+	}
+	if err = pas.evaluator.Add(keyStream, plaintext, keyStream); err != nil {
+		return nil, err
+	}
+	return keyStream, nil
+}
+
+// BlockSize returns the number of elements of a PASTA block
+func (pas *mfvPasta) BlockSize() int {
+	return int(pas.plainSize)
+}
+
+// SetNoiseTracer records the noise of every key stream evaluation in tracer, nil disables the tracing
+func (pas *mfvPasta) SetNoiseTracer(tracer *NoiseTracer) {
+	pas.tracer = tracer
+}
+
+// SetModDown sets the modulus switching schedule of the key stream, nil disables the switching
+func (pas *mfvPasta) SetModDown(modDown ModDown) error {
+	if err := modDown.Validate(pas.numRound, pas.bfvParams); err != nil {
+		return err
+	}
+	pas.modDown = modDown
+	return nil
+}
+
+// checkEvaluation checks that the evaluator holds the Galois keys galEls of the circuit and
+// that kCt has the levels of the modulus switching schedule
+func (pas *mfvPasta) checkEvaluation(kCt *rlwe.Ciphertext, galEls []uint64) error {
+	if err := rtf.CheckGaloisKeys(pas.evaluator, galEls); err != nil {
+		return err
+	}
+	if dropped := pas.modDown.Dropped(); kCt.Level() < dropped {
+		return fmt.Errorf("%w: key at level %d, the schedule drops %d moduli", HHESoK.ErrInsufficientLevel, kCt.Level(), dropped)
+	}
+	return nil
+}
+
+// ShallowCopy returns an evaluator sharing the parameters and the keys of pas but with its own
+// state, encoder and evaluator buffers, so that copies can transcipher blocks concurrently
+func (pas *mfvPasta) ShallowCopy() MFVPasta {
+	cp := *pas
+	cp.shake = nil
+	cp.mat1 = nil
+	cp.mat2 = nil
+	cp.state = nil
+	cp.rcPt = nil
+	cp.rc = nil
+	cp.gkIndices = make([]int, 0)
+	cp.encoder = pas.encoder.ShallowCopy()
+	if pas.encryptor != nil {
+		cp.encryptor = pas.encryptor.ShallowCopy()
+	}
+	if pas.evaluator != nil {
+		cp.evaluator = pas.evaluator.ShallowCopy()
+	}
+	return &cp
+}
+
+// keyStream evaluates the PASTA key stream of the block counter on a copy of kCt, the first
+// plainSize slots of the result hold the key stream of the block
+func (pas *mfvPasta) keyStream(nonce []byte, counter uint64, kCt *rlwe.Ciphertext) (keyStream *rlwe.Ciphertext, err error) {
+	defer func() { pas.state = nil }()
+	noise := pas.tracer.newRecorder(counter)
+	pas.state = kCt.CopyNew()
+	if err = modSwitch(pas.evaluator, pas.modDown, 0, pas.state); err != nil {
+		return nil, err
+	}
+	if err = noise.record(0, LayerKey, pas.state); err != nil {
+		return nil, err
+	}
+	pas.initShake(nonce, HHESoK.CounterBytes(counter))
+	R := pas.numRound
+	for r := 1; r <= R; r++ {
+		pas.logger.PrintMessages(">>> Round: ", r, " <<<")
+		// initialize random matrices and random constant
 		pas.mat1 = pas.genRandomMatrix()
 		pas.mat2 = pas.genRandomMatrix()
 		pas.rc = pas.genRcVector(pas.halfSlots)
 
-		pas.matMul()
-		pas.addRC()
-		pas.mix()
+		// PASTA key stream generation circuit
+		if err = pas.linearLayer(); err != nil {
+			return nil, err
+		}
+		if err = noise.record(r, LayerLinear, pas.state); err != nil {
+			return nil, err
+		}
 
-		// Q: do we need to remove the second vector?
-		// A: there's no need for that
+		layer := LayerFeistel
+		if r == R {
+			layer = LayerCube
+			err = pas.sBoxCube()
+		} else {
+			err = pas.sBoxFeistel()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err = modSwitch(pas.evaluator, pas.modDown, r, pas.state); err != nil {
+			return nil, err
+		}
+		if err = noise.record(r, layer, pas.state); err != nil {
+			return nil, err
+		}
+	}
+	//	final addition
+	pas.mat1 = pas.genRandomMatrix()
+	pas.mat2 = pas.genRandomMatrix()
+	pas.rc = pas.genRcVector(pas.halfSlots)
 
-		// converting
-		var sIndex = b * pas.plainSize
-		var eIndex = int(math.Min(float64((b+1)*pas.plainSize), float64(size)))
-		symCt := dCt[sIndex:eIndex]
-		plaintext := bgv.NewPlaintext(pas.bfvParams, pas.bfvParams.MaxLevel())
-		_ = pas.encoder.Encode(symCt, plaintext)
-		// negate state --> state = state * -1
-		pas.state, _ = pas.evaluator.MulNew(pas.state, -1)
-		// res = symCt + (-state)
-		res[b], _ = pas.evaluator.AddNew(pas.state, plaintext)
+	if err = pas.linearLayer(); err != nil {
+		return nil, err
 	}
-	return
+	if err = modSwitch(pas.evaluator, pas.modDown, R+1, pas.state); err != nil {
+		return nil, err
+	}
+	if err = noise.record(R+1, LayerLinear, pas.state); err != nil {
+		return nil, err
+	}
+	noise.done()
+	return pas.state, nil
 }
 
-func (pas *mfvPasta) EncKey(key []uint64) (res *rlwe.Ciphertext) {
+// linearLayer is the affine layer of a round: the matrix multiplication, the round constants
+// and the mixing of the two halves of the state
+func (pas *mfvPasta) linearLayer() error {
+	if err := pas.matMul(); err != nil {
+		return err
+	}
+	if err := pas.addRC(); err != nil {
+		return err
+	}
+	return pas.mix()
+}
 
+// EncKey encrypts the PASTA key, the two halves of the key fill the two rows of the slots
+func (pas *mfvPasta) EncKey(key []uint64) (res *rlwe.Ciphertext, err error) {
+	if uint64(len(key)) != 2*pas.plainSize {
+		return nil, fmt.Errorf("%w: got %d elements, want %d", HHESoK.ErrKeyLength, len(key), 2*pas.plainSize)
+	}
 	dupKey := make([]uint64, pas.halfSlots+pas.plainSize)
 
 	for i := uint64(0); i < pas.plainSize; i++ {
@@ -171,19 +351,31 @@ func (pas *mfvPasta) EncKey(key []uint64) (res *rlwe.Ciphertext) {
 	}
 
 	pKey := bgv.NewPlaintext(pas.bfvParams, pas.bfvParams.MaxLevel())
-	err := pas.encoder.Encode(dupKey, pKey)
-	HHESoK.HandleError(err)
-
-	res, err = pas.encryptor.EncryptNew(pKey)
-	HHESoK.HandleError(err)
-
-	return
+	if err = pas.encoder.Encode(dupKey, pKey); err != nil {
+		return nil, err
+	}
+	return pas.encryptor.EncryptNew(pKey)
 }
 
 func (pas *mfvPasta) GetGaloisElements(dataSize int) []uint64 {
 	pas.prepareGkIndices(dataSize)
-	galEls := make([]uint64, len(pas.gkIndices))
-	for i, k := range pas.gkIndices {
+	return pas.galoisElements(pas.gkIndices)
+}
+
+// keyStreamGaloisElements returns the Galois elements of the key stream of a block, the
+// elements of GetGaloisElements without the rotations of the flattening
+func (pas *mfvPasta) keyStreamGaloisElements() []uint64 {
+	gkIndices := pas.gkIndices
+	defer func() { pas.gkIndices = gkIndices }()
+	pas.gkIndices = make([]int, 0)
+	pas.addGkIndices()
+	return pas.galoisElements(pas.gkIndices)
+}
+
+// galoisElements returns the Galois elements of the rotations, 0 is the row rotation
+func (pas *mfvPasta) galoisElements(indices []int) []uint64 {
+	galEls := make([]uint64, len(indices))
+	for i, k := range indices {
 		if k == 0 {
 			galEls[i] = pas.bfvParams.GaloisElementForRowRotation()
 		} else {
@@ -257,28 +449,31 @@ func (pas *mfvPasta) addDiagonalIndices(size int) {
 }
 
 // ///////////////////////		PASTA's homomorphic functions		///////////////////////
-// addRC add round constant to the state
-func (pas *mfvPasta) addRC() {
-	pas.rcPt = bgv.NewPlaintext(pas.bfvParams, pas.bfvParams.MaxLevel())
-	err := pas.encoder.Encode(pas.rc, pas.rcPt)
-	HHESoK.HandleError(err)
-	err = pas.evaluator.Add(pas.state, pas.rcPt, pas.state)
-	HHESoK.HandleError(err)
-	return
+// addRC add round constant to the state, the constants are encoded at the scale of the state
+// so that the addition does not multiply the noise by a scale matching factor of up to t
+func (pas *mfvPasta) addRC() error {
+	pas.rcPt = bgv.NewPlaintext(pas.bfvParams, pas.state.Level())
+	pas.rcPt.Scale = pas.state.Scale
+	if err := pas.encoder.Encode(pas.rc, pas.rcPt); err != nil {
+		return err
+	}
+	return pas.evaluator.Add(pas.state, pas.rcPt, pas.state)
 }
 
-func (pas *mfvPasta) sBoxCube() {
+func (pas *mfvPasta) sBoxCube() error {
 	tmp := pas.state.CopyNew()
-	err := pas.evaluator.MulRelin(pas.state, pas.state, pas.state)
-	HHESoK.HandleError(err)
-	err = pas.evaluator.MulRelin(pas.state, tmp, pas.state)
-	HHESoK.HandleError(err)
+	if err := pas.evaluator.MulRelin(pas.state, pas.state, pas.state); err != nil {
+		return err
+	}
+	return pas.evaluator.MulRelin(pas.state, tmp, pas.state)
 }
 
-func (pas *mfvPasta) sBoxFeistel() {
+func (pas *mfvPasta) sBoxFeistel() error {
 	// rotate -1 to the left
 	stateRotate, err := pas.evaluator.RotateColumnsNew(pas.state, -1)
-	HHESoK.HandleError(err)
+	if err != nil {
+		return err
+	}
 
 	// generate masks
 	masks := make([]uint64, pas.plainSize+pas.halfSlots)
@@ -290,46 +485,43 @@ func (pas *mfvPasta) sBoxFeistel() {
 	for i := pas.plainSize; i < pas.halfSlots; i++ {
 		masks[i] = 0
 	}
-	maskPlaintext := bgv.NewPlaintext(pas.bfvParams, pas.bfvParams.MaxLevel())
-	err = pas.encoder.Encode(masks, maskPlaintext)
-	HHESoK.HandleError(err)
+	maskPlaintext := bgv.NewPlaintext(pas.bfvParams, pas.state.Level())
+	if err = pas.encoder.Encode(masks, maskPlaintext); err != nil {
+		return err
+	}
 	// stateRot = stateRot * mask
-	err = pas.evaluator.Mul(stateRotate, maskPlaintext, stateRotate)
-	HHESoK.HandleError(err)
+	if err = pas.evaluator.Mul(stateRotate, maskPlaintext, stateRotate); err != nil {
+		return err
+	}
 	// stateRot = stateRot ^ 2
-	err = pas.evaluator.MulRelin(stateRotate, stateRotate, stateRotate)
-	HHESoK.HandleError(err)
+	if err = pas.evaluator.MulRelin(stateRotate, stateRotate, stateRotate); err != nil {
+		return err
+	}
 	// state = state + stateRot^2
-	err = pas.evaluator.Add(pas.state, stateRotate, pas.state)
-	HHESoK.HandleError(err)
+	return pas.evaluator.Add(pas.state, stateRotate, pas.state)
 }
 
-func (pas *mfvPasta) matMul() {
+func (pas *mfvPasta) matMul() error {
 	if pas.useBsGs {
-		pas.babyStepGiantStep()
-	} else {
-		pas.diagonal()
+		return pas.babyStepGiantStep()
 	}
-	return
+	return pas.diagonal()
 }
 
-func (pas *mfvPasta) babyStepGiantStep() {
+func (pas *mfvPasta) babyStepGiantStep() error {
 	var err error
 	matrixDim := pas.plainSize
 	slots := pas.slots
 
 	if (matrixDim*2 != slots) && (matrixDim*4 > slots) {
-		panic("Slots are too short for matmul implementation!")
-	}
-
-	if pas.bsGsN1*pas.bsGsN2 != matrixDim {
-		println("WARNING: the baby-step giant-step parameters are wrong!")
+		return fmt.Errorf("%w: %d slots for a %d matrix", HHESoK.ErrSlotCapacity, slots, matrixDim)
 	}
 
 	// Prepare diagonal
 	matrix := make([]*rlwe.Plaintext, matrixDim)
 	for i := uint64(0); i < matrixDim; i++ {
-		diag := make([]uint64, matrixDim+pas.halfSlots)
+		// both diagonals are rotated over the matrix dimension before they are placed in the rows
+		diag := make([]uint64, matrixDim)
 		tmp := make([]uint64, matrixDim)
 
 		k := i / pas.bsGsN1
@@ -366,19 +558,22 @@ func (pas *mfvPasta) babyStepGiantStep() {
 			diag[j] = tmp[j-pas.halfSlots]
 		}
 
-		row := bgv.NewPlaintext(pas.bfvParams, pas.bfvParams.MaxLevel())
-		err = pas.encoder.Encode(diag, row)
-		HHESoK.HandleError(err)
+		row := bgv.NewPlaintext(pas.bfvParams, pas.state.Level())
+		if err = pas.encoder.Encode(diag, row); err != nil {
+			return err
+		}
 		matrix[i] = row
 	}
 
 	//	non-full-packed rotation
 	if pas.halfSlots != pas.plainSize {
 		stateRotate := pas.state.CopyNew()
-		err = pas.evaluator.RotateColumns(pas.state, int(pas.plainSize), stateRotate)
-		HHESoK.HandleError(err)
-		err = pas.evaluator.Add(pas.state, stateRotate, pas.state)
-		HHESoK.HandleError(err)
+		if err = pas.evaluator.RotateColumns(pas.state, int(pas.plainSize), stateRotate); err != nil {
+			return err
+		}
+		if err = pas.evaluator.Add(pas.state, stateRotate, pas.state); err != nil {
+			return err
+		}
 	}
 
 	rotates := make([]*rlwe.Ciphertext, pas.bsGsN1)
@@ -386,39 +581,57 @@ func (pas *mfvPasta) babyStepGiantStep() {
 
 	var outerSum *rlwe.Ciphertext
 	for j := uint64(1); j < pas.bsGsN1; j++ {
-		rotates[j], err = pas.evaluator.RotateColumnsNew(rotates[j-1], -1)
-		HHESoK.HandleError(err)
+		if rotates[j], err = pas.evaluator.RotateColumnsNew(rotates[j-1], -1); err != nil {
+			return err
+		}
 	}
 
 	for k := uint64(0); k < pas.bsGsN2; k++ {
-		innerSum, _ := pas.evaluator.MulNew(rotates[0], matrix[k*pas.bsGsN1])
+		innerSum, err := pas.evaluator.MulNew(rotates[0], matrix[k*pas.bsGsN1])
+		if err != nil {
+			return err
+		}
 		for j := uint64(1); j < pas.bsGsN1; j++ {
-			temp, _ := pas.evaluator.MulNew(rotates[0], matrix[k*pas.bsGsN1+j])
-			_ = pas.evaluator.Add(innerSum, temp, innerSum)
+			temp, err := pas.evaluator.MulNew(rotates[j], matrix[k*pas.bsGsN1+j])
+			if err != nil {
+				return err
+			}
+			if err = pas.evaluator.Add(innerSum, temp, innerSum); err != nil {
+				return err
+			}
 		}
 		if k == 0 {
 			outerSum = innerSum
 		} else {
-			innerSum, _ = pas.evaluator.RotateColumnsNew(innerSum, -int(k*pas.bsGsN1))
-			_ = pas.evaluator.Add(outerSum, innerSum, outerSum)
+			if innerSum, err = pas.evaluator.RotateColumnsNew(innerSum, -int(k*pas.bsGsN1)); err != nil {
+				return err
+			}
+			if err = pas.evaluator.Add(outerSum, innerSum, outerSum); err != nil {
+				return err
+			}
 		}
 	}
 	pas.state = outerSum
+	return nil
 }
 
-func (pas *mfvPasta) diagonal() {
+func (pas *mfvPasta) diagonal() error {
 	var err error
 	matrixDim := pas.plainSize
 	slots := pas.slots
 
 	if (matrixDim*2 != slots) && (matrixDim*4 > slots) {
-		panic("Slots are too short for matmul implementation!")
+		return fmt.Errorf("%w: %d slots for a %d matrix", HHESoK.ErrSlotCapacity, slots, matrixDim)
 	}
 
 	if pas.halfSlots != matrixDim {
-		stateRotate, _ := pas.evaluator.RotateColumnsNew(pas.state, int(matrixDim))
-		err = pas.evaluator.Add(pas.state, stateRotate, pas.state)
-		HHESoK.HandleError(err)
+		stateRotate, err := pas.evaluator.RotateColumnsNew(pas.state, int(matrixDim))
+		if err != nil {
+			return err
+		}
+		if err = pas.evaluator.Add(pas.state, stateRotate, pas.state); err != nil {
+			return err
+		}
 	}
 
 	//	prepare diagonal method
@@ -434,31 +647,43 @@ func (pas *mfvPasta) diagonal() {
 			diag[j+pas.halfSlots] = pas.mat2[j][(j+matrixDim-i)%matrixDim]
 		}
 
-		row := bgv.NewPlaintext(pas.bfvParams, pas.bfvParams.MaxLevel())
-		err = pas.encoder.Encode(diag, row)
-		HHESoK.HandleError(err)
+		row := bgv.NewPlaintext(pas.bfvParams, pas.state.Level())
+		if err = pas.encoder.Encode(diag, row); err != nil {
+			return err
+		}
 		matrix[i] = row
 	}
 
 	sum := pas.state.CopyNew()
-	err = pas.evaluator.Mul(sum, matrix[0], sum)
-	HHESoK.HandleError(err)
+	if err = pas.evaluator.Mul(sum, matrix[0], sum); err != nil {
+		return err
+	}
 	for i := uint64(1); i < matrixDim; i++ {
-		pas.state, _ = pas.evaluator.RotateColumnsNew(pas.state, -1)
-		tmp, _ := pas.evaluator.MulNew(pas.state, matrix[i])
-		_ = pas.evaluator.Add(sum, tmp, sum)
+		if pas.state, err = pas.evaluator.RotateColumnsNew(pas.state, -1); err != nil {
+			return err
+		}
+		tmp, err := pas.evaluator.MulNew(pas.state, matrix[i])
+		if err != nil {
+			return err
+		}
+		if err = pas.evaluator.Add(sum, tmp, sum); err != nil {
+			return err
+		}
 	}
 	pas.state = sum
+	return nil
 }
 
-func (pas *mfvPasta) mix() {
+func (pas *mfvPasta) mix() error {
 	originalState := pas.state.CopyNew()
 	tmp, err := pas.evaluator.RotateRowsNew(pas.state)
-	HHESoK.HandleError(err)
-	err = pas.evaluator.Add(tmp, originalState, tmp)
-	HHESoK.HandleError(err)
-	err = pas.evaluator.Add(originalState, tmp, pas.state)
-	HHESoK.HandleError(err)
+	if err != nil {
+		return err
+	}
+	if err = pas.evaluator.Add(tmp, originalState, tmp); err != nil {
+		return err
+	}
+	return pas.evaluator.Add(originalState, tmp, pas.state)
 }
 
 // ///////////////////////		PASTA's non-homomorphic functions	///////////////////////
@@ -490,10 +715,10 @@ func (pas *mfvPasta) genRcVector(size uint64) []uint64 {
 	ps := pas.plainSize
 	rc := make([]uint64, size+ps)
 	for i := uint64(0); i < ps; i++ {
-		rc[i] = pas.generateRandomFieldElement(false)
+		rc[i] = pas.generateRandomFieldElement(true)
 	}
 	for i := size; i < (size + ps); i++ {
-		rc[i] = pas.generateRandomFieldElement(false)
+		rc[i] = pas.generateRandomFieldElement(true)
 	}
 	return rc
 }
@@ -0,0 +1,352 @@
+package pasta
+
+import (
+	"HHESoK"
+	"fmt"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+	"golang.org/x/crypto/sha3"
+	"math/bits"
+)
+
+// The batched mode packs BatchSize() independent PASTA blocks side by side, block k of a batch
+// holds its left state in the slots [k*plainSize, (k+1)*plainSize) of the first row and its right
+// state in the same slots of the second row. The blocks of a batch use consecutive counters and
+// every block keeps its own random matrices and round constants.
+
+// BatchSize returns the number of blocks that are transciphered by one evaluation of the circuit
+func (pas *mfvPasta) BatchSize() int {
+	return int(pas.halfSlots / pas.plainSize)
+}
+
+// CryptBatch tranciphers SYM.Enc(dCt) into HE.Enc(res) with BatchSize() blocks per ciphertext
+// Parameters:
+//
+//	nonce
+//	kCt: homomorphically encrypted symmetric key, as returned by EncKey
+//	dCt: symmetrically encrypted ciphertext
+//
+// Returns:
+//
+//	res: homomorphically encrypted cipher, the first row of res[i] holds the plaintext
+//	elements [i*BatchSize()*plainSize, (i+1)*BatchSize()*plainSize) in order
+//	err: wraps HHESoK.ErrSlotCapacity when the slots do not hold a power of two blocks
+func (pas *mfvPasta) CryptBatch(nonce []byte, kCt *rlwe.Ciphertext, dCt HHESoK.Ciphertext) (res []*rlwe.Ciphertext, err error) {
+	return pas.CryptBatchWithCounter(nonce, 0, kCt, dCt)
+}
+
+// CryptBatchWithCounter is CryptBatch for the symmetric ciphertext of
+// EncryptWithCounter(nonce, counter, ...), block b of dCt uses the counter counter+b
+func (pas *mfvPasta) CryptBatchWithCounter(nonce []byte, counter uint64, kCt *rlwe.Ciphertext, dCt HHESoK.Ciphertext) (res []*rlwe.Ciphertext, err error) {
+	batchSize := uint64(pas.BatchSize())
+	if batchSize == 0 || bits.OnesCount64(batchSize) != 1 || pas.halfSlots%pas.plainSize != 0 {
+		return nil, fmt.Errorf("%w: %d slots for batches of blocks of %d elements", HHESoK.ErrSlotCapacity, pas.slots, pas.plainSize)
+	}
+	if err = pas.checkEvaluation(kCt, pas.GetBatchGaloisElements()); err != nil {
+		return nil, err
+	}
+
+	size := uint64(len(dCt))
+	numBlock := (size + pas.plainSize - 1) / pas.plainSize
+	numBatch := (numBlock + batchSize - 1) / batchSize
+
+	// the key is replicated once in every block of the batch
+	batchKey, err := pas.replicateKey(kCt)
+	if err != nil {
+		return nil, err
+	}
+
+	res = make([]*rlwe.Ciphertext, numBatch)
+	for i := uint64(0); i < numBatch; i++ {
+		first := i * batchSize
+		last := min(first+batchSize, numBlock)
+		keyStream, err := pas.keyStreamBatch(nonce, counter+first, last-first, batchKey)
+		if err != nil {
+			return nil, err
+		}
+
+		// converting, the symmetric ciphertext of the batch is already contiguous in the first row
+		symCt := dCt[first*pas.plainSize : min(last*pas.plainSize, size)]
+		plaintext := bgv.NewPlaintext(pas.bfvParams, keyStream.Level())
+		plaintext.Scale = keyStream.Scale
+		if err = pas.encoder.Encode([]uint64(symCt), plaintext); err != nil {
+			return nil, err
+		}
+		// res = symCt - keyStream
+		if err = pas.evaluator.Mul(keyStream, -1, keyStream); err != nil {
+			return nil, err
+		}
+		if err = pas.evaluator.Add(keyStream, plaintext, keyStream); err != nil {
+			return nil, err
+		}
+		res[i] = keyStream
+	}
+	return
+}
+
+// GetBatchGaloisElements returns the Galois elements of the batched mode, they do not depend
+// on the data size since the blocks of a batch are never flattened
+func (pas *mfvPasta) GetBatchGaloisElements() []uint64 {
+	// row rotation of mix and the right rotation of the feistel and the baby steps
+	indices := []int{0, -1}
+	// rotation to the next block for the wrapping part of the diagonals
+	if pas.plainSize != pas.halfSlots {
+		indices = append(indices, int(pas.plainSize))
+	}
+	n1, n2 := pas.batchBsGs()
+	for k := uint64(1); k < n2; k++ {
+		indices = append(indices, -int(k*n1))
+	}
+	// key replication
+	for s := pas.plainSize; s < pas.halfSlots; s <<= 1 {
+		indices = append(indices, -int(s))
+	}
+
+	galEls := make([]uint64, 0, len(indices))
+	seen := make(map[uint64]bool)
+	for _, k := range indices {
+		var galEl uint64
+		if k == 0 {
+			galEl = pas.bfvParams.GaloisElementForRowRotation()
+		} else {
+			galEl = pas.bfvParams.GaloisElementForColRotation(k)
+		}
+		if !seen[galEl] {
+			seen[galEl] = true
+			galEls = append(galEls, galEl)
+		}
+	}
+	return galEls
+}
+
+// batchBsGs returns the baby-step giant-step split of the batched matrix multiplication,
+// the diagonal method is the split with a single giant step
+func (pas *mfvPasta) batchBsGs() (n1, n2 uint64) {
+	if pas.useBsGs && pas.bsGsN1*pas.bsGsN2 == pas.plainSize {
+		return pas.bsGsN1, pas.bsGsN2
+	}
+	return pas.plainSize, 1
+}
+
+// replicateKey copies the key of the first block of kCt into every block of the batch
+func (pas *mfvPasta) replicateKey(kCt *rlwe.Ciphertext) (*rlwe.Ciphertext, error) {
+	batchKey := kCt.CopyNew()
+	for s := pas.plainSize; s < pas.halfSlots; s <<= 1 {
+		tmp, err := pas.evaluator.RotateColumnsNew(batchKey, -int(s))
+		if err != nil {
+			return nil, err
+		}
+		if err = pas.evaluator.Add(batchKey, tmp, batchKey); err != nil {
+			return nil, err
+		}
+	}
+	return batchKey, nil
+}
+
+// keyStreamBatch evaluates the PASTA key streams of the counters [first, first+numBlock) on a
+// copy of batchKey, block k of the result holds the key stream of the counter first+k
+func (pas *mfvPasta) keyStreamBatch(nonce []byte, first, numBlock uint64, batchKey *rlwe.Ciphertext) (keyStream *rlwe.Ciphertext, err error) {
+	defer func() { pas.shake, pas.state = nil, nil }()
+	shakes := make([]sha3.ShakeHash, numBlock)
+	for k := range shakes {
+		pas.initShake(nonce, HHESoK.CounterBytes(first+uint64(k)))
+		shakes[k] = pas.shake
+	}
+
+	noise := pas.tracer.newRecorder(first)
+	pas.state = batchKey.CopyNew()
+	if err = modSwitch(pas.evaluator, pas.modDown, 0, pas.state); err != nil {
+		return nil, err
+	}
+	if err = noise.record(0, LayerKey, pas.state); err != nil {
+		return nil, err
+	}
+	R := pas.numRound
+	for r := 0; r <= R; r++ {
+		pas.logger.PrintMessages(">>> Round: ", r+1, " <<<")
+		// the random matrices and round constants of every block, in the order of the sequential circuit
+		mat1 := make([][][]uint64, numBlock)
+		mat2 := make([][][]uint64, numBlock)
+		rc := make([]uint64, pas.slots)
+		for k, shake := range shakes {
+			pas.shake = shake
+			mat1[k] = pas.genRandomMatrix()
+			mat2[k] = pas.genRandomMatrix()
+			blockRc := pas.genRcVector(pas.halfSlots)
+			offset := uint64(k) * pas.plainSize
+			copy(rc[offset:offset+pas.plainSize], blockRc[:pas.plainSize])
+			copy(rc[pas.halfSlots+offset:pas.halfSlots+offset+pas.plainSize], blockRc[pas.halfSlots:])
+		}
+		pas.rc = rc
+
+		if err = pas.matMulBatch(mat1, mat2); err != nil {
+			return nil, err
+		}
+		if err = pas.addRC(); err != nil {
+			return nil, err
+		}
+		if err = pas.mix(); err != nil {
+			return nil, err
+		}
+		if r == R {
+			if err = modSwitch(pas.evaluator, pas.modDown, r+1, pas.state); err != nil {
+				return nil, err
+			}
+		}
+		if err = noise.record(r+1, LayerLinear, pas.state); err != nil {
+			return nil, err
+		}
+
+		// the last round is followed by the final affine layer only
+		if r == R {
+			break
+		}
+		layer := LayerFeistel
+		if r == R-1 {
+			layer = LayerCube
+			err = pas.sBoxCube()
+		} else {
+			err = pas.sBoxFeistelBatch(numBlock)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err = modSwitch(pas.evaluator, pas.modDown, r+1, pas.state); err != nil {
+			return nil, err
+		}
+		if err = noise.record(r+1, layer, pas.state); err != nil {
+			return nil, err
+		}
+	}
+	noise.done()
+	return pas.state, nil
+}
+
+// sBoxFeistelBatch is the feistel S-box, the first slot of every block is masked out so that
+// the rotation does not carry the last element of the previous block
+func (pas *mfvPasta) sBoxFeistelBatch(numBlock uint64) error {
+	stateRotate, err := pas.evaluator.RotateColumnsNew(pas.state, -1)
+	if err != nil {
+		return err
+	}
+
+	masks := make([]uint64, pas.slots)
+	for k := uint64(0); k < numBlock; k++ {
+		for j := uint64(1); j < pas.plainSize; j++ {
+			masks[k*pas.plainSize+j] = 1
+			masks[pas.halfSlots+k*pas.plainSize+j] = 1
+		}
+	}
+	maskPlaintext := bgv.NewPlaintext(pas.bfvParams, pas.state.Level())
+	if err = pas.encoder.Encode(masks, maskPlaintext); err != nil {
+		return err
+	}
+	if err = pas.evaluator.Mul(stateRotate, maskPlaintext, stateRotate); err != nil {
+		return err
+	}
+	if err = pas.evaluator.MulRelin(stateRotate, stateRotate, stateRotate); err != nil {
+		return err
+	}
+	return pas.evaluator.Add(pas.state, stateRotate, pas.state)
+}
+
+// matMulBatch multiplies every block by its own matrices, mat1[k] on the first row and mat2[k]
+// on the second row. Diagonal i of a block reads the element j-i of the same block, which is
+// in the state rotated by -i for j >= i and in the state rotated by plainSize-i for j < i,
+// so every diagonal is split in two plaintexts. The plaintexts of the giant step k are rotated
+// by k*n1 over the rows, as in the baby-step giant-step method of the sequential circuit
+func (pas *mfvPasta) matMulBatch(mat1, mat2 [][][]uint64) error {
+	var err error
+	ps := pas.plainSize
+	hs := pas.halfSlots
+	wrap := ps != hs
+	n1, n2 := pas.batchBsGs()
+
+	// diagonals returns the pre-rotated split diagonal i of all the blocks
+	diagonals := func(i, rot uint64) (direct, wrapped []uint64) {
+		direct = make([]uint64, pas.slots)
+		wrapped = make([]uint64, pas.slots)
+		for k := range mat1 {
+			for j := uint64(0); j < ps; j++ {
+				slot := (uint64(k)*ps + j + hs - rot) % hs
+				col := (j + ps - i) % ps
+				if j >= i || !wrap {
+					direct[slot] = mat1[k][j][col]
+					direct[hs+slot] = mat2[k][j][col]
+				} else {
+					wrapped[slot] = mat1[k][j][col]
+					wrapped[hs+slot] = mat2[k][j][col]
+				}
+			}
+		}
+		return
+	}
+
+	// baby steps of both parts of the diagonals
+	rotates := make([]*rlwe.Ciphertext, n1)
+	rotates[0] = pas.state
+	for j := uint64(1); j < n1; j++ {
+		if rotates[j], err = pas.evaluator.RotateColumnsNew(rotates[j-1], -1); err != nil {
+			return err
+		}
+	}
+	var wrapRotates []*rlwe.Ciphertext
+	if wrap {
+		wrapRotates = make([]*rlwe.Ciphertext, n1)
+		if wrapRotates[0], err = pas.evaluator.RotateColumnsNew(pas.state, int(ps)); err != nil {
+			return err
+		}
+		for j := uint64(1); j < n1; j++ {
+			if wrapRotates[j], err = pas.evaluator.RotateColumnsNew(wrapRotates[j-1], -1); err != nil {
+				return err
+			}
+		}
+	}
+
+	var outerSum *rlwe.Ciphertext
+	for k := uint64(0); k < n2; k++ {
+		var innerSum *rlwe.Ciphertext
+		for j := uint64(0); j < n1; j++ {
+			i := k*n1 + j
+			direct, wrapped := diagonals(i, k*n1)
+			if err = pas.mulAddDiagonal(&innerSum, rotates[j], direct); err != nil {
+				return err
+			}
+			// the wrapped part of the first diagonal is empty
+			if wrap && i > 0 {
+				if err = pas.mulAddDiagonal(&innerSum, wrapRotates[j], wrapped); err != nil {
+					return err
+				}
+			}
+		}
+		if k == 0 {
+			outerSum = innerSum
+		} else {
+			if innerSum, err = pas.evaluator.RotateColumnsNew(innerSum, -int(k*n1)); err != nil {
+				return err
+			}
+			if err = pas.evaluator.Add(outerSum, innerSum, outerSum); err != nil {
+				return err
+			}
+		}
+	}
+	pas.state = outerSum
+	return nil
+}
+
+// mulAddDiagonal adds ct * diag to sum, sum is allocated by the first call
+func (pas *mfvPasta) mulAddDiagonal(sum **rlwe.Ciphertext, ct *rlwe.Ciphertext, diag []uint64) error {
+	row := bgv.NewPlaintext(pas.bfvParams, ct.Level())
+	if err := pas.encoder.Encode(diag, row); err != nil {
+		return err
+	}
+	tmp, err := pas.evaluator.MulNew(ct, row)
+	if err != nil {
+		return err
+	}
+	if *sum == nil {
+		*sum = tmp
+		return nil
+	}
+	return pas.evaluator.Add(*sum, tmp, *sum)
+}
@@ -2,20 +2,25 @@ package pasta
 
 import (
 	"HHESoK"
+	"HHESoK/hhe/rtf"
 	"HHESoK/sym/pasta"
 	"encoding/binary"
+	"fmt"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
 	"golang.org/x/crypto/sha3"
 	"math"
 	"math/big"
 )
 
 type MFVPastaPack interface {
-	Crypt(nonce []byte, kCt *rlwe.Ciphertext, dCt []uint64) (res []*rlwe.Ciphertext)
-	EncKey(key []uint64) (res *rlwe.Ciphertext)
+	Crypt(nonce []byte, kCt *rlwe.Ciphertext, dCt []uint64) (res []*rlwe.Ciphertext, err error)
+	EncKey(key []uint64) (res *rlwe.Ciphertext, err error)
 	GetGaloisElements(dataSize int) []uint64
-	UpdateEvaluator(evaluator *bfv.Evaluator)
-	Flatten(ciphers []*rlwe.Ciphertext) (cipher *rlwe.Ciphertext)
-	Mask(cipher *rlwe.Ciphertext, mask []uint64)
+	UpdateEvaluator(evaluator *bgv.Evaluator)
+	Flatten(ciphers []*rlwe.Ciphertext) (cipher *rlwe.Ciphertext, err error)
+	Mask(cipher *rlwe.Ciphertext, mask []uint64) error
+	SetModDown(modDown ModDown) error
 }
 
 type mfvPastaPack struct {
@@ -43,9 +48,9 @@ type mfvPastaPack struct {
 	bsGsN2    uint64
 	gkIndices []int
 
-	bfvParams bfv.Parameters
-	encoder   *bfv.Encoder
-	evaluator *bfv.Evaluator
+	bfvParams bgv.Parameters
+	encoder   *bgv.Encoder
+	evaluator *bgv.Evaluator
 	encryptor *rlwe.Encryptor
 
 	rcPt *rlwe.Plaintext
@@ -53,15 +58,22 @@ type mfvPastaPack struct {
 
 	rem  int
 	mask []uint64
+
+	modDown ModDown
 }
 
-func NEWMFVPastaPack(params Parameter, fvParams bfv.Parameters, symParams pasta.Parameter, encoder *bfv.Encoder, encryptor *rlwe.Encryptor, evaluator *bfv.Evaluator) MFVPastaPack {
+// NewMFVPastaPack returns the homomorphic PASTA evaluator whose blocks are flattened into
+// one ciphertext, the encryptor is only used by EncKey
+func NewMFVPastaPack(params Parameter, fvParams bgv.Parameters, symParams pasta.Parameter, encoder *bgv.Encoder, encryptor *rlwe.Encryptor, evaluator *bgv.Evaluator) (MFVPastaPack, error) {
+	if err := checkParameters(params, fvParams, symParams); err != nil {
+		return nil, err
+	}
 	fvPastaPack := new(mfvPastaPack)
 	fvPastaPack.logger = HHESoK.NewLogger(HHESoK.DEBUG)
 
 	fvPastaPack.bfvParams = fvParams
 	fvPastaPack.numRound = symParams.Rounds
-	fvPastaPack.plainSize = uint64(symParams.PlainSize)
+	fvPastaPack.plainSize = uint64(symParams.BlockSize)
 
 	fvPastaPack.logN = fvParams.LogN()
 	fvPastaPack.modDegree = params.modDegree
@@ -80,7 +92,7 @@ func NEWMFVPastaPack(params Parameter, fvParams bfv.Parameters, symParams pasta.
 	fvPastaPack.evaluator = evaluator
 
 	mps := uint64(0) // max prime size
-	prime := fvPastaPack.modDegree
+	prime := fvPastaPack.modulus
 
 	// count the number of valid bits of prime number, using shift to right operation
 	for prime > 0 {
@@ -93,6 +105,13 @@ func NEWMFVPastaPack(params Parameter, fvParams bfv.Parameters, symParams pasta.
 
 	fvPastaPack.maxPrimeSize = mps
 
+	return fvPastaPack, nil
+}
+
+// MustNewMFVPastaPack is NewMFVPastaPack panicking on invalid parameters
+func MustNewMFVPastaPack(params Parameter, fvParams bgv.Parameters, symParams pasta.Parameter, encoder *bgv.Encoder, encryptor *rlwe.Encryptor, evaluator *bgv.Evaluator) MFVPastaPack {
+	fvPastaPack, err := NewMFVPastaPack(params, fvParams, symParams, encoder, encryptor, evaluator)
+	HHESoK.HandleError(err)
 	return fvPastaPack
 }
 
@@ -106,66 +125,110 @@ func NEWMFVPastaPack(params Parameter, fvParams bfv.Parameters, symParams pasta.
 // Returns:
 //
 //	res: homomorphically encrypted cipher
-func (pas *mfvPastaPack) Crypt(nonce []byte, kCt *rlwe.Ciphertext, dCt []uint64) (res []*rlwe.Ciphertext) {
+//	err: wraps HHESoK.ErrMissingGaloisKey or HHESoK.ErrInsufficientLevel when the keys or
+//	the level of kCt do not fit the circuit
+func (pas *mfvPastaPack) Crypt(nonce []byte, kCt *rlwe.Ciphertext, dCt []uint64) (res []*rlwe.Ciphertext, err error) {
+	if err = pas.checkEvaluation(kCt); err != nil {
+		return nil, err
+	}
+
 	size := len(dCt)
-	numBlock := uint64(math.Ceil(float64(size / int(pas.plainSize))))
+	numBlock := (uint64(size) + pas.plainSize - 1) / pas.plainSize
 
 	res = make([]*rlwe.Ciphertext, numBlock)
-
-	// state = homomorphically encrypted key
-	pas.state = kCt.CopyNew()
-
-	counter := make([]byte, 8)
 	for b := uint64(0); b < numBlock; b++ {
-		binary.BigEndian.PutUint64(counter, b)
-		pas.initShake(nonce, counter)
-		R := pas.numRound
-		for r := 1; r <= R; r++ {
-			pas.logger.PrintMessages(">>> Round: ", r, " <<<")
-			// initialize random matrices and random constant
-			pas.mat1 = pas.genRandomMatrix()
-			pas.mat2 = pas.genRandomMatrix()
-			pas.rc = pas.genRcVector(pas.halfSlots)
-
-			// PASTA key stream generation circuit
-			pas.matMul()
-			pas.addRC()
-			pas.mix()
-
-			if r == R {
-				pas.sBoxCube()
-			} else {
-				pas.sBoxFeistel()
-			}
-			//	print noise for state in each round
+		// the blocks are independent, each one starts from a fresh copy of the encrypted key
+		keyStream, err := pas.keyStream(nonce, HHESoK.CounterBytes(b), kCt)
+		if err != nil {
+			return nil, err
 		}
-		//	final addition
-		pas.mat1 = pas.genRandomMatrix()
-		pas.mat2 = pas.genRandomMatrix()
-		pas.rc = pas.genRcVector(pas.halfSlots)
-
-		pas.matMul()
-		pas.addRC()
-		pas.mix()
-
-		// Q: do we need to remove the second vector?
-		// A: there's no need for that
 
 		// converting
 		var sIndex = b * pas.plainSize
 		var eIndex = int(math.Min(float64((b+1)*pas.plainSize), float64(size)))
 		cTmp := dCt[sIndex:eIndex]
-		plaintext := bfv.NewPlaintext(pas.bfvParams, pas.bfvParams.MaxLevel())
-		_ = pas.encoder.Encode(cTmp, plaintext)
-		// negate state --> state = state * -1
-		pas.state, _ = pas.evaluator.MulNew(pas.state, -1)
-		// res = symCt + (-state)
-		res[b], _ = pas.evaluator.AddNew(pas.state, plaintext)
+		plaintext := bgv.NewPlaintext(pas.bfvParams, keyStream.Level())
+		plaintext.Scale = keyStream.Scale
+		if err = pas.encoder.Encode(cTmp, plaintext); err != nil {
+			return nil, err
+		}
+		// res = symCt - keyStream, the in place product keeps the scale of the key stream
+		if err = pas.evaluator.Mul(keyStream, -1, keyStream); err != nil {
+			return nil, err
+		}
+		if err = pas.evaluator.Add(keyStream, plaintext, keyStream); err != nil {
+			return nil, err
+		}
+		res[b] = keyStream
 	}
 	return
 }
 
-func (pas *mfvPastaPack) EncKey(key []uint64) (res *rlwe.Ciphertext) {
+// keyStream evaluates the PASTA key stream of the block counter on a copy of kCt, the first
+// plainSize slots of the result hold the key stream of the block
+func (pas *mfvPastaPack) keyStream(nonce, counter []byte, kCt *rlwe.Ciphertext) (keyStream *rlwe.Ciphertext, err error) {
+	defer func() { pas.state = nil }()
+	pas.state = kCt.CopyNew()
+	if err = modSwitch(pas.evaluator, pas.modDown, 0, pas.state); err != nil {
+		return nil, err
+	}
+	pas.initShake(nonce, counter)
+	R := pas.numRound
+	for r := 1; r <= R; r++ {
+		pas.logger.PrintMessages(">>> Round: ", r, " <<<")
+		// initialize random matrices and random constant
+		pas.mat1 = pas.genRandomMatrix()
+		pas.mat2 = pas.genRandomMatrix()
+		pas.rc = pas.genRcVector(pas.halfSlots)
+
+		// PASTA key stream generation circuit
+		if err = pas.linearLayer(); err != nil {
+			return nil, err
+		}
+
+		if r == R {
+			err = pas.sBoxCube()
+		} else {
+			err = pas.sBoxFeistel()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err = modSwitch(pas.evaluator, pas.modDown, r, pas.state); err != nil {
+			return nil, err
+		}
+	}
+	//	final addition
+	pas.mat1 = pas.genRandomMatrix()
+	pas.mat2 = pas.genRandomMatrix()
+	pas.rc = pas.genRcVector(pas.halfSlots)
+
+	if err = pas.linearLayer(); err != nil {
+		return nil, err
+	}
+	if err = modSwitch(pas.evaluator, pas.modDown, R+1, pas.state); err != nil {
+		return nil, err
+	}
+	return pas.state, nil
+}
+
+// linearLayer is the affine layer of a round: the matrix multiplication, the round constants
+// and the mixing of the two halves of the state
+func (pas *mfvPastaPack) linearLayer() error {
+	if err := pas.matMul(); err != nil {
+		return err
+	}
+	if err := pas.addRC(); err != nil {
+		return err
+	}
+	return pas.mix()
+}
+
+// EncKey encrypts the PASTA key, the two halves of the key fill the two rows of the slots
+func (pas *mfvPastaPack) EncKey(key []uint64) (res *rlwe.Ciphertext, err error) {
+	if uint64(len(key)) != 2*pas.plainSize {
+		return nil, fmt.Errorf("%w: got %d elements, want %d", HHESoK.ErrKeyLength, len(key), 2*pas.plainSize)
+	}
 	dupKey := make([]uint64, pas.halfSlots+pas.plainSize)
 
 	for i := uint64(0); i < pas.plainSize; i++ {
@@ -173,20 +236,22 @@ func (pas *mfvPastaPack) EncKey(key []uint64) (res *rlwe.Ciphertext) {
 		dupKey[i+pas.halfSlots] = key[i+pas.plainSize]
 	}
 
-	pKey := bfv.NewPlaintext(pas.bfvParams, pas.bfvParams.MaxLevel())
-	err := pas.encoder.Encode(dupKey, pKey)
-	HHESoK.HandleError(err)
-
-	res, err = pas.encryptor.EncryptNew(pKey)
-	HHESoK.HandleError(err)
-
-	return
+	pKey := bgv.NewPlaintext(pas.bfvParams, pas.bfvParams.MaxLevel())
+	if err = pas.encoder.Encode(dupKey, pKey); err != nil {
+		return nil, err
+	}
+	return pas.encryptor.EncryptNew(pKey)
 }
 
 func (pas *mfvPastaPack) GetGaloisElements(dataSize int) []uint64 {
 	pas.prepareGkIndices(dataSize)
-	galEls := make([]uint64, len(pas.gkIndices))
-	for i, k := range pas.gkIndices {
+	return pas.galoisElements(pas.gkIndices)
+}
+
+// galoisElements returns the Galois elements of the rotations, 0 is the row rotation
+func (pas *mfvPastaPack) galoisElements(indices []int) []uint64 {
+	galEls := make([]uint64, len(indices))
+	for i, k := range indices {
 		if k == 0 {
 			galEls[i] = pas.bfvParams.GaloisElementForRowRotation()
 		} else {
@@ -196,61 +261,104 @@ func (pas *mfvPastaPack) GetGaloisElements(dataSize int) []uint64 {
 	return galEls
 }
 
-func (pas *mfvPastaPack) UpdateEvaluator(evaluator *bfv.Evaluator) {
+func (pas *mfvPastaPack) UpdateEvaluator(evaluator *bgv.Evaluator) {
 	pas.evaluator = evaluator
 }
 
-func (pas *mfvPastaPack) Flatten(ciphers []*rlwe.Ciphertext) (cipher *rlwe.Ciphertext) {
-	var err error
-	cipher = ciphers[0].CopyNew()
-	tmp := ciphers[0].CopyNew()
-
-	for i := 1; i < len(ciphers); i++ {
-		k := -(i * int(pas.plainSize))
-		tmp, err = pas.evaluator.RotateColumnsNew(ciphers[i], k)
-		HHESoK.HandleError(err)
-		err = pas.evaluator.Add(cipher, tmp, cipher)
-		HHESoK.HandleError(err)
+// SetModDown sets the modulus switching schedule of the key stream, nil disables the switching
+func (pas *mfvPastaPack) SetModDown(modDown ModDown) error {
+	if err := modDown.Validate(pas.numRound, pas.bfvParams); err != nil {
+		return err
 	}
+	pas.modDown = modDown
+	return nil
+}
 
-	return
+// checkEvaluation checks that the evaluator holds the Galois keys of the key stream circuit
+// and that kCt has the levels of the modulus switching schedule
+func (pas *mfvPastaPack) checkEvaluation(kCt *rlwe.Ciphertext) error {
+	gkIndices := pas.gkIndices
+	pas.gkIndices = make([]int, 0)
+	pas.addGkIndices()
+	galEls := pas.galoisElements(pas.gkIndices)
+	pas.gkIndices = gkIndices
+
+	if err := rtf.CheckGaloisKeys(pas.evaluator, galEls); err != nil {
+		return err
+	}
+	if dropped := pas.modDown.Dropped(); kCt.Level() < dropped {
+		return fmt.Errorf("%w: key at level %d, the schedule drops %d moduli", HHESoK.ErrInsufficientLevel, kCt.Level(), dropped)
+	}
+	return nil
 }
 
-func (pas *mfvPastaPack) Mask(cipher *rlwe.Ciphertext, mask []uint64) {
-	var err error
-	plaintext := bfv.NewPlaintext(pas.bfvParams, pas.bfvParams.MaxLevel())
+// Flatten rotates every block of ciphers to its position in the data and adds them, the
+// evaluation keys must hold the rotations of GetGaloisElements for the size of the data
+func (pas *mfvPastaPack) Flatten(ciphers []*rlwe.Ciphertext) (cipher *rlwe.Ciphertext, err error) {
+	if len(ciphers) == 0 {
+		return nil, fmt.Errorf("%w: no ciphertext to flatten", HHESoK.ErrInvalidParameters)
+	}
+	galEls := make([]uint64, 0, len(ciphers))
+	for i := 1; i < len(ciphers); i++ {
+		galEls = append(galEls, pas.bfvParams.GaloisElementForColRotation(-(i * int(pas.plainSize))))
+	}
+	if err = rtf.CheckGaloisKeys(pas.evaluator, galEls); err != nil {
+		return nil, err
+	}
 
-	err = pas.encoder.Encode(mask, plaintext)
-	HHESoK.HandleError(err)
+	cipher = ciphers[0].CopyNew()
+	for i := 1; i < len(ciphers); i++ {
+		k := -(i * int(pas.plainSize))
+		tmp, err := pas.evaluator.RotateColumnsNew(ciphers[i], k)
+		if err != nil {
+			return nil, err
+		}
+		if err = pas.evaluator.Add(cipher, tmp, cipher); err != nil {
+			return nil, err
+		}
+	}
+	return cipher, nil
+}
 
-	err = pas.evaluator.Mul(cipher, plaintext, cipher) // ct = ct * pt
-	HHESoK.HandleError(err)
+// Mask multiplies cipher by the plaintext of mask in place
+func (pas *mfvPastaPack) Mask(cipher *rlwe.Ciphertext, mask []uint64) error {
+	if uint64(len(mask)) > pas.slots {
+		return fmt.Errorf("%w: mask of %d elements for %d slots", HHESoK.ErrSlotCapacity, len(mask), pas.slots)
+	}
+	plaintext := bgv.NewPlaintext(pas.bfvParams, cipher.Level())
+	if err := pas.encoder.Encode(mask, plaintext); err != nil {
+		return err
+	}
+	return pas.evaluator.Mul(cipher, plaintext, cipher) // ct = ct * pt
 }
 
 // ///////////////////////		PASTA's homomorphic functions		///////////////////////
 
-// addRC add round constant to the state
-func (pas *mfvPastaPack) addRC() {
-	pas.rcPt = bfv.NewPlaintext(pas.bfvParams, pas.bfvParams.MaxLevel())
-	err := pas.encoder.Encode(pas.rc, pas.rcPt)
-	HHESoK.HandleError(err)
-	err = pas.evaluator.Add(pas.state, pas.rcPt, pas.state)
-	HHESoK.HandleError(err)
-	return
+// addRC add round constant to the state, the constants are encoded at the scale of the state
+// so that the addition does not multiply the noise by a scale matching factor of up to t
+func (pas *mfvPastaPack) addRC() error {
+	pas.rcPt = bgv.NewPlaintext(pas.bfvParams, pas.state.Level())
+	pas.rcPt.Scale = pas.state.Scale
+	if err := pas.encoder.Encode(pas.rc, pas.rcPt); err != nil {
+		return err
+	}
+	return pas.evaluator.Add(pas.state, pas.rcPt, pas.state)
 }
 
-func (pas *mfvPastaPack) sBoxCube() {
+func (pas *mfvPastaPack) sBoxCube() error {
 	tmp := pas.state.CopyNew()
-	err := pas.evaluator.MulRelin(pas.state, pas.state, pas.state)
-	HHESoK.HandleError(err)
-	err = pas.evaluator.MulRelin(pas.state, tmp, pas.state)
-	HHESoK.HandleError(err)
+	if err := pas.evaluator.MulRelin(pas.state, pas.state, pas.state); err != nil {
+		return err
+	}
+	return pas.evaluator.MulRelin(pas.state, tmp, pas.state)
 }
 
-func (pas *mfvPastaPack) sBoxFeistel() {
+func (pas *mfvPastaPack) sBoxFeistel() error {
 	// rotate -1 to the left
 	stateRotate, err := pas.evaluator.RotateColumnsNew(pas.state, -1)
-	HHESoK.HandleError(err)
+	if err != nil {
+		return err
+	}
 
 	// generate masks
 	masks := make([]uint64, pas.plainSize+pas.halfSlots)
@@ -262,46 +370,43 @@ func (pas *mfvPastaPack) sBoxFeistel() {
 	for i := pas.plainSize; i < pas.halfSlots; i++ {
 		masks[i] = 0
 	}
-	maskPlaintext := bfv.NewPlaintext(pas.bfvParams, pas.bfvParams.MaxLevel())
-	err = pas.encoder.Encode(masks, maskPlaintext)
-	HHESoK.HandleError(err)
+	maskPlaintext := bgv.NewPlaintext(pas.bfvParams, pas.state.Level())
+	if err = pas.encoder.Encode(masks, maskPlaintext); err != nil {
+		return err
+	}
 	// stateRot = stateRot * mask
-	err = pas.evaluator.Mul(stateRotate, maskPlaintext, stateRotate)
-	HHESoK.HandleError(err)
+	if err = pas.evaluator.Mul(stateRotate, maskPlaintext, stateRotate); err != nil {
+		return err
+	}
 	// stateRot = stateRot ^ 2
-	err = pas.evaluator.MulRelin(stateRotate, stateRotate, stateRotate)
-	HHESoK.HandleError(err)
+	if err = pas.evaluator.MulRelin(stateRotate, stateRotate, stateRotate); err != nil {
+		return err
+	}
 	// state = state + stateRot^2
-	err = pas.evaluator.Add(pas.state, stateRotate, pas.state)
-	HHESoK.HandleError(err)
+	return pas.evaluator.Add(pas.state, stateRotate, pas.state)
 }
 
-func (pas *mfvPastaPack) matMul() {
+func (pas *mfvPastaPack) matMul() error {
 	if pas.useBsGs {
-		pas.babyStepGiantStep()
-	} else {
-		pas.diagonal()
+		return pas.babyStepGiantStep()
 	}
-	return
+	return pas.diagonal()
 }
 
-func (pas *mfvPastaPack) babyStepGiantStep() {
+func (pas *mfvPastaPack) babyStepGiantStep() error {
 	var err error
 	matrixDim := pas.plainSize
 	slots := pas.slots
 
 	if (matrixDim*2 != slots) && (matrixDim*4 > slots) {
-		panic("Slots are too short for matmul implementation!")
-	}
-
-	if pas.bsGsN1*pas.bsGsN2 != matrixDim {
-		println("WARNING: the baby-step giant-step parameters are wrong!")
+		return fmt.Errorf("%w: %d slots for a %d matrix", HHESoK.ErrSlotCapacity, slots, matrixDim)
 	}
 
 	// Prepare diagonal
 	matrix := make([]*rlwe.Plaintext, matrixDim)
 	for i := uint64(0); i < matrixDim; i++ {
-		diag := make([]uint64, matrixDim+pas.halfSlots)
+		// both diagonals are rotated over the matrix dimension before they are placed in the rows
+		diag := make([]uint64, matrixDim)
 		tmp := make([]uint64, matrixDim)
 
 		k := i / pas.bsGsN1
@@ -338,19 +443,22 @@ func (pas *mfvPastaPack) babyStepGiantStep() {
 			diag[j] = tmp[j-pas.halfSlots]
 		}
 
-		row := bfv.NewPlaintext(pas.bfvParams, pas.bfvParams.MaxLevel())
-		err = pas.encoder.Encode(diag, row)
-		HHESoK.HandleError(err)
+		row := bgv.NewPlaintext(pas.bfvParams, pas.state.Level())
+		if err = pas.encoder.Encode(diag, row); err != nil {
+			return err
+		}
 		matrix[i] = row
 	}
 
 	//	non-full-packed rotation
 	if pas.halfSlots != pas.plainSize {
 		stateRotate := pas.state.CopyNew()
-		err = pas.evaluator.RotateColumns(pas.state, int(pas.plainSize), stateRotate)
-		HHESoK.HandleError(err)
-		err = pas.evaluator.Add(pas.state, stateRotate, pas.state)
-		HHESoK.HandleError(err)
+		if err = pas.evaluator.RotateColumns(pas.state, int(pas.plainSize), stateRotate); err != nil {
+			return err
+		}
+		if err = pas.evaluator.Add(pas.state, stateRotate, pas.state); err != nil {
+			return err
+		}
 	}
 
 	rotates := make([]*rlwe.Ciphertext, pas.bsGsN1)
@@ -358,39 +466,57 @@ func (pas *mfvPastaPack) babyStepGiantStep() {
 
 	var outerSum *rlwe.Ciphertext
 	for j := uint64(1); j < pas.bsGsN1; j++ {
-		rotates[j], err = pas.evaluator.RotateColumnsNew(rotates[j-1], -1)
-		HHESoK.HandleError(err)
+		if rotates[j], err = pas.evaluator.RotateColumnsNew(rotates[j-1], -1); err != nil {
+			return err
+		}
 	}
 
 	for k := uint64(0); k < pas.bsGsN2; k++ {
-		innerSum, _ := pas.evaluator.MulNew(rotates[0], matrix[k*pas.bsGsN1])
+		innerSum, err := pas.evaluator.MulNew(rotates[0], matrix[k*pas.bsGsN1])
+		if err != nil {
+			return err
+		}
 		for j := uint64(1); j < pas.bsGsN1; j++ {
-			temp, _ := pas.evaluator.MulNew(rotates[0], matrix[k*pas.bsGsN1+j])
-			_ = pas.evaluator.Add(innerSum, temp, innerSum)
+			temp, err := pas.evaluator.MulNew(rotates[j], matrix[k*pas.bsGsN1+j])
+			if err != nil {
+				return err
+			}
+			if err = pas.evaluator.Add(innerSum, temp, innerSum); err != nil {
+				return err
+			}
 		}
 		if k == 0 {
 			outerSum = innerSum
 		} else {
-			innerSum, _ = pas.evaluator.RotateColumnsNew(innerSum, -int(k*pas.bsGsN1))
-			_ = pas.evaluator.Add(outerSum, innerSum, outerSum)
+			if innerSum, err = pas.evaluator.RotateColumnsNew(innerSum, -int(k*pas.bsGsN1)); err != nil {
+				return err
+			}
+			if err = pas.evaluator.Add(outerSum, innerSum, outerSum); err != nil {
+				return err
+			}
 		}
 	}
 	pas.state = outerSum
+	return nil
 }
 
-func (pas *mfvPastaPack) diagonal() {
+func (pas *mfvPastaPack) diagonal() error {
 	var err error
 	matrixDim := pas.plainSize
 	slots := pas.slots
 
 	if (matrixDim*2 != slots) && (matrixDim*4 > slots) {
-		panic("Slots are too short for matmul implementation!")
+		return fmt.Errorf("%w: %d slots for a %d matrix", HHESoK.ErrSlotCapacity, slots, matrixDim)
 	}
 
 	if pas.halfSlots != matrixDim {
-		stateRotate, _ := pas.evaluator.RotateColumnsNew(pas.state, int(matrixDim))
-		err = pas.evaluator.Add(pas.state, stateRotate, pas.state)
-		HHESoK.HandleError(err)
+		stateRotate, err := pas.evaluator.RotateColumnsNew(pas.state, int(matrixDim))
+		if err != nil {
+			return err
+		}
+		if err = pas.evaluator.Add(pas.state, stateRotate, pas.state); err != nil {
+			return err
+		}
 	}
 
 	//	prepare diagonal method
@@ -406,31 +532,43 @@ func (pas *mfvPastaPack) diagonal() {
 			diag[j+pas.halfSlots] = pas.mat2[j][(j+matrixDim-i)%matrixDim]
 		}
 
-		row := bfv.NewPlaintext(pas.bfvParams, pas.bfvParams.MaxLevel())
-		err = pas.encoder.Encode(diag, row)
-		HHESoK.HandleError(err)
+		row := bgv.NewPlaintext(pas.bfvParams, pas.state.Level())
+		if err = pas.encoder.Encode(diag, row); err != nil {
+			return err
+		}
 		matrix[i] = row
 	}
 
 	sum := pas.state.CopyNew()
-	err = pas.evaluator.Mul(sum, matrix[0], sum)
-	HHESoK.HandleError(err)
+	if err = pas.evaluator.Mul(sum, matrix[0], sum); err != nil {
+		return err
+	}
 	for i := uint64(1); i < matrixDim; i++ {
-		pas.state, _ = pas.evaluator.RotateColumnsNew(pas.state, -1)
-		tmp, _ := pas.evaluator.MulNew(pas.state, matrix[i])
-		_ = pas.evaluator.Add(sum, tmp, sum)
+		if pas.state, err = pas.evaluator.RotateColumnsNew(pas.state, -1); err != nil {
+			return err
+		}
+		tmp, err := pas.evaluator.MulNew(pas.state, matrix[i])
+		if err != nil {
+			return err
+		}
+		if err = pas.evaluator.Add(sum, tmp, sum); err != nil {
+			return err
+		}
 	}
 	pas.state = sum
+	return nil
 }
 
-func (pas *mfvPastaPack) mix() {
+func (pas *mfvPastaPack) mix() error {
 	originalState := pas.state.CopyNew()
 	tmp, err := pas.evaluator.RotateRowsNew(pas.state)
-	HHESoK.HandleError(err)
-	err = pas.evaluator.Add(tmp, originalState, tmp)
-	HHESoK.HandleError(err)
-	err = pas.evaluator.Add(originalState, tmp, pas.state)
-	HHESoK.HandleError(err)
+	if err != nil {
+		return err
+	}
+	if err = pas.evaluator.Add(tmp, originalState, tmp); err != nil {
+		return err
+	}
+	return pas.evaluator.Add(originalState, tmp, pas.state)
 }
 
 // ///////////////////////		PASTA's non-homomorphic functions	///////////////////////
@@ -463,10 +601,10 @@ func (pas *mfvPastaPack) genRcVector(size uint64) []uint64 {
 	ps := pas.plainSize
 	rc := make([]uint64, size+ps)
 	for i := uint64(0); i < ps; i++ {
-		rc[i] = pas.generateRandomFieldElement(false)
+		rc[i] = pas.generateRandomFieldElement(true)
 	}
 	for i := size; i < (size + ps); i++ {
-		rc[i] = pas.generateRandomFieldElement(false)
+		rc[i] = pas.generateRandomFieldElement(true)
 	}
 	return rc
 }
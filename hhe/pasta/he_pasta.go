@@ -57,20 +57,17 @@ func NewHEPasta() *HEPasta {
 	return hePasta
 }
 
-func (pas *HEPasta) InitParams(params Parameter, symParams pasta.Parameter) {
+func (pas *HEPasta) InitParams(params Parameter, symParams pasta.Parameter) error {
+	fvParams, err := params.bgvParameters()
+	if err != nil {
+		return err
+	}
 	pas.params = params
 	pas.symParams = symParams
 	pas.outSize = symParams.GetBlockSize()
 	pas.N = 1 << params.logN
-	// create bfvParams from Literal
-	fvParams, err := bgv.NewParametersFromLiteral(bgv.ParametersLiteral{
-		LogN:             params.logN,
-		LogQ:             []int{60, 59, 59, 57, 57, 55, 55, 53, 53, 51, 51, 47, 47},
-		LogP:             []int{57, 57, 55, 55, 53, 53, 51, 51, 47, 47},
-		PlaintextModulus: params.plainMod,
-	})
-	HHESoK.HandleError(err)
 	pas.bfvParams = fvParams
+	return nil
 }
 
 func (pas *HEPasta) HEKeyGen() {
@@ -87,15 +84,19 @@ func (pas *HEPasta) HEKeyGen() {
 		1<<params.LogN(), params.PlaintextModulus(), params.LogQP(), params.Xe(), params.Xe(), params.LogMaxSlots())
 }
 
-func (pas *HEPasta) InitFvPasta() MFVPasta {
-	pas.fvPasta = NEWMFVPasta(
+func (pas *HEPasta) InitFvPasta() (MFVPasta, error) {
+	fvPasta, err := NewMFVPasta(
 		pas.params,
 		pas.bfvParams,
 		pas.symParams,
 		pas.encoder,
 		pas.encryptor,
 		pas.evaluator)
-	return pas.fvPasta
+	if err != nil {
+		return nil, err
+	}
+	pas.fvPasta = fvPasta
+	return pas.fvPasta, nil
 }
 
 func (pas *HEPasta) CreateGaloisKeys(dataSize int) {
@@ -103,25 +104,106 @@ func (pas *HEPasta) CreateGaloisKeys(dataSize int) {
 	galEls := pas.fvPasta.GetGaloisElements(dataSize)
 	pas.glk = pas.keyGenerator.GenGaloisKeysNew(galEls, pas.sk)
 	pas.evk = rlwe.NewMemEvaluationKeySet(pas.rlk, pas.glk...)
-	pas.evaluator = bgv.NewEvaluator(pas.bfvParams, pas.evk)
+	pas.evaluator = newEvaluator(pas.bfvParams, pas.evk)
+	pas.fvPasta.UpdateEvaluator(pas.evaluator)
+}
+
+// CreateBatchGaloisKeys generates the evaluation keys of the batched mode
+func (pas *HEPasta) CreateBatchGaloisKeys() {
+	pas.rlk = pas.keyGenerator.GenRelinearizationKeyNew(pas.sk)
+	galEls := pas.fvPasta.GetBatchGaloisElements()
+	pas.glk = pas.keyGenerator.GenGaloisKeysNew(galEls, pas.sk)
+	pas.evk = rlwe.NewMemEvaluationKeySet(pas.rlk, pas.glk...)
+	pas.evaluator = newEvaluator(pas.bfvParams, pas.evk)
 	pas.fvPasta.UpdateEvaluator(pas.evaluator)
 }
 
-func (pas *HEPasta) EncryptSymKey(key HHESoK.Key) {
-	pas.symKeyCt = pas.fvPasta.EncKey(key)
+func (pas *HEPasta) EncryptSymKey(key HHESoK.Key) error {
+	symKeyCt, err := pas.fvPasta.EncKey(key)
+	if err != nil {
+		return err
+	}
+	pas.symKeyCt = symKeyCt
 	pas.logger.PrintMessages(">> Symmetric Key #slots: ", pas.symKeyCt.Slots())
+	return nil
+}
+
+func (pas *HEPasta) Trancipher(nonce []byte, dCt []uint64) ([]*rlwe.Ciphertext, error) {
+	return pas.fvPasta.Crypt(nonce, pas.symKeyCt, dCt)
+}
+
+// EnableNoiseTracing records the noise budget of the next transcipherings, the tracer holds the secret key
+func (pas *HEPasta) EnableNoiseTracing() *NoiseTracer {
+	tracer := NewNoiseTracer(pas.bfvParams, pas.sk)
+	pas.fvPasta.SetNoiseTracer(tracer)
+	return tracer
+}
+
+// SetModDown sets the modulus switching schedule of the transcipherings, nil keeps the top level
+func (pas *HEPasta) SetModDown(modDown ModDown) error {
+	return pas.fvPasta.SetModDown(modDown)
+}
+
+// TuneModDown traces the key stream of one block at the top level, sets the schedule of
+// ScheduleModDown and checks with a second trace that headroom bits of noise budget are left.
+// The encrypted symmetric key must be set, the noise tracing is disabled afterwards
+func (pas *HEPasta) TuneModDown(headroom int) (modDown ModDown, report NoiseReport, err error) {
+	trace := func() (NoiseReport, error) {
+		tracer := pas.EnableNoiseTracing()
+		defer pas.fvPasta.SetNoiseTracer(nil)
+		if _, err := pas.fvPasta.CryptBlock(HHESoK.NewNonce(), 0, pas.symKeyCt, make(HHESoK.Ciphertext, pas.outSize)); err != nil {
+			return NoiseReport{}, err
+		}
+		return tracer.Reports()[0], nil
+	}
+
+	_ = pas.fvPasta.SetModDown(nil)
+	if report, err = trace(); err != nil {
+		return
+	}
+	if modDown, err = ScheduleModDown(report, pas.bfvParams, headroom); err != nil {
+		return
+	}
+	if err = pas.fvPasta.SetModDown(modDown); err != nil {
+		return nil, report, err
+	}
+	if report, err = trace(); err != nil {
+		_ = pas.fvPasta.SetModDown(nil)
+		return nil, report, err
+	}
+	if budget := report.MinBudget(); budget < float64(headroom) {
+		_ = pas.fvPasta.SetModDown(nil)
+		return nil, report, fmt.Errorf("mod-down %v leaves %.2f bits of noise budget, want %d", modDown, budget, headroom)
+	}
+	return
 }
 
-func (pas *HEPasta) Trancipher(nonce []byte, dCt []uint64) []*rlwe.Ciphertext {
-	tranCipData := pas.fvPasta.Crypt(nonce, pas.symKeyCt, dCt)
-	return tranCipData
+// TrancipherParallel tranciphers the blocks of dCt on workers goroutines, workers <= 0 uses one worker per CPU
+func (pas *HEPasta) TrancipherParallel(nonce []byte, dCt []uint64, workers int) ([]*rlwe.Ciphertext, error) {
+	return NewParallelMFVPasta(pas.fvPasta, workers).Crypt(nonce, pas.symKeyCt, dCt)
+}
+
+// TrancipherBatch tranciphers dCt with the batched mode, every ciphertext holds BatchSize() blocks
+func (pas *HEPasta) TrancipherBatch(nonce []byte, dCt []uint64) ([]*rlwe.Ciphertext, error) {
+	return pas.fvPasta.CryptBatch(nonce, pas.symKeyCt, dCt)
 }
 
 // Decrypt homomorphic ciphertext
-func (pas *HEPasta) Decrypt(ciphertext *rlwe.Ciphertext) (res []uint64) {
+func (pas *HEPasta) Decrypt(ciphertext *rlwe.Ciphertext) ([]uint64, error) {
+	tmp := make([]uint64, pas.bfvParams.MaxSlots())
+	pt := pas.decryptor.DecryptNew(ciphertext)
+	if err := pas.encoder.Decode(pt, tmp); err != nil {
+		return nil, err
+	}
+	return tmp[:pas.symParams.GetBlockSize()], nil
+}
+
+// DecryptBatch decrypts a ciphertext of the batched mode, it returns the first row of the slots
+func (pas *HEPasta) DecryptBatch(ciphertext *rlwe.Ciphertext) ([]uint64, error) {
 	tmp := make([]uint64, pas.bfvParams.MaxSlots())
 	pt := pas.decryptor.DecryptNew(ciphertext)
-	err := pas.encoder.Decode(pt, tmp)
-	HHESoK.HandleError(err)
-	return tmp[:pas.symParams.GetBlockSize()]
+	if err := pas.encoder.Decode(pt, tmp); err != nil {
+		return nil, err
+	}
+	return tmp[:pas.bfvParams.MaxSlots()/2], nil
 }
@@ -58,20 +58,17 @@ func NewHEPastaPack() *HEPastaPack {
 	return hePasta
 }
 
-func (pas *HEPastaPack) InitParams(params Parameter, symParams pasta.Parameter) {
+func (pas *HEPastaPack) InitParams(params Parameter, symParams pasta.Parameter) error {
+	fvParams, err := params.bgvParameters()
+	if err != nil {
+		return err
+	}
 	pas.params = params
 	pas.symParams = symParams
 	pas.outSize = 16
 	pas.N = 1 << params.logN
-	// create bfvParams from Literal
-	fvParams, err := bgv.NewParametersFromLiteral(bgv.ParametersLiteral{
-		LogN:             params.logN,
-		LogQ:             []int{60, 59, 59, 57, 57, 55, 55, 53, 53, 51, 51, 47, 47},
-		LogP:             []int{57, 57, 55, 55, 53, 53, 51, 51, 47, 47},
-		PlaintextModulus: params.plainMod,
-	})
-	HHESoK.HandleError(err)
 	pas.bfvParams = fvParams
+	return nil
 }
 
 func (pas *HEPastaPack) HEKeyGen() {
@@ -88,15 +85,19 @@ func (pas *HEPastaPack) HEKeyGen() {
 		1<<params.LogN(), params.PlaintextModulus(), params.LogQP(), params.Xe(), params.Xe(), params.LogMaxSlots())
 }
 
-func (pas *HEPastaPack) InitFvPasta() MFVPastaPack {
-	pas.fvPasta = NEWMFVPastaPack(
+func (pas *HEPastaPack) InitFvPasta() (MFVPastaPack, error) {
+	fvPasta, err := NewMFVPastaPack(
 		pas.params,
 		pas.bfvParams,
 		pas.symParams,
 		pas.encoder,
 		pas.encryptor,
 		pas.evaluator)
-	return pas.fvPasta
+	if err != nil {
+		return nil, err
+	}
+	pas.fvPasta = fvPasta
+	return pas.fvPasta, nil
 }
 
 func (pas *HEPastaPack) CreateGaloisKeys(dataSize int) {
@@ -104,7 +105,7 @@ func (pas *HEPastaPack) CreateGaloisKeys(dataSize int) {
 	galEls := pas.fvPasta.GetGaloisElements(dataSize)
 	pas.glk = pas.keyGenerator.GenGaloisKeysNew(galEls, pas.sk)
 	pas.evk = rlwe.NewMemEvaluationKeySet(pas.rlk, pas.glk...)
-	pas.evaluator = bgv.NewEvaluator(pas.bfvParams, pas.evk)
+	pas.evaluator = newEvaluator(pas.bfvParams, pas.evk)
 	pas.fvPasta.UpdateEvaluator(pas.evaluator)
 }
 
@@ -120,38 +121,48 @@ func (pas *HEPastaPack) RandomDataGen() (data []uint64) {
 	return
 }
 
-func (pas *HEPastaPack) EncryptSymKey(key HHESoK.Key) {
-	pas.symKeyCt = pas.fvPasta.EncKey(key)
+func (pas *HEPastaPack) EncryptSymKey(key HHESoK.Key) error {
+	symKeyCt, err := pas.fvPasta.EncKey(key)
+	if err != nil {
+		return err
+	}
+	pas.symKeyCt = symKeyCt
 	pas.logger.PrintMessages(">> Symmetric Key #slots: ", pas.symKeyCt.Slots())
+	return nil
+}
+
+func (pas *HEPastaPack) Trancipher(nonces []byte, dCt []uint64) ([]*rlwe.Ciphertext, error) {
+	return pas.fvPasta.Crypt(nonces, pas.symKeyCt, dCt)
 }
 
-func (pas *HEPastaPack) Trancipher(nonces []byte, dCt []uint64) []*rlwe.Ciphertext {
-	tranCipData := pas.fvPasta.Crypt(nonces, pas.symKeyCt, dCt)
-	return tranCipData
+// SetModDown sets the modulus switching schedule of the transcipherings, the schedule of
+// HEPasta.TuneModDown with the same parameters fits the packed circuit
+func (pas *HEPastaPack) SetModDown(modDown ModDown) error {
+	return pas.fvPasta.SetModDown(modDown)
 }
 
 // Decrypt homomorphic ciphertext
-func (pas *HEPastaPack) Decrypt(ciphertext *rlwe.Ciphertext) (res []uint64) {
+func (pas *HEPastaPack) Decrypt(ciphertext *rlwe.Ciphertext) ([]uint64, error) {
 	tmp := make([]uint64, ciphertext.Slots())
 	pt := pas.decryptor.DecryptNew(ciphertext)
-	err := pas.encoder.Decode(pt, tmp)
-	HHESoK.HandleError(err)
-	return tmp[:pas.N]
+	if err := pas.encoder.Decode(pt, tmp); err != nil {
+		return nil, err
+	}
+	return tmp[:pas.N], nil
 }
 
-func (pas *HEPastaPack) Flatten(ciphers []*rlwe.Ciphertext, dataSize int) (res *rlwe.Ciphertext) {
+func (pas *HEPastaPack) Flatten(ciphers []*rlwe.Ciphertext, dataSize int) (*rlwe.Ciphertext, error) {
 	ps := pas.symParams.GetBlockSize()
-	rem := dataSize % ps
-	if rem != 0 {
-		// Create a mask slice with 'rem' elements, each initialized to 1.
-		mask := make([]uint64, rem)
+	// keep the data slots of every block only, the other slots of a block hold the second
+	// PASTA state and would overlap the next blocks once rotated
+	for b, cipher := range ciphers {
+		mask := make([]uint64, min(ps, dataSize-b*ps))
 		for i := range mask {
 			mask[i] = 1
 		}
-
-		// Apply the mask to the last element of ciphers using the cipher's mask function.
-		pas.fvPasta.Mask(ciphers[len(ciphers)-1], mask)
+		if err := pas.fvPasta.Mask(cipher, mask); err != nil {
+			return nil, err
+		}
 	}
-	res = pas.fvPasta.Flatten(ciphers)
-	return
+	return pas.fvPasta.Flatten(ciphers)
 }
@@ -0,0 +1,110 @@
+package pasta
+
+import (
+	"HHESoK"
+	"HHESoK/hhe/rtf"
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// ModDown is the modulus switching schedule of the PASTA circuit, entry r is the number of
+// moduli dropped at the end of round r, as numbered in the NoiseReport: entry 0 drops moduli
+// from the fresh copy of the key and entry Rounds+1 from the key stream. A nil schedule keeps
+// the whole circuit at the top level
+type ModDown []int
+
+// Dropped returns the number of moduli dropped by the schedule
+func (modDown ModDown) Dropped() (total int) {
+	for _, n := range modDown {
+		total += n
+	}
+	return
+}
+
+// modSwitchMargin is the margin in bits kept between the noise and the rounding noise of a
+// modulus switching by ScheduleModDown
+const modSwitchMargin = 4
+
+// Validate checks that the schedule fits a PASTA circuit of the given rounds and the moduli of params
+func (modDown ModDown) Validate(rounds int, params bgv.Parameters) error {
+	if modDown == nil {
+		return nil
+	}
+	if len(modDown) != rounds+2 {
+		return fmt.Errorf("%w: mod-down schedule of %d entries, want %d", HHESoK.ErrInvalidParameters, len(modDown), rounds+2)
+	}
+	for _, n := range modDown {
+		if n < 0 {
+			return fmt.Errorf("%w: negative entry in the mod-down schedule %v", HHESoK.ErrInvalidParameters, modDown)
+		}
+	}
+	if total := modDown.Dropped(); total > params.MaxLevel() {
+		return fmt.Errorf("%w: the mod-down schedule drops %d moduli, only %d available", HHESoK.ErrInsufficientLevel, total, params.MaxLevel())
+	}
+	return nil
+}
+
+// ScheduleModDown returns the schedule that ends the key stream at the lowest level leaving at
+// least headroom bits of noise budget, report must be traced without modulus switching.
+// The budget above headroom is spent on the key, where the switching makes all the rounds
+// cheaper, then moduli are dropped at the end of every round as long as the noise stays above
+// the noise of the fresh key, these switches do not consume noise budget
+func ScheduleModDown(report NoiseReport, params bgv.Parameters, headroom int) (ModDown, error) {
+	rounds := report.Rounds
+	if len(rounds) < 2 || len(rounds[0].Measures) == 0 {
+		return nil, fmt.Errorf("cannot schedule mod-down: incomplete noise report")
+	}
+	level := params.MaxLevel()
+	for _, round := range rounds {
+		for _, m := range round.Measures {
+			if m.Level != level {
+				return nil, fmt.Errorf("cannot schedule mod-down: report traced at level %d, want %d", m.Level, level)
+			}
+		}
+	}
+	last := func(round RoundNoise) NoiseMeasure {
+		return round.Measures[len(round.Measures)-1]
+	}
+	logQi := func(level int) float64 {
+		return math.Log2(float64(params.Q()[level]))
+	}
+
+	spare := last(rounds[len(rounds)-1]).Budget - float64(headroom)
+	if spare < 0 {
+		return nil, fmt.Errorf("cannot schedule mod-down: %.2f bits of noise budget left, want %d", spare+float64(headroom), headroom)
+	}
+
+	modDown := make(ModDown, len(rounds))
+	for level > 0 && logQi(level) <= spare {
+		spare -= logQi(level)
+		level--
+		modDown[0]++
+	}
+
+	floor := rounds[0].Measures[0].Noise + modSwitchMargin
+	dropped := 0.0
+	for r := 1; r < len(rounds); r++ {
+		noise := last(rounds[r]).Noise - dropped
+		for level > 0 && noise-logQi(level) >= floor {
+			noise -= logQi(level)
+			dropped += logQi(level)
+			level--
+			modDown[r]++
+		}
+	}
+	return modDown, nil
+}
+
+// modSwitch drops the moduli of the given round of the schedule from ct, the scale invariant
+// evaluator of the circuit does not rescale so the flag is lifted during the switching
+func modSwitch(evaluator *bgv.Evaluator, modDown ModDown, round int, ct *rlwe.Ciphertext) error {
+	if round >= len(modDown) || modDown[round] == 0 {
+		return nil
+	}
+	evaluator.ScaleInvariant = false
+	defer func() { evaluator.ScaleInvariant = true }()
+	return rtf.ModSwitchMany(evaluator, ct, modDown[round])
+}
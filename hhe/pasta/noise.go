@@ -0,0 +1,209 @@
+package pasta
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// Layers of the PASTA circuit measured by the NoiseTracer
+const (
+	LayerKey     = "key"
+	LayerLinear  = "linear"
+	LayerFeistel = "feistel"
+	LayerCube    = "cube"
+)
+
+// NoiseMeasure is the state of the circuit after one layer, Budget is the number of bits left
+// between the noise and half the modulus of the level, the decryption fails below zero
+type NoiseMeasure struct {
+	Layer  string
+	Level  int
+	Noise  float64 // log2 of the largest noise coefficient
+	Budget float64
+}
+
+// RoundNoise holds the measures of one round, round 0 is the encrypted key and round
+// Rounds+1 the final affine layer
+type RoundNoise struct {
+	Round    int
+	Measures []NoiseMeasure
+}
+
+// NoiseReport is the noise of the key stream evaluation of one block, or of one batch
+// starting at Counter in the batched mode
+type NoiseReport struct {
+	Counter uint64
+	Rounds  []RoundNoise
+}
+
+// MinBudget returns the smallest budget of the report, it is the budget of the key stream
+func (rep NoiseReport) MinBudget() float64 {
+	budget := 0.0
+	for i, round := range rep.Rounds {
+		for j, m := range round.Measures {
+			if (i == 0 && j == 0) || m.Budget < budget {
+				budget = m.Budget
+			}
+		}
+	}
+	return budget
+}
+
+func (rep NoiseReport) String() string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "block %d\n", rep.Counter)
+	for _, round := range rep.Rounds {
+		for _, m := range round.Measures {
+			_, _ = fmt.Fprintf(&sb, "  round %d %-8s level=%d noise=%.2f budget=%.2f\n",
+				round.Round, m.Layer, m.Level, m.Noise, m.Budget)
+		}
+	}
+	return sb.String()
+}
+
+// NoiseTracer records the noise budget of the PASTA state after every layer, it holds the
+// secret key and is meant for debugging and parameter selection only
+type NoiseTracer struct {
+	params    bgv.Parameters
+	encoder   *bgv.Encoder
+	decryptor *rlwe.Decryptor
+
+	mu      sync.Mutex
+	reports []NoiseReport
+}
+
+// NewNoiseTracer returns a tracer decrypting with sk
+func NewNoiseTracer(params bgv.Parameters, sk *rlwe.SecretKey) *NoiseTracer {
+	return &NoiseTracer{
+		params:    params,
+		encoder:   bgv.NewEncoder(params),
+		decryptor: bgv.NewDecryptor(params, sk),
+	}
+}
+
+// Reports returns the reports of the traced blocks in the order they were finished
+func (tr *NoiseTracer) Reports() []NoiseReport {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return append([]NoiseReport(nil), tr.reports...)
+}
+
+// Reset drops the recorded reports
+func (tr *NoiseTracer) Reset() {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.reports = nil
+}
+
+// measure returns the noise of ct, the message is decrypted and encoded again so that
+// ct minus the message only holds the noise
+func (tr *NoiseTracer) measure(layer string, ct *rlwe.Ciphertext) (NoiseMeasure, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	values := make([]uint64, tr.params.MaxSlots())
+	if err := tr.encoder.Decode(tr.decryptor.DecryptNew(ct), values); err != nil {
+		return NoiseMeasure{}, err
+	}
+
+	pt := bgv.NewPlaintext(tr.params, ct.Level())
+	pt.MetaData = ct.MetaData.CopyNew()
+	if err := tr.encoder.Encode(values, pt); err != nil {
+		return NoiseMeasure{}, err
+	}
+
+	noiseCt := ct.CopyNew()
+	ringQ := tr.params.RingQ().AtLevel(ct.Level())
+	ringQ.Sub(noiseCt.Value[0], pt.Value, noiseCt.Value[0])
+	noise := tr.log2Norm(noiseCt)
+
+	return NoiseMeasure{
+		Layer:  layer,
+		Level:  ct.Level(),
+		Noise:  noise,
+		Budget: tr.logModulus(ct.Level()) - 1 - noise,
+	}, nil
+}
+
+// logModulus returns the log2 of the modulus of the level
+func (tr *NoiseTracer) logModulus(level int) (logQ float64) {
+	for _, qi := range tr.params.Q()[:level+1] {
+		logQ += math.Log2(float64(qi))
+	}
+	return
+}
+
+// log2Norm returns the log2 of the largest coefficient of the decryption of ct, the noise of
+// the large plaintext moduli does not fit in a float64, unlike the norm of rlwe.Norm
+func (tr *NoiseTracer) log2Norm(ct *rlwe.Ciphertext) float64 {
+	pt := tr.decryptor.DecryptNew(ct)
+	ringQ := tr.params.RingQ().AtLevel(ct.Level())
+	if pt.IsNTT {
+		ringQ.INTT(pt.Value, pt.Value)
+	}
+	coeffs := make([]*big.Int, tr.params.N())
+	for i := range coeffs {
+		coeffs[i] = new(big.Int)
+	}
+	ringQ.PolyToBigintCentered(pt.Value, 1, coeffs)
+
+	norm := new(big.Int)
+	for _, c := range coeffs {
+		if c.CmpAbs(norm) > 0 {
+			norm.Abs(c)
+		}
+	}
+	if norm.Sign() == 0 {
+		return 0
+	}
+	// keep the 64 leading bits
+	shift := max(norm.BitLen()-64, 0)
+	return math.Log2(float64(norm.Rsh(norm, uint(shift)).Uint64())) + float64(shift)
+}
+
+// noiseRecorder collects the measures of one key stream evaluation, a nil recorder does nothing
+type noiseRecorder struct {
+	tracer *NoiseTracer
+	report NoiseReport
+}
+
+func (tr *NoiseTracer) newRecorder(counter uint64) *noiseRecorder {
+	if tr == nil {
+		return nil
+	}
+	return &noiseRecorder{tracer: tr, report: NoiseReport{Counter: counter}}
+}
+
+// record measures ct as the given layer of round
+func (rec *noiseRecorder) record(round int, layer string, ct *rlwe.Ciphertext) error {
+	if rec == nil {
+		return nil
+	}
+	measure, err := rec.tracer.measure(layer, ct)
+	if err != nil {
+		return err
+	}
+	rounds := rec.report.Rounds
+	if len(rounds) == 0 || rounds[len(rounds)-1].Round != round {
+		rec.report.Rounds = append(rounds, RoundNoise{Round: round})
+	}
+	last := &rec.report.Rounds[len(rec.report.Rounds)-1]
+	last.Measures = append(last.Measures, measure)
+	return nil
+}
+
+// done hands the report over to the tracer
+func (rec *noiseRecorder) done() {
+	if rec == nil {
+		return
+	}
+	rec.tracer.mu.Lock()
+	defer rec.tracer.mu.Unlock()
+	rec.tracer.reports = append(rec.tracer.reports, rec.report)
+}
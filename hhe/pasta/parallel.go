@@ -0,0 +1,46 @@
+package pasta
+
+import (
+	"HHESoK"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// ParallelMFVPasta transciphers the blocks of Crypt on a pool of workers, every worker owns a
+// ShallowCopy of the MFVPasta and block b is always CryptBlock(nonce, b, ...), so the output is
+// identical to the sequential MFVPasta.Crypt
+type ParallelMFVPasta struct {
+	fvPasta MFVPasta
+	workers int
+}
+
+// NewParallelMFVPasta returns a driver for fvPasta, workers <= 0 uses one worker per CPU
+func NewParallelMFVPasta(fvPasta MFVPasta, workers int) *ParallelMFVPasta {
+	return &ParallelMFVPasta{fvPasta: fvPasta, workers: HHESoK.Workers(workers)}
+}
+
+// Workers returns the size of the worker pool
+func (par *ParallelMFVPasta) Workers() int {
+	return par.workers
+}
+
+// Crypt tranciphers SYM.Enc(dCt) into HE.Enc(res), res[b] is the ciphertext of block b
+func (par *ParallelMFVPasta) Crypt(nonce []byte, kCt *rlwe.Ciphertext, dCt HHESoK.Ciphertext) (res []*rlwe.Ciphertext, err error) {
+	size := len(dCt)
+	plainSize := par.fvPasta.BlockSize()
+	numBlock := (size + plainSize - 1) / plainSize
+
+	res = make([]*rlwe.Ciphertext, numBlock)
+	err = HHESoK.ParallelRangeErr(numBlock, par.workers, func(first, last int) (err error) {
+		fvPasta := par.fvPasta.ShallowCopy()
+		for b := first; b < last; b++ {
+			if res[b], err = fvPasta.CryptBlock(nonce, uint64(b), kCt, dCt[b*plainSize:min((b+1)*plainSize, size)]); err != nil {
+				return
+			}
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
@@ -1,5 +1,31 @@
 package pasta
 
+import (
+	"HHESoK"
+	"HHESoK/sym/pasta"
+	"encoding/json"
+	"fmt"
+	"math/bits"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+	"gopkg.in/yaml.v3"
+)
+
+// ParameterLiteral is the public description of the BGV parameters of the homomorphic PASTA
+// evaluation, nil LogQ and LogP select the legacy modulus chain of the test vectors
+type ParameterLiteral struct {
+	LogN         int    `json:"logN" yaml:"logN"`
+	PlainModulus uint64 `json:"plainModulus" yaml:"plainModulus"`
+	UseBsGs      bool   `json:"useBsGs" yaml:"useBsGs"`
+	BsGsN1       int    `json:"bsGsN1,omitempty" yaml:"bsGsN1,omitempty"`
+	BsGsN2       int    `json:"bsGsN2,omitempty" yaml:"bsGsN2,omitempty"`
+	LogQ         []int  `json:"logQ,omitempty" yaml:"logQ,omitempty,flow"`
+	LogP         []int  `json:"logP,omitempty" yaml:"logP,omitempty,flow"`
+}
+
+// Parameter holds the BGV parameters of the homomorphic PASTA evaluation, it is created by
+// NewParameterFromLiteral, returned by PlanParameters or taken from the presets
 type Parameter struct {
 	logN      int
 	plainMod  uint64
@@ -7,4 +33,247 @@ type Parameter struct {
 	UseBsGs   bool
 	bSgSN1    int
 	bSgSN2    int
+	logQ      []int
+	logP      []int
+}
+
+// LogN returns the log2 of the ring degree
+func (params Parameter) LogN() int {
+	return params.logN
+}
+
+// PlainModulus returns the BGV plaintext modulus, it is the PASTA modulus
+func (params Parameter) PlainModulus() uint64 {
+	return params.plainMod
+}
+
+// BsGs returns the baby-step giant-step split of the matrix multiplications
+func (params Parameter) BsGs() (n1, n2 int) {
+	return params.bSgSN1, params.bSgSN2
+}
+
+// Moduli returns the bit sizes of the Q and P chains of the BGV parameters
+func (params Parameter) Moduli() (logQ, logP []int) {
+	if params.logQ != nil {
+		return params.logQ, params.logP
+	}
+	// the legacy chain of the test vectors
+	return []int{60, 59, 59, 57, 57, 55, 55, 53, 53, 51, 51, 47, 47}, []int{57, 57, 55, 55, 53, 53, 51, 51, 47, 47}
+}
+
+// bgvParameters returns the BGV parameters of the homomorphic PASTA evaluation, the circuit
+// runs at the top level so the noise budget is the whole modulus chain
+func (params Parameter) bgvParameters() (bgv.Parameters, error) {
+	logQ, logP := params.Moduli()
+	return bgv.NewParametersFromLiteral(bgv.ParametersLiteral{
+		LogN:             params.logN,
+		LogQ:             logQ,
+		LogP:             logP,
+		PlaintextModulus: params.plainMod,
+	})
+}
+
+// NewParameterFromLiteral returns the parameters of lit once they are checked to build valid
+// BGV parameters with the batching of the plaintext modulus
+func NewParameterFromLiteral(lit ParameterLiteral) (params Parameter, err error) {
+	if lit.LogN < rlwe.MinLogN || lit.LogN > rlwe.MaxLogN {
+		return params, fmt.Errorf("%w: PASTA logN=%d is not in [%d, %d]", HHESoK.ErrInvalidParameters, lit.LogN, rlwe.MinLogN, rlwe.MaxLogN)
+	}
+	// the slots need t = 1 mod 2N
+	if lit.PlainModulus < 2 || bits.TrailingZeros64(lit.PlainModulus-1) <= lit.LogN {
+		return params, fmt.Errorf("%w: PASTA plaintext modulus %d has no batching for logN=%d", HHESoK.ErrInvalidParameters, lit.PlainModulus, lit.LogN)
+	}
+	if lit.UseBsGs && (lit.BsGsN1 <= 0 || lit.BsGsN2 <= 0) {
+		return params, fmt.Errorf("%w: PASTA baby-step giant-step split %d*%d", HHESoK.ErrInvalidParameters, lit.BsGsN1, lit.BsGsN2)
+	}
+	if (lit.LogQ == nil) != (lit.LogP == nil) || (lit.LogQ != nil && len(lit.LogQ) == 0) {
+		return params, fmt.Errorf("%w: PASTA modulus chain logQ=%v logP=%v", HHESoK.ErrInvalidParameters, lit.LogQ, lit.LogP)
+	}
+	params = Parameter{
+		logN:      lit.LogN,
+		plainMod:  lit.PlainModulus,
+		modDegree: uint64(1) << lit.LogN,
+		UseBsGs:   lit.UseBsGs,
+		bSgSN1:    lit.BsGsN1,
+		bSgSN2:    lit.BsGsN2,
+		logQ:      append([]int(nil), lit.LogQ...),
+		logP:      append([]int(nil), lit.LogP...),
+	}
+	if lit.LogQ == nil {
+		params.logQ, params.logP = nil, nil
+	}
+	if _, err = params.bgvParameters(); err != nil {
+		return Parameter{}, fmt.Errorf("%w: %w", HHESoK.ErrInvalidParameters, err)
+	}
+	return params, nil
+}
+
+// Literal returns the literal of params, the modulus chain is always explicit
+func (params Parameter) Literal() ParameterLiteral {
+	logQ, logP := params.Moduli()
+	return ParameterLiteral{
+		LogN:         params.logN,
+		PlainModulus: params.plainMod,
+		UseBsGs:      params.UseBsGs,
+		BsGsN1:       params.bSgSN1,
+		BsGsN2:       params.bSgSN2,
+		LogQ:         append([]int(nil), logQ...),
+		LogP:         append([]int(nil), logP...),
+	}
+}
+
+// MarshalJSON encodes the literal of params
+func (params Parameter) MarshalJSON() ([]byte, error) {
+	return json.Marshal(params.Literal())
+}
+
+// UnmarshalJSON decodes and checks a literal
+func (params *Parameter) UnmarshalJSON(data []byte) (err error) {
+	var lit ParameterLiteral
+	if err = json.Unmarshal(data, &lit); err != nil {
+		return
+	}
+	*params, err = NewParameterFromLiteral(lit)
+	return
+}
+
+// MarshalYAML encodes the literal of params
+func (params Parameter) MarshalYAML() (interface{}, error) {
+	return params.Literal(), nil
+}
+
+// UnmarshalYAML decodes and checks a literal
+func (params *Parameter) UnmarshalYAML(value *yaml.Node) (err error) {
+	var lit ParameterLiteral
+	if err = value.Decode(&lit); err != nil {
+		return
+	}
+	*params, err = NewParameterFromLiteral(lit)
+	return
+}
+
+// ParameterSet is a complete HHE PASTA configuration: the PASTA cipher and the BGV parameters
+// of its homomorphic evaluation
+type ParameterSet struct {
+	Name      string          `json:"name,omitempty" yaml:"name,omitempty"`
+	Security  int             `json:"security,omitempty" yaml:"security,omitempty"` // in bits, zero if not claimed
+	Params    Parameter       `json:"params" yaml:"params"`
+	SymParams pasta.Parameter `json:"symParams" yaml:"symParams"`
+}
+
+// copy returns a copy of set that does not share the modulus chains
+func (set ParameterSet) copy() ParameterSet {
+	if set.Params.logQ != nil {
+		set.Params.logQ = append([]int(nil), set.Params.logQ...)
+		set.Params.logP = append([]int(nil), set.Params.logP...)
+	}
+	return set
+}
+
+// Validate checks that the PASTA cipher fits the slots and the BGV parameters and that the
+// modulus is below the bound of the claimed security
+func (set ParameterSet) Validate() error {
+	params, sym := set.Params, set.SymParams
+	switch {
+	case sym.Rounds <= 0 || sym.BlockSize <= 0 || sym.KeySize != 2*sym.BlockSize:
+		return fmt.Errorf("%w: parameter set %s: PASTA-%d with a key of %d and blocks of %d", HHESoK.ErrInvalidParameters, set.Name, sym.Rounds, sym.KeySize, sym.BlockSize)
+	case sym.Modulus != params.plainMod:
+		return fmt.Errorf("%w: parameter set %s: PASTA modulus %d, plaintext modulus %d", HHESoK.ErrInvalidParameters, set.Name, sym.Modulus, params.plainMod)
+	case uint64(sym.BlockSize)*2 != params.modDegree && uint64(sym.BlockSize)*4 > params.modDegree:
+		return fmt.Errorf("%w: parameter set %s: blocks of %d do not fit logN=%d", HHESoK.ErrInvalidParameters, set.Name, sym.BlockSize, params.logN)
+	case params.UseBsGs && params.bSgSN1*params.bSgSN2 != sym.BlockSize:
+		return fmt.Errorf("%w: parameter set %s: baby-step giant-step split %d*%d of blocks of %d", HHESoK.ErrInvalidParameters, set.Name, params.bSgSN1, params.bSgSN2, sym.BlockSize)
+	}
+	bfvParams, err := params.bgvParameters()
+	if err != nil {
+		return fmt.Errorf("%w: parameter set %s: %w", HHESoK.ErrInvalidParameters, set.Name, err)
+	}
+	if set.Security != 0 {
+		bounds, ok := maxLogQP[set.Security]
+		if !ok {
+			return fmt.Errorf("%w: parameter set %s: security level %d", HHESoK.ErrInvalidParameters, set.Name, set.Security)
+		}
+		if bound, ok := bounds[params.logN]; !ok || bfvParams.LogQP() > bound {
+			return fmt.Errorf("%w: parameter set %s: logQP=%.2f is above the %d-bit bound of logN=%d", HHESoK.ErrInvalidParameters, set.Name, bfvParams.LogQP(), set.Security, params.logN)
+		}
+	}
+	return nil
+}
+
+// preset returns the parameter set of a PASTA cipher with blocks of blockSize elements, the
+// chain has size primes of logQi bits and a special modulus of logP primes of 60 bits
+func preset(name string, rounds, blockSize int, modulus uint64, logN int, size, logQi, logP int) ParameterSet {
+	n1, n2 := bsgsSplit(blockSize)
+	lit := ParameterLiteral{
+		LogN:         logN,
+		PlainModulus: modulus,
+		UseBsGs:      true,
+		BsGsN1:       n1,
+		BsGsN2:       n2,
+		LogQ:         make([]int, size),
+		LogP:         make([]int, logP),
+	}
+	for i := range lit.LogQ {
+		lit.LogQ[i] = logQi
+	}
+	for i := range lit.LogP {
+		lit.LogP[i] = maxLogPrime
+	}
+	return ParameterSet{
+		Name:     name,
+		Security: Security128,
+		Params: Parameter{
+			logN:      lit.LogN,
+			plainMod:  lit.PlainModulus,
+			modDegree: uint64(1) << lit.LogN,
+			UseBsGs:   lit.UseBsGs,
+			bSgSN1:    lit.BsGsN1,
+			bSgSN2:    lit.BsGsN2,
+			logQ:      lit.LogQ,
+			logP:      lit.LogP,
+		},
+		SymParams: pasta.Parameter{KeySize: 2 * blockSize, BlockSize: blockSize, Rounds: rounds, Modulus: modulus},
+	}
+}
+
+// plaintext moduli of the presets, they are the moduli of the test vectors
+const (
+	modulus17 = 65537
+	modulus33 = 8088322049
+	modulus60 = 1096486890805657601
+)
+
+// presets are the 128-bit parameter sets of PASTA-3 and PASTA-4 with the 17, 33 and 60-bit
+// moduli. The chains hold the noise of PlanParameters with 10 bits of headroom, except the
+// one of PASTA3-T17-N14 that holds the measured noise, and TestPresetsTranscipher runs
+// VerifyParameters on all of them. The missing combinations of ring degree and modulus do
+// not exist at 128 bits or do not batch, 65537 has no slots for 2^16
+var presets = []ParameterSet{
+	preset("PASTA3-T17-N14", 3, 128, modulus17, 14, 6, 58, 1),
+	preset("PASTA3-T17-N15", 3, 128, modulus17, 15, 7, 58, 4),
+	preset("PASTA3-T33-N15", 3, 128, modulus33, 15, 12, 56, 3),
+	preset("PASTA3-T33-N16", 3, 128, modulus33, 16, 12, 56, 6),
+	preset("PASTA3-T60-N16", 3, 128, modulus60, 16, 19, 60, 10),
+	preset("PASTA4-T17-N15", 4, 32, modulus17, 15, 9, 57, 5),
+	preset("PASTA4-T33-N16", 4, 32, modulus33, 16, 15, 57, 8),
+	preset("PASTA4-T60-N16", 4, 32, modulus60, 16, 24, 60, 5),
+}
+
+// Presets returns a copy of the presets
+func Presets() []ParameterSet {
+	res := make([]ParameterSet, len(presets))
+	for i, set := range presets {
+		res[i] = set.copy()
+	}
+	return res
+}
+
+// Preset returns a copy of the preset of the given name
+func Preset(name string) (ParameterSet, error) {
+	for _, set := range presets {
+		if set.Name == name {
+			return set.copy(), nil
+		}
+	}
+	return ParameterSet{}, fmt.Errorf("%w: unknown PASTA preset %q", HHESoK.ErrInvalidParameters, name)
 }
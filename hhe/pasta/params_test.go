@@ -0,0 +1,137 @@
+package pasta
+
+import (
+	"HHESoK"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"runtime/debug"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestPresets(t *testing.T) {
+	for _, set := range Presets() {
+		t.Run(set.Name, func(t *testing.T) {
+			if err := set.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := Preset(set.Name); err != nil || !reflect.DeepEqual(got, set) {
+				t.Fatalf("Preset(%s): %v", set.Name, err)
+			}
+			params, err := NewParameterFromLiteral(set.Params.Literal())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(params, set.Params) {
+				t.Fatalf("literal round trip: got %+v, want %+v", params, set.Params)
+			}
+		})
+	}
+	if _, err := Preset("PASTA3-T17-N16"); err == nil {
+		t.Fatalf("got a preset without batching")
+	}
+
+	// the presets are copies
+	set := Presets()[0]
+	logQ, _ := set.Params.Moduli()
+	logQ[0]--
+	set.Name = "modified"
+	if got := Presets()[0]; got.Name == set.Name || reflect.DeepEqual(got.Params, set.Params) {
+		t.Fatalf("Presets returned the preset itself")
+	}
+	set, _ = Preset(Presets()[0].Name)
+	logQ, _ = set.Params.Moduli()
+	logQ[0]--
+	if got, _ := Preset(set.Name); reflect.DeepEqual(got.Params, set.Params) {
+		t.Fatalf("Preset returned the preset itself")
+	}
+}
+
+func TestParameterSetMarshal(t *testing.T) {
+	set := Presets()[0]
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON ParameterSet
+	if err = json.Unmarshal(data, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromJSON, set) {
+		t.Fatalf("JSON round trip: got %+v, want %+v", fromJSON, set)
+	}
+
+	data, err = yaml.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromYAML ParameterSet
+	if err = yaml.Unmarshal(data, &fromYAML); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromYAML, set) {
+		t.Fatalf("YAML round trip: got %+v, want %+v\n%s", fromYAML, set, data)
+	}
+
+	// the legacy chain of the test vectors is written explicitly
+	data, err = json.Marshal(pasta3TestVector[0].Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var legacy Parameter
+	if err = json.Unmarshal(data, &legacy); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(legacy.Literal(), pasta3TestVector[0].Params.Literal()) {
+		t.Fatalf("legacy round trip: got %s", data)
+	}
+}
+
+func TestNewParameterFromLiteral(t *testing.T) {
+	valid := Presets()[0].Params.Literal()
+	for name, edit := range map[string]func(*ParameterLiteral){
+		"logN":     func(lit *ParameterLiteral) { lit.LogN = 40 },
+		"batching": func(lit *ParameterLiteral) { lit.LogN = 16 },
+		"bsgs":     func(lit *ParameterLiteral) { lit.BsGsN2 = 0 },
+		"chain":    func(lit *ParameterLiteral) { lit.LogP = nil },
+		"prime":    func(lit *ParameterLiteral) { lit.LogQ = []int{70} },
+	} {
+		lit := valid
+		lit.LogQ = append([]int(nil), valid.LogQ...)
+		edit(&lit)
+		if _, err := NewParameterFromLiteral(lit); !errors.Is(err, HHESoK.ErrInvalidParameters) {
+			t.Fatalf("%s: got %v, want %v", name, err, HHESoK.ErrInvalidParameters)
+		}
+	}
+	if _, err := Preset("PASTA5"); !errors.Is(err, HHESoK.ErrInvalidParameters) {
+		t.Fatalf("unknown preset: got %v, want %v", err, HHESoK.ErrInvalidParameters)
+	}
+	if err := json.Unmarshal([]byte(`{"logN":16,"plainModulus":65537}`), new(Parameter)); err == nil {
+		t.Fatalf("invalid JSON parameters accepted")
+	}
+}
+
+// TestPresetsTranscipher transciphers a block with every preset, the short mode only checks
+// the smallest one
+func TestPresetsTranscipher(t *testing.T) {
+	// the rings of degree 2^16 leave gigabytes of garbage, collect it before it adds up
+	if debug.SetMemoryLimit(-1) == math.MaxInt64 {
+		defer debug.SetMemoryLimit(debug.SetMemoryLimit(4 << 30))
+	}
+	for _, set := range Presets() {
+		t.Run(set.Name, func(t *testing.T) {
+			if testing.Short() && set.Name != "PASTA3-T17-N14" {
+				t.Skip("large ring in short mode")
+			}
+			report, err := VerifyParameters(set.Params, set.SymParams, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("%s: %.2f bits of noise budget left", set.Name, report.MinBudget())
+		})
+	}
+}
@@ -2,8 +2,12 @@ package pasta
 
 import (
 	"HHESoK"
+	"HHESoK/sym/pasta"
 	"encoding/binary"
 	"fmt"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/utils/sampling"
+	"runtime"
 	"testing"
 )
 
@@ -42,7 +46,9 @@ func benchHEPasta(tc TestContext, b *testing.B) {
 
 	hePasta := NewHEPasta()
 
-	hePasta.InitParams(tc.Params, tc.SymParams)
+	if err := hePasta.InitParams(tc.Params, tc.SymParams); err != nil {
+		b.Fatal(err)
+	}
 
 	b.Run("PASTA/HEKeyGen", func(b *testing.B) {
 		b.ResetTimer()
@@ -51,7 +57,9 @@ func benchHEPasta(tc TestContext, b *testing.B) {
 		}
 	})
 
-	_ = hePasta.InitFvPasta()
+	if _, err := hePasta.InitFvPasta(); err != nil {
+		b.Fatal(err)
+	}
 
 	b.Run("PASTA/GaloisKeysGen", func(b *testing.B) {
 		b.ResetTimer()
@@ -64,7 +72,9 @@ func benchHEPasta(tc TestContext, b *testing.B) {
 	b.Run("PASTA/EncryptSymKey", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			hePasta.EncryptSymKey(tc.Key)
+			if err := hePasta.EncryptSymKey(tc.Key); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 
@@ -75,15 +85,144 @@ func benchHEPasta(tc TestContext, b *testing.B) {
 	var fvCiphers []*rlwe.Ciphertext
 	b.Run("PASTA/Trancipher", func(b *testing.B) {
 		b.ResetTimer()
+		var err error
 		for i := 0; i < b.N; i++ {
-			fvCiphers = hePasta.Trancipher(nonce, tc.ExpCipherText)
+			if fvCiphers, err = hePasta.Trancipher(nonce, tc.ExpCipherText); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 
 	b.Run("PASTA/Decrypt", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			hePasta.Decrypt(fvCiphers[0])
+			if _, err := hePasta.Decrypt(fvCiphers[0]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkPasta3Batch(b *testing.B) {
+	for _, tc := range multiBlock(pasta3TestVector) {
+		benchHEPastaBatch(tc, b)
+	}
+}
+
+func BenchmarkPasta4Batch(b *testing.B) {
+	for _, tc := range multiBlock(pasta4TestVector) {
+		benchHEPastaBatch(tc, b)
+	}
+}
+
+// benchHEPastaBatch compares the throughput of the per-block and the batched transciphering
+// on one full batch of blocks
+func benchHEPastaBatch(tc TestContext, b *testing.B) {
+	fmt.Println(testString("PASTA", tc.SymParams))
+	if testing.Short() {
+		b.Skip("skipping benchmark in short mode.")
+	}
+
+	hePasta := NewHEPasta()
+	if err := hePasta.InitParams(tc.Params, tc.SymParams); err != nil {
+		b.Fatal(err)
+	}
+	hePasta.HEKeyGen()
+	fvPasta, err := hePasta.InitFvPasta()
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := hePasta.EncryptSymKey(tc.Key); err != nil {
+		b.Fatal(err)
+	}
+
+	numBlock := fvPasta.BatchSize()
+	p := tc.SymParams.GetModulus()
+	data := make(HHESoK.Plaintext, numBlock*tc.SymParams.GetBlockSize())
+	for i := range data {
+		data[i] = sampling.RandUint64() % p
+	}
+	symCiphertexts := pasta.MustNewPasta(tc.Key, tc.SymParams).NewEncryptor().Encrypt(data)
+
+	nonce := make([]byte, 8)
+	binary.BigEndian.PutUint64(nonce, uint64(123456789))
+
+	// the per-block path does not flatten, the keys of a single block are enough
+	hePasta.CreateGaloisKeys(tc.SymParams.GetBlockSize())
+	b.Run("PASTA/Trancipher", func(b *testing.B) {
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := hePasta.Trancipher(nonce, symCiphertexts); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*numBlock), "ns/block")
+	})
+
+	hePasta.CreateBatchGaloisKeys()
+	b.Run("PASTA/TrancipherBatch", func(b *testing.B) {
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := hePasta.TrancipherBatch(nonce, symCiphertexts); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*numBlock), "ns/block")
+	})
+}
+
+func BenchmarkPastaParallel(b *testing.B) {
+	for _, tc := range multiBlock(pasta4TestVector) {
+		benchHEPastaParallel(tc, b)
+	}
+}
+
+// benchHEPastaParallel compares the sequential transciphering with the parallel driver on
+// one block per CPU
+func benchHEPastaParallel(tc TestContext, b *testing.B) {
+	fmt.Println(testString("PASTA", tc.SymParams))
+	if testing.Short() {
+		b.Skip("skipping benchmark in short mode.")
+	}
+
+	hePasta := NewHEPasta()
+	if err := hePasta.InitParams(tc.Params, tc.SymParams); err != nil {
+		b.Fatal(err)
+	}
+	hePasta.HEKeyGen()
+	if _, err := hePasta.InitFvPasta(); err != nil {
+		b.Fatal(err)
+	}
+	if err := hePasta.EncryptSymKey(tc.Key); err != nil {
+		b.Fatal(err)
+	}
+	// the blocks are not flattened, the keys of a single block are enough
+	hePasta.CreateGaloisKeys(tc.SymParams.GetBlockSize())
+
+	workers := runtime.NumCPU()
+	p := tc.SymParams.GetModulus()
+	data := make(HHESoK.Plaintext, workers*tc.SymParams.GetBlockSize())
+	for i := range data {
+		data[i] = sampling.RandUint64() % p
+	}
+	symCiphertexts := pasta.MustNewPasta(tc.Key, tc.SymParams).NewEncryptor().Encrypt(data)
+
+	nonce := make([]byte, 8)
+	binary.BigEndian.PutUint64(nonce, uint64(123456789))
+
+	b.Run("PASTA/Trancipher", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := hePasta.Trancipher(nonce, symCiphertexts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run(fmt.Sprintf("PASTA/TrancipherParallel/Workers=%d", workers), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := hePasta.TrancipherParallel(nonce, symCiphertexts, workers); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 }
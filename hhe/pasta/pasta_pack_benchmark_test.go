@@ -44,7 +44,9 @@ func benchHEPastaPack(tc TestContext, b *testing.B) {
 
 	hePastaPack := NewHEPastaPack()
 
-	hePastaPack.InitParams(tc.Params, tc.SymParams)
+	if err := hePastaPack.InitParams(tc.Params, tc.SymParams); err != nil {
+		b.Fatal(err)
+	}
 
 	b.Run("PASTA/HEKeyGen", func(b *testing.B) {
 		b.ResetTimer()
@@ -53,12 +55,14 @@ func benchHEPastaPack(tc TestContext, b *testing.B) {
 		}
 	})
 
-	_ = hePastaPack.InitFvPasta()
+	if _, err := hePastaPack.InitFvPasta(); err != nil {
+		b.Fatal(err)
+	}
 
 	// generates Random data for full coefficients
 	data := hePastaPack.RandomDataGen()
 
-	symPasta := pasta.NewPasta(tc.Key, tc.SymParams)
+	symPasta := pasta.MustNewPasta(tc.Key, tc.SymParams)
 	var symCipherTexts HHESoK.Ciphertext
 	b.Run("PASTA/EncryptSymData", func(b *testing.B) {
 		b.ResetTimer()
@@ -79,7 +83,9 @@ func benchHEPastaPack(tc TestContext, b *testing.B) {
 	b.Run("PASTA/EncryptSymKey", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			hePastaPack.EncryptSymKey(tc.Key)
+			if err := hePastaPack.EncryptSymKey(tc.Key); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 
@@ -90,16 +96,22 @@ func benchHEPastaPack(tc TestContext, b *testing.B) {
 	var fvCiphers []*rlwe.Ciphertext
 	b.Run("PASTA/Trancipher", func(b *testing.B) {
 		b.ResetTimer()
+		var err error
 		for i := 0; i < b.N; i++ {
-			fvCiphers = hePastaPack.Trancipher(nonce, symCipherTexts)
+			if fvCiphers, err = hePastaPack.Trancipher(nonce, symCipherTexts); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 
 	var ctRes *rlwe.Ciphertext
 	b.Run("PASTA/Flatten", func(b *testing.B) {
 		b.ResetTimer()
+		var err error
 		for i := 0; i < b.N; i++ {
-			ctRes = hePastaPack.Flatten(fvCiphers, len(symCipherTexts))
+			if ctRes, err = hePastaPack.Flatten(fvCiphers, len(symCipherTexts)); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 
@@ -107,7 +119,9 @@ func benchHEPastaPack(tc TestContext, b *testing.B) {
 	b.Run("PASTA/Decrypt", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_ = hePastaPack.Decrypt(ctRes)
+			if _, err := hePastaPack.Decrypt(ctRes); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 
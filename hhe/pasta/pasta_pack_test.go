@@ -1,24 +1,25 @@
 package pasta
 
 import (
-	"HHESoK/sym/pasta"
 	"encoding/binary"
-	"fmt"
+	"reflect"
 	"testing"
 )
 
 func TestPasta3Pack(t *testing.T) {
-	for _, tc := range pasta3TestVector {
-		fmt.Println(testString("PASTA-3", tc.SymParams))
-		testHEPastaPack(t, tc)
+	for _, tc := range shortVectors(pasta3TestVector) {
+		t.Run(testString("PASTA-3", tc.SymParams), func(t *testing.T) {
+			testHEPastaPack(t, tc)
+		})
 	}
 	//testHEPastaPack(t, pasta3TestVector[0])
 }
 
 func TestPasta4Pack(t *testing.T) {
-	for _, tc := range pasta4TestVector {
-		fmt.Println(testString("PASTA-4", tc.SymParams))
-		testHEPastaPack(t, tc)
+	for _, tc := range shortVectors(pasta4TestVector) {
+		t.Run(testString("PASTA-4", tc.SymParams), func(t *testing.T) {
+			testHEPastaPack(t, tc)
+		})
 	}
 	//testHEPastaPack(t, pasta4TestVector[0])
 }
@@ -28,44 +29,125 @@ func testHEPastaPack(t *testing.T, tc TestContext) {
 	lg := hePastaPack.logger
 	lg.PrintDataLen(tc.Key)
 
-	hePastaPack.InitParams(tc.Params, tc.SymParams)
+	if err := hePastaPack.InitParams(tc.Params, tc.SymParams); err != nil {
+		t.Fatal(err)
+	}
 
 	hePastaPack.HEKeyGen()
 	lg.PrintMemUsage("HEKeyGen")
 
-	_ = hePastaPack.InitFvPasta()
+	if _, err := hePastaPack.InitFvPasta(); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("InitFvPasta")
 
-	// generates Random data for full coefficients
-	data := hePastaPack.RandomDataGen()
-	lg.PrintMemUsage("RandomDataGen")
-
-	// generate key stream
-	symPasta := pasta.NewPasta(tc.Key, tc.SymParams)
-	symCiphertexts := symPasta.NewEncryptor().Encrypt(data)
-	lg.PrintMemUsage("EncryptSymData")
+	// the symmetric ciphertext of the test vector, encrypted under the default nonce
+	symCiphertexts := tc.ExpCipherText
 
 	// create Galois keys for evaluation
 	hePastaPack.CreateGaloisKeys(len(symCiphertexts))
 	lg.PrintMemUsage("CreateGaloisKeys")
 
 	// encrypts symmetric master key using BFV on the client side
-	hePastaPack.EncryptSymKey(tc.Key)
+	if err := hePastaPack.EncryptSymKey(tc.Key); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("EncryptSymKey")
 
 	nonce := make([]byte, 8)
 	binary.BigEndian.PutUint64(nonce, 123456789)
 
 	// the server side tranciphering
-	fvCiphers := hePastaPack.Trancipher(nonce, symCiphertexts)
+	fvCiphers, err := hePastaPack.Trancipher(nonce, symCiphertexts)
+	if err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("Trancipher")
 
-	ctRes := hePastaPack.Flatten(fvCiphers, len(symCiphertexts))
+	ctRes, err := hePastaPack.Flatten(fvCiphers, len(symCiphertexts))
+	if err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("Flatten")
 
-	ptRes := hePastaPack.Decrypt(ctRes)
+	ptRes, err := hePastaPack.Decrypt(ctRes)
+	if err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("Decrypt")
 
-	hePastaPack.logger.PrintDataLen(data)
-	hePastaPack.logger.PrintDataLen(ptRes)
+	if !reflect.DeepEqual([]uint64(tc.Plaintext), ptRes[:len(tc.Plaintext)]) {
+		t.Fatalf("decryption failure")
+	}
+}
+
+// TestPastaPackModDown sets the schedule tuned on the per-block circuit to the packed one and
+// decrypts every block at the reduced level, before and after the flattening
+func TestPastaPackModDown(t *testing.T) {
+	if testing.Short() {
+		t.Skip("PASTA-3 mod-down tuning in short mode")
+	}
+	tc := pasta3TestVector[0]
+	hePasta := NewHEPasta()
+	if err := hePasta.InitParams(tc.Params, tc.SymParams); err != nil {
+		t.Fatal(err)
+	}
+	hePasta.HEKeyGen()
+	if _, err := hePasta.InitFvPasta(); err != nil {
+		t.Fatal(err)
+	}
+	hePasta.CreateGaloisKeys(len(tc.ExpCipherText))
+	if err := hePasta.EncryptSymKey(tc.Key); err != nil {
+		t.Fatal(err)
+	}
+	modDown, _, err := hePasta.TuneModDown(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modDown.Dropped() == 0 {
+		t.Fatalf("the schedule %v drops no modulus", modDown)
+	}
+
+	hePastaPack := NewHEPastaPack()
+	if err := hePastaPack.InitParams(tc.Params, tc.SymParams); err != nil {
+		t.Fatal(err)
+	}
+	hePastaPack.HEKeyGen()
+	if _, err := hePastaPack.InitFvPasta(); err != nil {
+		t.Fatal(err)
+	}
+	hePastaPack.CreateGaloisKeys(len(tc.ExpCipherText))
+	if err := hePastaPack.EncryptSymKey(tc.Key); err != nil {
+		t.Fatal(err)
+	}
+	if err = hePastaPack.SetModDown(modDown); err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, 8)
+	binary.BigEndian.PutUint64(nonce, 123456789)
+	fvCiphers, err := hePastaPack.Trancipher(nonce, tc.ExpCipherText)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	level := hePastaPack.bfvParams.MaxLevel() - modDown.Dropped()
+	blockSize := tc.SymParams.GetBlockSize()
+	for b, ct := range fvCiphers {
+		if ct.Level() != level {
+			t.Fatalf("block %d: got level %d, want %d", b, ct.Level(), level)
+		}
+		want := tc.Plaintext[b*blockSize : min((b+1)*blockSize, len(tc.Plaintext))]
+		if got := decrypt(t, hePastaPack.Decrypt, ct)[:len(want)]; !reflect.DeepEqual([]uint64(want), got) {
+			t.Fatalf("block %d: decryption failure at level %d", b, ct.Level())
+		}
+	}
+
+	ctRes, err := hePastaPack.Flatten(fvCiphers, len(tc.ExpCipherText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]uint64(tc.Plaintext), decrypt(t, hePastaPack.Decrypt, ctRes)[:len(tc.Plaintext)]) {
+		t.Fatalf("decryption failure of the flattened blocks at level %d", ctRes.Level())
+	}
 }
@@ -1,9 +1,13 @@
 package pasta
 
 import (
+	"HHESoK"
 	"HHESoK/sym/pasta"
 	"encoding/binary"
 	"fmt"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"math/bits"
+	"reflect"
 	"testing"
 )
 
@@ -13,17 +17,19 @@ func testString(opName string, p pasta.Parameter) string {
 }
 
 func TestPasta3(t *testing.T) {
-	for _, tc := range pasta3TestVector {
-		fmt.Println(testString("PASTA-3", tc.SymParams))
-		testHEPasta(t, tc)
+	for _, tc := range shortVectors(pasta3TestVector) {
+		t.Run(testString("PASTA-3", tc.SymParams), func(t *testing.T) {
+			testHEPasta(t, tc)
+		})
 	}
 	//testHEPasta(t, pasta3TestVector[0])
 }
 
 func TestPasta4(t *testing.T) {
-	for _, tc := range pasta4TestVector {
-		fmt.Println(testString("PASTA-4", tc.SymParams))
-		testHEPasta(t, tc)
+	for _, tc := range shortVectors(pasta4TestVector) {
+		t.Run(testString("PASTA-4", tc.SymParams), func(t *testing.T) {
+			testHEPasta(t, tc)
+		})
 	}
 	//testHEPasta(t, pasta4TestVector[0])
 }
@@ -33,28 +39,404 @@ func testHEPasta(t *testing.T, tc TestContext) {
 	lg := hePasta.logger
 	lg.PrintDataLen(tc.Key)
 
-	hePasta.InitParams(tc.Params, tc.SymParams)
+	if err := hePasta.InitParams(tc.Params, tc.SymParams); err != nil {
+		t.Fatal(err)
+	}
 
 	hePasta.HEKeyGen()
 	lg.PrintMemUsage("HEKeyGen")
 
-	_ = hePasta.InitFvPasta()
+	if _, err := hePasta.InitFvPasta(); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("InitFvPasta")
 
 	hePasta.CreateGaloisKeys(len(tc.ExpCipherText))
 	lg.PrintMemUsage("CreateGaloisKeys")
 
 	//encrypts symmetric master key using BFV on the client side
-	hePasta.EncryptSymKey(tc.Key)
+	if err := hePasta.EncryptSymKey(tc.Key); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("EncryptSymKey")
 
 	nonce := make([]byte, 8)
 	binary.BigEndian.PutUint64(nonce, uint64(123456789))
 
 	// the server side
-	fvCiphers := hePasta.Trancipher(nonce, tc.ExpCipherText)
+	fvCiphers, err := hePasta.Trancipher(nonce, tc.ExpCipherText)
+	if err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("Trancipher")
 
-	hePasta.Decrypt(fvCiphers[0])
+	// every block is transciphered independently
+	blockSize := tc.SymParams.GetBlockSize()
+	if want := (len(tc.Plaintext) + blockSize - 1) / blockSize; len(fvCiphers) != want {
+		t.Fatalf("got %d blocks, want %d", len(fvCiphers), want)
+	}
+	for b, ct := range fvCiphers {
+		want := tc.Plaintext[b*blockSize : min((b+1)*blockSize, len(tc.Plaintext))]
+		got := decrypt(t, hePasta.Decrypt, ct)[:len(want)]
+		if !reflect.DeepEqual([]uint64(want), got) {
+			t.Fatalf("block %d: decryption failure", b)
+		}
+	}
 	lg.PrintMemUsage("Decrypt")
 }
+
+func TestPasta3Batch(t *testing.T) {
+	for _, tc := range multiBlock(shortVectors(pasta3TestVector)) {
+		t.Run(testString("PASTA-3", tc.SymParams), func(t *testing.T) {
+			testHEPastaBatch(t, tc)
+		})
+	}
+}
+
+func TestPasta4Batch(t *testing.T) {
+	for _, tc := range multiBlock(shortVectors(pasta4TestVector)) {
+		t.Run(testString("PASTA-4", tc.SymParams), func(t *testing.T) {
+			testHEPastaBatch(t, tc)
+		})
+	}
+}
+
+// multiBlock returns the test vectors of more than one block
+func multiBlock(vector []TestContext) (res []TestContext) {
+	for _, tc := range vector {
+		if len(tc.Plaintext) > tc.SymParams.GetBlockSize() {
+			res = append(res, tc)
+		}
+	}
+	return
+}
+
+// shortVectors returns the vectors of the 17-bit modulus on rings of degree 2^14 in short mode,
+// all of them otherwise
+func shortVectors(vector []TestContext) (res []TestContext) {
+	if !testing.Short() {
+		return vector
+	}
+	for _, tc := range vector {
+		if tc.Params.logN <= 14 && bits.Len64(tc.SymParams.Modulus) <= 17 {
+			res = append(res, tc)
+		}
+	}
+	return
+}
+
+func testHEPastaBatch(t *testing.T, tc TestContext) {
+	hePasta := NewHEPasta()
+	if err := hePasta.InitParams(tc.Params, tc.SymParams); err != nil {
+		t.Fatal(err)
+	}
+	hePasta.HEKeyGen()
+	fvPasta, err := hePasta.InitFvPasta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hePasta.CreateBatchGaloisKeys()
+	if err := hePasta.EncryptSymKey(tc.Key); err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, 8)
+	binary.BigEndian.PutUint64(nonce, uint64(123456789))
+
+	// all the blocks of the test vector fit in one batch
+	fvCiphers, err := hePasta.TrancipherBatch(nonce, tc.ExpCipherText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fvCiphers) != 1 {
+		t.Fatalf("got %d batches, want 1 of up to %d blocks", len(fvCiphers), fvPasta.BatchSize())
+	}
+	got := decrypt(t, hePasta.DecryptBatch, fvCiphers[0])[:len(tc.Plaintext)]
+	if !reflect.DeepEqual([]uint64(tc.Plaintext), got) {
+		t.Fatalf("decryption failure")
+	}
+}
+
+// TestPastaBatchCounter transciphers more than BatchSize() blocks from a non-zero counter, the
+// last block is partial. Every batch is checked against the plaintext and its first and last
+// blocks against the per-block path
+func TestPastaBatchCounter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("two batches of PASTA-3 in short mode")
+	}
+	tc := pasta3TestVector[0]
+	hePasta := NewHEPasta()
+	if err := hePasta.InitParams(tc.Params, tc.SymParams); err != nil {
+		t.Fatal(err)
+	}
+	hePasta.HEKeyGen()
+	fvPasta, err := hePasta.InitFvPasta()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the keys of both paths
+	blockSize := tc.SymParams.GetBlockSize()
+	galEls := append(fvPasta.GetGaloisElements(blockSize), fvPasta.GetBatchGaloisElements()...)
+	rlk := hePasta.keyGenerator.GenRelinearizationKeyNew(hePasta.sk)
+	evk := rlwe.NewMemEvaluationKeySet(rlk, hePasta.keyGenerator.GenGaloisKeysNew(galEls, hePasta.sk)...)
+	fvPasta.UpdateEvaluator(newEvaluator(hePasta.bfvParams, evk))
+	if err := hePasta.EncryptSymKey(tc.Key); err != nil {
+		t.Fatal(err)
+	}
+
+	const counter = 5
+	batchSize := fvPasta.BatchSize()
+	plaintext := make(HHESoK.Plaintext, (batchSize+1)*blockSize-3)
+	for i := range plaintext {
+		plaintext[i] = uint64(i*7919+3) % tc.SymParams.GetModulus()
+	}
+	nonce := HHESoK.NewNonce()
+	symPasta, err := pasta.NewPasta(tc.Key, tc.SymParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	symCt := symPasta.NewEncryptor().EncryptWithCounter(nonce, counter, plaintext)
+
+	batches, err := fvPasta.CryptBatchWithCounter(nonce, counter, hePasta.symKeyCt, symCt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 2 {
+		t.Fatalf("got %d batches, want 2 of up to %d blocks", len(batches), batchSize)
+	}
+	for i, ct := range batches {
+		first := i * batchSize
+		last := min(first+batchSize, (len(plaintext)+blockSize-1)/blockSize)
+		want := plaintext[first*blockSize : min(last*blockSize, len(plaintext))]
+		got := decrypt(t, hePasta.DecryptBatch, ct)[:len(want)]
+		if !reflect.DeepEqual([]uint64(want), got) {
+			t.Fatalf("batch %d: decryption failure", i)
+		}
+		for _, b := range []int{first, last - 1} {
+			blockCt := symCt[b*blockSize : min((b+1)*blockSize, len(symCt))]
+			res, err := fvPasta.CryptBlock(nonce, uint64(counter+b), hePasta.symKeyCt, blockCt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			k := b - first
+			if block := decrypt(t, hePasta.Decrypt, res)[:len(blockCt)]; !reflect.DeepEqual(got[k*blockSize:k*blockSize+len(blockCt)], block) {
+				t.Fatalf("batch %d: block %d differs from the per-block path", i, b)
+			}
+		}
+	}
+}
+
+func TestPastaParallel(t *testing.T) {
+	for _, tc := range multiBlock(shortVectors(pasta4TestVector)) {
+		t.Run(testString("PASTA-4", tc.SymParams), func(t *testing.T) {
+			testHEPastaParallel(t, tc)
+		})
+	}
+}
+
+// testHEPastaParallel checks that the parallel driver returns the ciphertexts of the sequential path
+func testHEPastaParallel(t *testing.T, tc TestContext) {
+	hePasta := NewHEPasta()
+	if err := hePasta.InitParams(tc.Params, tc.SymParams); err != nil {
+		t.Fatal(err)
+	}
+	hePasta.HEKeyGen()
+	if _, err := hePasta.InitFvPasta(); err != nil {
+		t.Fatal(err)
+	}
+	hePasta.CreateGaloisKeys(len(tc.ExpCipherText))
+	if err := hePasta.EncryptSymKey(tc.Key); err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, 8)
+	binary.BigEndian.PutUint64(nonce, uint64(123456789))
+
+	want, err := hePasta.Trancipher(nonce, tc.ExpCipherText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, workers := range []int{2, 4} {
+		got, err := hePasta.TrancipherParallel(nonce, tc.ExpCipherText, workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("workers=%d: got %d blocks, want %d", workers, len(got), len(want))
+		}
+		for b := range want {
+			if !got[b].Equal(want[b]) {
+				t.Fatalf("workers=%d: block %d differs from the sequential path", workers, b)
+			}
+		}
+	}
+}
+
+func TestPastaNoiseTracer(t *testing.T) {
+	tc := pasta3TestVector[0]
+	hePasta := NewHEPasta()
+	if err := hePasta.InitParams(tc.Params, tc.SymParams); err != nil {
+		t.Fatal(err)
+	}
+	hePasta.HEKeyGen()
+	if _, err := hePasta.InitFvPasta(); err != nil {
+		t.Fatal(err)
+	}
+	hePasta.CreateGaloisKeys(len(tc.ExpCipherText))
+	if err := hePasta.EncryptSymKey(tc.Key); err != nil {
+		t.Fatal(err)
+	}
+	tracer := hePasta.EnableNoiseTracing()
+
+	nonce := make([]byte, 8)
+	binary.BigEndian.PutUint64(nonce, uint64(123456789))
+	if _, err := hePasta.Trancipher(nonce, tc.ExpCipherText); err != nil {
+		t.Fatal(err)
+	}
+
+	reports := tracer.Reports()
+	if len(reports) != 1 {
+		t.Fatalf("got %d reports, want 1", len(reports))
+	}
+	report := reports[0]
+	t.Log(report)
+
+	// the key, every round and the final affine layer
+	rounds := tc.SymParams.GetRounds()
+	if len(report.Rounds) != rounds+2 {
+		t.Fatalf("got %d rounds, want %d", len(report.Rounds), rounds+2)
+	}
+	layers := []string{LayerKey}
+	for r := 1; r < rounds; r++ {
+		layers = append(layers, LayerLinear, LayerFeistel)
+	}
+	layers = append(layers, LayerLinear, LayerCube, LayerLinear)
+
+	var prev float64
+	i := 0
+	for r, round := range report.Rounds {
+		if round.Round != r {
+			t.Fatalf("got round %d at index %d", round.Round, r)
+		}
+		for _, m := range round.Measures {
+			if m.Layer != layers[i] {
+				t.Fatalf("measure %d: got layer %s, want %s", i, m.Layer, layers[i])
+			}
+			// the products of the S-boxes consume the budget
+			if m.Layer != LayerKey && m.Layer != LayerLinear && m.Budget >= prev {
+				t.Fatalf("round %d: the %s layer did not consume budget", r, m.Layer)
+			}
+			prev = m.Budget
+			i++
+		}
+	}
+	if budget := report.MinBudget(); budget <= 0 {
+		t.Fatalf("the key stream has no budget left: %.2f", budget)
+	}
+}
+
+func TestPastaModDown(t *testing.T) {
+	tc := pasta3TestVector[0]
+	hePasta := NewHEPasta()
+	if err := hePasta.InitParams(tc.Params, tc.SymParams); err != nil {
+		t.Fatal(err)
+	}
+	hePasta.HEKeyGen()
+	if _, err := hePasta.InitFvPasta(); err != nil {
+		t.Fatal(err)
+	}
+	hePasta.CreateGaloisKeys(len(tc.ExpCipherText))
+	if err := hePasta.EncryptSymKey(tc.Key); err != nil {
+		t.Fatal(err)
+	}
+
+	modDown, report, err := hePasta.TuneModDown(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("mod-down %v\n%s", modDown, report)
+
+	nonce := make([]byte, 8)
+	binary.BigEndian.PutUint64(nonce, uint64(123456789))
+	fvCiphers, err := hePasta.Trancipher(nonce, tc.ExpCipherText)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	maxLevel := hePasta.bfvParams.MaxLevel()
+	blockSize := tc.SymParams.GetBlockSize()
+	for b, ct := range fvCiphers {
+		if ct.Level() >= maxLevel {
+			t.Fatalf("block %d: the output is at the top level %d", b, ct.Level())
+		}
+		want := tc.Plaintext[b*blockSize : min((b+1)*blockSize, len(tc.Plaintext))]
+		if got := decrypt(t, hePasta.Decrypt, ct)[:len(want)]; !reflect.DeepEqual([]uint64(want), got) {
+			t.Fatalf("block %d: decryption failure at level %d", b, ct.Level())
+		}
+	}
+}
+
+// TestPastaBatchModDown sets the schedule tuned on the per-block circuit to the batched mode
+// and decrypts every block of the batch at the reduced level
+func TestPastaBatchModDown(t *testing.T) {
+	if testing.Short() {
+		t.Skip("PASTA-3 mod-down tuning in short mode")
+	}
+	tc := pasta3TestVector[0]
+	hePasta := NewHEPasta()
+	if err := hePasta.InitParams(tc.Params, tc.SymParams); err != nil {
+		t.Fatal(err)
+	}
+	hePasta.HEKeyGen()
+	fvPasta, err := hePasta.InitFvPasta()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blockSize := tc.SymParams.GetBlockSize()
+	galEls := append(fvPasta.GetGaloisElements(blockSize), fvPasta.GetBatchGaloisElements()...)
+	rlk := hePasta.keyGenerator.GenRelinearizationKeyNew(hePasta.sk)
+	evk := rlwe.NewMemEvaluationKeySet(rlk, hePasta.keyGenerator.GenGaloisKeysNew(galEls, hePasta.sk)...)
+	fvPasta.UpdateEvaluator(newEvaluator(hePasta.bfvParams, evk))
+	if err := hePasta.EncryptSymKey(tc.Key); err != nil {
+		t.Fatal(err)
+	}
+	modDown, _, err := hePasta.TuneModDown(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modDown.Dropped() == 0 {
+		t.Fatalf("the schedule %v drops no modulus", modDown)
+	}
+
+	nonce := make([]byte, 8)
+	binary.BigEndian.PutUint64(nonce, uint64(123456789))
+	fvCiphers, err := hePasta.TrancipherBatch(nonce, tc.ExpCipherText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fvCiphers) != 1 {
+		t.Fatalf("got %d batches, want 1", len(fvCiphers))
+	}
+	level := hePasta.bfvParams.MaxLevel() - modDown.Dropped()
+	if fvCiphers[0].Level() != level {
+		t.Fatalf("got level %d, want %d", fvCiphers[0].Level(), level)
+	}
+	got := decrypt(t, hePasta.DecryptBatch, fvCiphers[0])[:len(tc.Plaintext)]
+	for b := 0; b*blockSize < len(got); b++ {
+		end := min((b+1)*blockSize, len(got))
+		if !reflect.DeepEqual([]uint64(tc.Plaintext[b*blockSize:end]), got[b*blockSize:end]) {
+			t.Fatalf("block %d: decryption failure at level %d", b, level)
+		}
+	}
+}
+
+// decrypt decrypts ct with dec and fails the test on a decoding error
+func decrypt(t *testing.T, dec func(*rlwe.Ciphertext) ([]uint64, error), ct *rlwe.Ciphertext) []uint64 {
+	res, err := dec(ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
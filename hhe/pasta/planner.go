@@ -0,0 +1,230 @@
+package pasta
+
+import (
+	"HHESoK"
+	"HHESoK/sym/pasta"
+	"crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"reflect"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// Security levels of the parameter planner, in bits
+const (
+	Security128 = 128
+	Security192 = 192
+)
+
+// maxLogQP is the largest log2(QP) of a ternary secret for each log2 of the ring degree, from the
+// classical tables of the homomorphic encryption standard. The standard stops at 2^15, the 2^16
+// bound of 128 bits is the one of the PN16QP1761 default parameters of lattigo, the ring degrees
+// without a bound have no parameters at the security level
+var maxLogQP = map[int]map[int]float64{
+	Security128: {10: 27, 11: 54, 12: 109, 13: 218, 14: 438, 15: 881, 16: 1761},
+	Security192: {10: 19, 11: 37, 12: 75, 13: 152, 14: 305, 15: 611},
+}
+
+// planner bounds
+const (
+	minLogN     = 10
+	maxLogN     = 16
+	maxLogPrime = 60
+	minLogPrime = 30
+)
+
+// ErrNoParameters is returned when no ring degree fits the noise of the circuit at the security level
+var ErrNoParameters = fmt.Errorf("%w: pasta: no parameters for the security level", HHESoK.ErrInvalidParameters)
+
+// PlanParameters derives the BGV parameters of the homomorphic PASTA evaluation from a noise
+// model of the circuit: the smallest ring degree whose slots hold a block and whose secure
+// modulus holds the estimated noise plus headroom bits. The model is calibrated on the test
+// vectors and is conservative, TuneParameters replaces it with a measured noise
+func PlanParameters(symParams pasta.Parameter, security int, headroom int) (Parameter, error) {
+	return planParameters(symParams, security, headroom, estimateNoise)
+}
+
+// TuneParameters plans the parameters with the noise model, measures the noise of a
+// transciphering with them and plans again with the measured noise, the result is verified
+// with VerifyParameters. The returned report is the one of the verification
+func TuneParameters(symParams pasta.Parameter, security int, headroom int) (params Parameter, report NoiseReport, err error) {
+	if params, err = PlanParameters(symParams, security, headroom); err != nil {
+		return
+	}
+	// the margin of the model is the headroom of the calibration run
+	if report, err = VerifyParameters(params, symParams, 0); err != nil {
+		return params, report, fmt.Errorf("cannot calibrate: %w", err)
+	}
+	measured := maxNoise(report)
+	params, err = planParameters(symParams, security, headroom, func(pasta.Parameter, int) float64 {
+		return measured
+	})
+	if err != nil {
+		return
+	}
+	report, err = VerifyParameters(params, symParams, headroom)
+	return
+}
+
+// VerifyParameters transciphers one random block with params under a random key and checks
+// the decryption and that at least headroom bits of noise budget are left
+func VerifyParameters(params Parameter, symParams pasta.Parameter, headroom int) (report NoiseReport, err error) {
+	modulus := new(big.Int).SetUint64(symParams.Modulus)
+	random := func(size int) []uint64 {
+		v := make([]uint64, size)
+		for i := range v {
+			r, _ := rand.Int(rand.Reader, modulus)
+			v[i] = r.Uint64()
+		}
+		return v
+	}
+	key := HHESoK.Key(random(symParams.KeySize))
+	plaintext := HHESoK.Plaintext(random(symParams.BlockSize))
+	nonce := HHESoK.NewNonce()
+	symPasta, err := pasta.NewPasta(key, symParams)
+	if err != nil {
+		return report, err
+	}
+	symCt := symPasta.NewEncryptor().EncryptWithNonce(nonce, plaintext)
+
+	bfvParams, err := params.bgvParameters()
+	if err != nil {
+		return report, fmt.Errorf("%w: %w", HHESoK.ErrInvalidParameters, err)
+	}
+	keyGenerator := rlwe.NewKeyGenerator(bfvParams)
+	sk, pk := keyGenerator.GenKeyPairNew()
+	encoder := bgv.NewEncoder(bfvParams)
+	fvPasta, err := NewMFVPasta(params, bfvParams, symParams, encoder, rlwe.NewEncryptor(bfvParams, pk), nil)
+	if err != nil {
+		return report, err
+	}
+	fvPasta.(*mfvPasta).logger = HHESoK.NewLogger(false)
+	galEls := fvPasta.GetGaloisElements(len(symCt))
+	evk := rlwe.NewMemEvaluationKeySet(keyGenerator.GenRelinearizationKeyNew(sk), keyGenerator.GenGaloisKeysNew(galEls, sk)...)
+	fvPasta.UpdateEvaluator(newEvaluator(bfvParams, evk))
+	tracer := NewNoiseTracer(bfvParams, sk)
+	fvPasta.SetNoiseTracer(tracer)
+
+	kCt, err := fvPasta.EncKey(key)
+	if err != nil {
+		return report, err
+	}
+	res, err := fvPasta.Crypt(nonce, kCt, symCt)
+	if err != nil {
+		return report, err
+	}
+	report = tracer.Reports()[0]
+	got := make([]uint64, bfvParams.MaxSlots())
+	if err = encoder.Decode(rlwe.NewDecryptor(bfvParams, sk).DecryptNew(res[0]), got); err != nil {
+		return report, err
+	}
+	if !reflect.DeepEqual([]uint64(plaintext), got[:symParams.BlockSize]) {
+		return report, fmt.Errorf("decryption failure, %.2f bits of noise budget left", report.MinBudget())
+	}
+	if budget := report.MinBudget(); budget < float64(headroom) {
+		return report, fmt.Errorf("%.2f bits of noise budget left, want %d", budget, headroom)
+	}
+	return
+}
+
+// planParameters returns the parameters of the smallest ring degree that fits the noise of
+// the circuit, noise returns the noise of the key stream in bits for a ring degree
+func planParameters(symParams pasta.Parameter, security int, headroom int, noise func(pasta.Parameter, int) float64) (params Parameter, err error) {
+	bounds, ok := maxLogQP[security]
+	if !ok {
+		return params, fmt.Errorf("%w: security level %d", HHESoK.ErrInvalidParameters, security)
+	}
+	t := symParams.Modulus
+	// the batching needs t = 1 mod 2N
+	maxN := min(bits.TrailingZeros64(t-1)-1, maxLogN)
+	ps := uint64(symParams.BlockSize)
+	// the primes of Q are larger than t
+	primeSize := min(max(bits.Len64(t)+1, minLogPrime), maxLogPrime)
+
+	for logN := minLogN; logN <= maxN; logN++ {
+		n := uint64(1) << logN
+		// the matrix multiplications need a full row or half a row per block
+		if ps*2 != n && ps*4 > n {
+			continue
+		}
+		logQ := modulusChain(noise(symParams, logN)+1+float64(headroom), primeSize)
+		// one special prime as large as the largest prime of Q keeps the key switching noise small
+		logP := []int{maxLogPrime}
+		if bound, ok := bounds[logN]; !ok || float64(sum(logQ)+sum(logP)) > bound {
+			continue
+		}
+		n1, n2 := bsgsSplit(int(ps))
+		return Parameter{
+			logN:      logN,
+			plainMod:  t,
+			modDegree: n,
+			UseBsGs:   true,
+			bSgSN1:    n1,
+			bSgSN2:    n2,
+			logQ:      logQ,
+			logP:      logP,
+		}, nil
+	}
+	return params, fmt.Errorf("%w: PASTA-%d with a %d-bit modulus at %d bits", ErrNoParameters,
+		symParams.Rounds, bits.Len64(t), security)
+}
+
+// estimateNoise is the noise model of the key stream in bits, the linear layers multiply by
+// plaintexts and add a block of products, the S-boxes multiply ciphertexts. The costs are
+// fitted on the traces of the test vectors and round up the measures
+func estimateNoise(symParams pasta.Parameter, logN int) float64 {
+	logT := float64(bits.Len64(symParams.Modulus))
+	n := float64(logN)
+
+	const fresh = 8
+	firstLinear := 2.5*logT + 20
+	linear := 1.75*logT + 5
+	feistel := 3*logT + n + 10
+	cube := 2.5*logT + n + 5
+
+	rounds := float64(symParams.Rounds)
+	return fresh + firstLinear + rounds*linear + (rounds-1)*feistel + cube
+}
+
+// modulusChain returns the smallest chain of equal primes of at least minSize and at most
+// maxLogPrime bits whose product has logQ bits
+func modulusChain(logQ float64, minSize int) []int {
+	count := int(math.Ceil(logQ / maxLogPrime))
+	size := max(int(math.Ceil(logQ/float64(count))), minSize)
+	chain := make([]int, count)
+	for i := range chain {
+		chain[i] = size
+	}
+	return chain
+}
+
+// bsgsSplit returns the split n1*n2 = ps of the baby-step giant-step matrix multiplication
+// with the fewest rotations, n1 is the smallest divisor of ps not below its square root
+func bsgsSplit(ps int) (n1, n2 int) {
+	n1 = int(math.Ceil(math.Sqrt(float64(ps))))
+	for ps%n1 != 0 {
+		n1++
+	}
+	return n1, ps / n1
+}
+
+// maxNoise returns the largest noise of the report
+func maxNoise(report NoiseReport) (noise float64) {
+	for _, round := range report.Rounds {
+		for _, m := range round.Measures {
+			noise = math.Max(noise, m.Noise)
+		}
+	}
+	return
+}
+
+func sum(v []int) (s int) {
+	for _, x := range v {
+		s += x
+	}
+	return
+}
@@ -0,0 +1,78 @@
+package pasta
+
+import (
+	"HHESoK"
+	"HHESoK/sym/pasta"
+	"errors"
+	"testing"
+)
+
+func TestPlanParameters(t *testing.T) {
+	if _, err := PlanParameters(pasta3TestVector[0].SymParams, 100, 10); !errors.Is(err, HHESoK.ErrInvalidParameters) {
+		t.Fatalf("security level 100: got %v, want %v", err, HHESoK.ErrInvalidParameters)
+	}
+	for _, tc := range append(pasta3TestVector, pasta4TestVector...) {
+		for _, security := range []int{Security128, Security192} {
+			params, err := PlanParameters(tc.SymParams, security, 10)
+			if errors.Is(err, ErrNoParameters) {
+				t.Logf("%s: %v", testString("PASTA", tc.SymParams), err)
+				continue
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			bfvParams, err := params.bgvParameters()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bfvParams.LogQP() > maxLogQP[security][params.LogN()] {
+				t.Fatalf("logN=%d: logQP=%.2f is above the %d-bit bound", params.LogN(), bfvParams.LogQP(), security)
+			}
+			if n1, n2 := params.BsGs(); n1*n2 != tc.SymParams.BlockSize {
+				t.Fatalf("BSGS split %d*%d of a block of %d", n1, n2, tc.SymParams.BlockSize)
+			}
+		}
+	}
+}
+
+// TestPlanParametersUncovered plans a circuit that only fits the ring degree 2^16, which the
+// standard only bounds at 128 bits
+func TestPlanParametersUncovered(t *testing.T) {
+	symParams := pasta3TestVector[0].SymParams
+	// 786433 = 3*2^18 + 1 batches up to 2^17 slots
+	symParams.Modulus = 786433
+	noise := func(pasta.Parameter, int) float64 { return 1000 }
+	params, err := planParameters(symParams, Security128, 10, noise)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.LogN() != 16 {
+		t.Fatalf("128 bits: got logN=%d, want 16", params.LogN())
+	}
+	if _, err = planParameters(symParams, Security192, 10, noise); !errors.Is(err, ErrNoParameters) {
+		t.Fatalf("192 bits: got %v, want %v", err, ErrNoParameters)
+	}
+}
+
+func TestTuneParameters(t *testing.T) {
+	tc := pasta3TestVector[0]
+	params, report, err := TuneParameters(tc.SymParams, Security128, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logQ, logP := params.Moduli()
+	t.Logf("logN=%d logQ=%v logP=%v budget=%.2f", params.LogN(), logQ, logP, report.MinBudget())
+
+	// the planned modulus is below the legacy chain of the test vector
+	planned, err := params.bgvParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := tc.Params.bgvParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if planned.LogQP() >= legacy.LogQP() {
+		t.Fatalf("planned logQP=%.2f, legacy logQP=%.2f", planned.LogQP(), legacy.LogQP())
+	}
+}
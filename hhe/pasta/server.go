@@ -0,0 +1,96 @@
+package pasta
+
+import (
+	"HHESoK/hhe/wire"
+	"HHESoK/sym/pasta"
+	"fmt"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// Server transciphers the PASTA ciphertexts of a Client into BFV ciphertexts, it only holds
+// the evaluation keys and the encrypted PASTA key, all read from wire artifacts
+type Server struct {
+	params    Parameter
+	symParams pasta.Parameter
+	bfvParams bgv.Parameters
+	encoder   *bgv.Encoder
+	fvPasta   MFVPasta
+	symKeyCt  *rlwe.Ciphertext
+}
+
+// NewServer returns the server of the parameters artifact of a Client
+func NewServer(params []byte) (s *Server, err error) {
+	s = new(Server)
+	if s.params, s.symParams, err = unmarshalParameters(params); err != nil {
+		return nil, fmt.Errorf("cannot NewServer: %w", err)
+	}
+	if s.bfvParams, err = s.params.bgvParameters(); err != nil {
+		return nil, fmt.Errorf("cannot NewServer: %w", err)
+	}
+	s.encoder = bgv.NewEncoder(s.bfvParams)
+	return s, nil
+}
+
+// SetEvaluationKeys reads the evaluation keys artifact
+func (s *Server) SetEvaluationKeys(data []byte) error {
+	body, err := wire.Open(data, Scheme, wire.KindEvaluationKeys)
+	if err != nil {
+		return fmt.Errorf("cannot SetEvaluationKeys: %w", err)
+	}
+	evk := new(rlwe.MemEvaluationKeySet)
+	dec := wire.NewDecoder(body)
+	dec.Object(evk)
+	if err = dec.Finish(); err != nil {
+		return fmt.Errorf("cannot SetEvaluationKeys: %w", err)
+	}
+	// the server never encrypts, the encryptor of the evaluation is left out
+	if s.fvPasta, err = NewMFVPasta(s.params, s.bfvParams, s.symParams, s.encoder, nil, newEvaluator(s.bfvParams, evk)); err != nil {
+		return fmt.Errorf("cannot SetEvaluationKeys: %w", err)
+	}
+	return nil
+}
+
+// SetSymKeyCiphertext reads the artifact of the encrypted PASTA key
+func (s *Server) SetSymKeyCiphertext(data []byte) error {
+	body, err := wire.Open(data, Scheme, wire.KindSymKeyCiphertext)
+	if err != nil {
+		return fmt.Errorf("cannot SetSymKeyCiphertext: %w", err)
+	}
+	ct := new(rlwe.Ciphertext)
+	dec := wire.NewDecoder(body)
+	dec.Object(ct)
+	if err = dec.Finish(); err != nil {
+		return fmt.Errorf("cannot SetSymKeyCiphertext: %w", err)
+	}
+	s.symKeyCt = ct
+	return nil
+}
+
+// Transcipher evaluates the PASTA key stream under the encrypted key, removes it from the
+// symmetric ciphertext artifact and returns the result artifact, one BFV ciphertext per block
+func (s *Server) Transcipher(symCiphertext []byte) ([]byte, error) {
+	if s.fvPasta == nil || s.symKeyCt == nil {
+		return nil, fmt.Errorf("cannot Transcipher: the evaluation keys and the encrypted key must be set")
+	}
+	body, err := wire.Open(symCiphertext, Scheme, wire.KindSymCiphertext)
+	if err != nil {
+		return nil, fmt.Errorf("cannot Transcipher: %w", err)
+	}
+	dec := wire.NewDecoder(body)
+	nonce := dec.Bytes()
+	dCt := dec.Uint64s()
+	if err = dec.Finish(); err != nil {
+		return nil, fmt.Errorf("cannot Transcipher: %w", err)
+	}
+	for _, v := range dCt {
+		if v >= s.symParams.GetModulus() {
+			return nil, fmt.Errorf("cannot Transcipher: %w: element not reduced modulo %d", wire.ErrFormat, s.symParams.GetModulus())
+		}
+	}
+	res, err := s.fvPasta.Crypt(nonce, s.symKeyCt, dCt)
+	if err != nil {
+		return nil, fmt.Errorf("cannot Transcipher: %w", err)
+	}
+	return marshalResult(len(dCt), res)
+}
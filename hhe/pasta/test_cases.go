@@ -22,6 +22,17 @@ type TestContext struct {
 	ExpCipherText HHESoK.Ciphertext
 }
 
+// presetParams returns the BGV parameters of the preset of the given name, the vectors of the
+// 33 and 60-bit moduli use them since the legacy chain does not hold their noise
+func presetParams(name string) Parameter {
+	for _, set := range presets {
+		if set.Name == name {
+			return set.copy().Params
+		}
+	}
+	panic("unknown PASTA preset " + name)
+}
+
 // Decryption Test Vectors
 var pasta3TestVector = []TestContext{
 	{
@@ -109,15 +120,8 @@ var pasta3TestVector = []TestContext{
 			0x0a3ed, 0x03868, 0x09ea1, 0x0c657, 0x0b8e3, 0x05663, 0x07a04, 0x02e7b},
 	},
 	{
-		Tc: DEC,
-		Params: Parameter{
-			UseBsGs:   true,
-			bSgSN1:    16,
-			bSgSN2:    8,
-			logN:      15,
-			plainMod:  8088322049,
-			modDegree: uint64(math.Pow(2, 15)),
-		},
+		Tc:     DEC,
+		Params: presetParams("PASTA3-T33-N15"),
 		SymParams: pasta.Parameter{
 			KeySize:   256,
 			BlockSize: 128,
@@ -235,15 +239,8 @@ var pasta3TestVector = []TestContext{
 			0x00e62d01a, 0x14fb2137e, 0x0a9c2c126},
 	},
 	{
-		Tc: DEC,
-		Params: Parameter{
-			UseBsGs:   true,
-			logN:      15,
-			bSgSN1:    16,
-			bSgSN2:    8,
-			plainMod:  1096486890805657601,
-			modDegree: uint64(math.Pow(2, 15)),
-		},
+		Tc:     DEC,
+		Params: presetParams("PASTA3-T60-N16"),
 		SymParams: pasta.Parameter{
 			KeySize:   256,
 			BlockSize: 128,
@@ -429,6 +426,139 @@ var pasta3TestVector = []TestContext{
 			0x4070a4bf3a6f7e8, 0xb96c6f87591abae,
 		},
 	},
+	// multi-block regression vector, two and a half blocks encrypted with sym/pasta under HHESoK.DefaultNonce
+	{
+		Tc: DEC,
+		Params: Parameter{
+			UseBsGs:   true,
+			bSgSN1:    16,
+			bSgSN2:    8,
+			logN:      14,
+			plainMod:  65537,
+			modDegree: uint64(math.Pow(2, 14)),
+		},
+		SymParams: pasta.Parameter{
+			KeySize:   256,
+			BlockSize: 128,
+			Rounds:    3,
+			Modulus:   65537,
+		},
+		Key: HHESoK.Key{
+			0x0f275, 0x051ae, 0x0cf0a, 0x0bff9, 0x0aef3, 0x04963, 0x007c7, 0x0bc55,
+			0x0c050, 0x00255, 0x0ce4d, 0x07246, 0x03803, 0x016c5, 0x05b73, 0x0785f,
+			0x0d273, 0x0eac2, 0x050fb, 0x0f5ae, 0x01d5b, 0x030c5, 0x088d4, 0x030c7,
+			0x0da0f, 0x0ae17, 0x05398, 0x0a784, 0x04031, 0x0393c, 0x0cc51, 0x07d07,
+			0x07b0c, 0x0ba5e, 0x0c32f, 0x0d55f, 0x09ab5, 0x0644a, 0x0ddfb, 0x0f277,
+			0x08017, 0x0e2ee, 0x01ee2, 0x07e1f, 0x0a609, 0x0a6c5, 0x0a4d6, 0x011ae,
+			0x03bb3, 0x09db6, 0x0345a, 0x0ae8f, 0x09e10, 0x0ec5a, 0x01f54, 0x0d173,
+			0x00072, 0x0f8ef, 0x05077, 0x0b0a5, 0x0bff4, 0x0a2ec, 0x043c4, 0x04474,
+			0x028ae, 0x0cdab, 0x036a6, 0x0fbac, 0x0de2e, 0x03a77, 0x009ea, 0x0b373,
+			0x093d6, 0x067d6, 0x05ffb, 0x03198, 0x03214, 0x0fcd0, 0x054a0, 0x04d37,
+			0x0e871, 0x0ab8c, 0x0ffa8, 0x0c495, 0x0e7fd, 0x06858, 0x0a6c6, 0x047e6,
+			0x013eb, 0x04ba5, 0x0385b, 0x04e42, 0x04f6f, 0x0d093, 0x08489, 0x0d0ac,
+			0x04b7b, 0x04a6e, 0x05cab, 0x04ecb, 0x0bd47, 0x077c9, 0x0d873, 0x028c7,
+			0x01069, 0x04899, 0x0f0b7, 0x0d1bd, 0x07fc4, 0x0ff3b, 0x01b44, 0x01cea,
+			0x0563f, 0x0e76d, 0x06d40, 0x0c954, 0x0d7ff, 0x0fc28, 0x0c9af, 0x09669,
+			0x05be1, 0x07d4c, 0x08b06, 0x07a81, 0x0dd9d, 0x0ea96, 0x0f77c, 0x0b785,
+			0x0d5f4, 0x0b3a6, 0x0a865, 0x07ab1, 0x01759, 0x0ac01, 0x02d7c, 0x0e4f1,
+			0x01a40, 0x01d91, 0x0419e, 0x02bf8, 0x0b361, 0x0dc2e, 0x0f48a, 0x0eefa,
+			0x05898, 0x0853c, 0x0c380, 0x04754, 0x0f5f1, 0x03970, 0x077dd, 0x0f395,
+			0x00057, 0x08cf6, 0x0e207, 0x0bc84, 0x0e019, 0x0311d, 0x04152, 0x0eef5,
+			0x0d048, 0x0ff9a, 0x063f2, 0x024b9, 0x04160, 0x0d0e5, 0x07f72, 0x0671b,
+			0x0ab1e, 0x0f304, 0x09e7a, 0x0ce75, 0x01691, 0x099cc, 0x0f1af, 0x0b97b,
+			0x05c52, 0x05f0f, 0x00f8b, 0x08513, 0x030f3, 0x02938, 0x040fb, 0x0c1c8,
+			0x00738, 0x09c59, 0x03694, 0x0c63f, 0x02dbe, 0x085f1, 0x0e61b, 0x09ceb,
+			0x0f339, 0x0fbd8, 0x03487, 0x026c4, 0x0a0f6, 0x02d5f, 0x0eafe, 0x0c4e5,
+			0x09680, 0x02b10, 0x0bece, 0x0fa5f, 0x044f8, 0x0cc96, 0x074ae, 0x0cf49,
+			0x0f748, 0x0a78a, 0x05e99, 0x072c3, 0x0c516, 0x02a4a, 0x00c62, 0x043ce,
+			0x03fe9, 0x0081c, 0x0b48f, 0x0927b, 0x0a9b2, 0x0dd9f, 0x040fd, 0x067e3,
+			0x0dd43, 0x06f57, 0x09681, 0x0c03f, 0x04f3c, 0x0052e, 0x0ece5, 0x0897d,
+			0x0f777, 0x06c0c, 0x0e9f7, 0x00cb2, 0x0a968, 0x0845b, 0x0c1e1, 0x04a49,
+			0x03f38, 0x0d05c, 0x0e085, 0x071dc, 0x0955d, 0x096a7, 0x0a799, 0x05636,
+			0x063e6, 0x0adbb, 0x05eb5, 0x0c0df, 0x0ad47, 0x0121a, 0x08fd3, 0x0bf0b},
+		Plaintext: HHESoK.Plaintext{
+			0x069c4, 0x040dd, 0x0bada, 0x00e0e, 0x0bc0e, 0x0806d, 0x0992d, 0x00150,
+			0x0b6c3, 0x0a717, 0x09515, 0x0f80a, 0x0e945, 0x05007, 0x05427, 0x0d693,
+			0x05871, 0x0704a, 0x08125, 0x01614, 0x01781, 0x0d9f1, 0x0c855, 0x0d236,
+			0x0e71d, 0x08bd1, 0x08ef5, 0x0a626, 0x01666, 0x0012c, 0x091b6, 0x04cb8,
+			0x0578f, 0x04c87, 0x06d32, 0x07d0f, 0x049f1, 0x07cb0, 0x0e9df, 0x02acc,
+			0x0d053, 0x06a2f, 0x0e197, 0x0fb30, 0x0bbe3, 0x071be, 0x056fd, 0x0578e,
+			0x08e80, 0x0675a, 0x0beab, 0x016a0, 0x06c21, 0x04121, 0x0fe0a, 0x0a6cd,
+			0x01a71, 0x02f16, 0x0bbf3, 0x08758, 0x07160, 0x0810e, 0x0d88b, 0x0f941,
+			0x08fab, 0x08de4, 0x0247e, 0x0bfe1, 0x0149c, 0x07d43, 0x093a9, 0x09b55,
+			0x084c7, 0x037aa, 0x0eef6, 0x06b51, 0x0f020, 0x0d388, 0x005b0, 0x0d701,
+			0x02bbd, 0x0db56, 0x0ec38, 0x03508, 0x093fc, 0x01422, 0x04608, 0x0066c,
+			0x0a022, 0x06c18, 0x04dea, 0x095e9, 0x0b161, 0x0c262, 0x04684, 0x076d9,
+			0x00693, 0x0cb9d, 0x0fa8c, 0x04312, 0x02405, 0x0ffa8, 0x00545, 0x0370c,
+			0x01eb4, 0x01cb9, 0x0ada0, 0x012f1, 0x03032, 0x060c3, 0x079cd, 0x0a22a,
+			0x05c73, 0x0d96f, 0x0224e, 0x0fad5, 0x00c0d, 0x0a40e, 0x01006, 0x05eb0,
+			0x0e427, 0x0501e, 0x0849e, 0x001b4, 0x0426d, 0x0569e, 0x0f1ac, 0x01c09,
+			0x09690, 0x0ecb8, 0x03b17, 0x0f16b, 0x061dc, 0x05570, 0x0d1cd, 0x0fc85,
+			0x00c48, 0x0eea7, 0x02e8d, 0x0fcc7, 0x0738f, 0x0c5b4, 0x0784b, 0x02c95,
+			0x0754a, 0x06a78, 0x0975d, 0x084e1, 0x0eda0, 0x0714d, 0x0b188, 0x0e836,
+			0x09d67, 0x080b3, 0x01cff, 0x0c370, 0x05d56, 0x029f7, 0x0037a, 0x05a30,
+			0x0f2de, 0x0265f, 0x047c7, 0x0ac0b, 0x0a8c2, 0x0c7d1, 0x03af8, 0x04bac,
+			0x0f31d, 0x05eba, 0x0fe17, 0x0861d, 0x0b12e, 0x0ff91, 0x043e5, 0x06ca0,
+			0x0e926, 0x096f5, 0x04b6c, 0x06c0d, 0x06101, 0x06bd5, 0x09b20, 0x0ac2f,
+			0x066e3, 0x0ca98, 0x059cb, 0x0d4ea, 0x0048f, 0x07a5e, 0x0f160, 0x007de,
+			0x07c7f, 0x01545, 0x08062, 0x0a416, 0x08fd6, 0x05ac3, 0x07859, 0x084c2,
+			0x05494, 0x0f834, 0x06fe4, 0x093af, 0x0e5a0, 0x00aed, 0x0cdda, 0x084ca,
+			0x08f92, 0x0b37b, 0x072f3, 0x0df3b, 0x0a523, 0x0bdad, 0x04287, 0x0f190,
+			0x0bca4, 0x087aa, 0x03366, 0x09640, 0x02ef5, 0x095fb, 0x0e28c, 0x03fb2,
+			0x02373, 0x00954, 0x001ad, 0x0ebea, 0x03942, 0x04752, 0x02262, 0x03a0d,
+			0x0f6f8, 0x093aa, 0x0b64a, 0x0a045, 0x03ac6, 0x0af06, 0x0e249, 0x0f3f5,
+			0x09b72, 0x0a994, 0x0f3ad, 0x09663, 0x05acd, 0x0f83d, 0x0df8a, 0x0b375,
+			0x0c606, 0x02eb3, 0x02f17, 0x09bd5, 0x0196a, 0x0cdfb, 0x03fb2, 0x0a408,
+			0x0bf62, 0x0a1e0, 0x0a54f, 0x03d85, 0x04268, 0x00774, 0x06bf4, 0x02e15,
+			0x02903, 0x023e5, 0x08417, 0x0fa74, 0x07834, 0x0ae04, 0x0d378, 0x05233,
+			0x0be0e, 0x03e96, 0x0d591, 0x05298, 0x08b4c, 0x01468, 0x088ce, 0x0a376,
+			0x07bec, 0x063ea, 0x0759e, 0x08b24, 0x0d92c, 0x088d8, 0x0d6ad, 0x03e2c,
+			0x04cb2, 0x096e4, 0x088dc, 0x013d5, 0x02b88, 0x0f6b4, 0x02e1e, 0x08f3c,
+			0x0ea25, 0x028f2, 0x0bd6c, 0x05ee7, 0x09bb0, 0x078d6, 0x01c7d, 0x0b7aa,
+			0x087da, 0x0b495, 0x0f0cd, 0x0cd6d, 0x09d1f, 0x0d6d9, 0x0cc98, 0x08fa9,
+			0x0ad5f, 0x0e373, 0x00edc, 0x0e5bb, 0x0cbaf, 0x09ff6, 0x0bd3d, 0x0b070},
+		ExpCipherText: HHESoK.Ciphertext{
+			0x0529b, 0x035f7, 0x05eda, 0x04d94, 0x088c1, 0x08e41, 0x058f1, 0x0342d,
+			0x028bd, 0x0b9df, 0x0c4ed, 0x04a45, 0x0474d, 0x03b4c, 0x08eb4, 0x0ec74,
+			0x04b9b, 0x0c90d, 0x00be8, 0x050a5, 0x019b0, 0x08c32, 0x07445, 0x081ee,
+			0x06e0d, 0x08cc3, 0x0a9de, 0x04d23, 0x028cd, 0x0b438, 0x0e6a9, 0x0b88c,
+			0x0adda, 0x0ced0, 0x021cc, 0x0a3d1, 0x0753d, 0x0d049, 0x0340d, 0x02cf4,
+			0x05841, 0x081df, 0x01dec, 0x06f4a, 0x0fdd5, 0x006f2, 0x05cc1, 0x02d57,
+			0x02823, 0x026e9, 0x0ccd5, 0x0a993, 0x02f26, 0x0f74e, 0x0fee3, 0x03c37,
+			0x08799, 0x0bad7, 0x0c9a2, 0x0bd9b, 0x0db39, 0x0302f, 0x0ac44, 0x0d789,
+			0x01829, 0x0ee4b, 0x05e8c, 0x0b96a, 0x0ec4f, 0x07ee1, 0x0995c, 0x0b52b,
+			0x01a59, 0x0296a, 0x0d8b7, 0x0e462, 0x0a9d1, 0x0315e, 0x0c014, 0x04f63,
+			0x0dda6, 0x09182, 0x03713, 0x084be, 0x0f05e, 0x073d6, 0x09927, 0x065af,
+			0x0b8ba, 0x08ca2, 0x019eb, 0x0b843, 0x01352, 0x03019, 0x05158, 0x0b9cb,
+			0x088ec, 0x0aa85, 0x09711, 0x03e59, 0x0886d, 0x00d37, 0x06a93, 0x03557,
+			0x0d4df, 0x08dca, 0x0097f, 0x093e3, 0x04006, 0x0cbaf, 0x0d9f1, 0x04b42,
+			0x0bad5, 0x00c26, 0x01a52, 0x07020, 0x00607, 0x0b5a0, 0x0e15f, 0x03fba,
+			0x0cac2, 0x08156, 0x05586, 0x022e5, 0x059ea, 0x01ade, 0x0b26b, 0x0b78a,
+			0x077c9, 0x0069d, 0x062c3, 0x0d4c5, 0x0a9e4, 0x02c9f, 0x060c0, 0x0a8cf,
+			0x09935, 0x0a745, 0x0fe78, 0x02d79, 0x0db61, 0x0b7a3, 0x018c6, 0x0e328,
+			0x0dd7f, 0x03b9f, 0x0b1f2, 0x0fbf6, 0x045fe, 0x0f806, 0x085da, 0x07774,
+			0x0fcc1, 0x0bcbb, 0x065b4, 0x07c39, 0x05404, 0x0fd0f, 0x064f6, 0x0ed14,
+			0x06c7b, 0x079a6, 0x08f3c, 0x08c9a, 0x023de, 0x0fcec, 0x01eb5, 0x0822b,
+			0x0ba4c, 0x01f7a, 0x0d330, 0x047a5, 0x0a9c7, 0x04e41, 0x0727a, 0x0543e,
+			0x06bcd, 0x0dafe, 0x01243, 0x049d0, 0x08bd3, 0x0710f, 0x033dc, 0x01cd3,
+			0x07dd0, 0x0a317, 0x07144, 0x02303, 0x0740e, 0x08a1e, 0x01f10, 0x05e29,
+			0x0d648, 0x0fbbc, 0x0e536, 0x005b8, 0x0d238, 0x03937, 0x0aa2c, 0x058cf,
+			0x0c4e3, 0x0f896, 0x0733f, 0x007ac, 0x0cc9f, 0x016f0, 0x03dd2, 0x0843b,
+			0x08653, 0x0a92b, 0x0772c, 0x088e8, 0x00205, 0x07a1f, 0x06e9b, 0x01c38,
+			0x0a20b, 0x0d740, 0x08946, 0x0ca62, 0x07222, 0x0e68f, 0x03ce8, 0x09e05,
+			0x0df16, 0x07482, 0x07c2e, 0x08ebd, 0x0c8bd, 0x019ef, 0x03e45, 0x0aa56,
+			0x00e69, 0x0236a, 0x04b4e, 0x0a9a7, 0x0c24d, 0x03405, 0x0b77b, 0x0cfb0,
+			0x03bc1, 0x0190c, 0x0ef4c, 0x04d4b, 0x0b412, 0x05023, 0x04129, 0x0848c,
+			0x0412c, 0x082a7, 0x0f081, 0x072d8, 0x059e2, 0x0f9fd, 0x02dba, 0x0d608,
+			0x0e9a8, 0x0cacb, 0x09916, 0x0c458, 0x0220a, 0x08ed6, 0x04ba7, 0x0675b,
+			0x02d0e, 0x075e2, 0x0fb57, 0x08909, 0x0a27c, 0x05bd6, 0x0b32e, 0x068f0,
+			0x05cf4, 0x04374, 0x04d8b, 0x0a496, 0x061dc, 0x0dcf0, 0x0c012, 0x0c596,
+			0x04395, 0x0d45e, 0x09953, 0x06651, 0x05645, 0x065eb, 0x0ce23, 0x01191,
+			0x08e5d, 0x036db, 0x02d79, 0x073b7, 0x0a6ac, 0x03b59, 0x01617, 0x0756a,
+			0x0b8b9, 0x07248, 0x0ffd3, 0x0a496, 0x072f4, 0x0ed28, 0x0340c, 0x00c1d,
+			0x02691, 0x09d48, 0x039e7, 0x0de08, 0x0b3ab, 0x04fd8, 0x075d7, 0x0e1a5,
+			0x05633, 0x05f9d, 0x0178d, 0x01ab8, 0x01827, 0x004c5, 0x0fb0b, 0x0dd75},
+	},
 }
 
 var pasta4TestVector = []TestContext{
@@ -466,15 +596,8 @@ var pasta4TestVector = []TestContext{
 			0x014dc, 0x08b83, 0x000e7, 0x00097, 0x0b3c3, 0x08fe2, 0x08833, 0x0fad5},
 	},
 	{
-		Tc: DEC,
-		Params: Parameter{
-			UseBsGs:   true,
-			bSgSN1:    8,
-			bSgSN2:    4,
-			logN:      15,
-			plainMod:  8088322049,
-			modDegree: uint64(math.Pow(2, 15)),
-		},
+		Tc:     DEC,
+		Params: presetParams("PASTA4-T33-N16"),
 		SymParams: pasta.Parameter{
 			KeySize:   64,
 			BlockSize: 32,
@@ -513,15 +636,8 @@ var pasta4TestVector = []TestContext{
 			0x163a9d740, 0x04616d469},
 	},
 	{
-		Tc: DEC,
-		Params: Parameter{
-			UseBsGs:   true,
-			bSgSN1:    8,
-			bSgSN2:    4,
-			logN:      16,
-			plainMod:  1096486890805657601,
-			modDegree: uint64(math.Pow(2, 16)),
-		},
+		Tc:     DEC,
+		Params: presetParams("PASTA4-T60-N16"),
 		SymParams: pasta.Parameter{
 			KeySize:   64,
 			BlockSize: 32,
@@ -576,4 +692,53 @@ var pasta4TestVector = []TestContext{
 			0x89f9a5e9b2feab0, 0x4083de158a4d912, 0x2c7aef89fe68f45,
 			0x03955bed0567b8a, 0xd3e0f2d562cc47d},
 	},
+	// multi-block regression vector, two and a half blocks encrypted with sym/pasta under HHESoK.DefaultNonce
+	{
+		Tc: DEC,
+		Params: Parameter{
+			UseBsGs:   true,
+			bSgSN1:    8,
+			bSgSN2:    4,
+			logN:      14,
+			plainMod:  65537,
+			modDegree: uint64(math.Pow(2, 14)),
+		},
+		SymParams: pasta.Parameter{
+			KeySize:   64,
+			BlockSize: 32,
+			Rounds:    4,
+			Modulus:   65537,
+		},
+		Key: HHESoK.Key{
+			0x0ce22, 0x0242d, 0x06565, 0x0fdb1, 0x090f7, 0x05e08, 0x05cc1, 0x0f3aa,
+			0x04611, 0x0ab91, 0x0362b, 0x0d05d, 0x0254f, 0x0f54d, 0x0fafd, 0x0f5b9,
+			0x0c8fb, 0x0262b, 0x009eb, 0x0e937, 0x0b77a, 0x030f0, 0x035d5, 0x0cd01,
+			0x02643, 0x007e5, 0x0e85a, 0x0d521, 0x09985, 0x00b2d, 0x0a88a, 0x0cc19,
+			0x08737, 0x05a64, 0x0e30e, 0x021b7, 0x0616a, 0x02c63, 0x0a4fd, 0x0fab9,
+			0x0a7b5, 0x0ddf9, 0x04896, 0x082af, 0x0ec9b, 0x0aa12, 0x0c994, 0x02c7b,
+			0x0627c, 0x02d8f, 0x005c4, 0x0c79a, 0x0064d, 0x008b3, 0x0f69d, 0x096ae,
+			0x0557e, 0x0cacb, 0x0f7dd, 0x060d1, 0x05679, 0x0d467, 0x02260, 0x07d34},
+		Plaintext: HHESoK.Plaintext{
+			0x01500, 0x0e0be, 0x01e68, 0x0bd4e, 0x019df, 0x0a6a4, 0x0205b, 0x00348,
+			0x00469, 0x0ac85, 0x019a1, 0x0cfda, 0x0071d, 0x0d5e8, 0x03a6a, 0x03520,
+			0x0a5f3, 0x0d1a7, 0x04973, 0x080e6, 0x0e0a5, 0x097e5, 0x04614, 0x022ba,
+			0x0fcec, 0x0c89c, 0x0ba86, 0x0d37a, 0x0bcbd, 0x06e87, 0x0c69e, 0x04c0d,
+			0x0135c, 0x02bb4, 0x088cc, 0x08575, 0x0dff0, 0x099c8, 0x02978, 0x0a52e,
+			0x0bd2b, 0x063d1, 0x0a609, 0x0b001, 0x057a2, 0x0a3f4, 0x08333, 0x01eb0,
+			0x0f5c7, 0x07e08, 0x014c0, 0x08785, 0x032aa, 0x0fc81, 0x056bf, 0x0e514,
+			0x0f382, 0x07da0, 0x061a5, 0x0d310, 0x05129, 0x041c5, 0x07af0, 0x038ed,
+			0x0d220, 0x03ef4, 0x077eb, 0x0014d, 0x03e83, 0x07987, 0x053c0, 0x0c686,
+			0x0f316, 0x01bdf, 0x0d5fd, 0x0e0be, 0x0f162, 0x02026, 0x0b304, 0x0f9b0},
+		ExpCipherText: HHESoK.Ciphertext{
+			0x0e5d0, 0x095c5, 0x079e8, 0x0044a, 0x010b9, 0x0e122, 0x04f78, 0x008b3,
+			0x08dee, 0x036ee, 0x0d9ff, 0x0e99f, 0x04996, 0x08a63, 0x03825, 0x0f8bd,
+			0x03b4d, 0x057e7, 0x083e2, 0x038d2, 0x0c551, 0x016af, 0x0bb4f, 0x09216,
+			0x0c6b3, 0x0bdf6, 0x0532d, 0x000d2, 0x0f27b, 0x04c35, 0x02b06, 0x009f9,
+			0x01600, 0x0749d, 0x01750, 0x00c28, 0x087ac, 0x077ce, 0x0a3b3, 0x072de,
+			0x0657b, 0x0c08d, 0x0bb1d, 0x02b3c, 0x0d77b, 0x05264, 0x0d414, 0x03676,
+			0x07976, 0x09dde, 0x06fda, 0x0bf6b, 0x0a6a7, 0x024ce, 0x0755c, 0x01fd8,
+			0x0183a, 0x07641, 0x07934, 0x0384e, 0x08ea9, 0x023f2, 0x0bed9, 0x0d805,
+			0x085fb, 0x0dc34, 0x02062, 0x0395c, 0x0644b, 0x0ebda, 0x0543d, 0x069bf,
+			0x0a9c7, 0x04974, 0x090ee, 0x0cb24, 0x0ade4, 0x02496, 0x01ec1, 0x0bdc8},
+	},
 }
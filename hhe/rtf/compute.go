@@ -0,0 +1,41 @@
+package rtf
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/ckks"
+)
+
+// Computation is a homomorphic computation on the data of a HalfBoot output, evaluated in
+// place. With sparse coefficients the ciphertext also holds positions in the imaginary parts
+// of its slots, see DecodeHalfBoot
+type Computation func(eval *ckks.Evaluator, ct *rlwe.Ciphertext, sparse bool) error
+
+// Affine returns the computation x -> a*x + b of every data position, a non-integer a
+// consumes one level
+func Affine(a, b float64) Computation {
+	return func(eval *ckks.Evaluator, ct *rlwe.Ciphertext, sparse bool) (err error) {
+		if a != 1 {
+			scale := ct.Scale
+			if err = eval.Mul(ct, a, ct); err != nil {
+				return fmt.Errorf("cannot Affine: %w", err)
+			}
+			if ct.Scale.Cmp(scale) != 0 {
+				if err = eval.Rescale(ct, ct); err != nil {
+					return fmt.Errorf("cannot Affine: %w", err)
+				}
+			}
+		}
+		if b != 0 {
+			constant := complex(b, 0)
+			if sparse {
+				constant = complex(b, b)
+			}
+			if err = eval.Add(ct, constant, ct); err != nil {
+				return fmt.Errorf("cannot Affine: %w", err)
+			}
+		}
+		return nil
+	}
+}
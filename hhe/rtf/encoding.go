@@ -0,0 +1,109 @@
+package rtf
+
+import (
+	"fmt"
+	"math"
+
+	"HHESoK/rtf_ckks_integration/utils"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/ring"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// The data of the client is a vector of FVSlots values indexed by their position in the
+// HalfBoot output, the slots of ctReal then the slots of ctImag. Position pos is masked
+// by the key stream of block SlotBlock(pos), the symmetric block evaluated in BFV slot
+// SlotBlock(pos), which the slots to coefficients transform moves to Coefficient(pos).
+
+// SlotBlock returns the block whose key stream masks the data at position pos
+func (p Parameters) SlotBlock(pos int) int {
+	half := p.FVSlots() / 2
+	if pos < half {
+		return 2 * pos
+	}
+	return 2*(pos-half) + 1
+}
+
+// Coefficient returns the coefficient of the plaintext that holds the data at position pos
+func (p Parameters) Coefficient(pos int) int {
+	block := utils.BitReverse64(uint64(p.SlotBlock(pos)), uint64(p.LogFVSlots()))
+	return int(block) * (p.N() / p.FVSlots())
+}
+
+// MaskCoefficients returns the N coefficients in Z_t of data scaled by MessageScaling and
+// masked by keyStream, data is indexed by position and keyStream by block
+func (p Parameters) MaskCoefficients(data []float64, keyStream []uint64) ([]uint64, error) {
+	masked, err := p.Mask(data, keyStream)
+	if err != nil {
+		return nil, fmt.Errorf("cannot MaskCoefficients: %w", err)
+	}
+	return p.PositionsToCoefficients(masked)
+}
+
+// Mask returns the FVSlots values in Z_t of data scaled by MessageScaling and masked by
+// keyStream, the symmetric ciphertext of the client, data is indexed by position and
+// keyStream by block, the missing positions of data are zeros
+func (p Parameters) Mask(data []float64, keyStream []uint64) ([]uint64, error) {
+	fvSlots := p.FVSlots()
+	if len(data) > fvSlots || len(keyStream) != fvSlots {
+		return nil, fmt.Errorf("cannot Mask: at most %d values and %d key stream elements expected", fvSlots, fvSlots)
+	}
+	t := p.PlainModulus
+	masked := make([]uint64, fvSlots)
+	for pos := range masked {
+		var v uint64
+		if pos < len(data) {
+			scaled := int64(math.Round(data[pos] * p.MessageScaling()))
+			v = uint64((scaled%int64(t) + int64(t)) % int64(t))
+		}
+		masked[pos] = (v + keyStream[p.SlotBlock(pos)]) % t
+	}
+	return masked, nil
+}
+
+// PositionsToCoefficients returns the N coefficients that hold the FVSlots values indexed by position
+func (p Parameters) PositionsToCoefficients(values []uint64) ([]uint64, error) {
+	if len(values) != p.FVSlots() {
+		return nil, fmt.Errorf("cannot PositionsToCoefficients: %d values expected", p.FVSlots())
+	}
+	coeffs := make([]uint64, p.N())
+	for pos, v := range values {
+		coeffs[p.Coefficient(pos)] = v
+	}
+	return coeffs, nil
+}
+
+// EncodeCoefficients encodes coeffs in the coefficients of a plaintext at level 0 with the canonical scale
+func (p Parameters) EncodeCoefficients(encoder *bgv.Encoder, coeffs []uint64) (pt *rlwe.Plaintext, err error) {
+	pt = p.NewPlaintext(0)
+	pt.IsBatched = false
+	if err = encoder.Encode(coeffs, pt); err != nil {
+		return nil, fmt.Errorf("cannot EncodeCoefficients: %w", err)
+	}
+	return pt, nil
+}
+
+// ToCKKS removes the key stream ksCt, the output of the slots to coefficients transform
+// at level 0, from the masked plaintext pt and returns the CKKS ciphertext of the data
+// read by the HalfBoot
+func (p Parameters) ToCKKS(eval *bgv.Evaluator, pt *rlwe.Plaintext, ksCt *rlwe.Ciphertext) (ct *rlwe.Ciphertext, err error) {
+	if ksCt.Level() != 0 || pt.Level() != 0 {
+		return nil, fmt.Errorf("cannot ToCKKS: the key stream and the plaintext must be at level 0")
+	}
+	if ksCt.Scale.Cmp(p.CanonicalScale(0)) != 0 || pt.Scale.Cmp(p.CanonicalScale(0)) != 0 {
+		return nil, fmt.Errorf("cannot ToCKKS: the key stream and the plaintext must have the canonical scale")
+	}
+	if ct, err = eval.SubNew(ksCt, pt); err != nil {
+		return nil, fmt.Errorf("cannot ToCKKS: %w", err)
+	}
+	ringQ := p.bgvParams.RingQ().AtLevel(0)
+	for i := range ct.Value {
+		ringQ.Neg(ct.Value[i], ct.Value[i])
+	}
+
+	ct.IsBatched = true
+	ct.LogDimensions = ring.Dimensions{Rows: 0, Cols: p.LogSlots}
+	ct.Scale = rlwe.NewScale(float64(p.ckksParams.Q()[0]) / p.MessageRatio())
+	return ct, nil
+}
@@ -0,0 +1,85 @@
+package rtf
+
+import (
+	"HHESoK"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// KeyEpoch is the epoch of a symmetric key registered under the HE key, the data masked under
+// the nonces of index in [First, Last) are transciphered with the key of ID
+type KeyEpoch struct {
+	ID    uint64
+	First uint64
+	Last  uint64
+}
+
+// KeyEpochs are the epochs of the successive symmetric keys of a client, ordered by their
+// first nonce. A new key ends the epoch of the previous one, whose nonces below the rotation
+// keep their key, so that the data of the epochs can be transciphered in any order
+type KeyEpochs []KeyEpoch
+
+// NonceIndex returns the index of a nonce of NonceSize bytes, its big endian value
+func NonceIndex(nonce []byte) (uint64, error) {
+	if len(nonce) != HHESoK.NonceSize {
+		return 0, fmt.Errorf("%w: nonce of %d bytes, want %d", HHESoK.ErrKeyEpoch, len(nonce), HHESoK.NonceSize)
+	}
+	return binary.BigEndian.Uint64(nonce), nil
+}
+
+// Rotate returns the epochs with the key of id used from the nonce of index first on, the
+// first key may start at any nonce, a new key must start after the first nonce of the
+// current one and have a new ID
+func (e KeyEpochs) Rotate(id, first uint64) (KeyEpochs, error) {
+	for _, epoch := range e {
+		if epoch.ID == id {
+			return nil, fmt.Errorf("cannot Rotate: %w: key %d already registered", HHESoK.ErrKeyEpoch, id)
+		}
+	}
+	rotated := append(KeyEpochs(nil), e...)
+	if n := len(rotated); n > 0 {
+		if first <= rotated[n-1].First {
+			return nil, fmt.Errorf("cannot Rotate: %w: key %d starts at nonce %d, not after the current key", HHESoK.ErrKeyEpoch, id, first)
+		}
+		rotated[n-1].Last = first
+	}
+	return append(rotated, KeyEpoch{ID: id, First: first, Last: math.MaxUint64}), nil
+}
+
+// Retire returns the epochs without the nonces below the index below and the number of
+// epochs dropped, the first ones of e: the epochs that end before below are dropped and the
+// epoch that holds below starts at it. The epoch of the last registered key is never dropped
+func (e KeyEpochs) Retire(below uint64) (KeyEpochs, int) {
+	dropped := 0
+	for dropped < len(e)-1 && e[dropped].Last <= below {
+		dropped++
+	}
+	retired := append(KeyEpochs(nil), e[dropped:]...)
+	if len(retired) > 0 && retired[0].First < below {
+		retired[0].First = below
+	}
+	return retired, dropped
+}
+
+// Current returns the epoch of the last registered key
+func (e KeyEpochs) Current() (KeyEpoch, error) {
+	if len(e) == 0 {
+		return KeyEpoch{}, fmt.Errorf("%w: no key registered", HHESoK.ErrKeyEpoch)
+	}
+	return e[len(e)-1], nil
+}
+
+// Select returns the position in e of the epoch of nonce
+func (e KeyEpochs) Select(nonce []byte) (int, error) {
+	index, err := NonceIndex(nonce)
+	if err != nil {
+		return 0, err
+	}
+	for i, epoch := range e {
+		if index >= epoch.First && index < epoch.Last {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: no key for nonce %d", HHESoK.ErrKeyEpoch, index)
+}
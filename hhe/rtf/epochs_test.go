@@ -0,0 +1,69 @@
+package rtf
+
+import (
+	"HHESoK"
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestKeyEpochs rotates three keys and checks the epoch selected for the nonces around the
+// rotations, that the invalid rotations leave the epochs unchanged and that Retire drops the
+// epochs below a nonce but the current one
+func TestKeyEpochs(t *testing.T) {
+	var epochs KeyEpochs
+	if _, err := epochs.Select(HHESoK.CounterBytes(0)); !errors.Is(err, HHESoK.ErrKeyEpoch) {
+		t.Fatalf("Select without key: got %v, want %v", err, HHESoK.ErrKeyEpoch)
+	}
+	var err error
+	for i, first := range []uint64{10, 20, 30} {
+		if epochs, err = epochs.Rotate(uint64(i), first); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := KeyEpochs{{ID: 0, First: 10, Last: 20}, {ID: 1, First: 20, Last: 30}, {ID: 2, First: 30, Last: math.MaxUint64}}
+	for i := range want {
+		if epochs[i] != want[i] {
+			t.Fatalf("epoch %d: got %+v, want %+v", i, epochs[i], want[i])
+		}
+	}
+
+	for index, epoch := range map[uint64]int{10: 0, 19: 0, 20: 1, 29: 1, 30: 2, math.MaxUint64 - 1: 2} {
+		got, err := epochs.Select(HHESoK.CounterBytes(index))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != epoch {
+			t.Fatalf("nonce %d: epoch %d, want %d", index, got, epoch)
+		}
+	}
+	for _, nonce := range [][]byte{HHESoK.CounterBytes(9), HHESoK.CounterBytes(math.MaxUint64), make([]byte, 4)} {
+		if _, err = epochs.Select(nonce); !errors.Is(err, HHESoK.ErrKeyEpoch) {
+			t.Fatalf("Select of nonce %v: got %v, want %v", nonce, err, HHESoK.ErrKeyEpoch)
+		}
+	}
+
+	if _, err = epochs.Rotate(1, 40); !errors.Is(err, HHESoK.ErrKeyEpoch) {
+		t.Fatalf("Rotate to a registered key: got %v, want %v", err, HHESoK.ErrKeyEpoch)
+	}
+	if _, err = epochs.Rotate(3, 30); !errors.Is(err, HHESoK.ErrKeyEpoch) {
+		t.Fatalf("Rotate at the current first nonce: got %v, want %v", err, HHESoK.ErrKeyEpoch)
+	}
+	if current, _ := epochs.Current(); current != want[2] {
+		t.Fatalf("current epoch %+v after the invalid rotations, want %+v", current, want[2])
+	}
+
+	retired, dropped := epochs.Retire(25)
+	if dropped != 1 || len(retired) != 2 || retired[0] != (KeyEpoch{ID: 1, First: 25, Last: 30}) || retired[1] != want[2] {
+		t.Fatalf("Retire(25): %d dropped, epochs %+v", dropped, retired)
+	}
+	if _, err = retired.Select(HHESoK.CounterBytes(24)); !errors.Is(err, HHESoK.ErrKeyEpoch) {
+		t.Fatalf("Select of a retired nonce: got %v, want %v", err, HHESoK.ErrKeyEpoch)
+	}
+	if epochs[0] != want[0] {
+		t.Fatal("Retire modified the receiver")
+	}
+	if retired, dropped = epochs.Retire(math.MaxUint64); dropped != 2 || len(retired) != 1 || retired[0].ID != 2 {
+		t.Fatalf("Retire(MaxUint64): %d dropped, epochs %+v, want the current epoch kept", dropped, retired)
+	}
+}
@@ -0,0 +1,197 @@
+package rtf
+
+import (
+	"HHESoK"
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v6/circuits/ckks/dft"
+	"github.com/tuneinsight/lattigo/v6/circuits/ckks/mod1"
+	"github.com/tuneinsight/lattigo/v6/circuits/ckks/polynomial"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/ring"
+	"github.com/tuneinsight/lattigo/v6/schemes/ckks"
+)
+
+// HalfBootstrapper evaluates the HalfBoot of RtF, the first half of the CKKS bootstrapping:
+// ModUp, CoeffsToSlots and EvalMod, on a ciphertext at level 0 whose coefficients hold
+// the data scaled by Q[0]/MessageRatio. The slots to coefficients of the bootstrapping is
+// done beforehand on the BFV side, so that the output data lies in the slots
+type HalfBootstrapper struct {
+	params     Parameters
+	eval       *ckks.Evaluator
+	dftEval    *dft.Evaluator
+	mod1Eval   *mod1.Evaluator
+	ctsMatrix  dft.Matrix
+	mod1Params mod1.Parameters
+}
+
+// NewHalfBootstrapper encodes the CoeffsToSlots matrices and the EvalMod polynomials,
+// evk must hold the relinearization key and the keys of HalfBootGaloisElements
+func NewHalfBootstrapper(params Parameters, evk rlwe.EvaluationKeySet) (hbtp *HalfBootstrapper, err error) {
+	if evk == nil {
+		return nil, fmt.Errorf("cannot NewHalfBootstrapper: %w: no evaluation keys", HHESoK.ErrMissingGaloisKey)
+	}
+	for _, galEl := range params.HalfBootGaloisElements() {
+		if _, err = evk.GetGaloisKey(galEl); err != nil {
+			return nil, fmt.Errorf("cannot NewHalfBootstrapper: %w: Galois element %d", HHESoK.ErrMissingGaloisKey, galEl)
+		}
+	}
+	ckksParams := params.CKKS()
+	hbtp = &HalfBootstrapper{params: params}
+
+	if hbtp.mod1Params, err = mod1.NewParametersFromLiteral(ckksParams, params.mod1Params); err != nil {
+		return nil, fmt.Errorf("cannot NewHalfBootstrapper: %w", err)
+	}
+
+	ctsLiteral := params.ctsParams
+	ctsLiteral.Scaling = params.ctsScaling(hbtp.mod1Params)
+	encoder := ckks.NewEncoder(ckksParams)
+	if hbtp.ctsMatrix, err = dft.NewMatrixFromLiteral(ckksParams, ctsLiteral, encoder); err != nil {
+		return nil, fmt.Errorf("cannot NewHalfBootstrapper: %w", err)
+	}
+
+	hbtp.eval = ckks.NewEvaluator(ckksParams, evk)
+	hbtp.dftEval = dft.NewEvaluator(ckksParams, hbtp.eval)
+	hbtp.mod1Eval = mod1.NewEvaluator(hbtp.eval, polynomial.NewEvaluator(ckksParams, hbtp.eval), hbtp.mod1Params)
+	return hbtp, nil
+}
+
+// HalfBootGaloisElements returns the Galois elements of the trace, the CoeffsToSlots and the conjugation
+func (p Parameters) HalfBootGaloisElements() []uint64 {
+	ckksParams := p.CKKS()
+	galEls := ckksParams.GaloisElementsForTrace(p.LogSlots)
+	galEls = append(galEls, p.ctsParams.GaloisElements(ckksParams)...)
+	return append(galEls, ckksParams.GaloisElementOrderTwoOrthogonalSubgroup())
+}
+
+// HalfBoot returns the data of the coefficients of ct in the slots of ctReal and ctImag at the
+// residual level and the default scale. With full coefficients, ctReal holds the first N/2
+// coefficients and ctImag the last N/2, otherwise ctImag is nil and the 2*Slots coefficients
+// are all in ctReal. ct is modified
+func (hbtp *HalfBootstrapper) HalfBoot(ct *rlwe.Ciphertext) (ctReal, ctImag *rlwe.Ciphertext, err error) {
+	if ct.Level() != 0 {
+		return nil, nil, fmt.Errorf("cannot HalfBoot: input must be at level 0")
+	}
+
+	if err = hbtp.modUp(ct); err != nil {
+		return nil, nil, fmt.Errorf("cannot HalfBoot: %w", err)
+	}
+
+	if ctReal, ctImag, err = hbtp.dftEval.CoeffsToSlotsNew(ct, hbtp.ctsMatrix); err != nil {
+		return nil, nil, fmt.Errorf("cannot HalfBoot: %w", err)
+	}
+
+	if ctReal, err = hbtp.evalMod(ctReal); err != nil {
+		return nil, nil, fmt.Errorf("cannot HalfBoot: %w", err)
+	}
+	if ctImag != nil {
+		if ctImag, err = hbtp.evalMod(ctImag); err != nil {
+			return nil, nil, fmt.Errorf("cannot HalfBoot: %w", err)
+		}
+	}
+	return
+}
+
+// HalfBootVector returns the HalfBoot output of ct as a Vector, ct is modified
+func (hbtp *HalfBootstrapper) HalfBootVector(ct *rlwe.Ciphertext) (*Vector, error) {
+	ctReal, ctImag, err := hbtp.HalfBoot(ct)
+	if err != nil {
+		return nil, err
+	}
+	return hbtp.params.NewVector(ctReal, ctImag)
+}
+
+// DecodeHalfBoot decrypts the output of HalfBoot and returns the data indexed by position,
+// the real parts of the slots of ctReal then ctImag. The sparse output holds the 2*Slots
+// positions in the real and imaginary parts of ctReal
+func (p Parameters) DecodeHalfBoot(encoder *ckks.Encoder, decryptor *rlwe.Decryptor, ctReal, ctImag *rlwe.Ciphertext) (values []float64, err error) {
+	for _, ct := range []*rlwe.Ciphertext{ctReal, ctImag} {
+		if ct == nil {
+			continue
+		}
+		pt := decryptor.DecryptNew(ct)
+		if ctImag == nil {
+			pt.LogDimensions.Cols++
+		}
+		slots := make([]complex128, 1<<pt.LogDimensions.Cols)
+		if err = encoder.Decode(pt, slots); err != nil {
+			return nil, fmt.Errorf("cannot DecodeHalfBoot: %w", err)
+		}
+		for _, v := range slots {
+			values = append(values, real(v))
+		}
+	}
+	return values, nil
+}
+
+// evalMod reduces ct modulo Q[0] and consumes the DiffScale modulus to reach the default scale
+func (hbtp *HalfBootstrapper) evalMod(ct *rlwe.Ciphertext) (ctOut *rlwe.Ciphertext, err error) {
+	if ctOut, err = hbtp.mod1Eval.EvaluateNew(ct); err != nil {
+		return nil, err
+	}
+	if err = hbtp.eval.SetScale(ctOut, hbtp.params.CKKS().DefaultScale()); err != nil {
+		return nil, err
+	}
+	return ctOut, nil
+}
+
+// modUp raises the modulus from Q[0] to Q, scales the message to the EvalMod scaling factor
+// and applies the trace if the coefficients are sparse
+func (hbtp *HalfBootstrapper) modUp(ct *rlwe.Ciphertext) (err error) {
+	ckksParams := hbtp.params.CKKS()
+
+	ringQ := ckksParams.RingQ().AtLevel(0)
+	for i := range ct.Value {
+		ringQ.INTT(ct.Value[i], ct.Value[i])
+	}
+
+	// Extend the ciphertext from q to Q with zero values.
+	ct.Resize(ct.Degree(), ckksParams.MaxLevel())
+
+	levelQ := ckksParams.MaxLevel()
+	ringQ = ckksParams.RingQ().AtLevel(levelQ)
+
+	Q := ringQ.ModuliChain()
+	q := Q[0]
+	BRCQ := ringQ.BRedConstants()
+
+	var coeff, tmp, pos, neg uint64
+
+	N := ringQ.N()
+
+	// ModUp q->Q for ct centered around q
+	for k := range ct.Value {
+		for j := 0; j < N; j++ {
+
+			coeff = ct.Value[k].Coeffs[0][j]
+			pos, neg = 1, 0
+			if coeff >= (q >> 1) {
+				coeff = q - coeff
+				pos, neg = 0, 1
+			}
+
+			for i := 1; i < levelQ+1; i++ {
+				tmp = ring.BRedAdd(coeff, Q[i], BRCQ[i])
+				ct.Value[k].Coeffs[i][j] = tmp*pos + (Q[i]-tmp)*neg
+			}
+		}
+	}
+
+	ringQ.NTT(ct.Value[0], ct.Value[0])
+	ringQ.NTT(ct.Value[1], ct.Value[1])
+
+	// Scale the message from Q0/|m| to QL/|m|, where QL is the largest modulus used during the bootstrapping.
+	if scale := (hbtp.mod1Params.ScalingFactor().Float64() / hbtp.mod1Params.MessageRatio()) / ct.Scale.Float64(); scale > 1 {
+
+		scalar := uint64(math.Round(scale))
+
+		ringQ.MulScalar(ct.Value[0], scalar, ct.Value[0])
+		ringQ.MulScalar(ct.Value[1], scalar, ct.Value[1])
+
+		ct.Scale = ct.Scale.Mul(rlwe.NewScale(scale))
+	}
+
+	//SubSum X -> (N/dslots) * Y^dslots
+	return hbtp.eval.Trace(ct, hbtp.params.LogSlots, ct)
+}
@@ -0,0 +1,73 @@
+package rtf
+
+import (
+	"HHESoK/hhe/wire"
+
+	"github.com/tuneinsight/lattigo/v6/circuits/ckks/mod1"
+)
+
+// MarshalBinary encodes the fields of lit in their declaration order
+func (lit ParametersLiteral) MarshalBinary() ([]byte, error) {
+	var enc wire.Encoder
+	enc.PutInt(lit.LogN)
+	enc.PutInt(lit.LogSlots)
+	enc.PutInt(lit.LogDefaultScale)
+	enc.PutUint64(lit.PlainModulus)
+	enc.PutInt(lit.H)
+	enc.PutUint64s(lit.ResidualModuli)
+	enc.PutUint64s(lit.KeySwitchModuli)
+	enc.PutUint64(lit.DiffScaleModulus)
+	enc.PutUint64s(lit.EvalModModuli)
+	enc.PutInt(lit.EvalModLogScale)
+	enc.PutUint64s(lit.CoeffsToSlotsModuli)
+	enc.PutInts(lit.CoeffsToSlotsLevels)
+	enc.PutUint64(uint64(lit.Mod1Type))
+	enc.PutInt(lit.LogMessageRatio)
+	enc.PutInt(lit.K)
+	enc.PutInt(lit.Mod1Degree)
+	enc.PutInt(lit.DoubleAngle)
+	enc.PutInt(lit.Mod1InvDegree)
+	enc.PutInt(lit.LogBSGSRatio)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes data written by MarshalBinary into lit
+func (lit *ParametersLiteral) UnmarshalBinary(data []byte) error {
+	dec := wire.NewDecoder(data)
+	lit.LogN = dec.Int()
+	lit.LogSlots = dec.Int()
+	lit.LogDefaultScale = dec.Int()
+	lit.PlainModulus = dec.Uint64()
+	lit.H = dec.Int()
+	lit.ResidualModuli = dec.Uint64s()
+	lit.KeySwitchModuli = dec.Uint64s()
+	lit.DiffScaleModulus = dec.Uint64()
+	lit.EvalModModuli = dec.Uint64s()
+	lit.EvalModLogScale = dec.Int()
+	lit.CoeffsToSlotsModuli = dec.Uint64s()
+	lit.CoeffsToSlotsLevels = dec.Ints()
+	lit.Mod1Type = mod1.Type(dec.Uint64())
+	lit.LogMessageRatio = dec.Int()
+	lit.K = dec.Int()
+	lit.Mod1Degree = dec.Int()
+	lit.DoubleAngle = dec.Int()
+	lit.Mod1InvDegree = dec.Int()
+	lit.LogBSGSRatio = dec.Int()
+	return dec.Finish()
+}
+
+// MarshalBinary encodes the cipher then the slots to coefficients mod-downs
+func (md ModDown) MarshalBinary() ([]byte, error) {
+	var enc wire.Encoder
+	enc.PutInts(md.CipherModDown)
+	enc.PutInts(md.StCModDown)
+	return enc.Bytes()
+}
+
+// UnmarshalBinary decodes data written by MarshalBinary into md
+func (md *ModDown) UnmarshalBinary(data []byte) error {
+	dec := wire.NewDecoder(data)
+	md.CipherModDown = dec.Ints()
+	md.StCModDown = dec.Ints()
+	return dec.Finish()
+}
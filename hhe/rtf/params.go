@@ -0,0 +1,376 @@
+package rtf
+
+import (
+	"HHESoK"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/tuneinsight/lattigo/v6/circuits/ckks/dft"
+	"github.com/tuneinsight/lattigo/v6/circuits/ckks/mod1"
+	"github.com/tuneinsight/lattigo/v6/ring"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+	"github.com/tuneinsight/lattigo/v6/schemes/ckks"
+)
+
+// ParametersLiteral is the RtF parameter set on lattigo v6, the BFV evaluation of the
+// symmetric cipher, the slots to coefficients transform and the HalfBoot share one
+// modulus chain, from the bottom:
+// ResidualModuli | DiffScaleModulus | EvalModModuli | CoeffsToSlotsModuli
+type ParametersLiteral struct {
+	LogN            int
+	LogSlots        int // CKKS slots of the HalfBoot output, LogN-1 uses the full coefficients
+	LogDefaultScale int
+	PlainModulus    uint64
+	H               int // Hamming weight of the secret key
+
+	ResidualModuli      []uint64 // ResidualModuli[0] receives the CKKS message
+	KeySwitchModuli     []uint64
+	DiffScaleModulus    uint64 // consumed to set the scale after EvalMod
+	EvalModModuli       []uint64
+	EvalModLogScale     int
+	CoeffsToSlotsModuli []uint64
+	CoeffsToSlotsLevels []int // number of merged DFT factors per CoeffsToSlots modulus
+
+	Mod1Type        mod1.Type
+	LogMessageRatio int // log2(Q[0]/|m|)
+	K               int // EvalMod interval [-K, K]
+	Mod1Degree      int
+	DoubleAngle     int
+	Mod1InvDegree   int // degree of the arcsine, zero if not used
+	LogBSGSRatio    int // log2 of the baby-step giant-step ratio of the linear transforms
+}
+
+// Parameters is an instantiated ParametersLiteral, the BGV and CKKS parameters share
+// the ring and the secret key distribution, a ciphertext of the first is read as a
+// ciphertext of the second at level 0
+type Parameters struct {
+	ParametersLiteral
+	bgvParams  bgv.Parameters
+	ckksParams ckks.Parameters
+	mod1Params mod1.ParametersLiteral
+	ctsParams  dft.MatrixLiteral
+}
+
+// NewParametersFromLiteral instantiates the BGV and CKKS parameters of lit
+func NewParametersFromLiteral(lit ParametersLiteral) (p Parameters, err error) {
+	if lit.LogSlots >= lit.LogN || lit.LogSlots < 1 {
+		return Parameters{}, fmt.Errorf("%w: RtF LogSlots=%d must be in [1, LogN-1]", HHESoK.ErrInvalidParameters, lit.LogSlots)
+	}
+	if len(lit.CoeffsToSlotsLevels) != len(lit.CoeffsToSlotsModuli) {
+		return Parameters{}, fmt.Errorf("%w: RtF one CoeffsToSlots level per modulus expected", HHESoK.ErrInvalidParameters)
+	}
+	// the slots to coefficients transform needs the 2*FVSlots-th roots of unity of Z_t
+	if m := uint64(1) << (lit.LogSlots + 2); lit.PlainModulus < 2 || (lit.PlainModulus-1)%m != 0 {
+		return Parameters{}, fmt.Errorf("%w: RtF plaintext modulus %d is not 1 mod %d", HHESoK.ErrInvalidParameters, lit.PlainModulus, m)
+	}
+
+	qi := append([]uint64{}, lit.ResidualModuli...)
+	qi = append(qi, lit.DiffScaleModulus)
+	qi = append(qi, lit.EvalModModuli...)
+	qi = append(qi, lit.CoeffsToSlotsModuli...)
+	xs := ring.Ternary{H: lit.H}
+
+	p.ParametersLiteral = lit
+	if p.bgvParams, err = bgv.NewParametersFromLiteral(bgv.ParametersLiteral{
+		LogN:             lit.LogN,
+		Q:                qi,
+		P:                lit.KeySwitchModuli,
+		Xs:               xs,
+		PlaintextModulus: lit.PlainModulus,
+	}); err != nil {
+		return Parameters{}, fmt.Errorf("%w: %w", HHESoK.ErrInvalidParameters, err)
+	}
+	if p.ckksParams, err = ckks.NewParametersFromLiteral(ckks.ParametersLiteral{
+		LogN:            lit.LogN,
+		Q:               qi,
+		P:               lit.KeySwitchModuli,
+		Xs:              xs,
+		LogDefaultScale: lit.LogDefaultScale,
+	}); err != nil {
+		return Parameters{}, fmt.Errorf("%w: %w", HHESoK.ErrInvalidParameters, err)
+	}
+
+	maxLevel := len(qi) - 1
+	p.mod1Params = mod1.ParametersLiteral{
+		LevelQ:          maxLevel - len(lit.CoeffsToSlotsModuli),
+		LogScale:        lit.EvalModLogScale,
+		Mod1Type:        lit.Mod1Type,
+		LogMessageRatio: lit.LogMessageRatio,
+		K:               lit.K,
+		Mod1Degree:      lit.Mod1Degree,
+		DoubleAngle:     lit.DoubleAngle,
+		Mod1InvDegree:   lit.Mod1InvDegree,
+	}
+	if depth := p.mod1Params.Depth(); depth != len(lit.EvalModModuli) {
+		return Parameters{}, fmt.Errorf("%w: RtF EvalMod depth %d but %d moduli", HHESoK.ErrInvalidParameters, depth, len(lit.EvalModModuli))
+	}
+	p.ctsParams = dft.MatrixLiteral{
+		Type:         dft.HomomorphicEncode,
+		Format:       dft.RepackImagAsReal,
+		LogSlots:     lit.LogSlots,
+		LevelQ:       maxLevel,
+		LevelP:       len(lit.KeySwitchModuli) - 1,
+		Levels:       lit.CoeffsToSlotsLevels,
+		LogBSGSRatio: lit.LogBSGSRatio,
+	}
+	return p, nil
+}
+
+// BGV returns the parameters of the homomorphic evaluation of the symmetric cipher
+func (p Parameters) BGV() bgv.Parameters {
+	return p.bgvParams
+}
+
+// CKKS returns the parameters of the HalfBoot
+func (p Parameters) CKKS() ckks.Parameters {
+	return p.ckksParams
+}
+
+// N returns the ring degree
+func (p Parameters) N() int {
+	return 1 << p.LogN
+}
+
+// LogFVSlots returns the log2 of the number of BFV slots, one symmetric block per slot,
+// the slots to coefficients transform fills the 2*Slots coefficients read by the HalfBoot
+func (p Parameters) LogFVSlots() int {
+	return p.LogSlots + 1
+}
+
+// FVSlots returns the number of blocks transciphered at once
+func (p Parameters) FVSlots() int {
+	return 1 << p.LogFVSlots()
+}
+
+// FullCoefficients reports whether all the coefficients of the ring hold data,
+// the HalfBoot then returns two ciphertexts
+func (p Parameters) FullCoefficients() bool {
+	return p.LogSlots == p.LogN-1
+}
+
+// MessageRatio returns Q[0]/|m|
+func (p Parameters) MessageRatio() float64 {
+	return math.Exp2(float64(p.LogMessageRatio))
+}
+
+// MessageScaling returns the scaling of the CKKS data in Z_t, t/MessageRatio
+func (p Parameters) MessageScaling() float64 {
+	return float64(p.PlainModulus) / p.MessageRatio()
+}
+
+// MaxLevel returns the level of fresh ciphertexts
+func (p Parameters) MaxLevel() int {
+	return p.bgvParams.MaxLevel()
+}
+
+// ResidualLevel returns the level of the HalfBoot output
+func (p Parameters) ResidualLevel() int {
+	return len(p.ResidualModuli) - 1
+}
+
+// ctsScaling returns the scaling of the CoeffsToSlots matrices, the change of variable
+// of EvalMod and the correction of the division by Q[0]
+func (p Parameters) ctsScaling(mod1Params mod1.Parameters) *big.Float {
+	qDiv := mod1Params.ScalingFactor().Float64() / math.Exp2(math.Round(math.Log2(float64(p.ckksParams.Q()[0]))))
+	if qDiv > 1 {
+		qDiv = 1
+	}
+	return new(big.Float).SetFloat64(qDiv / (mod1Params.K * mod1Params.QDiff))
+}
+
+var (
+	rtfQ0 = uint64(0x10000000006e0001) // 60
+
+	// 40-bit residual chain of the HERA parameters
+	heraResidualModuli = []uint64{
+		rtfQ0,
+		0x10000140001, 0xffffe80001, 0xffffc40001, 0x100003e0001, 0xffffb20001, 0x10000500001,
+		0xffff940001, 0xffff8a0001, 0xffff820001, 0xffff780001, 0x10000960001,
+	}
+
+	// 45-bit residual chain of the parameters with arcsine evaluation
+	arcSineResidualModuli = []uint64{
+		rtfQ0,
+		0x2000000a0001, 0x2000000e0001, 0x1fffffc20001, 0x200000440001, 0x200000500001,
+		0x200000620001, 0x1fffff980001,
+	}
+
+	keySwitchModuli = []uint64{
+		0x1fffffffffe00001, 0x1fffffffffc80001, 0x1fffffffffb40001, 0x1fffffffff500001, 0x1fffffffff420001,
+	}
+
+	// double angle then sine
+	sineModuli = []uint64{
+		0xfffffffff840001, 0x1000000000860001,
+		0xfffffffff6a0001, 0x1000000000980001, 0xfffffffff5a0001, 0x1000000000b00001, 0x1000000000ce0001, 0xfffffffff2a0001,
+	}
+
+	// arcsine, double angle then sine
+	arcSineModuli = append([]uint64{0xffffffffffc0001, 0xfffffffff240001, 0x1000000000f00001}, sineModuli...)
+
+	heraCtSModuli    = []uint64{0x100000000060001, 0xfffffffff00001, 0xffffffffd80001, 0x1000000002a0001}
+	arcSineCtSModuli = []uint64{0x400000000360001, 0x3ffffffffbe0001, 0x400000000660001, 0x4000000008a0001}
+)
+
+// heraLiteral is the RtF parameter set of HERA with LogSlots CKKS slots
+func heraLiteral(logSlots int) ParametersLiteral {
+	return ParametersLiteral{
+		LogN:                16,
+		LogSlots:            logSlots,
+		LogDefaultScale:     40,
+		PlainModulus:        268042241, // 28-bit
+		H:                   192,
+		ResidualModuli:      heraResidualModuli,
+		KeySwitchModuli:     keySwitchModuli,
+		DiffScaleModulus:    0xfc0001, // 24
+		EvalModModuli:       sineModuli,
+		EvalModLogScale:     60,
+		CoeffsToSlotsModuli: heraCtSModuli,
+		CoeffsToSlotsLevels: []int{1, 1, 1, 1},
+		Mod1Type:            mod1.CosDiscrete,
+		LogMessageRatio:     9,
+		K:                   25,
+		Mod1Degree:          63,
+		DoubleAngle:         2,
+		Mod1InvDegree:       0,
+		LogBSGSRatio:        4,
+	}
+}
+
+// arcSineLiteral is the RtF parameter set with arcsine evaluation with LogSlots CKKS slots
+func arcSineLiteral(logSlots int) ParametersLiteral {
+	return ParametersLiteral{
+		LogN:                16,
+		LogSlots:            logSlots,
+		LogDefaultScale:     45,
+		PlainModulus:        33292289, // 25-bit
+		H:                   192,
+		ResidualModuli:      arcSineResidualModuli,
+		KeySwitchModuli:     keySwitchModuli[:4],
+		DiffScaleModulus:    0x2a0001, // 22
+		EvalModModuli:       arcSineModuli,
+		EvalModLogScale:     60,
+		CoeffsToSlotsModuli: arcSineCtSModuli,
+		CoeffsToSlotsLevels: []int{1, 1, 1, 1},
+		Mod1Type:            mod1.CosDiscrete,
+		LogMessageRatio:     4,
+		K:                   25,
+		Mod1Degree:          63,
+		DoubleAngle:         2,
+		Mod1InvDegree:       7,
+		LogBSGSRatio:        4,
+	}
+}
+
+// HeraParams are the RtF parameter sets of HERA in the order of the ckks_fv RtFHeraParams:
+// 128f, 128s, 128af and 128as, the sparse sets transcipher 32 blocks instead of 16
+var HeraParams = []ParametersLiteral{
+	heraLiteral(15),
+	heraLiteral(4),
+	arcSineLiteral(15),
+	arcSineLiteral(4),
+}
+
+// RubatoParams are the RtF parameter sets of Rubato in the order of the ckks_fv RtFRubatoParams: 128af
+var RubatoParams = []ParametersLiteral{
+	arcSineLiteral(15),
+}
+
+// TestParams returns a copy of lit on a ring of degree 2^logN with the same modulus chain,
+// the sets are NOT secure and only meant to run the pipelines in tests
+func TestParams(lit ParametersLiteral, logN int) ParametersLiteral {
+	lit.LogN = logN
+	if lit.LogSlots > logN-1 {
+		lit.LogSlots = logN - 1
+	}
+	return lit
+}
+
+// ModDown are the moduli dropped by the homomorphic evaluation of the symmetric cipher,
+// CipherModDown[0] before the first round and CipherModDown[r] after the non-linear layer
+// of round r, and by the slots to coefficients transform, StCModDown[i] before its i-th factor
+type ModDown struct {
+	CipherModDown []int
+	StCModDown    []int
+}
+
+// HeraModDown80 are the mod-down indices of the ckks_fv HeraModDownParams80 for the HeraParams,
+// they were tuned with the ckks_fv noise growth and TestHeraProduction checks them on lattigo v6,
+// the sparse sets use radix 0
+var HeraModDown80 = []ModDown{
+	{
+		// with RtF param 128f and radix 2
+		CipherModDown: []int{6, 2, 2, 2, 3},
+		StCModDown:    []int{1, 0, 1, 1, 1, 1, 1, 1},
+	},
+	{
+		// with RtF param 128s and radix 0
+		CipherModDown: []int{10, 2, 3, 3, 3},
+		StCModDown:    []int{0},
+	},
+	{
+		// with RtF param 128af and radix 2
+		CipherModDown: []int{7, 2, 2, 2, 2},
+		StCModDown:    []int{1, 1, 0, 1, 1, 1, 0, 0},
+	},
+	{
+		// with RtF param 128as and radix 0
+		CipherModDown: []int{11, 2, 2, 3, 2},
+		StCModDown:    []int{0},
+	},
+}
+
+// HeraModDown128 are the mod-down indices of the ckks_fv HeraModDownParams128 for the HeraParams
+var HeraModDown128 = []ModDown{
+	{
+		// with RtF param 128f and radix 2
+		CipherModDown: []int{4, 2, 2, 2, 2, 3},
+		StCModDown:    []int{0, 1, 1, 1, 1, 1, 1, 1},
+	},
+	{
+		// with RtF param 128s and radix 0
+		CipherModDown: []int{9, 2, 3, 3, 3, 2},
+		StCModDown:    []int{1},
+	},
+	{
+		// with RtF param 128af and radix 2
+		CipherModDown: []int{5, 2, 2, 2, 2, 2},
+		StCModDown:    []int{1, 1, 0, 1, 1, 1, 0, 0},
+	},
+	{
+		// with RtF param 128as and radix 2
+		CipherModDown: []int{9, 2, 2, 2, 3, 2},
+		StCModDown:    []int{0, 1},
+	},
+}
+
+// RubatoModDown are the mod-down indices of the ckks_fv RubatoModDownParams with RtF param 128af
+// and radix 2, indexed like the Rubato parameter sets 80S, 80M, 80L, 128S, 128M and 128L,
+// TestRubatoProduction checks them on lattigo v6
+var RubatoModDown = []ModDown{
+	{
+		CipherModDown: []int{12, 0, 1},
+		StCModDown:    []int{1, 0, 2, 0, 1, 1, 1, 1},
+	},
+	{
+		CipherModDown: []int{13, 0, 1},
+		StCModDown:    []int{2, 0, 1, 1, 0, 1, 1, 1},
+	},
+	{
+		CipherModDown: []int{13, 0, 1},
+		StCModDown:    []int{2, 0, 1, 1, 0, 1, 1, 1},
+	},
+	{
+		CipherModDown: []int{10, 0, 1, 1, 1, 1},
+		StCModDown:    []int{1, 1, 1, 0, 1, 1, 1, 0},
+	},
+	{
+		CipherModDown: []int{12, 0, 1, 1},
+		StCModDown:    []int{2, 0, 1, 1, 0, 1, 1, 1},
+	},
+	{
+		CipherModDown: []int{13, 0, 1},
+		StCModDown:    []int{2, 0, 1, 1, 0, 1, 1, 1},
+	},
+}
@@ -0,0 +1,395 @@
+package rtf
+
+import (
+	"HHESoK"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/circuits/bgv/lintrans"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/ring"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// SlotsToCoeffs moves the BFV slots of a ciphertext to its coefficients, slot i of the
+// FVSlots slots lands on coefficient bitrev(i)*N/FVSlots. It is the decoding matrix of the
+// ckks_fv MFV encoder, factorized into sparse block diagonal matrices, the last two factors
+// act on the ciphertext and its rows rotation and are summed
+type SlotsToCoeffs struct {
+	params   Parameters
+	matrices []lintrans.LinearTransformation
+}
+
+// NewSlotsToCoeffs encodes the decoding matrix factorized with the given radix:
+// radix 0 - decoding matrix is merged into one
+// radix 1 - decoding matrix is factorized with radix 1
+// radix 2 - decoding matrix is factorized with radix 2
+// other values are treated as radix 1
+func NewSlotsToCoeffs(params Parameters, encoder *bgv.Encoder, radix int) (*SlotsToCoeffs, error) {
+	diagonals, err := genDcdMatsRadix(params.LogFVSlots(), params.PlainModulus, radix)
+	if err != nil {
+		return nil, fmt.Errorf("cannot NewSlotsToCoeffs: %w", err)
+	}
+
+	bgvParams := params.BGV()
+	stc := &SlotsToCoeffs{params: params, matrices: make([]lintrans.LinearTransformation, len(diagonals))}
+	for i, diags := range diagonals {
+		embedded := embedDiagonals(diags, params.FVSlots(), bgvParams.N())
+		ltParams := lintrans.Parameters{
+			DiagonalsIndexList:        embedded.DiagonalsIndexList(),
+			LevelQ:                    bgvParams.MaxLevel(),
+			LevelP:                    bgvParams.MaxLevelP(),
+			Scale:                     rlwe.NewScale(1),
+			LogDimensions:             ring.Dimensions{Rows: 1, Cols: bgvParams.LogN() - 1},
+			LogBabyStepGiantStepRatio: params.LogBSGSRatio,
+		}
+		stc.matrices[i] = lintrans.NewLinearTransformation(bgvParams, ltParams)
+		if err := lintrans.Encode(encoder, embedded, stc.matrices[i]); err != nil {
+			return nil, fmt.Errorf("cannot encode the slots to coefficients matrix %d: %w", i, err)
+		}
+	}
+	return stc, nil
+}
+
+// Depth returns the number of mod-down steps of Evaluate, the length of stcModDown
+func (stc *SlotsToCoeffs) Depth() int {
+	return len(stc.matrices) - 1
+}
+
+// SlotsToCoeffsDepth returns the Depth of the slots to coefficients transform with the given radix
+func (p Parameters) SlotsToCoeffsDepth(radix int) int {
+	// factors of the decoding matrix after the first one, the last two are merged with it
+	depth := p.LogFVSlots() - 1
+	switch radix {
+	case 0:
+		return 1
+	case 2:
+		return (depth + 1) / 2
+	default:
+		return depth - 1
+	}
+}
+
+// GaloisElements returns the Galois elements of the matrices and of the rows rotation
+func (stc *SlotsToCoeffs) GaloisElements() []uint64 {
+	bgvParams := stc.params.BGV()
+	galEls := []uint64{bgvParams.GaloisElementOrderTwoOrthogonalSubgroup()}
+	for _, lt := range stc.matrices {
+		galEls = append(galEls, lt.GaloisElements(bgvParams)...)
+	}
+	return galEls
+}
+
+// Evaluate returns a ciphertext whose coefficients are the slots of ct, stcModDown[i] moduli
+// are dropped before the i-th factor
+func (stc *SlotsToCoeffs) Evaluate(eval *bgv.Evaluator, ct *rlwe.Ciphertext, stcModDown []int) (ctOut *rlwe.Ciphertext, err error) {
+	depth := stc.Depth()
+	if len(stcModDown) < depth {
+		return nil, fmt.Errorf("cannot SlotsToCoeffs: %d mod-down steps given but %d expected", len(stcModDown), depth)
+	}
+	ltEval := lintrans.NewEvaluator(eval)
+
+	ctOut = ct.CopyNew()
+	for i := 0; i < depth-1; i++ {
+		if err = ModSwitchMany(eval, ctOut, stcModDown[i]); err != nil {
+			return nil, err
+		}
+		tmp := bgv.NewCiphertext(stc.params.BGV(), 1, ctOut.Level())
+		if err = ltEval.Evaluate(ctOut, stc.matrices[i], tmp); err != nil {
+			return nil, fmt.Errorf("cannot SlotsToCoeffs: %w", err)
+		}
+		ctOut = tmp
+	}
+	if err = ModSwitchMany(eval, ctOut, stcModDown[depth-1]); err != nil {
+		return nil, err
+	}
+
+	level := ctOut.Level()
+	rot, err := eval.RotateRowsNew(ctOut)
+	if err != nil {
+		return nil, fmt.Errorf("cannot SlotsToCoeffs: %w", err)
+	}
+	res := bgv.NewCiphertext(stc.params.BGV(), 1, level)
+	tmp := bgv.NewCiphertext(stc.params.BGV(), 1, level)
+	if err = ltEval.Evaluate(ctOut, stc.matrices[depth-1], res); err != nil {
+		return nil, fmt.Errorf("cannot SlotsToCoeffs: %w", err)
+	}
+	if err = ltEval.Evaluate(rot, stc.matrices[depth], tmp); err != nil {
+		return nil, fmt.Errorf("cannot SlotsToCoeffs: %w", err)
+	}
+	if err = eval.Add(res, tmp, res); err != nil {
+		return nil, fmt.Errorf("cannot SlotsToCoeffs: %w", err)
+	}
+	// the output encodes the data in its coefficients
+	res.IsBatched = false
+	return res, nil
+}
+
+// ModSwitchMany drops nbModSwitch moduli of ct with the plaintext-modulus aware rescaling,
+// the scale of the BFV canonical encoding -Q_l mod t follows the level
+func ModSwitchMany(eval *bgv.Evaluator, ct *rlwe.Ciphertext, nbModSwitch int) error {
+	if nbModSwitch > ct.Level() {
+		return fmt.Errorf("cannot ModSwitchMany: input does not have enough levels")
+	}
+	for i := 0; i < nbModSwitch; i++ {
+		if err := eval.Rescale(ct, ct); err != nil {
+			return fmt.Errorf("cannot ModSwitchMany: %w", err)
+		}
+	}
+	return nil
+}
+
+// embedDiagonals lifts the diagonals of a matrix acting on fvSlots slots to the n slots of
+// the ring, each row of fvSlots/2 slots is repeated, which is the slot representation of
+// the subring polynomials in X^(n/fvSlots) of the ckks_fv encoder
+func embedDiagonals(diags map[int][]uint64, fvSlots, n int) lintrans.Diagonals[uint64] {
+	half, fvHalf := n/2, fvSlots/2
+	embedded := make(lintrans.Diagonals[uint64], len(diags))
+	for rot, d := range diags {
+		v := make([]uint64, n)
+		for j := 0; j < half; j++ {
+			v[j] = d[j%fvHalf]
+			v[half+j] = d[fvHalf+j%fvHalf]
+		}
+		embedded[rot] = v
+	}
+	return embedded
+}
+
+// EmbedVector lifts a vector of fvSlots slots to the n slots of the ring as embedDiagonals
+func EmbedVector(vec []uint64, fvSlots, n int) []uint64 {
+	return embedDiagonals(map[int][]uint64{0: vec}, fvSlots, n)[0]
+}
+
+// ExtractVector returns the vector of fvSlots slots embedded by EmbedVector in the n slots of values
+func ExtractVector(values []uint64, fvSlots, n int) []uint64 {
+	half, fvHalf := n/2, fvSlots/2
+	vec := make([]uint64, fvSlots)
+	for i := 0; i < fvHalf; i++ {
+		vec[i] = values[i]
+		vec[fvHalf+i] = values[half+i]
+	}
+	return vec
+}
+
+// genDcdMatsRadix generates the factors of the decoding matrix with the given radix
+func genDcdMatsRadix(logSlots int, plainModulus uint64, radix int) ([]map[int][]uint64, error) {
+	t, err := HHESoK.NewModulus(plainModulus)
+	if err != nil {
+		return nil, err
+	}
+	switch radix {
+	case 0:
+		return genDcdMatsInOne(logSlots, t)
+	case 2:
+		return genDcdMatsRad2(logSlots, t)
+	default:
+		return genDcdMats(logSlots, t)
+	}
+}
+
+// genDcdMats generates decoding matrix that is factorized into sparse block diagonal matrices with radix 1
+func genDcdMats(logSlots int, t HHESoK.Modulus) (plainVector []map[int][]uint64, err error) {
+	roots, err := computePrimitiveRoots(1<<(logSlots+1), t.Q())
+	if err != nil {
+		return nil, err
+	}
+	diabMats := genDcdDiabDecomp(logSlots, roots)
+	depth := len(diabMats) - 1
+
+	plainVector = make([]map[int][]uint64, depth)
+	for i := 0; i < depth-2; i++ {
+		plainVector[i] = diabMats[i]
+	}
+	plainVector[depth-2] = multDiabMats(diabMats[depth-1], diabMats[depth-2], t)
+	plainVector[depth-1] = multDiabMats(diabMats[depth], diabMats[depth-2], t)
+	return plainVector, nil
+}
+
+// genDcdMatsRad2 generates decoding matrix that is factorized into sparse block diagonal matrices with radix 2
+func genDcdMatsRad2(logSlots int, t HHESoK.Modulus) (plainVector []map[int][]uint64, err error) {
+	roots, err := computePrimitiveRoots(1<<(logSlots+1), t.Q())
+	if err != nil {
+		return nil, err
+	}
+	diabMats := genDcdDiabDecomp(logSlots, roots)
+	depth := len(diabMats) - 1
+
+	plainVector = make([]map[int][]uint64, (depth+1)/2+1)
+	if depth%2 == 0 {
+		for i := 0; i < depth-2; i += 2 {
+			plainVector[i/2] = multDiabMats(diabMats[i+1], diabMats[i], t)
+		}
+	} else {
+		plainVector[0] = diabMats[0]
+		for i := 1; i < depth-2; i += 2 {
+			plainVector[(i+1)/2] = multDiabMats(diabMats[i+1], diabMats[i], t)
+		}
+	}
+	plainVector[(depth-1)/2] = multDiabMats(diabMats[depth-1], diabMats[depth-2], t)
+	plainVector[(depth+1)/2] = multDiabMats(diabMats[depth], diabMats[depth-2], t)
+	return plainVector, nil
+}
+
+// genDcdMatsInOne generates decoding matrix which is not factorized, ckks_fv only supports
+// logSlots 4, the product of the first factors is generalised to any number of slots
+func genDcdMatsInOne(logSlots int, t HHESoK.Modulus) (plainVector []map[int][]uint64, err error) {
+	roots, err := computePrimitiveRoots(1<<(logSlots+1), t.Q())
+	if err != nil {
+		return nil, err
+	}
+	diabMats := genDcdDiabDecomp(logSlots, roots)
+	depth := len(diabMats) - 1
+
+	plainVector = make([]map[int][]uint64, 2)
+	tmp := diabMats[0]
+	for i := 1; i < depth-1; i++ {
+		tmp = multDiabMats(diabMats[i], tmp, t)
+	}
+	plainVector[0] = multDiabMats(diabMats[depth-1], tmp, t)
+	plainVector[1] = multDiabMats(diabMats[depth], tmp, t)
+	return plainVector, nil
+}
+
+func multDiabMats(A map[int][]uint64, B map[int][]uint64, t HHESoK.Modulus) (res map[int][]uint64) {
+	res = make(map[int][]uint64)
+
+	for rotA := range A {
+		for rotB := range B {
+			N := len(A[rotA])
+			if res[(rotA+rotB)%(N/2)] == nil {
+				res[(rotA+rotB)%(N/2)] = make([]uint64, N)
+			}
+
+			for i := 0; i < N/2; i++ {
+				res[(rotA+rotB)%(N/2)][i] = t.Add(res[(rotA+rotB)%(N/2)][i], t.Mul(A[rotA][i], B[rotB][(rotA+i)%(N/2)]))
+			}
+
+			for i := N / 2; i < N; i++ {
+				res[(rotA+rotB)%(N/2)][i] = t.Add(res[(rotA+rotB)%(N/2)][i], t.Mul(A[rotA][i], B[rotB][N/2+(rotA+i)%(N/2)]))
+			}
+		}
+	}
+	return
+}
+
+func genDcdDiabDecomp(logN int, roots []uint64) (res []map[int][]uint64) {
+	N := 1 << logN
+	M := 2 * N
+	pow5 := make([]int, M)
+	res = make([]map[int][]uint64, logN)
+
+	for i, exp5 := 0, 1; i < N; i, exp5 = i+1, exp5*5%M {
+		pow5[i] = exp5
+	}
+	res[0] = make(map[int][]uint64)
+	res[0][0] = make([]uint64, N)
+	res[0][1] = make([]uint64, N)
+	res[0][2] = make([]uint64, N)
+	res[0][3] = make([]uint64, N)
+	res[0][N/2-1] = make([]uint64, N)
+	res[0][N/2-2] = make([]uint64, N)
+	res[0][N/2-3] = make([]uint64, N)
+	for i := 0; i < N; i += 4 {
+		res[0][0][i] = 1
+		res[0][0][i+1] = roots[2*N/4]
+		res[0][0][i+2] = roots[7*N/4]
+		res[0][0][i+3] = roots[1*N/4]
+
+		res[0][1][i] = roots[2*N/4]
+		res[0][1][i+1] = roots[5*N/4]
+		res[0][1][i+2] = roots[5*N/4]
+
+		res[0][2][i] = roots[1*N/4]
+		res[0][2][i+1] = roots[7*N/4]
+
+		res[0][3][i] = roots[3*N/4]
+
+		res[0][N/2-1][i+1] = 1
+		res[0][N/2-1][i+2] = roots[6*N/4]
+		res[0][N/2-1][i+3] = roots[3*N/4]
+
+		res[0][N/2-2][i+2] = 1
+		res[0][N/2-2][i+3] = roots[6*N/4]
+
+		res[0][N/2-3][i+3] = 1
+	}
+
+	for ind := 1; ind < logN-2; ind++ {
+		s := 1 << ind // size of each diabMat
+		gap := N / s / 4
+
+		res[ind] = make(map[int][]uint64)
+		for _, rot := range []int{0, s, 2 * s, N/2 - s, N/2 - 2*s} {
+			if res[ind][rot] == nil {
+				res[ind][rot] = make([]uint64, N)
+			}
+		}
+
+		for i := 0; i < N; i += 4 * s {
+			/*
+				[I 0 W0 0 ]
+				[I 0 W1 0 ]
+				[0 I 0 W0-]
+				[0 I 0 W1-]
+			*/
+			for j := 0; j < s; j++ {
+				res[ind][2*s][i+j] = roots[pow5[j]*gap%M]     // W0
+				res[ind][s][i+s+j] = roots[pow5[s+j]*gap%M]   // W1
+				res[ind][s][i+2*s+j] = roots[M-pow5[j]*gap%M] // W0-
+				res[ind][0][i+j] = 1
+				res[ind][0][i+3*s+j] = roots[M-pow5[s+j]*gap%M] // W1-
+				res[ind][N/2-s][i+s+j] = 1
+				res[ind][N/2-s][i+2*s+j] = 1
+				res[ind][N/2-2*s][i+3*s+j] = 1
+			}
+		}
+	}
+
+	s := N / 4
+
+	res[logN-2] = make(map[int][]uint64)
+	res[logN-2][0] = make([]uint64, N)
+	res[logN-2][s] = make([]uint64, N)
+
+	res[logN-1] = make(map[int][]uint64)
+	res[logN-1][0] = make([]uint64, N)
+	res[logN-1][s] = make([]uint64, N)
+
+	for i := 0; i < s; i++ {
+		res[logN-2][0][i] = 1
+		res[logN-2][0][i+3*s] = roots[M-pow5[s+i]%M]
+		res[logN-2][s][i+s] = 1
+		res[logN-2][s][i+2*s] = roots[M-pow5[i]%M]
+
+		res[logN-1][0][i] = roots[pow5[i]%M]
+		res[logN-1][0][i+3*s] = 1
+		res[logN-1][s][i+s] = roots[pow5[s+i]%M]
+		res[logN-1][s][i+2*s] = 1
+	}
+	return
+}
+
+// computePrimitiveRoots computes the M-th roots of unity in Z_plainModulus, the products are
+// reduced in 128 bits so that any word-sized plaintext modulus is supported. The plaintext
+// modulus must be a prime equal to 1 mod M
+func computePrimitiveRoots(M int, plainModulus uint64) (roots []uint64, err error) {
+	if plainModulus < 2 || (plainModulus-1)%uint64(M) != 0 {
+		return nil, fmt.Errorf("%w: plaintext modulus %d is not 1 mod %d", HHESoK.ErrInvalidParameters, plainModulus, M)
+	}
+	g, _, err := ring.PrimitiveRoot(plainModulus, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: plaintext modulus %d: %w", HHESoK.ErrInvalidParameters, plainModulus, err)
+	}
+	w := ring.ModExp(g, (plainModulus-1)/uint64(M), plainModulus)
+
+	t, err := HHESoK.NewModulus(plainModulus)
+	if err != nil {
+		return nil, err
+	}
+	roots = make([]uint64, M)
+	roots[0] = 1
+	for i := 1; i < M; i++ {
+		roots[i] = t.Mul(roots[i-1], w)
+	}
+	return roots, nil
+}
@@ -0,0 +1,118 @@
+package rtf
+
+import (
+	"HHESoK"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// TestSlotsToCoeffs moves the FVSlots slots to the coefficients and checks that the value of
+// block SlotBlock(pos) is at Coefficient(pos) for every position and that the other coefficients are zero
+func TestSlotsToCoeffs(t *testing.T) {
+	for _, idx := range []int{0, 1} {
+		params, err := NewParametersFromLiteral(TestParams(HeraParams[idx], 11))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, radix := range []int{0, 1, 2} {
+			t.Run(fmt.Sprintf("LogFVSlots=%d/Radix=%d", params.LogFVSlots(), radix), func(t *testing.T) {
+				testSlotsToCoeffs(t, params, radix)
+			})
+		}
+	}
+}
+
+func testSlotsToCoeffs(t *testing.T, params Parameters, radix int) {
+	bgvParams := params.BGV()
+	kgen := rlwe.NewKeyGenerator(bgvParams)
+	sk := kgen.GenSecretKeyNew()
+	encoder := bgv.NewEncoder(bgvParams)
+	stc, err := NewSlotsToCoeffs(params, encoder, radix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stc.Depth() != params.SlotsToCoeffsDepth(radix) {
+		t.Fatalf("depth %d, SlotsToCoeffsDepth %d", stc.Depth(), params.SlotsToCoeffsDepth(radix))
+	}
+	eval := bgv.NewEvaluator(bgvParams, rlwe.NewMemEvaluationKeySet(nil, kgen.GenGaloisKeysNew(stc.GaloisElements(), sk)...))
+
+	fvSlots := params.FVSlots()
+	values := make([]uint64, fvSlots)
+	for i := range values {
+		values[i] = uint64(i + 1)
+	}
+	pt := params.NewPlaintext(params.MaxLevel())
+	if err = encoder.Encode(EmbedVector(values, fvSlots, params.N()), pt); err != nil {
+		t.Fatal(err)
+	}
+	ct, err := rlwe.NewEncryptor(bgvParams, sk).EncryptNew(pt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stcModDown := make([]int, stc.Depth())
+	stcModDown[0] = 1
+	if ct, err = stc.Evaluate(eval, ct, stcModDown); err != nil {
+		t.Fatal(err)
+	}
+	if ct.Level() != params.MaxLevel()-1 {
+		t.Fatalf("level %d, want %d", ct.Level(), params.MaxLevel()-1)
+	}
+
+	pt = rlwe.NewDecryptor(bgvParams, sk).DecryptNew(ct)
+	coeffs := make([]uint64, params.N())
+	if err = encoder.Decode(pt, coeffs); err != nil {
+		t.Fatal(err)
+	}
+	want := make([]uint64, params.N())
+	for pos := 0; pos < fvSlots; pos++ {
+		want[params.Coefficient(pos)] = values[params.SlotBlock(pos)]
+	}
+	for i := range coeffs {
+		if coeffs[i] != want[i] {
+			t.Fatalf("coefficient %d: got %d, want %d", i, coeffs[i], want[i])
+		}
+	}
+}
+
+// TestComputePrimitiveRoots checks the roots of unity against big integer arithmetic, the
+// 40-bit plaintext modulus overflows a 64-bit product
+func TestComputePrimitiveRoots(t *testing.T) {
+	const M = 1 << 12
+	for _, plainModulus := range []uint64{HeraParams[0].PlainModulus, 1099511480321} {
+		t.Run(fmt.Sprintf("T=%d", plainModulus), func(t *testing.T) {
+			roots, err := computePrimitiveRoots(M, plainModulus)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q := new(big.Int).SetUint64(plainModulus)
+			w := new(big.Int).SetUint64(roots[1])
+			want := big.NewInt(1)
+			for i, root := range roots {
+				if want.Uint64() != root {
+					t.Fatalf("root %d: got %d, want %d", i, root, want.Uint64())
+				}
+				want.Mul(want, w).Mod(want, q)
+			}
+			// w is a primitive M-th root: w^(M/2) = -1 and w^M = 1
+			if roots[M/2] != plainModulus-1 || want.Uint64() != 1 {
+				t.Fatalf("w^(M/2) = %d and w^M = %d", roots[M/2], want.Uint64())
+			}
+		})
+	}
+
+	// 7681 = 15*2^9 + 1 has no 2^12-th roots of unity
+	if _, err := computePrimitiveRoots(M, 7681); !errors.Is(err, HHESoK.ErrInvalidParameters) {
+		t.Fatalf("computePrimitiveRoots(%d, 7681): got %v, want %v", M, err, HHESoK.ErrInvalidParameters)
+	}
+	lit := TestParams(HeraParams[0], 11)
+	lit.PlainModulus = 7681
+	if _, err := NewParametersFromLiteral(lit); !errors.Is(err, HHESoK.ErrInvalidParameters) {
+		t.Fatalf("NewParametersFromLiteral with t=7681: got %v, want %v", err, HHESoK.ErrInvalidParameters)
+	}
+}
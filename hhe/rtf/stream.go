@@ -0,0 +1,177 @@
+package rtf
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/ckks"
+)
+
+// Manifest maps the rows of a streamed dataset [Rows][Width] to the CKKS ciphertexts of its
+// transciphering. The dataset is cut into batches of FVSlots rows, each masked under a fresh
+// nonce, and row r of batch b is at position r - b*FVSlots. Column c of the rows of a batch
+// is transciphered into one Vector, so batch b is the Width Vectors of the ciphertexts
+// [b*Width*VectorCiphertexts, (b+1)*Width*VectorCiphertexts) of the stream
+type Manifest struct {
+	Rows   int
+	Width  int
+	Nonces [][]byte // nonce of every batch
+
+	params Parameters
+}
+
+// BatchEntry is the entry of one batch of a manifest, the rows [First, Last) of the dataset
+// masked under Nonce
+type BatchEntry struct {
+	Batch int
+	First int
+	Last  int
+	Nonce []byte
+}
+
+// NewManifest returns the manifest of a dataset of rows of width values, width is at most
+// the output size of the symmetric cipher. The nonces of the batches are set by the caller
+func (p Parameters) NewManifest(rows, width, outSize int) (Manifest, error) {
+	if rows <= 0 || width <= 0 || width > outSize {
+		return Manifest{}, fmt.Errorf("cannot NewManifest: %d rows of width %d, at least one row of width in [1, %d] expected", rows, width, outSize)
+	}
+	m := Manifest{Rows: rows, Width: width, params: p}
+	m.Nonces = make([][]byte, m.Batches())
+	return m, nil
+}
+
+// Batches returns the number of batches of the dataset
+func (m Manifest) Batches() int {
+	fvSlots := m.params.FVSlots()
+	return (m.Rows + fvSlots - 1) / fvSlots
+}
+
+// BatchRows returns the rows [first, last) of batch b
+func (m Manifest) BatchRows(b int) (first, last int) {
+	fvSlots := m.params.FVSlots()
+	first = b * fvSlots
+	return first, min(first+fvSlots, m.Rows)
+}
+
+// Entry returns the entry of batch b
+func (m Manifest) Entry(b int) BatchEntry {
+	first, last := m.BatchRows(b)
+	return BatchEntry{Batch: b, First: first, Last: last, Nonce: m.Nonces[b]}
+}
+
+// BatchCiphertexts returns the number of ciphertexts of one batch
+func (m Manifest) BatchCiphertexts() int {
+	return m.Width * m.params.VectorCiphertexts()
+}
+
+// Ciphertexts returns the number of ciphertexts of the stream
+func (m Manifest) Ciphertexts() int {
+	return m.Batches() * m.BatchCiphertexts()
+}
+
+// Locate returns the ciphertext of the stream and the slot that hold the value of the
+// dataset at (row, col)
+func (m Manifest) Locate(row, col int) (ct, slot int, err error) {
+	if row < 0 || row >= m.Rows || col < 0 || col >= m.Width {
+		return 0, 0, fmt.Errorf("cannot Locate: (%d, %d) not in the dataset [%d][%d]", row, col, m.Rows, m.Width)
+	}
+	fvSlots := m.params.FVSlots()
+	i, slot := m.params.Slot(row % fvSlots)
+	return ((row/fvSlots)*m.Width+col)*m.params.VectorCiphertexts() + i, slot, nil
+}
+
+// Batch returns the data [Width][<= FVSlots] of batch b of dataset, column i is the row
+// BatchRows(b) + i, the layout of the data of one transciphering
+func (m Manifest) Batch(dataset [][]float64, b int) ([][]float64, error) {
+	if len(dataset) != m.Rows {
+		return nil, fmt.Errorf("cannot Batch: %d rows expected", m.Rows)
+	}
+	first, last := m.BatchRows(b)
+	data := make([][]float64, m.Width)
+	for c := range data {
+		data[c] = make([]float64, last-first)
+	}
+	for r, row := range dataset[first:last] {
+		if len(row) != m.Width {
+			return nil, fmt.Errorf("cannot Batch: row %d of length %d, want %d", first+r, len(row), m.Width)
+		}
+		for c, v := range row {
+			data[c][r] = v
+		}
+	}
+	return data, nil
+}
+
+// Vectors returns the Vectors of batch b of the stream cts
+func (m Manifest) Vectors(cts []*rlwe.Ciphertext, b int) ([]*Vector, error) {
+	if len(cts) != m.Ciphertexts() {
+		return nil, fmt.Errorf("cannot Vectors: %d ciphertexts expected", m.Ciphertexts())
+	}
+	return m.params.batchVectors(cts[b*m.BatchCiphertexts() : (b+1)*m.BatchCiphertexts()])
+}
+
+// batchVectors returns the Vectors of the ciphertexts cts of one batch, one Vector per column
+func (p Parameters) batchVectors(cts []*rlwe.Ciphertext) ([]*Vector, error) {
+	nbCts := p.VectorCiphertexts()
+	if len(cts) == 0 || len(cts)%nbCts != 0 {
+		return nil, fmt.Errorf("cannot Vectors: %d ciphertexts, want a non-zero multiple of %d", len(cts), nbCts)
+	}
+	vectors := make([]*Vector, len(cts)/nbCts)
+	for c := range vectors {
+		first := c * nbCts
+		var ctImag *rlwe.Ciphertext
+		if nbCts == 2 {
+			ctImag = cts[first+1]
+		}
+		var err error
+		if vectors[c], err = p.NewVector(cts[first], ctImag); err != nil {
+			return nil, fmt.Errorf("cannot Vectors: %w", err)
+		}
+	}
+	return vectors, nil
+}
+
+// DecodeStream decrypts the stream cts of manifest m and returns the dataset [Rows][Width]
+func (m Manifest) DecodeStream(encoder *ckks.Encoder, decryptor *rlwe.Decryptor, cts []*rlwe.Ciphertext) ([][]float64, error) {
+	if len(cts) != m.Ciphertexts() {
+		return nil, fmt.Errorf("cannot DecodeStream: %d ciphertexts expected", m.Ciphertexts())
+	}
+	dataset := make([][]float64, m.Rows)
+	for r := range dataset {
+		dataset[r] = make([]float64, m.Width)
+	}
+	batchCts := m.BatchCiphertexts()
+	for b := 0; b < m.Batches(); b++ {
+		entry := m.Entry(b)
+		rows, err := m.params.DecodeBatch(encoder, decryptor, cts[b*batchCts:(b+1)*batchCts], entry)
+		if err != nil {
+			return nil, fmt.Errorf("cannot DecodeStream: %w", err)
+		}
+		copy(dataset[entry.First:], rows)
+	}
+	return dataset, nil
+}
+
+// DecodeBatch decrypts the ciphertexts cts of the batch of entry, the width of the rows is
+// the number of Vectors of cts, and returns its rows [Last-First][width]
+func (p Parameters) DecodeBatch(encoder *ckks.Encoder, decryptor *rlwe.Decryptor, cts []*rlwe.Ciphertext, entry BatchEntry) ([][]float64, error) {
+	if entry.First >= entry.Last || entry.Last-entry.First > p.FVSlots() {
+		return nil, fmt.Errorf("cannot DecodeBatch: rows [%d, %d) of a batch of at most %d rows expected", entry.First, entry.Last, p.FVSlots())
+	}
+	vectors, err := p.batchVectors(cts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot DecodeBatch: %w", err)
+	}
+	data, err := p.DecodeVectors(encoder, decryptor, vectors, Layout{Rows: len(vectors), Cols: entry.Last - entry.First})
+	if err != nil {
+		return nil, fmt.Errorf("cannot DecodeBatch: %w", err)
+	}
+	rows := make([][]float64, entry.Last-entry.First)
+	for i := range rows {
+		rows[i] = make([]float64, len(vectors))
+		for c := range data {
+			rows[i][c] = data[c][i]
+		}
+	}
+	return rows, nil
+}
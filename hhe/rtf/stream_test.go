@@ -0,0 +1,76 @@
+package rtf
+
+import (
+	"testing"
+)
+
+// TestManifest cuts a dataset into batches and checks that the position of every value in
+// its batch is the slot given by Locate, and that the ciphertexts of the batches do not overlap
+// and follow the batch entries
+func TestManifest(t *testing.T) {
+	for _, idx := range []int{0, 1} {
+		params, err := NewParametersFromLiteral(TestParams(HeraParams[idx], 11))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fvSlots := params.FVSlots()
+		rows, width := 2*fvSlots+7, 3
+		dataset := make([][]float64, rows)
+		for r := range dataset {
+			dataset[r] = make([]float64, width)
+			for c := range dataset[r] {
+				dataset[r][c] = float64(r*width + c)
+			}
+		}
+		m, err := params.NewManifest(rows, width, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Batches() != 3 || len(m.Nonces) != 3 {
+			t.Fatalf("%d batches and %d nonces, want 3", m.Batches(), len(m.Nonces))
+		}
+
+		located := make(map[[2]int]bool)
+		for b := 0; b < m.Batches(); b++ {
+			data, err := m.Batch(dataset, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			first, last := m.BatchRows(b)
+			m.Nonces[b] = []byte{byte(b)}
+			if entry := m.Entry(b); entry.Batch != b || entry.First != first || entry.Last != last || entry.Nonce[0] != byte(b) {
+				t.Fatalf("batch %d: entry %+v", b, entry)
+			}
+			for c := range data {
+				if len(data[c]) != last-first {
+					t.Fatalf("batch %d column %d of length %d, want %d", b, c, len(data[c]), last-first)
+				}
+				for pos, v := range data[c] {
+					row := first + pos
+					if v != dataset[row][c] {
+						t.Fatalf("batch %d: (%d, %d) holds %f, want %f", b, c, pos, v, dataset[row][c])
+					}
+					ct, slot, err := m.Locate(row, c)
+					if err != nil {
+						t.Fatal(err)
+					}
+					vecCt, vecSlot := params.Slot(pos)
+					if ct != ((b*width)+c)*params.VectorCiphertexts()+vecCt || slot != vecSlot {
+						t.Fatalf("(%d, %d) located at ciphertext %d slot %d", row, c, ct, slot)
+					}
+					if ct/m.BatchCiphertexts() != b || ct >= m.Ciphertexts() || located[[2]int{ct, slot}] {
+						t.Fatalf("(%d, %d) located at ciphertext %d slot %d twice or outside the stream", row, c, ct, slot)
+					}
+					located[[2]int{ct, slot}] = true
+				}
+			}
+		}
+
+		if _, _, err = m.Locate(rows, 0); err == nil {
+			t.Fatal("Locate outside the dataset should fail")
+		}
+		if _, err = params.NewManifest(rows, 17, 16); err == nil {
+			t.Fatal("NewManifest wider than the output size should fail")
+		}
+	}
+}
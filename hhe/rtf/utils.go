@@ -0,0 +1,89 @@
+package rtf
+
+import (
+	"HHESoK"
+	"fmt"
+	"io"
+	"math/big"
+	"math/bits"
+	"sort"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+)
+
+// SampleZqx samples a uniform element of Z_q from rand by rejection sampling,
+// it consumes rand exactly as the ckks_fv MFV ciphers to derive the same round constants
+func SampleZqx(rand io.Reader, q uint64) (res uint64, err error) {
+	// the rejection sampling draws at least one bit, which rules out Z_2
+	if q < 3 {
+		return 0, fmt.Errorf("%w: modulus %d, want at least 3", HHESoK.ErrInvalidParameters, q)
+	}
+	bitLen := bits.Len64(q - 2)
+	byteLen := (bitLen + 7) / 8
+	b := bitLen % 8
+	if b == 0 {
+		b = 8
+	}
+
+	bytes := make([]byte, byteLen)
+	for {
+		if _, err = io.ReadFull(rand, bytes); err != nil {
+			return 0, fmt.Errorf("cannot SampleZqx: %w", err)
+		}
+		bytes[byteLen-1] &= uint8((1 << b) - 1)
+
+		res = 0
+		for i := 0; i < byteLen; i++ {
+			res += uint64(bytes[i]) << (8 * i)
+		}
+
+		if res < q {
+			return res, nil
+		}
+	}
+}
+
+// CanonicalScale returns the scale -Q_level mod t, a BGV ciphertext with this scale is a BFV
+// ciphertext, the rescaling maps the canonical scale of a level to the one of the level below
+func (p Parameters) CanonicalScale(level int) rlwe.Scale {
+	t := new(big.Int).SetUint64(p.PlainModulus)
+	qMod := new(big.Int).Mod(p.bgvParams.RingQ().ModulusAtLevel[level], t).Uint64()
+	return p.bgvParams.NewScale(p.PlainModulus - qMod)
+}
+
+// NewPlaintext returns a batched BGV plaintext at the given level with the canonical scale
+func (p Parameters) NewPlaintext(level int) *rlwe.Plaintext {
+	pt := bgv.NewPlaintext(p.bgvParams, level)
+	pt.Scale = p.CanonicalScale(level)
+	return pt
+}
+
+// GaloisElements returns the sorted union of the Galois elements, without duplicates
+func GaloisElements(galEls ...[]uint64) (union []uint64) {
+	set := make(map[uint64]bool)
+	for _, els := range galEls {
+		for _, el := range els {
+			if !set[el] {
+				set[el] = true
+				union = append(union, el)
+			}
+		}
+	}
+	sort.Slice(union, func(i, j int) bool { return union[i] < union[j] })
+	return
+}
+
+// CheckGaloisKeys returns an error wrapping HHESoK.ErrMissingGaloisKey if the evaluation keys
+// of eval do not hold the Galois key of every element of galEls
+func CheckGaloisKeys(eval *bgv.Evaluator, galEls []uint64) error {
+	if eval == nil || eval.EvaluationKeySet == nil {
+		return fmt.Errorf("%w: no evaluation keys", HHESoK.ErrMissingGaloisKey)
+	}
+	for _, galEl := range galEls {
+		if _, err := eval.GetGaloisKey(galEl); err != nil {
+			return fmt.Errorf("%w: Galois element %d", HHESoK.ErrMissingGaloisKey, galEl)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package rtf
+
+import (
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/ckks"
+)
+
+// Vector is the CKKS output of the HalfBoot of one row of data, the FVSlots positions are the
+// real parts of the slots of one or two ciphertexts. With full coefficients the first N/2
+// positions are the slots of the first ciphertext and the last N/2 the slots of the second,
+// otherwise the single ciphertext holds the 2*Slots positions in its slots read at LogSlots+1
+type Vector struct {
+	params Parameters
+	cts    []*rlwe.Ciphertext
+}
+
+// NewVector returns the Vector of the HalfBoot output ctReal, ctImag, ctImag must be nil
+// unless the parameters use the full coefficients
+func (p Parameters) NewVector(ctReal, ctImag *rlwe.Ciphertext) (*Vector, error) {
+	if ctReal == nil {
+		return nil, fmt.Errorf("cannot NewVector: nil ciphertext")
+	}
+	if p.FullCoefficients() != (ctImag != nil) {
+		return nil, fmt.Errorf("cannot NewVector: %d ciphertexts expected", p.VectorCiphertexts())
+	}
+	v := &Vector{params: p, cts: []*rlwe.Ciphertext{ctReal}}
+	if ctImag != nil {
+		v.cts = append(v.cts, ctImag)
+	}
+	return v, nil
+}
+
+// VectorCiphertexts returns the number of ciphertexts of a Vector, two with full coefficients
+func (p Parameters) VectorCiphertexts() int {
+	if p.FullCoefficients() {
+		return 2
+	}
+	return 1
+}
+
+// VectorSlots returns the number of positions held by each ciphertext of a Vector
+func (p Parameters) VectorSlots() int {
+	return p.FVSlots() / p.VectorCiphertexts()
+}
+
+// Slot returns the ciphertext of a Vector and the slot of that ciphertext that hold the data at position pos
+func (p Parameters) Slot(pos int) (ct, slot int) {
+	return pos / p.VectorSlots(), pos % p.VectorSlots()
+}
+
+// Len returns the number of data positions of v
+func (v *Vector) Len() int {
+	return v.params.FVSlots()
+}
+
+// Ciphertexts returns the ciphertexts of v, one per VectorSlots positions
+func (v *Vector) Ciphertexts() []*rlwe.Ciphertext {
+	return v.cts
+}
+
+// Ciphertext returns the i-th ciphertext of v
+func (v *Vector) Ciphertext(i int) *rlwe.Ciphertext {
+	return v.cts[i]
+}
+
+// Slot returns the ciphertext and the slot that hold the data at position pos
+func (v *Vector) Slot(pos int) (ct *rlwe.Ciphertext, slot int, err error) {
+	if pos < 0 || pos >= v.Len() {
+		return nil, 0, fmt.Errorf("cannot Slot: position %d not in [0, %d)", pos, v.Len())
+	}
+	i, slot := v.params.Slot(pos)
+	return v.cts[i], slot, nil
+}
+
+// Decode decrypts v and returns its data indexed by position
+func (v *Vector) Decode(encoder *ckks.Encoder, decryptor *rlwe.Decryptor) ([]float64, error) {
+	var ctImag *rlwe.Ciphertext
+	if len(v.cts) == 2 {
+		ctImag = v.cts[1]
+	}
+	return v.params.DecodeHalfBoot(encoder, decryptor, v.cts[0], ctImag)
+}
+
+// Layout is the shape of the data matrix [Rows][Cols] of the caller, row r is transciphered
+// into Vector r and column c is its position c
+type Layout struct {
+	Rows int
+	Cols int
+}
+
+// NewLayout returns the layout of data, its rows must all have the same length of at most FVSlots
+func (p Parameters) NewLayout(data [][]float64) (Layout, error) {
+	if len(data) == 0 {
+		return Layout{}, fmt.Errorf("cannot NewLayout: no data")
+	}
+	l := Layout{Rows: len(data), Cols: len(data[0])}
+	for _, row := range data {
+		if len(row) != l.Cols {
+			return Layout{}, fmt.Errorf("cannot NewLayout: rows of different lengths")
+		}
+	}
+	if l.Cols > p.FVSlots() {
+		return Layout{}, fmt.Errorf("cannot NewLayout: at most %d columns expected", p.FVSlots())
+	}
+	return l, nil
+}
+
+// Locate returns the Vector, its ciphertext and the slot that hold the data of layout l at (row, col)
+func (p Parameters) Locate(l Layout, row, col int) (vector, ct, slot int, err error) {
+	if row < 0 || row >= l.Rows || col < 0 || col >= l.Cols || l.Cols > p.FVSlots() {
+		return 0, 0, 0, fmt.Errorf("cannot Locate: (%d, %d) not in the layout [%d][%d]", row, col, l.Rows, l.Cols)
+	}
+	ct, slot = p.Slot(col)
+	return row, ct, slot, nil
+}
+
+// DecodeVectors decrypts vectors, the transciphering of data of layout l, and returns the
+// data [l.Rows][l.Cols]
+func (p Parameters) DecodeVectors(encoder *ckks.Encoder, decryptor *rlwe.Decryptor, vectors []*Vector, l Layout) ([][]float64, error) {
+	if len(vectors) != l.Rows || l.Cols > p.FVSlots() {
+		return nil, fmt.Errorf("cannot DecodeVectors: %d vectors for the layout [%d][%d]", len(vectors), l.Rows, l.Cols)
+	}
+	data := make([][]float64, l.Rows)
+	for r, v := range vectors {
+		values, err := v.Decode(encoder, decryptor)
+		if err != nil {
+			return nil, fmt.Errorf("cannot DecodeVectors: %w", err)
+		}
+		data[r] = values[:l.Cols]
+	}
+	return data, nil
+}
@@ -0,0 +1,105 @@
+package rtf
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/ckks"
+)
+
+// TestVector encrypts every position in the slot given by Slot, as the HalfBoot outputs it,
+// and checks that DecodeVectors returns the data in the shape of its layout
+func TestVector(t *testing.T) {
+	for _, idx := range []int{0, 1} {
+		params, err := NewParametersFromLiteral(TestParams(HeraParams[idx], 11))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(fmt.Sprintf("FullCoefficients=%t", params.FullCoefficients()), func(t *testing.T) {
+			testVector(t, params)
+		})
+	}
+}
+
+func testVector(t *testing.T, params Parameters) {
+	ckksParams := params.CKKS()
+	kgen := rlwe.NewKeyGenerator(ckksParams)
+	sk := kgen.GenSecretKeyNew()
+	encoder := ckks.NewEncoder(ckksParams)
+	encryptor := rlwe.NewEncryptor(ckksParams, sk)
+	decryptor := rlwe.NewDecryptor(ckksParams, sk)
+
+	data := [][]float64{make([]float64, params.FVSlots()-3), make([]float64, params.FVSlots()-3)}
+	for r := range data {
+		for c := range data[r] {
+			data[r][c] = float64(r*len(data[r])+c) / float64(len(data)*len(data[r]))
+		}
+	}
+	layout, err := params.NewLayout(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vectors := make([]*Vector, layout.Rows)
+	for r := range vectors {
+		slots := make([][]float64, params.VectorCiphertexts())
+		for i := range slots {
+			slots[i] = make([]float64, params.VectorSlots())
+		}
+		for c, v := range data[r] {
+			vector, ct, slot, err := params.Locate(layout, r, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if vector != r {
+				t.Fatalf("(%d, %d) located in vector %d", r, c, vector)
+			}
+			slots[ct][slot] = v
+		}
+		cts := make([]*rlwe.Ciphertext, 2)
+		for i := range slots {
+			pt := ckks.NewPlaintext(ckksParams, params.ResidualLevel())
+			pt.LogDimensions.Cols = params.LogSlots
+			if !params.FullCoefficients() {
+				pt.LogDimensions.Cols++
+			}
+			if err = encoder.Encode(slots[i], pt); err != nil {
+				t.Fatal(err)
+			}
+			if cts[i], err = encryptor.EncryptNew(pt); err != nil {
+				t.Fatal(err)
+			}
+			cts[i].LogDimensions.Cols = params.LogSlots
+		}
+		if vectors[r], err = params.NewVector(cts[0], cts[1]); err != nil {
+			t.Fatal(err)
+		}
+		if len(vectors[r].Ciphertexts()) != params.VectorCiphertexts() {
+			t.Fatalf("%d ciphertexts, want %d", len(vectors[r].Ciphertexts()), params.VectorCiphertexts())
+		}
+	}
+
+	got, err := params.DecodeVectors(encoder, decryptor, vectors, layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r := range data {
+		if len(got[r]) != layout.Cols {
+			t.Fatalf("row %d of length %d, want %d", r, len(got[r]), layout.Cols)
+		}
+		for c := range data[r] {
+			if math.Abs(got[r][c]-data[r][c]) > 1e-6 {
+				t.Fatalf("(%d, %d): got %f, want %f", r, c, got[r][c], data[r][c])
+			}
+		}
+	}
+
+	if _, _, _, err = params.Locate(layout, 0, layout.Cols); err == nil {
+		t.Fatal("Locate outside the layout should fail")
+	}
+	if _, err = params.NewVector(vectors[0].Ciphertext(0), nil); (err == nil) == params.FullCoefficients() {
+		t.Fatalf("NewVector of one ciphertext: error %v with full coefficients %t", err, params.FullCoefficients())
+	}
+}
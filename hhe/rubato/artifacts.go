@@ -0,0 +1,151 @@
+package rubato
+
+import (
+	"HHESoK/hhe/rtf"
+	"HHESoK/hhe/wire"
+	"HHESoK/sym/rubato"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// Scheme is the scheme name in the header of the Rubato artifacts
+const Scheme = "rubato"
+
+// setup is the content of the parameters artifact, everything the server needs besides the keys
+type setup struct {
+	lit       rtf.ParametersLiteral
+	symParams rubato.Parameter
+	modDown   rtf.ModDown
+}
+
+// outSize is the size of the key stream of a block
+func (s setup) outSize() int {
+	return s.symParams.BlockSize - 4
+}
+
+func (s setup) marshal() ([]byte, error) {
+	var enc wire.Encoder
+	enc.PutObject(s.lit)
+	enc.PutInt(s.symParams.BlockSize)
+	enc.PutUint64(s.symParams.Modulus)
+	enc.PutInt(s.symParams.Rounds)
+	enc.PutFloat64(s.symParams.Sigma)
+	enc.PutObject(s.modDown)
+	body, err := enc.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return wire.Seal(Scheme, wire.KindParameters, body), nil
+}
+
+func unmarshalSetup(data []byte) (s setup, err error) {
+	body, err := wire.Open(data, Scheme, wire.KindParameters)
+	if err != nil {
+		return setup{}, err
+	}
+	dec := wire.NewDecoder(body)
+	dec.Object(&s.lit)
+	s.symParams.BlockSize = dec.Int()
+	s.symParams.Modulus = dec.Uint64()
+	s.symParams.Rounds = dec.Int()
+	s.symParams.Sigma = dec.Float64()
+	dec.Object(&s.modDown)
+	if err = dec.Finish(); err != nil {
+		return setup{}, err
+	}
+	if linearCoefficients[s.symParams.BlockSize] == nil || len(s.modDown.CipherModDown) != s.symParams.Rounds+1 {
+		return setup{}, fmt.Errorf("%w: invalid Rubato parameters", wire.ErrFormat)
+	}
+	return s, nil
+}
+
+// result holds the HalfBoot output of every key stream element, ctImag is nil for the sparse sets
+type result struct {
+	ctReal []*rlwe.Ciphertext
+	ctImag []*rlwe.Ciphertext
+}
+
+func (r result) marshal() ([]byte, error) {
+	var enc wire.Encoder
+	enc.PutCiphertexts(r.ctReal)
+	enc.PutBool(r.ctImag != nil)
+	if r.ctImag != nil {
+		enc.PutCiphertexts(r.ctImag)
+	}
+	body, err := enc.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return wire.Seal(Scheme, wire.KindResult, body), nil
+}
+
+func unmarshalResult(data []byte) (r result, err error) {
+	body, err := wire.Open(data, Scheme, wire.KindResult)
+	if err != nil {
+		return result{}, err
+	}
+	dec := wire.NewDecoder(body)
+	r.ctReal = dec.Ciphertexts()
+	if dec.Bool() {
+		r.ctImag = dec.Ciphertexts()
+	}
+	if err = dec.Finish(); err != nil {
+		return result{}, err
+	}
+	if r.ctImag != nil && len(r.ctImag) != len(r.ctReal) {
+		return result{}, fmt.Errorf("%w: %d real and %d imaginary ciphertexts", wire.ErrFormat, len(r.ctReal), len(r.ctImag))
+	}
+	return r, nil
+}
+
+// vectors returns the HalfBoot output of every row as an rtf.Vector
+func (r result) vectors(params rtf.Parameters) ([]*rtf.Vector, error) {
+	vectors := make([]*rtf.Vector, len(r.ctReal))
+	for i := range vectors {
+		var ctImag *rlwe.Ciphertext
+		if r.ctImag != nil {
+			ctImag = r.ctImag[i]
+		}
+		var err error
+		if vectors[i], err = params.NewVector(r.ctReal[i], ctImag); err != nil {
+			return nil, err
+		}
+	}
+	return vectors, nil
+}
+
+// symKey is the content of an encrypted key artifact, the key of epoch id whose nonces start
+// at index first
+type symKey struct {
+	id    uint64
+	first uint64
+	cts   []*rlwe.Ciphertext
+}
+
+func (k symKey) marshal() ([]byte, error) {
+	var enc wire.Encoder
+	enc.PutUint64(k.id)
+	enc.PutUint64(k.first)
+	enc.PutCiphertexts(k.cts)
+	body, err := enc.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return wire.Seal(Scheme, wire.KindSymKeyCiphertext, body), nil
+}
+
+func unmarshalSymKey(data []byte) (k symKey, err error) {
+	body, err := wire.Open(data, Scheme, wire.KindSymKeyCiphertext)
+	if err != nil {
+		return symKey{}, err
+	}
+	dec := wire.NewDecoder(body)
+	k.id = dec.Uint64()
+	k.first = dec.Uint64()
+	k.cts = dec.Ciphertexts()
+	if err = dec.Finish(); err != nil {
+		return symKey{}, err
+	}
+	return k, nil
+}
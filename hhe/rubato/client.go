@@ -0,0 +1,246 @@
+package rubato
+
+import (
+	"HHESoK"
+	"HHESoK/hhe/rtf"
+	"HHESoK/hhe/wire"
+	"HHESoK/sym/rubato"
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+	"github.com/tuneinsight/lattigo/v6/schemes/ckks"
+)
+
+// Client is the data owner of the Rubato RtF pipeline: it generates the HE keys, encrypts the
+// Rubato key under BFV, masks its data with the noisy Rubato key stream and decrypts the CKKS results.
+// Everything it sends to the Server is a wire artifact
+type Client struct {
+	setup  setup
+	params rtf.Parameters
+	keys   []HHESoK.Key    // key of every epoch
+	symCip []rubato.Rubato // cipher of every epoch
+	epochs rtf.KeyEpochs
+	next   uint64 // index of the first nonce of the current epoch not used yet
+
+	keyGenerator  *rlwe.KeyGenerator
+	sk            *rlwe.SecretKey
+	pk            *rlwe.PublicKey
+	fvEncoder     *bgv.Encoder
+	ckksEncoder   *ckks.Encoder
+	ckksDecryptor *rlwe.Decryptor
+	fvRub         MFVRubato
+}
+
+// NewClient returns the client of the RtF parameters rtf.RubatoParams[0] with the mod-down
+// presets rtf.RubatoModDown[paramIndex]
+func NewClient(paramIndex int, symParams rubato.Parameter, key HHESoK.Key) (*Client, error) {
+	return NewClientFromLiteral(rtf.RubatoParams[0], symParams, rtf.RubatoModDown[paramIndex], key)
+}
+
+// NewClientFromLiteral returns the client of the RtF parameters lit with the plaintext modulus of
+// symParams, it generates a fresh key pair
+func NewClientFromLiteral(lit rtf.ParametersLiteral, symParams rubato.Parameter, modDown rtf.ModDown, key HHESoK.Key) (c *Client, err error) {
+	lit.PlainModulus = symParams.GetModulus()
+	c = &Client{
+		setup: setup{lit: lit, symParams: symParams, modDown: modDown},
+	}
+	if c.params, err = rtf.NewParametersFromLiteral(lit); err != nil {
+		return nil, fmt.Errorf("cannot NewClient: %w", err)
+	}
+	if err = c.addKey(key, 0, 0); err != nil {
+		return nil, fmt.Errorf("cannot NewClient: %w", err)
+	}
+
+	bgvParams := c.params.BGV()
+	c.keyGenerator = rlwe.NewKeyGenerator(bgvParams)
+	c.sk, c.pk = c.keyGenerator.GenKeyPairNew()
+	c.fvEncoder = bgv.NewEncoder(bgvParams)
+	c.ckksEncoder = ckks.NewEncoder(c.params.CKKS())
+	c.ckksDecryptor = rlwe.NewDecryptor(c.params.CKKS(), c.sk)
+	// the evaluator only switches the moduli of the encrypted key
+	if c.fvRub, err = NewMFVRubato(symParams, c.params, c.fvEncoder, rlwe.NewEncryptor(bgvParams, c.pk),
+		bgv.NewEvaluator(bgvParams, nil), modDown.CipherModDown[0]); err != nil {
+		return nil, fmt.Errorf("cannot NewClient: %w", err)
+	}
+	return c, nil
+}
+
+// addKey registers key as the key of epoch id from the nonce of index first on
+func (c *Client) addKey(key HHESoK.Key, id, first uint64) error {
+	symCip, err := rubato.NewRubato(key, c.setup.symParams)
+	if err != nil {
+		return err
+	}
+	epochs, err := c.epochs.Rotate(id, first)
+	if err != nil {
+		return err
+	}
+	c.keys = append(c.keys, key)
+	c.symCip = append(c.symCip, symCip)
+	c.epochs = epochs
+	c.next = first
+	return nil
+}
+
+// FVSlots returns the number of data positions of every key stream element
+func (c *Client) FVSlots() int {
+	return c.params.FVSlots()
+}
+
+// MarshalParameters returns the parameters artifact
+func (c *Client) MarshalParameters() ([]byte, error) {
+	return c.setup.marshal()
+}
+
+// GenEvaluationKeys returns the evaluation keys artifact: the public key, which the server
+// needs to encrypt the initial Rubato states, the relinearization key and the Galois keys of
+// the slots to coefficients transform and the HalfBoot
+func (c *Client) GenEvaluationKeys() ([]byte, error) {
+	stc, err := rtf.NewSlotsToCoeffs(c.params, c.fvEncoder, stcRadix)
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenEvaluationKeys: %w", err)
+	}
+	galEls := rtf.GaloisElements(c.params.HalfBootGaloisElements(), stc.GaloisElements())
+	rlk := c.keyGenerator.GenRelinearizationKeyNew(c.sk)
+	evk := rlwe.NewMemEvaluationKeySet(rlk, c.keyGenerator.GenGaloisKeysNew(galEls, c.sk)...)
+
+	var enc wire.Encoder
+	enc.PutObject(c.pk)
+	enc.PutObject(evk)
+	body, err := enc.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("cannot GenEvaluationKeys: %w", err)
+	}
+	return wire.Seal(Scheme, wire.KindEvaluationKeys, body), nil
+}
+
+// EncryptSymKey returns the artifact of the current Rubato key encrypted under BFV, with its epoch
+func (c *Client) EncryptSymKey() ([]byte, error) {
+	last := len(c.keys) - 1
+	kCt, err := c.fvRub.EncKey(c.keys[last])
+	if err != nil {
+		return nil, fmt.Errorf("cannot EncryptSymKey: %w", err)
+	}
+	return symKey{id: c.epochs[last].ID, first: c.epochs[last].First, cts: kCt}.marshal()
+}
+
+// RotateKey registers key as the Rubato key of the nonces after the last nonce used and returns
+// its encrypted key artifact for the Server. The data of the previous nonces keep their keys,
+// and the HE keys, the costly evaluation keys included, are unchanged
+func (c *Client) RotateKey(key HHESoK.Key) ([]byte, error) {
+	if c.next == math.MaxUint64 {
+		return nil, fmt.Errorf("cannot RotateKey: %w: no nonce left", HHESoK.ErrKeyEpoch)
+	}
+	if err := c.addKey(key, c.Epoch().ID+1, c.next); err != nil {
+		return nil, fmt.Errorf("cannot RotateKey: %w", err)
+	}
+	return c.EncryptSymKey()
+}
+
+// RetireEpochs retires the nonces below the index below: the keys of the epochs that end
+// before it are dropped and EncryptData rejects the nonces below it. The current key is kept
+func (c *Client) RetireEpochs(below uint64) {
+	epochs, dropped := c.epochs.Retire(below)
+	c.keys = append([]HHESoK.Key(nil), c.keys[dropped:]...)
+	c.symCip = append([]rubato.Rubato(nil), c.symCip[dropped:]...)
+	c.epochs = epochs
+	c.next = max(c.next, below)
+}
+
+// Epoch returns the epoch of the current key
+func (c *Client) Epoch() rtf.KeyEpoch {
+	return c.epochs[len(c.epochs)-1]
+}
+
+// NewNonce returns the next nonce of the current epoch, the nonces are used in sequence so
+// that a rotation starts after all of them. Use it rather than the random HHESoK.NewNonce: a
+// nonce of EncryptData moves the start of the next rotation past it, so a random nonce would
+// close all the nonces below it
+func (c *Client) NewNonce() []byte {
+	nonce := HHESoK.CounterBytes(c.next)
+	if c.next < math.MaxUint64 {
+		c.next++
+	}
+	return nonce
+}
+
+// EncryptData masks data [BlockSize-4][<= FVSlots], indexed by position like the HalfBoot output,
+// with the key streams of the blocks nonce || CounterBytes(i) under the key of the epoch of nonce
+// and returns the symmetric ciphertext artifact
+func (c *Client) EncryptData(nonce []byte, data [][]float64) ([]byte, error) {
+	outSize := c.setup.outSize()
+	if len(data) != outSize {
+		return nil, fmt.Errorf("cannot EncryptData: %d rows expected", outSize)
+	}
+	epoch, err := c.epochs.Select(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("cannot EncryptData: %w", err)
+	}
+	index, _ := rtf.NonceIndex(nonce)
+	if epoch != len(c.epochs)-1 {
+		return nil, fmt.Errorf("cannot EncryptData: %w: nonce %d of the closed epoch of key %d", HHESoK.ErrKeyEpoch, index, c.epochs[epoch].ID)
+	}
+	if index >= c.next {
+		c.next = index + 1
+	}
+	fvSlots := c.params.FVSlots()
+	keyStream := make([][]uint64, fvSlots)
+	for i := range keyStream {
+		keyStream[i] = append([]uint64{}, c.symCip[epoch].KeyStream(nonce, HHESoK.CounterBytes(uint64(i)))...)
+		// the Rubato encryption adds fresh Gaussian noise to the key stream
+		c.symCip[epoch].AddNoise(keyStream[i])
+	}
+
+	var enc wire.Encoder
+	enc.PutBytes(nonce)
+	column := make([]uint64, fvSlots)
+	for s := 0; s < outSize; s++ {
+		for i := range column {
+			column[i] = keyStream[i][s]
+		}
+		masked, err := c.params.Mask(data[s], column)
+		if err != nil {
+			return nil, fmt.Errorf("cannot EncryptData: %w", err)
+		}
+		enc.PutUint64s(masked)
+	}
+	body, err := enc.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("cannot EncryptData: %w", err)
+	}
+	return wire.Seal(Scheme, wire.KindSymCiphertext, body), nil
+}
+
+// DecryptResult decrypts the result artifact and returns the data [BlockSize-4][FVSlots]
+func (c *Client) DecryptResult(data []byte) ([][]float64, error) {
+	res, err := unmarshalResult(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot DecryptResult: %w", err)
+	}
+	return c.decodeResult(res, rtf.Layout{Rows: len(res.ctReal), Cols: c.params.FVSlots()})
+}
+
+// DecryptResultLayout decrypts the result artifact of the data of layout l, the shape of the
+// data given to EncryptData, and returns the data [l.Rows][l.Cols]
+func (c *Client) DecryptResultLayout(data []byte, l rtf.Layout) ([][]float64, error) {
+	res, err := unmarshalResult(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot DecryptResultLayout: %w", err)
+	}
+	return c.decodeResult(res, l)
+}
+
+// decodeResult decrypts the vectors of res and returns the data of layout l
+func (c *Client) decodeResult(res result, l rtf.Layout) ([][]float64, error) {
+	vectors, err := res.vectors(c.params)
+	if err != nil {
+		return nil, fmt.Errorf("cannot DecryptResult: %w", err)
+	}
+	values, err := c.params.DecodeVectors(c.ckksEncoder, c.ckksDecryptor, vectors, l)
+	if err != nil {
+		return nil, fmt.Errorf("cannot DecryptResult: %w", err)
+	}
+	return values, nil
+}
@@ -0,0 +1,401 @@
+package rubato
+
+import (
+	"HHESoK"
+	"HHESoK/hhe/rtf"
+	"HHESoK/sym/rubato"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+	"golang.org/x/crypto/sha3"
+)
+
+// MFVRubato evaluates the Rubato key stream of FVSlots blocks in the BFV slots, state element
+// i of block j is in slot j of the ciphertext i
+type MFVRubato interface {
+	Crypt(nonces [][]byte, counter []byte, kCt []*rlwe.Ciphertext, rubatoModDown []int) ([]*rlwe.Ciphertext, error)
+	CryptNoModSwitch(nonces [][]byte, counter []byte, kCt []*rlwe.Ciphertext) ([]*rlwe.Ciphertext, error)
+	Reset(nbInitModDown int) error
+	EncKey(key []uint64) (res []*rlwe.Ciphertext, err error)
+	ShallowCopy() MFVRubato
+}
+
+// linearCoefficients are the first rows of the circulant MixColumns and MixRows matrices
+var linearCoefficients = map[int][]uint64{
+	16: {2, 3, 1, 1},
+	36: {4, 2, 4, 3, 1, 1},
+	64: {5, 3, 4, 3, 6, 2, 1, 1},
+}
+
+type mfvRubato struct {
+	blocksize     int
+	numRound      int
+	slots         int
+	nbInitModDown int
+	coefficients  []uint64
+
+	params    rtf.Parameters
+	encoder   *bgv.Encoder
+	encryptor *rlwe.Encryptor
+	evaluator *bgv.Evaluator
+
+	icCt []*rlwe.Ciphertext // encrypted initial states
+	stCt []*rlwe.Ciphertext
+	mkCt []*rlwe.Ciphertext
+	rc   [][][]uint64 // RoundConstants[round][state][slot]
+	xof  []sha3.ShakeHash
+}
+
+// NewMFVRubato returns the Rubato evaluator of the lattigo v6 RtF pipeline, the evaluator must
+// hold the relinearization key, nbInitModDown moduli are dropped from the fresh states and keys
+func NewMFVRubato(symParams rubato.Parameter, params rtf.Parameters, encoder *bgv.Encoder, encryptor *rlwe.Encryptor, evaluator *bgv.Evaluator, nbInitModDown int) (MFVRubato, error) {
+	rub := new(mfvRubato)
+
+	rub.blocksize = symParams.GetBlockSize()
+	rub.numRound = symParams.GetRounds()
+	rub.slots = params.FVSlots()
+	rub.coefficients = linearCoefficients[rub.blocksize]
+	if rub.coefficients == nil {
+		return nil, fmt.Errorf("%w: Rubato block size %d, want 16, 36 or 64", HHESoK.ErrInvalidParameters, rub.blocksize)
+	}
+	if rub.numRound < 1 {
+		return nil, fmt.Errorf("%w: Rubato with %d rounds", HHESoK.ErrInvalidParameters, rub.numRound)
+	}
+
+	rub.params = params
+	rub.encoder = encoder
+	rub.encryptor = encryptor
+	rub.evaluator = evaluator
+
+	rub.allocateState()
+
+	if err := rub.Reset(nbInitModDown); err != nil {
+		return nil, err
+	}
+	return rub, nil
+}
+
+// MustNewMFVRubato is NewMFVRubato panicking on invalid parameters
+func MustNewMFVRubato(symParams rubato.Parameter, params rtf.Parameters, encoder *bgv.Encoder, encryptor *rlwe.Encryptor, evaluator *bgv.Evaluator, nbInitModDown int) MFVRubato {
+	rub, err := NewMFVRubato(symParams, params, encoder, encryptor, evaluator, nbInitModDown)
+	HHESoK.HandleError(err)
+	return rub
+}
+
+// allocateState allocates the buffers of the state, the round constants and the XOFs
+func (rub *mfvRubato) allocateState() {
+	rub.stCt = make([]*rlwe.Ciphertext, rub.blocksize)
+	rub.mkCt = make([]*rlwe.Ciphertext, rub.blocksize)
+	rub.xof = make([]sha3.ShakeHash, rub.slots)
+
+	rub.rc = make([][][]uint64, rub.numRound+1)
+	for r := 0; r <= rub.numRound; r++ {
+		rub.rc[r] = make([][]uint64, rub.blocksize)
+		for i := 0; i < rub.blocksize; i++ {
+			rub.rc[r][i] = make([]uint64, rub.slots)
+		}
+	}
+}
+
+// ShallowCopy returns an evaluator sharing the parameters and the encrypted initial states of
+// rub but with its own state, encoder and evaluator buffers, so that copies can evaluate
+// key streams concurrently
+func (rub *mfvRubato) ShallowCopy() MFVRubato {
+	cp := &mfvRubato{
+		blocksize:     rub.blocksize,
+		numRound:      rub.numRound,
+		slots:         rub.slots,
+		nbInitModDown: rub.nbInitModDown,
+		coefficients:  rub.coefficients,
+		params:        rub.params,
+		encoder:       rub.encoder.ShallowCopy(),
+		evaluator:     rub.evaluator.ShallowCopy(),
+		icCt:          rub.icCt,
+	}
+	if rub.encryptor != nil {
+		cp.encryptor = rub.encryptor.ShallowCopy()
+	}
+	cp.allocateState()
+	return cp
+}
+
+// Reset encrypts the initial states again and drops nbInitModDown moduli
+func (rub *mfvRubato) Reset(nbInitModDown int) (err error) {
+	if nbInitModDown < 0 || nbInitModDown > rub.params.MaxLevel() {
+		return fmt.Errorf("%w: %d moduli dropped from the states, only %d available", HHESoK.ErrInsufficientLevel,
+			nbInitModDown, rub.params.MaxLevel())
+	}
+	rub.nbInitModDown = nbInitModDown
+	rub.icCt = make([]*rlwe.Ciphertext, rub.blocksize)
+
+	state := make([]uint64, rub.slots)
+	for i := 0; i < rub.blocksize; i++ {
+		for j := 0; j < rub.slots; j++ {
+			state[j] = uint64(i + 1) // ic = 1, ..., blocksize
+		}
+		if rub.icCt[i], err = rub.encryptSlots(state); err != nil {
+			return err
+		}
+	}
+	return
+}
+
+// EncKey encrypts every key element in all the slots of its own ciphertext
+func (rub *mfvRubato) EncKey(key []uint64) (res []*rlwe.Ciphertext, err error) {
+	if len(key) != rub.blocksize {
+		return nil, fmt.Errorf("%w: got %d elements, want %d", HHESoK.ErrKeyLength, len(key), rub.blocksize)
+	}
+	res = make([]*rlwe.Ciphertext, rub.blocksize)
+
+	dupKey := make([]uint64, rub.slots)
+	for i := 0; i < rub.blocksize; i++ {
+		for j := 0; j < rub.slots; j++ {
+			dupKey[j] = key[i]
+		}
+		if res[i], err = rub.encryptSlots(dupKey); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// encryptSlots encrypts one value per BFV slot at the initial level of the states
+func (rub *mfvRubato) encryptSlots(values []uint64) (*rlwe.Ciphertext, error) {
+	pt := rub.params.NewPlaintext(rub.params.MaxLevel())
+	if err := rub.encoder.Encode(rtf.EmbedVector(values, rub.slots, rub.params.N()), pt); err != nil {
+		return nil, err
+	}
+	ct, err := rub.encryptor.EncryptNew(pt)
+	if err != nil {
+		return nil, err
+	}
+	return ct, rtf.ModSwitchMany(rub.evaluator, ct, rub.nbInitModDown)
+}
+
+// init computes the round constants of every slot and brings the key to the level of the states
+func (rub *mfvRubato) init(nonces [][]byte, counter []byte, kCt []*rlwe.Ciphertext) (err error) {
+	for i := 0; i < rub.blocksize; i++ {
+		rub.stCt[i] = rub.icCt[i].CopyNew()
+		rub.mkCt[i] = kCt[i].CopyNew()
+	}
+
+	slots := rub.slots
+	for i := 0; i < slots; i++ {
+		rub.xof[i] = sha3.NewShake256()
+		_, _ = rub.xof[i].Write(nonces[i])
+		_, _ = rub.xof[i].Write(counter)
+	}
+
+	t := rub.params.PlainModulus
+	for r := 0; r <= rub.numRound; r++ {
+		for i := 0; i < rub.blocksize; i++ {
+			for slot := 0; slot < slots; slot++ {
+				if rub.rc[r][i][slot], err = rtf.SampleZqx(rub.xof[slot], t); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for i := 0; i < rub.blocksize; i++ {
+		nbSwitch := rub.mkCt[i].Level() - rub.stCt[i].Level()
+		if nbSwitch > 0 {
+			if err := rtf.ModSwitchMany(rub.evaluator, rub.mkCt[i], nbSwitch); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// check checks that there is one nonce per slot and one ciphertext per key element
+func (rub *mfvRubato) check(nonces [][]byte, kCt []*rlwe.Ciphertext) error {
+	if len(nonces) != rub.slots {
+		return fmt.Errorf("%w: got %d nonces for %d slots", HHESoK.ErrSlotCapacity, len(nonces), rub.slots)
+	}
+	if len(kCt) != rub.blocksize {
+		return fmt.Errorf("%w: got %d encrypted key elements, want %d", HHESoK.ErrKeyLength, len(kCt), rub.blocksize)
+	}
+	return nil
+}
+
+// CryptNoModSwitch computes the key stream without modulus switching
+func (rub *mfvRubato) CryptNoModSwitch(nonces [][]byte, counter []byte, kCt []*rlwe.Ciphertext) (res []*rlwe.Ciphertext, err error) {
+	if err = rub.check(nonces, kCt); err != nil {
+		return nil, err
+	}
+	if err = rub.evaluate(nonces, counter, kCt, nil); err != nil {
+		return nil, err
+	}
+	return rub.stCt[:rub.blocksize-4], nil
+}
+
+// Crypt computes the key stream under the homomorphically encrypted key kCt with the
+// modulus switching given in rubatoModDown, rubatoModDown[0] must be the nbInitModDown of the states
+func (rub *mfvRubato) Crypt(nonces [][]byte, counter []byte, kCt []*rlwe.Ciphertext, rubatoModDown []int) (res []*rlwe.Ciphertext, err error) {
+	if err = rub.check(nonces, kCt); err != nil {
+		return nil, err
+	}
+	if err = HHESoK.CheckModDown(rubatoModDown, rub.numRound, rub.nbInitModDown, rub.params.MaxLevel()); err != nil {
+		return nil, err
+	}
+	if err = rub.evaluate(nonces, counter, kCt, rubatoModDown); err != nil {
+		return nil, err
+	}
+	return rub.stCt[:rub.blocksize-4], nil
+}
+
+// evaluate runs the rounds of the key stream on the states, rubatoModDown is nil without
+// modulus switching
+func (rub *mfvRubato) evaluate(nonces [][]byte, counter []byte, kCt []*rlwe.Ciphertext, rubatoModDown []int) (err error) {
+	modSwitch := func(r int) error {
+		if rubatoModDown == nil {
+			return nil
+		}
+		return rub.modSwitch(rubatoModDown[r])
+	}
+	if err = rub.init(nonces, counter, kCt); err != nil {
+		return err
+	}
+	if err = rub.addRoundKey(0, rub.blocksize); err != nil {
+		return err
+	}
+	for r := 1; r < rub.numRound; r++ {
+		if err = rub.linLayer(rub.blocksize); err != nil {
+			return err
+		}
+		if err = rub.feistel(); err != nil {
+			return err
+		}
+		if err = modSwitch(r); err != nil {
+			return err
+		}
+		if err = rub.addRoundKey(r, rub.blocksize); err != nil {
+			return err
+		}
+	}
+	if err = rub.linLayer(rub.blocksize); err != nil {
+		return err
+	}
+	if err = rub.feistel(); err != nil {
+		return err
+	}
+	if err = modSwitch(rub.numRound); err != nil {
+		return err
+	}
+	if err = rub.linLayer(rub.blocksize - 4); err != nil {
+		return err
+	}
+	return rub.addRoundKey(rub.numRound, rub.blocksize-4)
+}
+
+func (rub *mfvRubato) modSwitch(nbSwitch int) error {
+	if nbSwitch <= 0 {
+		return nil
+	}
+	for i := 0; i < rub.blocksize; i++ {
+		if err := rtf.ModSwitchMany(rub.evaluator, rub.stCt[i], nbSwitch); err != nil {
+			return err
+		}
+		if err := rtf.ModSwitchMany(rub.evaluator, rub.mkCt[i], nbSwitch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRoundKey adds key * rc to the first outputSize states, the round constants are plaintexts of scale 1
+func (rub *mfvRubato) addRoundKey(round, outputSize int) error {
+	ev := rub.evaluator
+	n := rub.params.N()
+	for i := 0; i < outputSize; i++ {
+		rk, err := ev.MulNew(rub.mkCt[i], rtf.EmbedVector(rub.rc[round][i], rub.slots, n))
+		if err != nil {
+			return err
+		}
+		if err = ev.Add(rub.stCt[i], rk, rub.stCt[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linLayer applies MixColumns then MixRows and computes the first outputSize states
+func (rub *mfvRubato) linLayer(outputSize int) error {
+	n := len(rub.coefficients)
+	buf := make([]*rlwe.Ciphertext, rub.blocksize)
+
+	// MixColumns
+	column := make([]*rlwe.Ciphertext, n)
+	for col := 0; col < n; col++ {
+		for row := 0; row < n; row++ {
+			column[row] = rub.stCt[row*n+col]
+		}
+		y, err := rub.mix(column)
+		if err != nil {
+			return err
+		}
+		for row := range y {
+			buf[row*n+col] = y[row]
+		}
+	}
+	// MixRows
+	for row := 0; row*n < outputSize; row++ {
+		y, err := rub.mix(buf[row*n : (row+1)*n])
+		if err != nil {
+			return err
+		}
+		for col := range y {
+			if row*n+col < outputSize {
+				rub.stCt[row*n+col] = y[col]
+			}
+		}
+	}
+	return nil
+}
+
+// mix multiplies x by the circulant matrix of the linear coefficients,
+// y_i = sum + sum_k (c_k - 1) * x_(i+k)
+func (rub *mfvRubato) mix(x []*rlwe.Ciphertext) (y []*rlwe.Ciphertext, err error) {
+	ev := rub.evaluator
+	n := len(x)
+
+	sum, err := ev.AddNew(x[0], x[1])
+	if err != nil {
+		return nil, err
+	}
+	for i := 2; i < n; i++ {
+		if err = ev.Add(sum, x[i], sum); err != nil {
+			return nil, err
+		}
+	}
+
+	y = make([]*rlwe.Ciphertext, n)
+	for i := 0; i < n; i++ {
+		y[i] = sum.CopyNew()
+		for k, c := range rub.coefficients {
+			if c > 1 {
+				if err = ev.MulThenAdd(x[(i+k)%n], c-1, y[i]); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return
+}
+
+// feistel computes x_i += x_(i-1)^2 from the last state down to the second one
+func (rub *mfvRubato) feistel() error {
+	ev := rub.evaluator
+	for i := rub.blocksize - 1; i > 0; i-- {
+		sq, err := ev.MulRelinScaleInvariantNew(rub.stCt[i-1], rub.stCt[i-1])
+		if err != nil {
+			return err
+		}
+		if err = ev.Add(rub.stCt[i], sq, rub.stCt[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
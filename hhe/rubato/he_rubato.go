@@ -2,149 +2,167 @@ package rubato
 
 import (
 	"HHESoK"
-	ckks "HHESoK/rtf_ckks_integration/ckks_fv"
+	"HHESoK/hhe/rtf"
 	"HHESoK/rtf_ckks_integration/utils"
 	"HHESoK/sym/rubato"
 	"crypto/rand"
-	"math"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+	"github.com/tuneinsight/lattigo/v6/schemes/ckks"
 )
 
+// stcRadix is the radix of the slots to coefficients transform of the Rubato mod-down presets
+const stcRadix = 2
+
 type HERubato struct {
-	logger          HHESoK.Logger
-	paramIndex      int
-	params          *ckks.Parameters
-	symParams       rubato.Parameter
-	hbtp            *ckks.HalfBootstrapper
-	hbtpParams      *ckks.HalfBootParameters
-	keyGenerator    ckks.KeyGenerator
-	fvEncoder       ckks.MFVEncoder
-	ckksEncoder     ckks.CKKSEncoder
-	ckksDecryptor   ckks.CKKSDecryptor
-	sk              *ckks.SecretKey
-	pk              *ckks.PublicKey
-	fvEncryptor     ckks.MFVEncryptor
-	fvEvaluator     ckks.MFVEvaluator
-	plainCKKSRingTs []*ckks.PlaintextRingT
-	plaintexts      []*ckks.Plaintext
-
-	fvRub          ckks.MFVRubato
+	logger        HHESoK.Logger
+	paramIndex    int
+	params        rtf.Parameters
+	symParams     rubato.Parameter
+	hbtp          *rtf.HalfBootstrapper
+	keyGenerator  *rlwe.KeyGenerator
+	fvEncoder     *bgv.Encoder
+	ckksEncoder   *ckks.Encoder
+	ckksDecryptor *rlwe.Decryptor
+	sk            *rlwe.SecretKey
+	pk            *rlwe.PublicKey
+	fvEncryptor   *rlwe.Encryptor
+	fvEvaluator   *bgv.Evaluator
+	plaintexts    []*rlwe.Plaintext
+
+	fvRub          MFVRubato
 	messageScaling float64
 	rubatoModDown  []int
 	stcModDown     []int
-	pDcds          [][]*ckks.PtDiagMatrixT
-	rotkeys        *ckks.RotationKeySet
-	rlk            *ckks.RelinearizationKey
-	hbtpKey        ckks.BootstrappingKey
+	stc            *rtf.SlotsToCoeffs
+	gks            []*rlwe.GaloisKey
+	rlk            *rlwe.RelinearizationKey
+	evk            *rlwe.MemEvaluationKeySet
 
 	N            int
 	outSize      int
 	coefficients [][]float64
-	symKeyCt     []*ckks.Ciphertext
-	ciphertext   *ckks.Ciphertext
+	maskedCoeffs [][]uint64
+	symKeyCt     []*rlwe.Ciphertext
+	symCip       rubato.Rubato
+	ciphertext   *rlwe.Ciphertext
 }
 
 func NewHERubato() *HERubato {
 	rubato := &HERubato{
-		logger:          HHESoK.NewLogger(HHESoK.DEBUG),
-		paramIndex:      0,
-		params:          nil,
-		symParams:       rubato.Parameter{},
-		hbtp:            nil,
-		hbtpParams:      nil,
-		keyGenerator:    nil,
-		fvEncoder:       nil,
-		ckksEncoder:     nil,
-		ckksDecryptor:   nil,
-		sk:              nil,
-		pk:              nil,
-		fvEncryptor:     nil,
-		fvEvaluator:     nil,
-		plainCKKSRingTs: nil,
-		plaintexts:      nil,
-		fvRub:           nil,
-		messageScaling:  0,
-		rubatoModDown:   nil,
-		stcModDown:      nil,
-		pDcds:           nil,
-		rotkeys:         nil,
-		rlk:             nil,
-		hbtpKey:         ckks.BootstrappingKey{},
-		N:               0,
-		outSize:         0,
-		coefficients:    nil,
-		symKeyCt:        nil,
-		ciphertext:      nil,
+		logger:         HHESoK.NewLogger(HHESoK.DEBUG),
+		paramIndex:     0,
+		params:         rtf.Parameters{},
+		symParams:      rubato.Parameter{},
+		hbtp:           nil,
+		keyGenerator:   nil,
+		fvEncoder:      nil,
+		ckksEncoder:    nil,
+		ckksDecryptor:  nil,
+		sk:             nil,
+		pk:             nil,
+		fvEncryptor:    nil,
+		fvEvaluator:    nil,
+		plaintexts:     nil,
+		fvRub:          nil,
+		messageScaling: 0,
+		rubatoModDown:  nil,
+		stcModDown:     nil,
+		stc:            nil,
+		gks:            nil,
+		rlk:            nil,
+		evk:            nil,
+		N:              0,
+		outSize:        0,
+		coefficients:   nil,
+		maskedCoeffs:   nil,
+		symKeyCt:       nil,
+		symCip:         nil,
+		ciphertext:     nil,
 	}
 	return rubato
 }
 
-func (hR *HERubato) InitParams(paramIndex int, symParams rubato.Parameter, plainSize int) {
-	var err error
+// InitParams sets the RtF parameters rtf.RubatoParams[0] (Rubato 128af) and the mod-down
+// presets rtf.RubatoModDown[paramIndex]
+func (hR *HERubato) InitParams(paramIndex int, symParams rubato.Parameter, plainSize int) error {
 	hR.paramIndex = paramIndex
+	return hR.InitParamsFromLiteral(rtf.RubatoParams[0], symParams, rtf.RubatoModDown[paramIndex])
+}
+
+// InitParamsFromLiteral sets the RtF parameters lit with the plaintext modulus of symParams,
+// the sets of rtf.TestParams run the pipeline on a small ring
+func (hR *HERubato) InitParamsFromLiteral(lit rtf.ParametersLiteral, symParams rubato.Parameter, modDown rtf.ModDown) error {
+	var err error
 	hR.symParams = symParams
 	hR.outSize = symParams.BlockSize - 4
-	hR.hbtpParams = ckks.RtFRubatoParams[0] // using Rubato 128af
-	hR.params, err = hR.hbtpParams.Params()
-	if err != nil {
-		panic(err)
+	lit.PlainModulus = symParams.GetModulus()
+	if hR.params, err = rtf.NewParametersFromLiteral(lit); err != nil {
+		return err
 	}
-	//hR.N = int(math.Ceil(float64(plainSize / hR.outSize)))
 	hR.N = hR.params.N()
-	hR.params.SetPlainModulus(symParams.GetModulus())
-	hR.params.SetLogFVSlots(hR.params.LogN())
-	hR.messageScaling = float64(hR.params.PlainModulus()) / hR.hbtpParams.MessageRatio
-	hR.rubatoModDown = ckks.RubatoModDownParams[paramIndex].CipherModDown
-	hR.stcModDown = ckks.RubatoModDownParams[paramIndex].StCModDown
+	hR.messageScaling = hR.params.MessageScaling()
+	hR.rubatoModDown = modDown.CipherModDown
+	hR.stcModDown = modDown.StCModDown
+	return nil
 }
 
-func (hR *HERubato) HEKeyGen() {
-	hR.keyGenerator = ckks.NewKeyGenerator(hR.params)
-	hR.sk, hR.pk = hR.keyGenerator.GenKeyPairSparse(hR.hbtpParams.H)
-
-	hR.fvEncoder = ckks.NewMFVEncoder(hR.params)
-	hR.ckksEncoder = ckks.NewCKKSEncoder(hR.params)
-	hR.fvEncryptor = ckks.NewMFVEncryptorFromPk(hR.params, hR.pk)
-	hR.ckksDecryptor = ckks.NewCKKSDecryptor(hR.params, hR.sk)
+// FVSlots returns the number of blocks transciphered at once
+func (hR *HERubato) FVSlots() int {
+	return hR.params.FVSlots()
 }
 
-func (hR *HERubato) HalfBootKeyGen() {
-	// Generating half-bootstrapping keys
-	rotationsHalfBoot := hR.keyGenerator.GenRotationIndexesForHalfBoot(hR.params.LogSlots(), hR.hbtpParams)
-	hR.pDcds = hR.fvEncoder.GenSlotToCoeffMatFV(2) // radix = 2
-	rotationsStC := hR.keyGenerator.GenRotationIndexesForSlotsToCoeffsMat(hR.pDcds)
-	rotations := append(rotationsHalfBoot, rotationsStC...)
-	hR.rotkeys = hR.keyGenerator.GenRotationKeysForRotations(rotations, true, hR.sk)
-	hR.rlk = hR.keyGenerator.GenRelinearizationKey(hR.sk)
-	hR.hbtpKey = ckks.BootstrappingKey{Rlk: hR.rlk, Rtks: hR.rotkeys}
+func (hR *HERubato) HEKeyGen() {
+	bgvParams := hR.params.BGV()
+	hR.keyGenerator = rlwe.NewKeyGenerator(bgvParams)
+	hR.sk, hR.pk = hR.keyGenerator.GenKeyPairNew()
+
+	hR.fvEncoder = bgv.NewEncoder(bgvParams)
+	hR.ckksEncoder = ckks.NewEncoder(hR.params.CKKS())
+	hR.fvEncryptor = rlwe.NewEncryptor(bgvParams, hR.pk)
+	hR.ckksDecryptor = rlwe.NewDecryptor(hR.params.CKKS(), hR.sk)
 }
 
-func (hR *HERubato) InitHalfBootstrapper() {
+func (hR *HERubato) HalfBootKeyGen() error {
 	var err error
-	if hR.hbtp, err = ckks.NewHalfBootstrapper(hR.params, hR.hbtpParams, hR.hbtpKey); err != nil {
-		panic(err)
+	// Generating half-bootstrapping and slots to coefficients keys
+	if hR.stc, err = rtf.NewSlotsToCoeffs(hR.params, hR.fvEncoder, stcRadix); err != nil {
+		return err
 	}
+	galEls := rtf.GaloisElements(hR.params.HalfBootGaloisElements(), hR.stc.GaloisElements())
+	hR.gks = hR.keyGenerator.GenGaloisKeysNew(galEls, hR.sk)
+	hR.rlk = hR.keyGenerator.GenRelinearizationKeyNew(hR.sk)
+	hR.evk = rlwe.NewMemEvaluationKeySet(hR.rlk, hR.gks...)
+	return nil
+}
+
+func (hR *HERubato) InitHalfBootstrapper() (err error) {
+	hR.hbtp, err = rtf.NewHalfBootstrapper(hR.params, hR.evk)
+	return
 }
 
 func (hR *HERubato) InitEvaluator() {
-	hR.fvEvaluator = ckks.NewMFVEvaluator(hR.params, ckks.EvaluationKey{Rlk: hR.rlk, Rtks: hR.rotkeys}, hR.pDcds)
+	hR.fvEvaluator = bgv.NewEvaluator(hR.params.BGV(), hR.evk)
 }
 
 // InitCoefficients initialize the coefficient matrix
-// coefficients = [output size * number of block]
+// coefficients = [output size * FVSlots]
 func (hR *HERubato) InitCoefficients() {
 	hR.coefficients = make([][]float64, hR.outSize)
 	for s := 0; s < hR.outSize; s++ {
-		hR.coefficients[s] = make([]float64, hR.N)
+		hR.coefficients[s] = make([]float64, hR.params.FVSlots())
 	}
 }
 
 // RandomDataGen generates the matrix of random data
-// = [output size * number of block]
+// = [output size * FVSlots]
 func (hR *HERubato) RandomDataGen() (data [][]float64) {
+	fvSlots := hR.params.FVSlots()
 	data = make([][]float64, hR.outSize)
 	for i := 0; i < hR.outSize; i++ {
-		data[i] = make([]float64, hR.N)
-		for j := 0; j < hR.N; j++ {
+		data[i] = make([]float64, fvSlots)
+		for j := 0; j < fvSlots; j++ {
 			data[i][j] = utils.RandFloat64(-1, 1)
 		}
 	}
@@ -153,95 +171,227 @@ func (hR *HERubato) RandomDataGen() (data [][]float64) {
 
 // NonceGen generates the matrix of nonces
 //
-//	= [number of block * 8]
+//	= [FVSlots * 8]
 func (hR *HERubato) NonceGen() (nonces [][]byte) {
-	nonces = make([][]byte, hR.N)
-	for i := 0; i < hR.N; i++ {
+	fvSlots := hR.params.FVSlots()
+	nonces = make([][]byte, fvSlots)
+	for i := 0; i < fvSlots; i++ {
 		nonces[i] = make([]byte, 8)
-		rand.Read(nonces[i])
+		_, _ = rand.Read(nonces[i])
 	}
 	return
 }
 
+// DataToCoefficients sets the data matrix [output size][FVSlots], column i is the data at
+// position i of the HalfBoot output and is masked by the key stream of block params.SlotBlock(i)
 func (hR *HERubato) DataToCoefficients(data [][]float64) {
 	for s := 0; s < hR.outSize; s++ {
-		for i := 0; i < hR.N/2; i++ {
-			j := utils.BitReverse64(uint64(i), uint64(hR.params.LogN()-1))
-			hR.coefficients[s][j] = data[s][i]
-			hR.coefficients[s][j+uint64(hR.N/2)] = data[s][i+hR.params.N()/2]
-		}
+		copy(hR.coefficients[s], data[s])
 	}
 }
 
-// EncodeEncrypt Encode plaintext and Encrypt with key stream
-func (hR *HERubato) EncodeEncrypt(keystream [][]uint64) {
-	hR.plainCKKSRingTs = make([]*ckks.PlaintextRingT, hR.outSize)
+// EncodeEncrypt masks the data with keystream [FVSlots][output size], the key stream of one block per row
+func (hR *HERubato) EncodeEncrypt(keystream [][]uint64) error {
+	var err error
+	hR.maskedCoeffs = make([][]uint64, hR.outSize)
+	column := make([]uint64, hR.params.FVSlots())
 	for s := 0; s < hR.outSize; s++ {
-		hR.plainCKKSRingTs[s] = hR.ckksEncoder.EncodeCoeffsRingTNew(hR.coefficients[s], hR.messageScaling)
-		poly := hR.plainCKKSRingTs[s].Value()[0]
-		for i := 0; i < hR.N; i++ {
-			j := utils.BitReverse64(uint64(i), uint64(hR.params.LogN()))
-			poly.Coeffs[0][j] = (poly.Coeffs[0][j] + keystream[i][s]) % hR.params.PlainModulus()
+		for i := range column {
+			column[i] = keystream[i][s]
+		}
+		if hR.maskedCoeffs[s], err = hR.params.MaskCoefficients(hR.coefficients[s], column); err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
-func (hR *HERubato) ScaleUp() {
-	hR.plaintexts = make([]*ckks.Plaintext, hR.outSize)
+func (hR *HERubato) ScaleUp() error {
+	var err error
+	hR.plaintexts = make([]*rlwe.Plaintext, hR.outSize)
 	for s := 0; s < hR.outSize; s++ {
-		hR.plaintexts[s] = ckks.NewPlaintextFVLvl(hR.params, 0)
-		hR.fvEncoder.FVScaleUp(hR.plainCKKSRingTs[s], hR.plaintexts[s])
+		if hR.plaintexts[s], err = hR.params.EncodeCoefficients(hR.fvEncoder, hR.maskedCoeffs[s]); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (hR *HERubato) InitFvRubato() ckks.MFVRubato {
-	hR.fvRub = ckks.NewMFVRubato(hR.paramIndex, hR.params, hR.fvEncoder, hR.fvEncryptor,
+func (hR *HERubato) InitFvRubato() (MFVRubato, error) {
+	fvRubato, err := NewMFVRubato(hR.symParams, hR.params, hR.fvEncoder, hR.fvEncryptor,
 		hR.fvEvaluator, hR.rubatoModDown[0])
-	return hR.fvRub
+	if err != nil {
+		return nil, err
+	}
+	hR.fvRub = fvRubato
+	return hR.fvRub, nil
 }
 
-func (hR *HERubato) EncryptSymKey(key []uint64) {
-	hR.symKeyCt = hR.fvRub.EncKey(key)
+// EncryptSymKey encrypts the symmetric key under BFV, the key is kept to mask the data of TranscipherStream
+func (hR *HERubato) EncryptSymKey(key []uint64) error {
+	symKeyCt, err := hR.fvRub.EncKey(key)
+	if err != nil {
+		return err
+	}
+	symCip, err := rubato.NewRubato(key, hR.symParams)
+	if err != nil {
+		return err
+	}
+	hR.symKeyCt, hR.symCip = symKeyCt, symCip
 	hR.logger.PrintMessages(">> Symmetric Key Length: ", len(hR.symKeyCt))
+	return nil
 }
 
-func (hR *HERubato) GetFvKeyStreams(nonces [][]byte, counter []byte) []*ckks.Ciphertext {
-	fvKeyStreams := hR.fvRub.Crypt(nonces, counter, hR.symKeyCt, hR.rubatoModDown)
+func (hR *HERubato) GetFvKeyStreams(nonces [][]byte, counter []byte) ([]*rlwe.Ciphertext, error) {
+	fvKeyStreams, err := hR.fvRub.Crypt(nonces, counter, hR.symKeyCt, hR.rubatoModDown)
+	if err != nil {
+		return nil, err
+	}
 	for i := 0; i < hR.outSize; i++ {
 		hR.logger.PrintMessages(">> index: ", i)
-		fvKeyStreams[i] = hR.fvEvaluator.SlotsToCoeffs(fvKeyStreams[i], hR.stcModDown)
-		hR.fvEvaluator.ModSwitchMany(fvKeyStreams[i], fvKeyStreams[i], fvKeyStreams[i].Level())
+		if fvKeyStreams[i], err = hR.stc.Evaluate(hR.fvEvaluator, fvKeyStreams[i], hR.stcModDown); err != nil {
+			return nil, err
+		}
+		if err = rtf.ModSwitchMany(hR.fvEvaluator, fvKeyStreams[i], fvKeyStreams[i].Level()); err != nil {
+			return nil, err
+		}
 	}
-	return fvKeyStreams
+	return fvKeyStreams, nil
 }
 
-func (hR *HERubato) ScaleCiphertext(fvKeyStreams []*ckks.Ciphertext) {
-	hR.ciphertext = ckks.NewCiphertextFVLvl(hR.params, 1, 0)
-	hR.ciphertext.Value()[0] = hR.plaintexts[0].Value()[0].CopyNew()
-	hR.fvEvaluator.Sub(hR.ciphertext, fvKeyStreams[0], hR.ciphertext)
-	hR.fvEvaluator.TransformToNTT(hR.ciphertext, hR.ciphertext)
-	hR.ciphertext.SetScale(
-		math.Exp2(
-			math.Round(
-				math.Log2(
-					float64(hR.params.Qi()[0]) /
-						float64(hR.params.PlainModulus()) *
-						hR.messageScaling,
-				),
-			),
-		),
-	)
-
+// ScaleCiphertext removes the key stream from the masked data of the first state element
+// and returns the CKKS ciphertext at level 0
+func (hR *HERubato) ScaleCiphertext(fvKeyStreams []*rlwe.Ciphertext) (err error) {
+	hR.ciphertext, err = hR.params.ToCKKS(hR.fvEvaluator, hR.plaintexts[0], fvKeyStreams[0])
+	return
 }
 
 // HalfBoot Half-Bootstrap the ciphertext (homomorphic evaluation of ModRaise -> SubSum -> CtS -> EvalMod)
-// It takes a ciphertext at level 0 (if not at level 0, then it will reduce it to level 0)
-// and returns a ciphertext at level MaxLevel - k, where k is the depth of the bootstrapping circuit.
+// It takes the ciphertext at level 0 and returns ciphertexts at the residual level.
 // Difference from the bootstrapping is that the last StC is missing.
-// CAUTION: the scale of the ciphertext MUST be equal (or very close) to params.Scale
-// To equalize the scale, the function evaluator.SetScale(ciphertext, parameters.Scale) can be used at the expense of one level.
-func (hR *HERubato) HalfBoot() *ckks.Ciphertext {
-	var ctBoot *ckks.Ciphertext
-	ctBoot, _ = hR.hbtp.HalfBoot(hR.ciphertext, false)
-	return ctBoot
+// With full coefficients, ctReal holds the first N/2 data positions and ctImag the last N/2,
+// otherwise ctImag is nil and the FVSlots positions are in ctReal.
+func (hR *HERubato) HalfBoot() (ctReal, ctImag *rlwe.Ciphertext, err error) {
+	return hR.hbtp.HalfBoot(hR.ciphertext)
+}
+
+// HalfBootVector half-bootstraps the ciphertext like HalfBoot and returns both outputs as one
+// rtf.Vector, the transciphering of the first row of the data
+func (hR *HERubato) HalfBootVector() (*rtf.Vector, error) {
+	return hR.hbtp.HalfBootVector(hR.ciphertext)
+}
+
+// DecodeVectors decrypts vectors, the transciphering of the rows of data of layout l, and
+// returns the data [l.Rows][l.Cols]
+func (hR *HERubato) DecodeVectors(vectors []*rtf.Vector, l rtf.Layout) ([][]float64, error) {
+	return hR.params.DecodeVectors(hR.ckksEncoder, hR.ckksDecryptor, vectors, l)
+}
+
+// TranscipherStream transciphers dataset [rows][<= output size], any number of rows of the same
+// width, in batches of FVSlots rows under fresh nonces, and returns the CKKS ciphertexts of the
+// stream and its manifest, see rtf.Manifest. The symmetric key must be encrypted beforehand
+func (hR *HERubato) TranscipherStream(dataset [][]float64) ([]*rlwe.Ciphertext, rtf.Manifest, error) {
+	var cts []*rlwe.Ciphertext
+	manifest, err := hR.TranscipherBatches(dataset, func(batchCts []*rlwe.Ciphertext, _ rtf.BatchEntry) bool {
+		cts = append(cts, batchCts...)
+		return true
+	})
+	if err != nil {
+		return nil, rtf.Manifest{}, err
+	}
+	return cts, manifest, nil
+}
+
+// TranscipherBatches transciphers dataset like TranscipherStream, but yields the CKKS ciphertexts
+// of every batch with its manifest entry as soon as the batch is transciphered, so that the
+// stream is never held in memory. It stops after the first batch for which yield returns false
+// and returns the manifest, the nonces of the batches not transciphered are nil. It stops on the
+// first error, the batches yielded before it are valid
+func (hR *HERubato) TranscipherBatches(dataset [][]float64, yield func(cts []*rlwe.Ciphertext, entry rtf.BatchEntry) bool) (rtf.Manifest, error) {
+	var width int
+	if len(dataset) > 0 {
+		width = len(dataset[0])
+	}
+	manifest, err := hR.params.NewManifest(len(dataset), width, hR.outSize)
+	if err != nil {
+		return rtf.Manifest{}, err
+	}
+	for b := 0; b < manifest.Batches(); b++ {
+		data, err := manifest.Batch(dataset, b)
+		if err != nil {
+			return manifest, err
+		}
+		manifest.Nonces[b] = HHESoK.NewNonce()
+		vectors, err := hR.transcipherBatch(manifest.Nonces[b], data)
+		if err != nil {
+			return manifest, err
+		}
+		cts := make([]*rlwe.Ciphertext, 0, manifest.BatchCiphertexts())
+		for _, v := range vectors {
+			cts = append(cts, v.Ciphertexts()...)
+		}
+		if !yield(cts, manifest.Entry(b)) {
+			break
+		}
+	}
+	return manifest, nil
+}
+
+// transcipherBatch masks data [width][<= FVSlots] with the key streams of the blocks
+// nonce || CounterBytes(i), evaluates the Rubato key streams under the encrypted key and
+// returns the Vector of every row of data
+func (hR *HERubato) transcipherBatch(nonce []byte, data [][]float64) ([]*rtf.Vector, error) {
+	fvSlots := hR.params.FVSlots()
+	keyStream := make([][]uint64, fvSlots)
+	for i := range keyStream {
+		keyStream[i] = append([]uint64{}, hR.symCip.KeyStream(nonce, HHESoK.CounterBytes(uint64(i)))...)
+		// the Rubato encryption adds fresh Gaussian noise to the key stream
+		hR.symCip.AddNoise(keyStream[i])
+	}
+	fvKeyStreams, err := hR.fvRub.Crypt(HHESoK.BlockNonces(nonce, 0, fvSlots), []byte{}, hR.symKeyCt, hR.rubatoModDown)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make([]*rtf.Vector, len(data))
+	column := make([]uint64, fvSlots)
+	for s := range data {
+		for i := range column {
+			column[i] = keyStream[i][s]
+		}
+		coeffs, err := hR.params.MaskCoefficients(data[s], column)
+		if err != nil {
+			return nil, err
+		}
+		pt, err := hR.params.EncodeCoefficients(hR.fvEncoder, coeffs)
+		if err != nil {
+			return nil, err
+		}
+		ks, err := hR.stc.Evaluate(hR.fvEvaluator, fvKeyStreams[s], hR.stcModDown)
+		if err != nil {
+			return nil, err
+		}
+		if err = rtf.ModSwitchMany(hR.fvEvaluator, ks, ks.Level()); err != nil {
+			return nil, err
+		}
+		ct, err := hR.params.ToCKKS(hR.fvEvaluator, pt, ks)
+		if err != nil {
+			return nil, err
+		}
+		if vectors[s], err = hR.hbtp.HalfBootVector(ct); err != nil {
+			return nil, err
+		}
+	}
+	return vectors, nil
+}
+
+// DecodeStream decrypts the ciphertexts of TranscipherStream and returns the dataset of manifest
+func (hR *HERubato) DecodeStream(cts []*rlwe.Ciphertext, manifest rtf.Manifest) ([][]float64, error) {
+	return manifest.DecodeStream(hR.ckksEncoder, hR.ckksDecryptor, cts)
+}
+
+// DecodeBatch decrypts the ciphertexts of one batch of TranscipherBatches and returns the rows
+// [entry.First, entry.Last) of the dataset
+func (hR *HERubato) DecodeBatch(cts []*rlwe.Ciphertext, entry rtf.BatchEntry) ([][]float64, error) {
+	return hR.params.DecodeBatch(hR.ckksEncoder, hR.ckksDecryptor, cts, entry)
 }
@@ -0,0 +1,46 @@
+package rubato
+
+import (
+	"HHESoK"
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// ParallelMFVRubato evaluates batches of FVSlots nonces on a pool of workers, every worker owns
+// a ShallowCopy of the MFVRubato and batch i is always Crypt(nonces[i], counter, ...), so the
+// output is identical to the sequential calls of MFVRubato.Crypt
+type ParallelMFVRubato struct {
+	fvRubato MFVRubato
+	workers  int
+}
+
+// NewParallelMFVRubato returns a driver for fvRubato, workers <= 0 uses one worker per CPU
+func NewParallelMFVRubato(fvRubato MFVRubato, workers int) *ParallelMFVRubato {
+	return &ParallelMFVRubato{fvRubato: fvRubato, workers: HHESoK.Workers(workers)}
+}
+
+// Workers returns the size of the worker pool
+func (par *ParallelMFVRubato) Workers() int {
+	return par.workers
+}
+
+// Crypt computes the key streams of the nonce batches, res[i] is the key stream of nonces[i]
+func (par *ParallelMFVRubato) Crypt(nonces [][][]byte, counter []byte, kCt []*rlwe.Ciphertext, rubatoModDown []int) (res [][]*rlwe.Ciphertext, err error) {
+	res = make([][]*rlwe.Ciphertext, len(nonces))
+	err = HHESoK.ParallelRangeErr(len(nonces), par.workers, func(first, last int) error {
+		fvRubato := par.fvRubato.ShallowCopy()
+		for i := first; i < last; i++ {
+			keyStreams, err := fvRubato.Crypt(nonces[i], counter, kCt, rubatoModDown)
+			if err != nil {
+				return err
+			}
+			// the returned slice is the state slice of the worker, whose entries the next batch
+			// replaces with fresh copies, so only the slice is copied and the ciphertexts are kept
+			res[i] = append([]*rlwe.Ciphertext(nil), keyStreams...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
@@ -1,11 +1,15 @@
 package rubato
 
 import (
-	"HHESoK/rtf_ckks_integration/ckks_fv"
+	"HHESoK"
 	"HHESoK/sym/rubato"
 	"crypto/rand"
 	"fmt"
+	"runtime"
 	"testing"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
 )
 
 func BenchmarkRubato(b *testing.B) {
@@ -28,7 +32,9 @@ func benchHERubato(tc rubato.TestContext, b *testing.B) {
 
 	heRubato := NewHERubato()
 
-	heRubato.InitParams(tc.FVParamIndex, tc.Params, len(tc.Plaintext))
+	if err := heRubato.InitParams(tc.FVParamIndex, tc.Params, len(tc.Plaintext)); err != nil {
+		b.Fatal(err)
+	}
 
 	b.Run("Rubato/HEKeyGen", func(b *testing.B) {
 		b.ResetTimer()
@@ -40,11 +46,15 @@ func benchHERubato(tc rubato.TestContext, b *testing.B) {
 	b.Run("Rubato/HalfBootKeyGen", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			heRubato.HalfBootKeyGen()
+			if err := heRubato.HalfBootKeyGen(); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 
-	heRubato.InitHalfBootstrapper()
+	if err := heRubato.InitHalfBootstrapper(); err != nil {
+		b.Fatal(err)
+	}
 
 	heRubato.InitEvaluator()
 
@@ -61,14 +71,15 @@ func benchHERubato(tc rubato.TestContext, b *testing.B) {
 	_, _ = rand.Read(counter)
 
 	// generate key stream using plain rubato
-	keyStream := make([][]uint64, heRubato.N)
+	fvSlots := heRubato.FVSlots()
+	keyStream := make([][]uint64, fvSlots)
 
 	b.Run("Rubato/SymKeyStream", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			for i := 0; i < heRubato.N; i++ {
-				symRub := rubato.NewRubato(tc.Key, tc.Params)
-				keyStream[i] = symRub.KeyStream(nonces[i], counter)
+			for i := 0; i < fvSlots; i++ {
+				symRub := rubato.MustNewRubato(tc.Key, tc.Params)
+				keyStream[i] = append([]uint64{}, symRub.KeyStream(nonces[i], counter)...)
 			}
 		}
 	})
@@ -79,37 +90,98 @@ func benchHERubato(tc rubato.TestContext, b *testing.B) {
 	b.Run("Rubato/EncryptSymData", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			heRubato.EncodeEncrypt(keyStream)
+			if err := heRubato.EncodeEncrypt(keyStream); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 
-	heRubato.ScaleUp()
+	if err := heRubato.ScaleUp(); err != nil {
+		b.Fatal(err)
+	}
 
 	// FV Key Stream, encrypts symmetric key stream using BFV on the client side
 	b.Run("Rubato/EncSymKey", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_ = heRubato.InitFvRubato()
-			heRubato.EncryptSymKey(tc.Key)
+			if _, err := heRubato.InitFvRubato(); err != nil {
+				b.Fatal(err)
+			}
+			if err := heRubato.EncryptSymKey(tc.Key); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 
 	// get BFV key stream using encrypted symmetric key, nonce, and counter on the server side
-	var fvKeyStreams []*ckks_fv.Ciphertext
+	var fvKeyStreams []*rlwe.Ciphertext
 	b.Run("Rubato/FVKeyStream", func(b *testing.B) {
 		b.ResetTimer()
+		var err error
 		for i := 0; i < b.N; i++ {
-			fvKeyStreams = heRubato.GetFvKeyStreams(nonces, counter)
+			if fvKeyStreams, err = heRubato.GetFvKeyStreams(nonces, counter); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 
-	heRubato.ScaleCiphertext(fvKeyStreams)
+	if err := heRubato.ScaleCiphertext(fvKeyStreams); err != nil {
+		b.Fatal(err)
+	}
 
 	// half bootstrapping
 	b.Run("Rubato/HalfBoot", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_ = heRubato.HalfBoot()
+			if _, _, err := heRubato.HalfBoot(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkRubatoParallel compares the sequential key stream evaluation with the parallel driver
+// on one batch of FVSlots nonces per CPU, on the test ring
+func BenchmarkRubatoParallel(b *testing.B) {
+	tc := rubato.TestsVector[0]
+	params := testParams(b, tc)
+	bgvParams := params.BGV()
+	modDown := testModDown(params, tc).CipherModDown
+
+	kgen := rlwe.NewKeyGenerator(bgvParams)
+	sk, pk := kgen.GenKeyPairNew()
+	rlk := kgen.GenRelinearizationKeyNew(sk)
+	fvEvaluator := bgv.NewEvaluator(bgvParams, rlwe.NewMemEvaluationKeySet(rlk))
+	fvRubato := MustNewMFVRubato(tc.Params, params, bgv.NewEncoder(bgvParams), rlwe.NewEncryptor(bgvParams, pk), fvEvaluator, modDown[0])
+	kCt, err := fvRubato.EncKey(tc.Key)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	workers := runtime.NumCPU()
+	nonce := HHESoK.NewNonce()
+	numBlock := params.FVSlots()
+	nonces := make([][][]byte, workers)
+	for i := range nonces {
+		nonces[i] = HHESoK.BlockNonces(nonce, uint64(i*numBlock), numBlock)
+	}
+
+	b.Run("Rubato/Crypt", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, batch := range nonces {
+				if _, err := fvRubato.Crypt(batch, []byte{}, kCt, modDown); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run(fmt.Sprintf("Rubato/CryptParallel/Workers=%d", workers), func(b *testing.B) {
+		par := NewParallelMFVRubato(fvRubato, workers)
+		for i := 0; i < b.N; i++ {
+			if _, err := par.Crypt(nonces, []byte{}, kCt, modDown); err != nil {
+				b.Fatal(err)
+			}
 		}
 	})
 }
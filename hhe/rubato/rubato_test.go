@@ -1,40 +1,274 @@
 package rubato
 
 import (
-	ckks "HHESoK/rtf_ckks_integration/ckks_fv"
+	"HHESoK"
+	"HHESoK/hhe/rtf"
+	"HHESoK/rtf_ckks_integration/ckks_fv"
 	"HHESoK/sym/rubato"
-	"encoding/binary"
+	"crypto/rand"
 	"fmt"
 	"math"
+	"math/big"
 	"testing"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
 )
 
+// testLogN is the ring degree of the RtF test parameters, the sets are not secure
+const testLogN = 11
+
 func testString(opName string, p rubato.Parameter) string {
 	return fmt.Sprintf("%s/BlockSize=%d/Modulus=%d/Rounds=%d/Sigma=%f",
 		opName, p.GetBlockSize(), p.GetModulus(), p.GetRounds(), p.GetSigma())
 }
 
+// testParams returns the RtF parameters of tc on a ring of degree 2^testLogN
+func testParams(t testing.TB, tc rubato.TestContext) rtf.Parameters {
+	lit := rtf.TestParams(rtf.RubatoParams[0], testLogN)
+	lit.PlainModulus = tc.Params.GetModulus()
+	params, err := rtf.NewParametersFromLiteral(lit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return params
+}
+
+// testModDown returns the mod-down presets of tc, the slots to coefficients transform of the
+// test ring is shorter and drops no modulus
+func testModDown(params rtf.Parameters, tc rubato.TestContext) rtf.ModDown {
+	modDown := rtf.RubatoModDown[tc.FVParamIndex]
+	modDown.StCModDown = make([]int, params.SlotsToCoeffsDepth(stcRadix))
+	return modDown
+}
+
 func TestRubato(t *testing.T) {
 	for _, tc := range rubato.TestsVector {
-		fmt.Println(testString("Rubato", tc.Params))
-		testHERubato(t, tc)
+		t.Run(testString("Rubato", tc.Params), func(t *testing.T) {
+			testHERubato(t, tc, false)
+		})
+	}
+}
+
+// TestRubatoSymCrossCheck encrypts a multi-block message with the symmetric Rubato encryptor
+// and removes the key stream homomorphically with MFVRubato, block i of the symmetric
+// ciphertext is slot i of the HHE data matrix, the result differs from the message by
+// the Gaussian noise of the symmetric key stream only
+func TestRubatoSymCrossCheck(t *testing.T) {
+	for _, tc := range rubato.TestsVector {
+		t.Run(testString("Rubato/SymCrossCheck", tc.Params), func(t *testing.T) {
+			testRubatoSymCrossCheck(t, tc)
+		})
+	}
+}
+
+// TestRubatoCKKSFVCrossCheck evaluates the key stream and the slots to coefficients transform
+// with the lattigo v6 pipeline and with ckks_fv on the same modulus chain, the decrypted
+// results must be equal
+func TestRubatoCKKSFVCrossCheck(t *testing.T) {
+	for _, tc := range rubato.TestsVector {
+		t.Run(testString("Rubato/CKKSFVCrossCheck", tc.Params), func(t *testing.T) {
+			testRubatoCKKSFVCrossCheck(t, tc)
+		})
 	}
 }
 
-func testHERubato(t *testing.T, tc rubato.TestContext) {
+func testRubatoSymCrossCheck(t *testing.T, tc rubato.TestContext) {
+	params := testParams(t, tc)
+	bgvParams := params.BGV()
+
+	kgen := rlwe.NewKeyGenerator(bgvParams)
+	sk, pk := kgen.GenKeyPairNew()
+	rlk := kgen.GenRelinearizationKeyNew(sk)
+	fvEncoder := bgv.NewEncoder(bgvParams)
+	fvEncryptor := rlwe.NewEncryptor(bgvParams, pk)
+	fvDecryptor := rlwe.NewDecryptor(bgvParams, sk)
+	fvEvaluator := bgv.NewEvaluator(bgvParams, rlwe.NewMemEvaluationKeySet(rlk))
+	fvRubato := MustNewMFVRubato(tc.Params, params, fvEncoder, fvEncryptor, fvEvaluator, 0)
+
+	ksSize := tc.Params.GetBlockSize() - 4
+	numBlock := params.FVSlots()
+	modulus := tc.Params.GetModulus()
+	bound := uint64(6 * tc.Params.GetSigma())
+
+	plaintext := make(HHESoK.Plaintext, numBlock*ksSize)
+	for i := range plaintext {
+		v, _ := rand.Int(rand.Reader, new(big.Int).SetUint64(modulus))
+		plaintext[i] = v.Uint64()
+	}
+
+	// client side: CTR mode encryption starting from an arbitrary counter
+	nonce := HHESoK.NewNonce()
+	counter := uint64(42)
+	symRub := rubato.MustNewRubato(tc.Key, tc.Params)
+	ciphertext := symRub.NewEncryptor().EncryptWithCounter(nonce, counter, plaintext)
+
+	// server side: the block counter is already part of each nonce, so the HE counter is empty
+	kCt, err := fvRubato.EncKey(tc.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fvKeyStreams, err := fvRubato.CryptNoModSwitch(HHESoK.BlockNonces(nonce, counter, numBlock), []byte{}, kCt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	column := make([]uint64, numBlock)
+	for s := 0; s < ksSize; s++ {
+		for i := 0; i < numBlock; i++ {
+			column[i] = ciphertext[i*ksSize+s]
+		}
+		// negate in place, MulNew does not keep the scale
+		ct := fvKeyStreams[s].CopyNew()
+		if err := fvEvaluator.Mul(ct, -1, ct); err != nil {
+			t.Fatal(err)
+		}
+		if err := fvEvaluator.Add(ct, rtf.EmbedVector(column, numBlock, params.N()), ct); err != nil {
+			t.Fatal(err)
+		}
+
+		got := decodeSlots(params, fvEncoder, fvDecryptor, ct)
+		for i := 0; i < numBlock; i++ {
+			want := plaintext[i*ksSize+s]
+			diff := (got[i] + modulus - want) % modulus
+			if diff > bound && modulus-diff > bound {
+				t.Fatalf("block %d, element %d: got %d, want %d (noise bound %d)", i, s, got[i], want, bound)
+			}
+		}
+	}
+}
+
+func testRubatoCKKSFVCrossCheck(t *testing.T, tc rubato.TestContext) {
+	params := testParams(t, tc)
+	bgvParams := params.BGV()
+	numBlock := params.FVSlots()
+	ksSize := tc.Params.GetBlockSize() - 4
+	nonces := make([][]byte, numBlock)
+	for i := range nonces {
+		nonces[i] = make([]byte, 8)
+		_, _ = rand.Read(nonces[i])
+	}
+	counter := []byte{0, 0, 0, 0, 0, 0, 0, 42}
+
+	// lattigo v6
+	kgen := rlwe.NewKeyGenerator(bgvParams)
+	sk, pk := kgen.GenKeyPairNew()
+	fvEncoder := bgv.NewEncoder(bgvParams)
+	stc, err := rtf.NewSlotsToCoeffs(params, fvEncoder, stcRadix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evk := rlwe.NewMemEvaluationKeySet(kgen.GenRelinearizationKeyNew(sk), kgen.GenGaloisKeysNew(stc.GaloisElements(), sk)...)
+	fvDecryptor := rlwe.NewDecryptor(bgvParams, sk)
+	fvEvaluator := bgv.NewEvaluator(bgvParams, evk)
+	fvRubato := MustNewMFVRubato(tc.Params, params, fvEncoder, rlwe.NewEncryptor(bgvParams, pk), fvEvaluator, 0)
+	kCt, err := fvRubato.EncKey(tc.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyStreams, err := fvRubato.CryptNoModSwitch(nonces, counter, kCt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ckks_fv on the same modulus chain and slots
+	ckksFVParams, err := ckks_fv.NewParametersFromModuli(params.LogN, &ckks_fv.Moduli{Qi: bgvParams.Q(), Pi: bgvParams.P()}, params.PlainModulus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ckksFVParams.SetLogFVSlots(params.LogFVSlots())
+	ckksFVKgen := ckks_fv.NewKeyGenerator(ckksFVParams)
+	ckksFVSk, ckksFVPk := ckksFVKgen.GenKeyPair()
+	ckksFVEncoder := ckks_fv.NewMFVEncoder(ckksFVParams)
+	pDcds := ckksFVEncoder.GenSlotToCoeffMatFV(stcRadix)
+	rotKeys := ckksFVKgen.GenRotationKeysForRotations(ckksFVKgen.GenRotationIndexesForSlotsToCoeffsMat(pDcds), true, ckksFVSk)
+	ckksFVEvaluator := ckks_fv.NewMFVEvaluator(ckksFVParams, ckks_fv.EvaluationKey{Rlk: ckksFVKgen.GenRelinearizationKey(ckksFVSk), Rtks: rotKeys}, pDcds)
+	ckksFVDecryptor := ckks_fv.NewMFVDecryptor(ckksFVParams, ckksFVSk)
+	ckksFVRubato := ckks_fv.MustNewMFVRubato(tc.FVParamIndex, ckksFVParams, ckksFVEncoder, ckks_fv.NewMFVEncryptorFromPk(ckksFVParams, ckksFVPk), ckksFVEvaluator, 0)
+	ckksFVKCt, err := ckksFVRubato.EncKey(tc.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ckksFVKeyStreams, err := ckksFVRubato.CryptNoModSwitch(nonces, counter, ckksFVKCt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keyStreams) != ksSize {
+		t.Fatalf("got %d key stream ciphertexts, want %d", len(keyStreams), ksSize)
+	}
+	for s := 0; s < ksSize; s++ {
+		got := decodeSlots(params, fvEncoder, fvDecryptor, keyStreams[s])
+		want := ckksFVEncoder.DecodeUintSmallNew(ckksFVDecryptor.DecryptNew(ckksFVKeyStreams[s]))
+		for i := 0; i < numBlock; i++ {
+			if got[i] != want[i] {
+				t.Fatalf("state %d, block %d: got %d, ckks_fv %d", s, i, got[i], want[i])
+			}
+		}
+	}
+
+	ctStC, err := stc.Evaluate(fvEvaluator, keyStreams[0], make([]int, stc.Depth()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := fvDecryptor.DecryptNew(ctStC)
+	pt.IsBatched = false
+	got := make([]uint64, params.N())
+	if err = fvEncoder.Decode(pt, got); err != nil {
+		t.Fatal(err)
+	}
+	ptRt := ckks_fv.NewPlaintextRingT(ckksFVParams)
+	ckksFVEncoder.DecodeRingT(ckksFVDecryptor.DecryptNew(ckksFVEvaluator.SlotsToCoeffsNoModSwitch(ckksFVKeyStreams[0])), ptRt)
+	want := ptRt.Value()[0].Coeffs[0]
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("SlotsToCoeffs coefficient %d: got %d, ckks_fv %d", i, got[i], want[i])
+		}
+	}
+}
+
+// TestRubatoProduction runs the pipeline on the production RtF parameters with the mod-down
+// presets of InitParams, so that the presets are checked against the noise growth of lattigo v6
+func TestRubatoProduction(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the production RtF parameters in short mode")
+	}
+	for _, tc := range rubato.TestsVector {
+		t.Run(testString("Rubato/Production", tc.Params)+fmt.Sprintf("/FVParamIndex=%d", tc.FVParamIndex), func(t *testing.T) {
+			testHERubato(t, tc, true)
+		})
+	}
+}
+
+// testHERubato runs the pipeline of tc on the RtF test ring, or on the production parameters
+// rtf.RubatoParams[0] with the presets of InitParams
+func testHERubato(t *testing.T, tc rubato.TestContext, production bool) {
 	heRubato := NewHERubato()
 	lg := heRubato.logger
 	lg.PrintDataLen(tc.Key)
 
-	heRubato.InitParams(tc.FVParamIndex, tc.Params, len(tc.Plaintext))
+	if production {
+		if err := heRubato.InitParams(tc.FVParamIndex, tc.Params, len(tc.Plaintext)); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		params := testParams(t, tc)
+		if err := heRubato.InitParamsFromLiteral(params.ParametersLiteral, tc.Params, testModDown(params, tc)); err != nil {
+			t.Fatal(err)
+		}
+	}
 
 	heRubato.HEKeyGen()
 	lg.PrintMemUsage("HEKeyGen")
 
-	heRubato.HalfBootKeyGen()
+	if err := heRubato.HalfBootKeyGen(); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("HalfBootKeyGen")
 
-	heRubato.InitHalfBootstrapper()
+	if err := heRubato.InitHalfBootstrapper(); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("InitHalfBootstrapper")
 
 	heRubato.InitEvaluator()
@@ -43,22 +277,21 @@ func testHERubato(t *testing.T, tc rubato.TestContext) {
 	heRubato.InitCoefficients()
 	lg.PrintMemUsage("InitCoefficients")
 
-	// use the plaintext data from test vector or generate Random ones for full coefficients
 	data := heRubato.RandomDataGen()
 	lg.PrintMemUsage("RandomDataGen")
 
 	// need an array of 8-byte nonce for each block of data
 	nonces := heRubato.NonceGen()
 
-	// need an 8-byte counter
+	// need an 8-byte counter, shared by the blocks
 	counter := make([]byte, 8)
 
 	// generate key stream using plain rubato
-	keyStream := make([][]uint64, heRubato.params.N())
-	for i := 0; i < heRubato.params.N(); i++ {
-		symRub := rubato.NewRubato(tc.Key, tc.Params)
-		binary.BigEndian.PutUint64(counter, uint64(i))
-		keyStream[i] = symRub.KeyStream(nonces[i], counter)
+	fvSlots := heRubato.FVSlots()
+	keyStream := make([][]uint64, fvSlots)
+	symRub := rubato.MustNewRubato(tc.Key, tc.Params)
+	for i := 0; i < fvSlots; i++ {
+		keyStream[i] = append([]uint64{}, symRub.KeyStream(nonces[i], counter)...)
 	}
 	lg.PrintMemUsage("SymKeyStreamGen")
 
@@ -67,53 +300,236 @@ func testHERubato(t *testing.T, tc rubato.TestContext) {
 	lg.PrintMemUsage("DataToCoefficients")
 
 	// simulate the data encryption on client side and encode the result into polynomial representations
-	heRubato.EncodeEncrypt(keyStream)
+	if err := heRubato.EncodeEncrypt(keyStream); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("EncodeEncrypt")
 
-	heRubato.ScaleUp()
+	if err := heRubato.ScaleUp(); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("ScaleUp")
 
-	_ = heRubato.InitFvRubato()
+	if _, err := heRubato.InitFvRubato(); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("InitFvRubato")
 
 	// encrypts symmetric master key using BFV on the client side
-	heRubato.EncryptSymKey(tc.Key)
+	if err := heRubato.EncryptSymKey(tc.Key); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("EncryptSymKey")
 
 	// get BFV key stream using encrypted symmetric key, nonce, and counter on the server side
-	fvKeyStreams := heRubato.GetFvKeyStreams(nonces, counter)
+	fvKeyStreams, err := heRubato.GetFvKeyStreams(nonces, counter)
+	if err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("GetFvKeyStreams")
 
-	heRubato.ScaleCiphertext(fvKeyStreams)
+	if err := heRubato.ScaleCiphertext(fvKeyStreams); err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("ScaleCiphertext")
 
 	// half bootstrapping
-	ctBoot := heRubato.HalfBoot()
+	// HalfBoot modifies the ciphertext, HalfBootVector runs on a copy
+	ct := heRubato.ciphertext.CopyNew()
+	ctReal, ctImag, err := heRubato.HalfBoot()
+	if err != nil {
+		t.Fatal(err)
+	}
 	lg.PrintMemUsage("HalfBoot")
 
-	valuesWant := make([]complex128, heRubato.params.Slots())
-	for i := 0; i < heRubato.params.Slots(); i++ {
-		valuesWant[i] = complex(data[0][i], 0)
+	valuesTest := decodeHalfBoot(heRubato, ctReal, ctImag)
+	checkPrecision(t, ctReal, data[0], valuesTest)
+
+	heRubato.ciphertext = ct
+	vector, err := heRubato.HalfBootVector()
+	if err != nil {
+		t.Fatal(err)
 	}
+	lg.PrintMemUsage("HalfBootVector")
 
-	fmt.Println("Precision of HalfBoot(ciphertext)")
-	printDebug(heRubato.params, ctBoot, valuesWant,
-		heRubato.ckksDecryptor, heRubato.ckksEncoder)
+	vectorValues, err := heRubato.DecodeVectors([]*rtf.Vector{vector}, rtf.Layout{Rows: 1, Cols: len(data[0])})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPrecision(t, vector.Ciphertext(0), data[0], vectorValues[0])
 }
 
-func printDebug(params *ckks.Parameters, ciphertext *ckks.Ciphertext,
-	valuesWant []complex128, decryptor ckks.CKKSDecryptor, encoder ckks.CKKSEncoder) {
+// decodeSlots returns the FVSlots slots of a BFV ciphertext
+func decodeSlots(params rtf.Parameters, encoder *bgv.Encoder, decryptor *rlwe.Decryptor, ct *rlwe.Ciphertext) []uint64 {
+	values := make([]uint64, params.N())
+	HHESoK.HandleError(encoder.Decode(decryptor.DecryptNew(ct), values))
+	return rtf.ExtractVector(values, params.FVSlots(), params.N())
+}
 
-	valuesTest := encoder.DecodeComplex(decryptor.DecryptNew(ciphertext), params.LogSlots())
-	logSlots := params.LogSlots()
-	sigma := params.Sigma()
+// decodeHalfBoot returns the data positions of the HalfBoot output
+func decodeHalfBoot(heRubato *HERubato, ctReal, ctImag *rlwe.Ciphertext) []float64 {
+	values, err := heRubato.params.DecodeHalfBoot(heRubato.ckksEncoder, heRubato.ckksDecryptor, ctReal, ctImag)
+	HHESoK.HandleError(err)
+	return values
+}
 
-	fmt.Printf("Level: %d (logQ = %d)\n", ciphertext.Level(), params.LogQLvl(ciphertext.Level()))
-	fmt.Printf("Scale: 2^%f\n", math.Log2(ciphertext.Scale()))
+func checkPrecision(t *testing.T, ct *rlwe.Ciphertext, valuesWant, valuesTest []float64) {
+	maxErr := maxError(valuesWant, valuesTest)
+	fmt.Printf("Level: %d, Scale: 2^%f\n", ct.Level(), math.Log2(ct.Scale.Float64()))
 	fmt.Printf("ValuesTest: %6.10f %6.10f %6.10f %6.10f...\n", valuesTest[0], valuesTest[1], valuesTest[2], valuesTest[3])
 	fmt.Printf("ValuesWant: %6.10f %6.10f %6.10f %6.10f...\n", valuesWant[0], valuesWant[1], valuesWant[2], valuesWant[3])
+	fmt.Printf("Precision of HalfBoot(ciphertext): max error 2^%.2f\n", math.Log2(maxErr))
+	if maxErr > 1e-3 {
+		t.Fatalf("HalfBoot precision too low: max error %e", maxErr)
+	}
+}
+
+// maxError returns the largest absolute difference between the values of want and got
+func maxError(want, got []float64) (maxErr float64) {
+	for i := range want {
+		maxErr = math.Max(maxErr, math.Abs(want[i]-got[i]))
+	}
+	return
+}
+
+// TestRubatoParallel checks that the parallel driver returns the key streams of the sequential calls
+func TestRubatoParallel(t *testing.T) {
+	tc := rubato.TestsVector[0]
+	t.Run(testString("Rubato/Parallel", tc.Params), func(t *testing.T) {
+		params := testParams(t, tc)
+		bgvParams := params.BGV()
+		modDown := testModDown(params, tc).CipherModDown
+
+		kgen := rlwe.NewKeyGenerator(bgvParams)
+		sk, pk := kgen.GenKeyPairNew()
+		rlk := kgen.GenRelinearizationKeyNew(sk)
+		fvEncoder := bgv.NewEncoder(bgvParams)
+		fvEncryptor := rlwe.NewEncryptor(bgvParams, pk)
+		fvEvaluator := bgv.NewEvaluator(bgvParams, rlwe.NewMemEvaluationKeySet(rlk))
+		fvRubato := MustNewMFVRubato(tc.Params, params, fvEncoder, fvEncryptor, fvEvaluator, modDown[0])
+		kCt, err := fvRubato.EncKey(tc.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nonce := HHESoK.NewNonce()
+		numBlock := params.FVSlots()
+		nonces := make([][][]byte, 3)
+		for i := range nonces {
+			nonces[i] = HHESoK.BlockNonces(nonce, uint64(i*numBlock), numBlock)
+		}
+
+		got, err := NewParallelMFVRubato(fvRubato, 2).Crypt(nonces, []byte{}, kCt, modDown)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range nonces {
+			want, err := fvRubato.Crypt(nonces[i], []byte{}, kCt, modDown)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for s := range want {
+				if !got[i][s].Equal(want[s]) {
+					t.Fatalf("batch %d, element %d differs from the sequential path", i, s)
+				}
+			}
+		}
+	})
+}
+
+// TestRubatoStream transciphers a dataset of more rows than one batch and narrower than the
+// output size, and checks every row against the manifest, then transciphers it batch by batch
+// and checks the rows of the first batch against its entry. The ragged, empty and too wide
+// datasets must fail before any batch is transciphered
+func TestRubatoStream(t *testing.T) {
+	tc := rubato.TestsVector[0]
+	t.Run(testString("Rubato/Stream", tc.Params), func(t *testing.T) {
+		heRubato := NewHERubato()
+		params := testParams(t, tc)
+		if err := heRubato.InitParamsFromLiteral(params.ParametersLiteral, tc.Params, testModDown(params, tc)); err != nil {
+			t.Fatal(err)
+		}
+		heRubato.HEKeyGen()
+		if err := heRubato.HalfBootKeyGen(); err != nil {
+			t.Fatal(err)
+		}
+		if err := heRubato.InitHalfBootstrapper(); err != nil {
+			t.Fatal(err)
+		}
+		heRubato.InitEvaluator()
+		if _, err := heRubato.InitFvRubato(); err != nil {
+			t.Fatal(err)
+		}
+		if err := heRubato.EncryptSymKey(tc.Key); err != nil {
+			t.Fatal(err)
+		}
+
+		rows, width := params.FVSlots()+5, 3
+		dataset := make([][]float64, rows)
+		for r := range dataset {
+			dataset[r] = make([]float64, width)
+			for c := range dataset[r] {
+				dataset[r][c] = float64(r*width+c)/float64(rows*width) - 0.5
+			}
+		}
+		cts, manifest, err := heRubato.TranscipherStream(dataset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if manifest.Batches() != 2 || len(cts) != manifest.Ciphertexts() {
+			t.Fatalf("%d batches and %d ciphertexts, want 2 and %d", manifest.Batches(), len(cts), manifest.Ciphertexts())
+		}
+		if string(manifest.Nonces[0]) == string(manifest.Nonces[1]) {
+			t.Fatal("the batches share a nonce")
+		}
+		got, err := heRubato.DecodeStream(cts, manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for r := range dataset {
+			if maxErr := maxError(dataset[r], got[r]); maxErr > 1e-3 {
+				t.Fatalf("row %d: max error %e", r, maxErr)
+			}
+		}
 
-	precStats := ckks.GetPrecisionStats(params, encoder, nil, valuesWant, valuesTest, logSlots, sigma)
+		var yields int
+		manifest, err = heRubato.TranscipherBatches(dataset, func(batchCts []*rlwe.Ciphertext, entry rtf.BatchEntry) bool {
+			yields++
+			if len(batchCts) != manifest.BatchCiphertexts() || entry.First != 0 || entry.Last != params.FVSlots() {
+				t.Fatalf("batch %d: %d ciphertexts of rows [%d, %d)", entry.Batch, len(batchCts), entry.First, entry.Last)
+			}
+			rows, err := heRubato.DecodeBatch(batchCts, entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range rows {
+				if maxErr := maxError(dataset[entry.First+i], rows[i]); maxErr > 1e-3 {
+					t.Fatalf("row %d: max error %e", entry.First+i, maxErr)
+				}
+			}
+			return false
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if yields != 1 || manifest.Nonces[0] == nil || manifest.Nonces[1] != nil {
+			t.Fatalf("%d batches transciphered after the first one stopped the stream", yields)
+		}
 
-	fmt.Println(precStats.String())
+		// the invalid datasets are rejected before any batch is yielded
+		ragged := [][]float64{make([]float64, width), make([]float64, width-1)}
+		wide := [][]float64{make([]float64, heRubato.outSize+1)}
+		for name, dataset := range map[string][][]float64{"ragged": ragged, "empty": nil, "wide": wide} {
+			yields = 0
+			if _, err := heRubato.TranscipherBatches(dataset, func([]*rlwe.Ciphertext, rtf.BatchEntry) bool {
+				yields++
+				return true
+			}); err == nil || yields != 0 {
+				t.Fatalf("%s dataset: got error %v after %d batches", name, err, yields)
+			}
+			if _, _, err := heRubato.TranscipherStream(dataset); err == nil {
+				t.Fatalf("%s dataset: no error", name)
+			}
+		}
+	})
 }
@@ -0,0 +1,208 @@
+package rubato
+
+import (
+	"HHESoK"
+	"HHESoK/hhe/rtf"
+	"HHESoK/hhe/wire"
+	"fmt"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+	"github.com/tuneinsight/lattigo/v6/schemes/bgv"
+	"github.com/tuneinsight/lattigo/v6/schemes/ckks"
+)
+
+// Server transciphers the Rubato ciphertexts of a Client into CKKS ciphertexts, it only holds
+// the public key, the evaluation keys and the encrypted Rubato key, all read from wire artifacts
+type Server struct {
+	setup  setup
+	params rtf.Parameters
+
+	fvEncoder   *bgv.Encoder
+	fvEvaluator *bgv.Evaluator
+	stc         *rtf.SlotsToCoeffs
+	hbtp        *rtf.HalfBootstrapper
+	fvRub       MFVRubato
+	symKeyCt    [][]*rlwe.Ciphertext // encrypted key of every epoch
+	epochs      rtf.KeyEpochs
+
+	ckksEvaluator *ckks.Evaluator
+	compute       rtf.Computation
+}
+
+// NewServer returns the server of the parameters artifact of a Client
+func NewServer(params []byte) (s *Server, err error) {
+	s = new(Server)
+	if s.setup, err = unmarshalSetup(params); err != nil {
+		return nil, fmt.Errorf("cannot NewServer: %w", err)
+	}
+	if s.params, err = rtf.NewParametersFromLiteral(s.setup.lit); err != nil {
+		return nil, fmt.Errorf("cannot NewServer: %w", err)
+	}
+	s.fvEncoder = bgv.NewEncoder(s.params.BGV())
+	if s.stc, err = rtf.NewSlotsToCoeffs(s.params, s.fvEncoder, stcRadix); err != nil {
+		return nil, fmt.Errorf("cannot NewServer: %w", err)
+	}
+	return s, nil
+}
+
+// SetEvaluationKeys reads the evaluation keys artifact and encrypts the initial Rubato states
+func (s *Server) SetEvaluationKeys(data []byte) (err error) {
+	body, err := wire.Open(data, Scheme, wire.KindEvaluationKeys)
+	if err != nil {
+		return fmt.Errorf("cannot SetEvaluationKeys: %w", err)
+	}
+	pk, evk := new(rlwe.PublicKey), new(rlwe.MemEvaluationKeySet)
+	dec := wire.NewDecoder(body)
+	dec.Object(pk)
+	dec.Object(evk)
+	if err = dec.Finish(); err != nil {
+		return fmt.Errorf("cannot SetEvaluationKeys: %w", err)
+	}
+
+	bgvParams := s.params.BGV()
+	s.fvEvaluator = bgv.NewEvaluator(bgvParams, evk)
+	s.ckksEvaluator = ckks.NewEvaluator(s.params.CKKS(), evk)
+	if s.hbtp, err = rtf.NewHalfBootstrapper(s.params, evk); err != nil {
+		return fmt.Errorf("cannot SetEvaluationKeys: %w", err)
+	}
+	if s.fvRub, err = NewMFVRubato(s.setup.symParams, s.params, s.fvEncoder, rlwe.NewEncryptor(bgvParams, pk),
+		s.fvEvaluator, s.setup.modDown.CipherModDown[0]); err != nil {
+		return fmt.Errorf("cannot SetEvaluationKeys: %w", err)
+	}
+	return nil
+}
+
+// SetSymKeyCiphertext reads the artifact of an encrypted Rubato key and registers its epoch,
+// the key of a rotation ends the epoch of the previous key
+func (s *Server) SetSymKeyCiphertext(data []byte) (err error) {
+	k, err := unmarshalSymKey(data)
+	if err != nil {
+		return fmt.Errorf("cannot SetSymKeyCiphertext: %w", err)
+	}
+	if len(k.cts) != s.setup.symParams.BlockSize {
+		return fmt.Errorf("cannot SetSymKeyCiphertext: %d ciphertexts expected", s.setup.symParams.BlockSize)
+	}
+	epochs, err := s.epochs.Rotate(k.id, k.first)
+	if err != nil {
+		return fmt.Errorf("cannot SetSymKeyCiphertext: %w", err)
+	}
+	s.epochs = epochs
+	s.symKeyCt = append(s.symKeyCt, k.cts)
+	return nil
+}
+
+// RetireEpochs retires the nonces below the index below, the encrypted keys of the epochs
+// that end before it are released and Transcipher rejects the nonces below it. The current
+// key is kept
+func (s *Server) RetireEpochs(below uint64) {
+	epochs, dropped := s.epochs.Retire(below)
+	s.symKeyCt = append([][]*rlwe.Ciphertext(nil), s.symKeyCt[dropped:]...)
+	s.epochs = epochs
+}
+
+// Epochs returns the epochs of the registered keys, Transcipher selects the key of the epoch
+// of the nonce of each symmetric ciphertext
+func (s *Server) Epochs() rtf.KeyEpochs {
+	return s.epochs
+}
+
+// SetComputation sets the computation evaluated on the HalfBoot outputs before they are
+// returned, a nil computation returns the transciphered data
+func (s *Server) SetComputation(compute rtf.Computation) {
+	s.compute = compute
+}
+
+// Transcipher evaluates the Rubato key streams of the symmetric ciphertext artifact under the
+// encrypted key, removes them from the masked data and returns the result artifact, the
+// HalfBoot output of every key stream element
+func (s *Server) Transcipher(symCiphertext []byte) ([]byte, error) {
+	if s.fvRub == nil || s.symKeyCt == nil {
+		return nil, fmt.Errorf("cannot Transcipher: the evaluation keys and the encrypted key must be set")
+	}
+	body, err := wire.Open(symCiphertext, Scheme, wire.KindSymCiphertext)
+	if err != nil {
+		return nil, fmt.Errorf("cannot Transcipher: %w", err)
+	}
+	outSize := s.setup.outSize()
+	dec := wire.NewDecoder(body)
+	nonce := dec.Bytes()
+	masked := make([][]uint64, outSize)
+	for i := range masked {
+		masked[i] = dec.Uint64s()
+	}
+	if err = dec.Finish(); err != nil {
+		return nil, fmt.Errorf("cannot Transcipher: %w", err)
+	}
+	epoch, err := s.epochs.Select(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("cannot Transcipher: %w", err)
+	}
+
+	plaintexts := make([]*rlwe.Plaintext, outSize)
+	for i := range plaintexts {
+		if err = s.checkMasked(masked[i]); err != nil {
+			return nil, fmt.Errorf("cannot Transcipher: %w", err)
+		}
+		coeffs, err := s.params.PositionsToCoefficients(masked[i])
+		if err != nil {
+			return nil, fmt.Errorf("cannot Transcipher: %w", err)
+		}
+		if plaintexts[i], err = s.params.EncodeCoefficients(s.fvEncoder, coeffs); err != nil {
+			return nil, fmt.Errorf("cannot Transcipher: %w", err)
+		}
+	}
+
+	// the block counter is part of each nonce, so the Rubato counter is empty
+	nonces := HHESoK.BlockNonces(nonce, 0, s.params.FVSlots())
+	keyStreams, err := s.fvRub.Crypt(nonces, []byte{}, s.symKeyCt[epoch], s.setup.modDown.CipherModDown)
+	if err != nil {
+		return nil, fmt.Errorf("cannot Transcipher: %w", err)
+	}
+
+	var res result
+	for i := 0; i < outSize; i++ {
+		ks, err := s.stc.Evaluate(s.fvEvaluator, keyStreams[i], s.setup.modDown.StCModDown)
+		if err != nil {
+			return nil, fmt.Errorf("cannot Transcipher: %w", err)
+		}
+		if err = rtf.ModSwitchMany(s.fvEvaluator, ks, ks.Level()); err != nil {
+			return nil, fmt.Errorf("cannot Transcipher: %w", err)
+		}
+		ct, err := s.params.ToCKKS(s.fvEvaluator, plaintexts[i], ks)
+		if err != nil {
+			return nil, fmt.Errorf("cannot Transcipher: %w", err)
+		}
+		ctReal, ctImag, err := s.hbtp.HalfBoot(ct)
+		if err != nil {
+			return nil, fmt.Errorf("cannot Transcipher: %w", err)
+		}
+		if s.compute != nil {
+			for _, ct := range []*rlwe.Ciphertext{ctReal, ctImag} {
+				if ct == nil {
+					continue
+				}
+				if err = s.compute(s.ckksEvaluator, ct, ctImag == nil); err != nil {
+					return nil, fmt.Errorf("cannot Transcipher: %w", err)
+				}
+			}
+		}
+		res.ctReal = append(res.ctReal, ctReal)
+		if ctImag != nil {
+			res.ctImag = append(res.ctImag, ctImag)
+		}
+	}
+	return res.marshal()
+}
+
+// checkMasked checks that the masked values of a key stream element are FVSlots elements of Z_t
+func (s *Server) checkMasked(masked []uint64) error {
+	if len(masked) != s.params.FVSlots() {
+		return fmt.Errorf("%w: %d masked values, want %d", wire.ErrFormat, len(masked), s.params.FVSlots())
+	}
+	for _, v := range masked {
+		if v >= s.params.PlainModulus {
+			return fmt.Errorf("%w: masked value not reduced modulo %d", wire.ErrFormat, s.params.PlainModulus)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client sends the artifacts of one session to a Handler
+type Client struct {
+	baseURL string
+	id      string
+	http    *http.Client
+}
+
+// NewClient returns the client of the session id on the service at baseURL, e.g. http://127.0.0.1:8750
+func NewClient(baseURL, id string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/") + "/v1/sessions/" + url.PathEscape(id),
+		id:      id,
+		http:    http.DefaultClient,
+	}
+}
+
+// ID returns the client ID of the session
+func (c *Client) ID() string {
+	return c.id
+}
+
+// Open opens the session with the parameters artifact, it replaces a previous session of the same ID
+func (c *Client) Open(params []byte) error {
+	return c.do(http.MethodPut, "", params)
+}
+
+// SetEvaluationKeys uploads the evaluation keys artifact
+func (c *Client) SetEvaluationKeys(data []byte) error {
+	return c.do(http.MethodPut, "/evaluation-keys", data)
+}
+
+// SetSymKeyCiphertext uploads the encrypted symmetric key artifact
+func (c *Client) SetSymKeyCiphertext(data []byte) error {
+	return c.do(http.MethodPut, "/symkey", data)
+}
+
+// Close deletes the session
+func (c *Client) Close() error {
+	return c.do(http.MethodDelete, "", nil)
+}
+
+// Transcipher streams the symmetric ciphertext artifacts to the service and returns the
+// result artifacts in the same order
+func (c *Client) Transcipher(records [][]byte) (results [][]byte, err error) {
+	reader, writer := io.Pipe()
+	go func() {
+		buf := bufio.NewWriter(writer)
+		for _, record := range records {
+			if err := WriteFrame(buf, record); err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+		}
+		writer.CloseWithError(buf.Flush())
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/records", reader)
+	if err != nil {
+		return nil, fmt.Errorf("cannot Transcipher: %w", err)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot Transcipher: %w", err)
+	}
+	defer resp.Body.Close()
+	if err = checkStatus(resp); err != nil {
+		return nil, fmt.Errorf("cannot Transcipher: %w", err)
+	}
+
+	body := bufio.NewReader(resp.Body)
+	for {
+		res, err := ReadFrame(body)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot Transcipher: %w", err)
+		}
+		results = append(results, res)
+	}
+	if msg := resp.Trailer.Get(ErrorTrailer); msg != "" {
+		return nil, fmt.Errorf("cannot Transcipher: record %d: %s", len(results), msg)
+	}
+	if len(results) != len(records) {
+		return nil, fmt.Errorf("cannot Transcipher: %d results for %d records", len(results), len(records))
+	}
+	return results, nil
+}
+
+func (c *Client) do(method, resource string, data []byte) error {
+	req, err := http.NewRequest(method, c.baseURL+resource, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkStatus(resp)
+}
+
+// StatusError is the error of a request rejected by the service
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("service: %s: %s", http.StatusText(e.StatusCode), e.Message)
+}
+
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	return &StatusError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+}
+
+// IsNotFound reports whether err is the rejection of a request on an unknown session
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,334 @@
+// Package service is a transciphering service over HTTP. A client opens a session with the
+// parameters artifact of its scheme, uploads its evaluation keys once and its encrypted
+// symmetric key, again on every key rotation, then streams symmetric ciphertext artifacts and
+// reads back the result artifacts.
+// The sessions are kept in memory and keyed by the client ID in the URL:
+//
+//	PUT    /v1/sessions/{id}                  parameters artifact
+//	PUT    /v1/sessions/{id}/evaluation-keys  evaluation keys artifact
+//	PUT    /v1/sessions/{id}/symkey           encrypted symmetric key artifact of an epoch
+//	POST   /v1/sessions/{id}/records          framed symmetric ciphertexts, framed results
+//	DELETE /v1/sessions/{id}
+//
+// A frame is the length of the artifact on 8 big endian bytes followed by the artifact. An
+// error after the first result is reported in the ErrorTrailer of the response. The request
+// bodies are bounded by the Limits of the Handler.
+package service
+
+import (
+	"HHESoK"
+	"HHESoK/hhe/hera"
+	"HHESoK/hhe/pasta"
+	"HHESoK/hhe/rtf"
+	"HHESoK/hhe/rubato"
+	"HHESoK/hhe/wire"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrorTrailer is the trailer of the records response that holds the error ending the stream
+const ErrorTrailer = "Hhe-Error"
+
+// maxFrame bounds the length of a frame. The largest frames are the records of a full-slot
+// Rubato batch, 64 state elements of 2^15 values, 16 MiB, and the HalfBoot results of two
+// CKKS ciphertexts of at most 25 MiB at LogN = 16
+const maxFrame = 1 << 28
+
+// Limits bounds the bodies of the requests served by a Handler
+type Limits struct {
+	Parameters     int64 // the parameters artifact
+	EvaluationKeys int64 // the evaluation keys artifact
+	SymKey         int64 // the encrypted symmetric key artifact
+	Records        int64 // the framed records of one request
+}
+
+// DefaultLimits are the limits of NewHandler. The 89 Galois keys of the full-slot HERA and
+// Rubato parameters take up to 15 GiB, the encrypted key of Rubato-L 64 BGV ciphertexts of at
+// most 24 MiB. The PASTA keys grow with the message size, larger messages need larger limits
+var DefaultLimits = Limits{
+	Parameters:     1 << 20,
+	EvaluationKeys: 16 << 30,
+	SymKey:         2 << 30,
+	Records:        16 << 30,
+}
+
+// Transcipherer is the server side of an HHE pipeline, see hera.Server and rubato.Server
+type Transcipherer interface {
+	SetEvaluationKeys(data []byte) error
+	SetSymKeyCiphertext(data []byte) error
+	Transcipher(symCiphertext []byte) ([]byte, error)
+}
+
+// NewTranscipherer returns the server of a parameters artifact, compute is evaluated on the
+// transciphered data and may be nil
+type NewTranscipherer func(params []byte, compute rtf.Computation) (Transcipherer, error)
+
+// Schemes are the schemes served by a Handler, keyed by the scheme name of the artifacts
+var Schemes = map[string]NewTranscipherer{
+	hera.Scheme: func(params []byte, compute rtf.Computation) (Transcipherer, error) {
+		s, err := hera.NewServer(params)
+		if err != nil {
+			return nil, err
+		}
+		s.SetComputation(compute)
+		return s, nil
+	},
+	rubato.Scheme: func(params []byte, compute rtf.Computation) (Transcipherer, error) {
+		s, err := rubato.NewServer(params)
+		if err != nil {
+			return nil, err
+		}
+		s.SetComputation(compute)
+		return s, nil
+	},
+	// the PASTA results are BFV ciphertexts, the CKKS computations do not apply
+	pasta.Scheme: func(params []byte, compute rtf.Computation) (Transcipherer, error) {
+		if compute != nil {
+			return nil, fmt.Errorf("%w of %s", ErrComputation, pasta.Scheme)
+		}
+		return pasta.NewServer(params)
+	},
+}
+
+var (
+	// ErrSession is returned for the requests of a client without session
+	ErrSession = errors.New("service: unknown session")
+	// ErrScheme is returned for the parameters of a scheme missing from Schemes
+	ErrScheme = errors.New("service: unsupported scheme")
+	// ErrComputation is returned for the parameters of a scheme that cannot evaluate the
+	// computation of the Handler
+	ErrComputation = errors.New("service: unsupported computation")
+)
+
+// session is the server of one client, the pipelines are stateful so the requests of a
+// session are served one at a time
+type session struct {
+	mu     sync.Mutex
+	server Transcipherer
+}
+
+// Handler serves the sessions of the transciphering service
+type Handler struct {
+	compute rtf.Computation
+	limits  Limits
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// NewHandler returns a handler without sessions, compute is evaluated on the transciphered
+// data of every session and may be nil
+func NewHandler(compute rtf.Computation) *Handler {
+	return &Handler{compute: compute, limits: DefaultLimits, sessions: make(map[string]*session)}
+}
+
+// SetLimits sets the limits of the request bodies, the larger bodies are rejected with
+// http.StatusRequestEntityTooLarge
+func (h *Handler) SetLimits(limits Limits) {
+	h.limits = limits
+}
+
+// Sessions returns the number of open sessions
+func (h *Handler) Sessions() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.sessions)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, resource, ok := parsePath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	switch {
+	case resource == "" && r.Method == http.MethodPut:
+		h.open(w, r, id)
+	case resource == "" && r.Method == http.MethodDelete:
+		h.mu.Lock()
+		delete(h.sessions, id)
+		h.mu.Unlock()
+	case resource == "evaluation-keys" && r.Method == http.MethodPut:
+		h.set(w, r, id, h.limits.EvaluationKeys, Transcipherer.SetEvaluationKeys)
+	case resource == "symkey" && r.Method == http.MethodPut:
+		h.set(w, r, id, h.limits.SymKey, Transcipherer.SetSymKeyCiphertext)
+	case resource == "records" && r.Method == http.MethodPost:
+		h.records(w, r, id)
+	case resource == "" || resource == "evaluation-keys" || resource == "symkey" || resource == "records":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// parsePath splits /v1/sessions/{id}[/resource]
+func parsePath(path string) (id, resource string, ok bool) {
+	rest, ok := strings.CutPrefix(path, "/v1/sessions/")
+	if !ok {
+		return "", "", false
+	}
+	id, resource, _ = strings.Cut(rest, "/")
+	return id, resource, id != "" && !strings.Contains(resource, "/")
+}
+
+// open creates the session of a client, or replaces it
+func (h *Handler) open(w http.ResponseWriter, r *http.Request, id string) {
+	params, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.limits.Parameters))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	name, _, err := wire.Peek(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	newServer, ok := Schemes[name]
+	if !ok {
+		writeError(w, fmt.Errorf("%w %q", ErrScheme, name))
+		return
+	}
+	server, err := newServer(params, h.compute)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	h.mu.Lock()
+	h.sessions[id] = &session{server: server}
+	h.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) session(id string) (*session, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrSession, id)
+	}
+	return s, nil
+}
+
+// set reads an artifact of at most limit bytes and passes it to the server of the session with setter
+func (h *Handler) set(w http.ResponseWriter, r *http.Request, id string, limit int64, setter func(Transcipherer, []byte) error) {
+	s, err := h.session(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = setter(s.server, data); err != nil {
+		writeError(w, err)
+	}
+}
+
+// records transciphers the frames of the request body and writes every result as soon as it is ready
+func (h *Handler) records(w http.ResponseWriter, r *http.Request, id string) {
+	s, err := h.session(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Trailer", ErrorTrailer)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	// the results are written while the records are read
+	rc := http.NewResponseController(w)
+	_ = rc.EnableFullDuplex()
+	body := bufio.NewReader(http.MaxBytesReader(w, r.Body, h.limits.Records))
+	for started := false; ; started = true {
+		record, err := ReadFrame(body)
+		if err == io.EOF {
+			return
+		}
+		var res []byte
+		if err == nil {
+			res, err = s.server.Transcipher(record)
+		}
+		if err != nil {
+			if !started {
+				writeError(w, err)
+				return
+			}
+			w.Header().Set(ErrorTrailer, err.Error())
+			return
+		}
+		if err = WriteFrame(w, res); err != nil {
+			return
+		}
+		if err = rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeError replies with the status of err, the typed errors of the artifacts and of the
+// pipelines are the faults of the request
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrSession):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrScheme), errors.Is(err, ErrComputation), errors.Is(err, wire.ErrFormat), errors.Is(err, wire.ErrVersion), errors.Is(err, wire.ErrKind):
+		status = http.StatusBadRequest
+	case errors.Is(err, HHESoK.ErrKeyEpoch), errors.Is(err, HHESoK.ErrKeyLength), errors.Is(err, HHESoK.ErrInvalidParameters),
+		errors.Is(err, HHESoK.ErrSlotCapacity), errors.Is(err, HHESoK.ErrInsufficientLevel), errors.Is(err, HHESoK.ErrMissingGaloisKey),
+		errors.Is(err, HHESoK.ErrCiphertextLength):
+		status = http.StatusBadRequest
+	}
+	w.Header().Del("Trailer")
+	http.Error(w, err.Error(), status)
+}
+
+// WriteFrame writes the length of data then data
+func WriteFrame(w io.Writer, data []byte) error {
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(data)))
+	if _, err := w.Write(length[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// ReadFrame reads a frame written by WriteFrame, it returns io.EOF if r ends before the frame.
+// The frame is read as it arrives, the length header alone does not allocate the frame
+func ReadFrame(r io.Reader) ([]byte, error) {
+	var length [8]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, wire.ErrFormat
+		}
+		return nil, err
+	}
+	n := binary.BigEndian.Uint64(length[:])
+	if n > maxFrame {
+		return nil, fmt.Errorf("%w: frame of %d bytes, at most %d", wire.ErrFormat, n, maxFrame)
+	}
+	data := new(bytes.Buffer)
+	if _, err := io.CopyN(data, r, int64(n)); err != nil {
+		if err == io.EOF {
+			return nil, wire.ErrFormat
+		}
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"HHESoK"
+	"HHESoK/hhe/hera"
+	"HHESoK/hhe/pasta"
+	"HHESoK/hhe/rtf"
+	"HHESoK/hhe/wire"
+	"HHESoK/rtf_ckks_integration/utils"
+	symHera "HHESoK/sym/hera"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestService runs a HERA session on the RtF test ring against a localhost server that
+// evaluates x -> 0.5*x + 0.25 on the transciphered data
+func TestService(t *testing.T) {
+	const a, b = 0.5, 0.25
+	handler := NewHandler(rtf.Affine(a, b))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	tc := symHera.TestVector[4+symHera.HR128AS]
+	lit := rtf.TestParams(rtf.HeraParams[tc.FVParamIndex], 11)
+	lit.PlainModulus = tc.Params.GetModulus()
+	params, err := rtf.NewParametersFromLiteral(lit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modDown := rtf.HeraModDown128[tc.FVParamIndex]
+	modDown.StCModDown = make([]int, params.SlotsToCoeffsDepth(tc.Radix))
+	heraClient, err := hera.NewClientFromLiteral(lit, tc.Params, modDown, tc.Radix, tc.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient(server.URL, "client-1")
+	if err = client.SetEvaluationKeys(nil); !IsNotFound(err) {
+		t.Fatalf("SetEvaluationKeys before Open: got %v, want a not found error", err)
+	}
+	mustArtifact := func(data []byte, err error) []byte {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	if err = client.Open(mustArtifact(heraClient.MarshalParameters())); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.SetEvaluationKeys(mustArtifact(heraClient.GenEvaluationKeys())); err != nil {
+		t.Fatal(err)
+	}
+	symKey := mustArtifact(heraClient.EncryptSymKey())
+	if err = client.SetSymKeyCiphertext(symKey); err != nil {
+		t.Fatal(err)
+	}
+	var statusErr *StatusError
+	if err = client.SetSymKeyCiphertext(symKey); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("SetSymKeyCiphertext of a registered key: got %v, want a bad request", err)
+	}
+
+	data := make([][][]float64, 2)
+	records := make([][]byte, len(data))
+	for r := range data {
+		data[r] = make([][]float64, tc.Params.BlockSize)
+		for s := range data[r] {
+			data[r][s] = make([]float64, heraClient.FVSlots())
+			for i := range data[r][s] {
+				data[r][s][i] = utils.RandFloat64(-1, 1)
+			}
+		}
+		records[r] = mustArtifact(heraClient.EncryptData(heraClient.NewNonce(), data[r]))
+	}
+	results, err := client.Transcipher(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r, res := range results {
+		values, err := heraClient.DecryptResult(res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for s := range data[r] {
+			for i, x := range data[r][s] {
+				if math.Abs(a*x+b-values[s][i]) > 1e-3 {
+					t.Fatalf("record %d, state element %d, position %d: got %f, want %f", r, s, i, values[s][i], a*x+b)
+				}
+			}
+		}
+	}
+
+	if _, err = client.Transcipher([][]byte{records[0][:10]}); err == nil {
+		t.Fatal("Transcipher of a truncated record: got no error")
+	}
+	if err = client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if handler.Sessions() != 0 {
+		t.Fatalf("got %d sessions after Close, want 0", handler.Sessions())
+	}
+}
+
+// TestServicePasta runs a PASTA session on the smallest preset, the server of a handler with
+// a computation refuses the session
+func TestServicePasta(t *testing.T) {
+	set := pasta.Presets()[0]
+	key := make(HHESoK.Key, set.SymParams.KeySize)
+	for i := range key {
+		key[i] = uint64(i*7919+1) % set.SymParams.GetModulus()
+	}
+	pastaClient, err := pasta.NewClient(set.Params, set.SymParams, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustArtifact := func(data []byte, err error) []byte {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	params := mustArtifact(pastaClient.MarshalParameters())
+
+	if _, err = Schemes[pasta.Scheme](params, rtf.Affine(0.5, 0.25)); !errors.Is(err, ErrComputation) {
+		t.Fatalf("PASTA session with a computation: got %v, want %v", err, ErrComputation)
+	}
+
+	handler := NewHandler(nil)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := NewClient(server.URL, "client-1")
+	if err = client.Open(params); err != nil {
+		t.Fatal(err)
+	}
+
+	// a full block and a partial one per record
+	size := 2*set.SymParams.GetBlockSize() - 1
+	if err = client.SetEvaluationKeys(mustArtifact(pastaClient.GenEvaluationKeys(size))); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.SetSymKeyCiphertext(mustArtifact(pastaClient.EncryptSymKey())); err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]HHESoK.Plaintext, 2)
+	records := make([][]byte, len(data))
+	for r := range data {
+		data[r] = make(HHESoK.Plaintext, size)
+		for i := range data[r] {
+			data[r][i] = uint64(r*size+i) % set.SymParams.GetModulus()
+		}
+		records[r] = mustArtifact(pastaClient.EncryptData(HHESoK.NewNonce(), data[r]))
+	}
+	results, err := client.Transcipher(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(records) {
+		t.Fatalf("got %d results, want %d", len(results), len(records))
+	}
+	for r, res := range results {
+		values, err := pastaClient.DecryptResult(res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(values) != len(data[r]) {
+			t.Fatalf("record %d: got %d elements, want %d", r, len(values), len(data[r]))
+		}
+		for i := range values {
+			if values[i] != data[r][i] {
+				t.Fatalf("record %d, element %d: got %d, want %d", r, i, values[i], data[r][i])
+			}
+		}
+	}
+
+	if err = client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if handler.Sessions() != 0 {
+		t.Fatalf("got %d sessions after Close, want 0", handler.Sessions())
+	}
+}
+
+// TestServiceLimits sends an oversized frame length and oversized bodies to the handler, they
+// are rejected without reading or allocating the announced lengths
+func TestServiceLimits(t *testing.T) {
+	// a length header above maxFrame, or without the announced bytes, is malformed
+	for _, n := range []uint64{math.MaxUint64, 1 << 32, maxFrame + 1, maxFrame} {
+		var frame [8 + 16]byte
+		binary.BigEndian.PutUint64(frame[:8], n)
+		if _, err := ReadFrame(bytes.NewReader(frame[:])); !errors.Is(err, wire.ErrFormat) {
+			t.Fatalf("frame of length %d: got %v, want %v", n, err, wire.ErrFormat)
+		}
+	}
+
+	set := pasta.Presets()[0]
+	pastaClient, err := pasta.NewClient(set.Params, set.SymParams, make(HHESoK.Key, set.SymParams.KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := pastaClient.MarshalParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewHandler(nil)
+	handler.SetLimits(Limits{Parameters: int64(len(params)), EvaluationKeys: 1 << 10, SymKey: 1 << 10, Records: 1 << 10})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := NewClient(server.URL, "client-1")
+
+	wantStatus := func(name string, err error, status int) {
+		t.Helper()
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != status {
+			t.Fatalf("%s: got %v, want status %d", name, err, status)
+		}
+	}
+	wantStatus("oversized parameters", client.Open(append(params, 0)), http.StatusRequestEntityTooLarge)
+	if err = client.Open(params); err != nil {
+		t.Fatal(err)
+	}
+	wantStatus("oversized evaluation keys", client.SetEvaluationKeys(make([]byte, 1<<10+1)), http.StatusRequestEntityTooLarge)
+	wantStatus("oversized key", client.SetSymKeyCiphertext(make([]byte, 1<<10+1)), http.StatusRequestEntityTooLarge)
+
+	_, err = client.Transcipher([][]byte{make([]byte, 1<<10)})
+	wantStatus("oversized records", err, http.StatusRequestEntityTooLarge)
+	var header [8]byte
+	binary.BigEndian.PutUint64(header[:], math.MaxUint64)
+	resp, err := http.Post(server.URL+"/v1/sessions/client-1/records", "application/octet-stream", bytes.NewReader(header[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("oversized frame length: got status %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,299 @@
+// Package wire is the binary encoding of the artifacts exchanged by the clients and the
+// servers of the HHE pipelines. An artifact is a header, the magic "HHES", the format
+// version, the kind of the artifact and the name of the scheme, followed by a body of
+// big endian integers and length-prefixed byte strings.
+package wire
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/tuneinsight/lattigo/v6/core/rlwe"
+)
+
+// Version is the version of the artifact format written by Seal
+const Version uint8 = 1
+
+var magic = []byte("HHES")
+
+// maxLength bounds the length prefixes read by the Decoder, so that a corrupted artifact
+// cannot request huge allocations
+const maxLength = 1 << 32
+
+// Kind is the type of an artifact
+type Kind uint8
+
+const (
+	KindParameters Kind = iota + 1
+	KindEvaluationKeys
+	KindSymKeyCiphertext
+	KindSymCiphertext
+	KindResult
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindParameters:
+		return "Parameters"
+	case KindEvaluationKeys:
+		return "EvaluationKeys"
+	case KindSymKeyCiphertext:
+		return "SymKeyCiphertext"
+	case KindSymCiphertext:
+		return "SymCiphertext"
+	case KindResult:
+		return "Result"
+	}
+	return fmt.Sprintf("Kind(%d)", uint8(k))
+}
+
+var (
+	// ErrFormat is returned for data that is not a well-formed artifact
+	ErrFormat = errors.New("wire: malformed artifact")
+	// ErrVersion is returned for artifacts of another format version
+	ErrVersion = errors.New("wire: unsupported version")
+	// ErrKind is returned for artifacts of another kind or scheme than expected
+	ErrKind = errors.New("wire: unexpected artifact")
+)
+
+// Seal prepends the header of an artifact of the given scheme and kind to body
+func Seal(scheme string, kind Kind, body []byte) []byte {
+	data := make([]byte, 0, len(magic)+3+len(scheme)+len(body))
+	data = append(data, magic...)
+	data = append(data, Version, byte(kind), byte(len(scheme)))
+	data = append(data, scheme...)
+	return append(data, body...)
+}
+
+// Peek checks the header of data and returns the scheme and the kind of the artifact
+func Peek(data []byte) (scheme string, kind Kind, err error) {
+	scheme, kind, _, err = parse(data)
+	return
+}
+
+// Open checks the header of data and returns the body of the artifact
+func Open(data []byte, scheme string, kind Kind) (body []byte, err error) {
+	gotScheme, gotKind, body, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if gotScheme != scheme || gotKind != kind {
+		return nil, fmt.Errorf("%w: %s %v, want %s %v", ErrKind, gotScheme, gotKind, scheme, kind)
+	}
+	return body, nil
+}
+
+// parse splits data into the fields of the header and the body
+func parse(data []byte) (scheme string, kind Kind, body []byte, err error) {
+	if len(data) < len(magic)+3 || !bytes.Equal(data[:len(magic)], magic) {
+		return "", 0, nil, ErrFormat
+	}
+	data = data[len(magic):]
+	if data[0] != Version {
+		return "", 0, nil, fmt.Errorf("%w %d", ErrVersion, data[0])
+	}
+	kind, n := Kind(data[1]), int(data[2])
+	data = data[3:]
+	if len(data) < n {
+		return "", 0, nil, ErrFormat
+	}
+	return string(data[:n]), kind, data[n:], nil
+}
+
+// Encoder writes the body of an artifact
+type Encoder struct {
+	buf bytes.Buffer
+	err error
+}
+
+// Bytes returns the body written so far and the first error of PutObject
+func (e *Encoder) Bytes() ([]byte, error) {
+	return e.buf.Bytes(), e.err
+}
+
+func (e *Encoder) PutUint64(v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	e.buf.Write(b[:])
+}
+
+func (e *Encoder) PutInt(v int) {
+	e.PutUint64(uint64(int64(v)))
+}
+
+func (e *Encoder) PutFloat64(v float64) {
+	e.PutUint64(math.Float64bits(v))
+}
+
+func (e *Encoder) PutBool(v bool) {
+	if v {
+		e.buf.WriteByte(1)
+	} else {
+		e.buf.WriteByte(0)
+	}
+}
+
+// PutBytes writes the length of b then b
+func (e *Encoder) PutBytes(b []byte) {
+	e.PutUint64(uint64(len(b)))
+	e.buf.Write(b)
+}
+
+func (e *Encoder) PutUint64s(v []uint64) {
+	e.PutUint64(uint64(len(v)))
+	for _, x := range v {
+		e.PutUint64(x)
+	}
+}
+
+func (e *Encoder) PutInts(v []int) {
+	e.PutUint64(uint64(len(v)))
+	for _, x := range v {
+		e.PutInt(x)
+	}
+}
+
+// PutObject writes the binary encoding of o as a byte string
+func (e *Encoder) PutObject(o encoding.BinaryMarshaler) {
+	if e.err != nil {
+		return
+	}
+	b, err := o.MarshalBinary()
+	if err != nil {
+		e.err = err
+		return
+	}
+	e.PutBytes(b)
+}
+
+// PutCiphertexts writes the number of ciphertexts then every ciphertext with PutObject
+func (e *Encoder) PutCiphertexts(cts []*rlwe.Ciphertext) {
+	e.PutUint64(uint64(len(cts)))
+	for _, ct := range cts {
+		e.PutObject(ct)
+	}
+}
+
+// Decoder reads the body of an artifact, after the first error every read returns
+// the zero value and Err reports the error
+type Decoder struct {
+	data []byte
+	err  error
+}
+
+func NewDecoder(body []byte) *Decoder {
+	return &Decoder{data: body}
+}
+
+// Err returns the first error of the reads
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+// Finish returns the first error of the reads, or ErrFormat if the body is not fully read
+func (d *Decoder) Finish() error {
+	if d.err == nil && len(d.data) != 0 {
+		d.err = ErrFormat
+	}
+	return d.err
+}
+
+func (d *Decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.data) < n {
+		d.err = ErrFormat
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *Decoder) Uint64() uint64 {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (d *Decoder) Int() int {
+	return int(int64(d.Uint64()))
+}
+
+func (d *Decoder) Float64() float64 {
+	return math.Float64frombits(d.Uint64())
+}
+
+func (d *Decoder) Bool() bool {
+	b := d.next(1)
+	if b == nil {
+		return false
+	}
+	if b[0] > 1 {
+		d.err = ErrFormat
+	}
+	return b[0] == 1
+}
+
+// length reads a length prefix of elements of size bytes each
+func (d *Decoder) length(size int) int {
+	n := d.Uint64()
+	if d.err == nil && (n > maxLength || n*uint64(size) > uint64(len(d.data))) {
+		d.err = ErrFormat
+		return 0
+	}
+	return int(n)
+}
+
+func (d *Decoder) Bytes() []byte {
+	b := d.next(d.length(1))
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func (d *Decoder) Uint64s() []uint64 {
+	v := make([]uint64, d.length(8))
+	for i := range v {
+		v[i] = d.Uint64()
+	}
+	return v
+}
+
+func (d *Decoder) Ints() []int {
+	v := make([]int, d.length(8))
+	for i := range v {
+		v[i] = d.Int()
+	}
+	return v
+}
+
+// Object reads a byte string written by PutObject into o
+func (d *Decoder) Object(o encoding.BinaryUnmarshaler) {
+	b := d.next(d.length(1))
+	if b == nil {
+		return
+	}
+	if err := o.UnmarshalBinary(b); err != nil {
+		d.err = fmt.Errorf("%w: %w", ErrFormat, err)
+	}
+}
+
+// Ciphertexts reads the ciphertexts written by PutCiphertexts
+func (d *Decoder) Ciphertexts() []*rlwe.Ciphertext {
+	// a ciphertext takes at least its length prefix
+	cts := make([]*rlwe.Ciphertext, d.length(8))
+	for i := range cts {
+		cts[i] = new(rlwe.Ciphertext)
+		d.Object(cts[i])
+	}
+	return cts
+}
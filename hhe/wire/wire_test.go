@@ -0,0 +1,73 @@
+package wire
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var enc Encoder
+	enc.PutUint64(1<<63 + 5)
+	enc.PutInt(-7)
+	enc.PutFloat64(0.25)
+	enc.PutBool(true)
+	enc.PutBytes([]byte("nonce"))
+	enc.PutUint64s([]uint64{1, 2, 3})
+	enc.PutInts([]int{-1, 0, 1})
+	body, err := enc.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := Seal("hera", KindSymCiphertext, body)
+	if body, err = Open(data, "hera", KindSymCiphertext); err != nil {
+		t.Fatal(err)
+	}
+	dec := NewDecoder(body)
+	got := []interface{}{dec.Uint64(), dec.Int(), dec.Float64(), dec.Bool(), dec.Bytes(), dec.Uint64s(), dec.Ints()}
+	want := []interface{}{uint64(1<<63 + 5), -7, 0.25, true, []byte("nonce"), []uint64{1, 2, 3}, []int{-1, 0, 1}}
+	if err = dec.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	data := Seal("pasta", KindResult, []byte{1, 2, 3})
+	if scheme, kind, err := Peek(data); err != nil || scheme != "pasta" || kind != KindResult {
+		t.Fatalf("Peek: got %s %v %v", scheme, kind, err)
+	}
+
+	if _, err := Open(data, "hera", KindResult); !errors.Is(err, ErrKind) {
+		t.Fatalf("other scheme: got %v, want %v", err, ErrKind)
+	}
+	if _, err := Open(data, "pasta", KindParameters); !errors.Is(err, ErrKind) {
+		t.Fatalf("other kind: got %v, want %v", err, ErrKind)
+	}
+
+	future := append([]byte{}, data...)
+	future[len(magic)] = Version + 1
+	if _, err := Open(future, "pasta", KindResult); !errors.Is(err, ErrVersion) {
+		t.Fatalf("other version: got %v, want %v", err, ErrVersion)
+	}
+	if _, err := Open(data[:5], "pasta", KindResult); !errors.Is(err, ErrFormat) {
+		t.Fatalf("truncated header: got %v, want %v", err, ErrFormat)
+	}
+
+	var enc Encoder
+	enc.PutUint64s([]uint64{1, 2, 3})
+	body, _ := enc.Bytes()
+	dec := NewDecoder(body[:len(body)-1])
+	_ = dec.Uint64s()
+	if err := dec.Finish(); !errors.Is(err, ErrFormat) {
+		t.Fatalf("truncated body: got %v, want %v", err, ErrFormat)
+	}
+	dec = NewDecoder(append(body, 0))
+	_ = dec.Uint64s()
+	if err := dec.Finish(); !errors.Is(err, ErrFormat) {
+		t.Fatalf("trailing bytes: got %v, want %v", err, ErrFormat)
+	}
+}
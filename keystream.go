@@ -0,0 +1,68 @@
+package HHESoK
+
+// KeyStreamGenerator fills large key stream buffers on a pool of workers, every worker
+// owns a ShallowCopy of the cipher and block b is always KeyStream(nonce, CounterBytes(counter+b)),
+// so the output is identical to the sequential CTR mode of the encryptors. The key stream of a
+// NoisyCipher such as Rubato is its noiseless part, the noise is sampled fresh by Encrypt
+type KeyStreamGenerator struct {
+	cipher  SymmetricCipher
+	workers int
+}
+
+// NewKeyStreamGenerator returns a generator for the given cipher, workers <= 0 uses one worker per CPU
+func NewKeyStreamGenerator(cipher SymmetricCipher, workers int) *KeyStreamGenerator {
+	return &KeyStreamGenerator{cipher: cipher, workers: Workers(workers)}
+}
+
+// Workers returns the size of the worker pool
+func (gen *KeyStreamGenerator) Workers() int {
+	return gen.workers
+}
+
+// KeyStream returns size key stream elements starting at block counter, the last block is truncated
+func (gen *KeyStreamGenerator) KeyStream(nonce []byte, counter uint64, size int) Block {
+	ks := make(Block, size)
+	gen.Fill(ks, nonce, counter)
+	return ks
+}
+
+// Fill overwrites buf with the key stream starting at block counter, the blocks are split
+// into contiguous ranges, one per worker
+func (gen *KeyStreamGenerator) Fill(buf Block, nonce []byte, counter uint64) {
+	ksSize := gen.cipher.GetKeyStreamSize()
+	numBlock := (len(buf) + ksSize - 1) / ksSize
+	ParallelRange(numBlock, gen.workers, func(first, last int) {
+		cipher := gen.cipher.ShallowCopy()
+		for b := first; b < last; b++ {
+			ks := cipher.KeyStream(nonce, CounterBytes(counter+uint64(b)))
+			end := (b + 1) * ksSize
+			if end > len(buf) {
+				end = len(buf)
+			}
+			copy(buf[b*ksSize:end], ks)
+		}
+	})
+}
+
+// Encrypt adds the parallel key stream to plaintext, it matches Encryptor.EncryptWithCounter
+func (gen *KeyStreamGenerator) Encrypt(nonce []byte, counter uint64, plaintext Plaintext) Ciphertext {
+	modulus := gen.cipher.GetModulus()
+	ciphertext := Ciphertext(gen.KeyStream(nonce, counter, len(plaintext)))
+	for i := range ciphertext {
+		ciphertext[i] = (ciphertext[i] + plaintext[i]) % modulus
+	}
+	if noisy, ok := gen.cipher.(NoisyCipher); ok {
+		noisy.AddNoise(ciphertext)
+	}
+	return ciphertext
+}
+
+// Decrypt subtracts the parallel key stream from ciphertext, it matches Encryptor.DecryptWithCounter
+func (gen *KeyStreamGenerator) Decrypt(nonce []byte, counter uint64, ciphertext Ciphertext) Plaintext {
+	modulus := gen.cipher.GetModulus()
+	plaintext := Plaintext(gen.KeyStream(nonce, counter, len(ciphertext)))
+	for i := range plaintext {
+		plaintext[i] = (ciphertext[i] + modulus - plaintext[i]) % modulus
+	}
+	return plaintext
+}
@@ -0,0 +1,23 @@
+package HHESoK
+
+import "fmt"
+
+// CheckModDown checks the mod-down of the key stream of a cipher with the given rounds,
+// modDown must have rounds+1 entries starting with the nbInitModDown of the states and must
+// not drop more than the maxLevel moduli of the fresh ciphertexts
+func CheckModDown(modDown []int, rounds, nbInitModDown, maxLevel int) error {
+	if len(modDown) != rounds+1 {
+		return fmt.Errorf("%w: mod-down of %d entries, want %d", ErrInvalidParameters, len(modDown), rounds+1)
+	}
+	if modDown[0] != nbInitModDown {
+		return fmt.Errorf("%w: nbInitModDown expected %d but %d given", ErrInvalidParameters, nbInitModDown, modDown[0])
+	}
+	dropped := 0
+	for _, n := range modDown {
+		dropped += n
+	}
+	if dropped > maxLevel {
+		return fmt.Errorf("%w: the mod-down drops %d moduli, only %d available", ErrInsufficientLevel, dropped, maxLevel)
+	}
+	return nil
+}
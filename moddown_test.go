@@ -0,0 +1,31 @@
+package HHESoK
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckModDown(t *testing.T) {
+	tests := []struct {
+		name    string
+		modDown []int
+		want    error
+	}{
+		{"valid", []int{2, 1, 1, 0}, nil},
+		{"all moduli", []int{2, 2, 2, 0}, nil},
+		{"entries", []int{2, 1, 1}, ErrInvalidParameters},
+		{"init", []int{1, 1, 1, 0}, ErrInvalidParameters},
+		{"level", []int{2, 2, 2, 1}, ErrInsufficientLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckModDown(tt.modDown, 3, 2, 6)
+			if tt.want == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
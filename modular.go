@@ -0,0 +1,98 @@
+package HHESoK
+
+import (
+	"HHESoK/rtf_ckks_integration/ring"
+	"fmt"
+	"math/bits"
+)
+
+// bredMaxBits is the largest modulus size supported by the Barrett and Montgomery
+// helpers of the ring package, larger moduli fall back to a 128-bit division
+const bredMaxBits = 61
+
+// Modulus is a word-sized prime field Z_q with q < 2^64, it precomputes the Barrett and
+// Montgomery constants of the ring package so that the field operations never allocate
+type Modulus struct {
+	q     uint64
+	bred  []uint64
+	mred  uint64
+	small bool
+}
+
+// NewModulus returns the field arithmetic for the modulus q, it fails with
+// ErrInvalidParameters for q < 2
+func NewModulus(q uint64) (Modulus, error) {
+	if q < 2 {
+		return Modulus{}, fmt.Errorf("%w: modulus %d, want at least 2", ErrInvalidParameters, q)
+	}
+	m := Modulus{q: q, small: bits.Len64(q) <= bredMaxBits}
+	if m.small {
+		m.bred = ring.BRedParams(q)
+		m.mred = ring.MRedParams(q)
+	}
+	return m, nil
+}
+
+// MustNewModulus is NewModulus panicking on an invalid modulus
+func MustNewModulus(q uint64) Modulus {
+	m, err := NewModulus(q)
+	HandleError(err)
+	return m
+}
+
+// Q returns the modulus
+func (m Modulus) Q() uint64 {
+	return m.q
+}
+
+// Reduce returns a mod q for any a < 2^64
+func (m Modulus) Reduce(a uint64) uint64 {
+	if m.small {
+		return ring.BRedAdd(a, m.q, m.bred)
+	}
+	return a % m.q
+}
+
+// Add returns a + b mod q for a, b < q
+func (m Modulus) Add(a, b uint64) uint64 {
+	s, carry := bits.Add64(a, b, 0)
+	if carry != 0 || s >= m.q {
+		s -= m.q
+	}
+	return s
+}
+
+// Sub returns a - b mod q for a, b < q
+func (m Modulus) Sub(a, b uint64) uint64 {
+	if a >= b {
+		return a - b
+	}
+	return a + (m.q - b)
+}
+
+// Mul returns a * b mod q for a, b < q
+func (m Modulus) Mul(a, b uint64) uint64 {
+	if m.small {
+		return ring.BRed(a, b, m.q, m.bred)
+	}
+	hi, lo := bits.Mul64(a, b)
+	_, r := bits.Div64(hi, lo, m.q)
+	return r
+}
+
+// MForm switches a < q to the Montgomery domain, it is meant for constants that
+// multiply many field elements through MulMForm
+func (m Modulus) MForm(a uint64) uint64 {
+	if m.small {
+		return ring.MForm(a, m.q, m.bred)
+	}
+	return a
+}
+
+// MulMForm returns a * b mod q where b is in the Montgomery domain and a is not
+func (m Modulus) MulMForm(a, bMForm uint64) uint64 {
+	if m.small {
+		return ring.MRed(a, bMForm, m.q, m.mred)
+	}
+	return m.Mul(a, bMForm)
+}
@@ -0,0 +1,81 @@
+package HHESoK
+
+import (
+	"HHESoK/rtf_ckks_integration/utils"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testModuli covers the PASTA, HERA and Rubato plaintext moduli and the 128-bit division fallback
+var testModuli = []uint64{
+	65537,
+	33292289,
+	268042241,
+	8088322049,
+	1096486890805657601,
+	0x1fffffffffffffff, // 61 bits, largest Barrett modulus
+	0xffffffffffffffc5, // largest 64-bit prime
+}
+
+func TestModulus(t *testing.T) {
+	for _, q := range testModuli {
+		m := MustNewModulus(q)
+		bigQ := new(big.Int).SetUint64(q)
+		values := []uint64{0, 1, q - 1, q - 2}
+		for i := 0; i < 1000; i++ {
+			values = append(values, utils.RandUint64()%q)
+		}
+
+		t.Run(fmt.Sprintf("Modulus=%d", q), func(t *testing.T) {
+			for i := 0; i+1 < len(values); i++ {
+				a, b := values[i], values[i+1]
+				bigA, bigB := new(big.Int).SetUint64(a), new(big.Int).SetUint64(b)
+
+				want := new(big.Int).Add(bigA, bigB)
+				assert.Equal(t, want.Mod(want, bigQ).Uint64(), m.Add(a, b))
+				want.Sub(bigA, bigB)
+				assert.Equal(t, want.Mod(want, bigQ).Uint64(), m.Sub(a, b))
+				want.Mul(bigA, bigB)
+				want.Mod(want, bigQ)
+				assert.Equal(t, want.Uint64(), m.Mul(a, b))
+				assert.Equal(t, want.Uint64(), m.MulMForm(a, m.MForm(b)))
+				r := utils.RandUint64()
+				assert.Equal(t, r%q, m.Reduce(r))
+			}
+		})
+	}
+}
+
+// TestNewModulusErrors checks that moduli below 2 are rejected with ErrInvalidParameters
+func TestNewModulusErrors(t *testing.T) {
+	for _, q := range []uint64{0, 1} {
+		if _, err := NewModulus(q); !errors.Is(err, ErrInvalidParameters) {
+			t.Fatalf("NewModulus(%d): got %v, want %v", q, err, ErrInvalidParameters)
+		}
+	}
+}
+
+func BenchmarkModulus(b *testing.B) {
+	for _, q := range testModuli {
+		m := MustNewModulus(q)
+		x, y := utils.RandUint64()%q, utils.RandUint64()%q
+
+		b.Run(fmt.Sprintf("Mul/Modulus=%d", q), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				x = m.Mul(x, y)
+			}
+		})
+
+		b.Run(fmt.Sprintf("BigIntMul/Modulus=%d", q), func(b *testing.B) {
+			bigQ := new(big.Int).SetUint64(q)
+			for i := 0; i < b.N; i++ {
+				z := new(big.Int).Mul(new(big.Int).SetUint64(x), new(big.Int).SetUint64(y))
+				x = z.Mod(z, bigQ).Uint64()
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package HHESoK
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// NonceSize is the size in bytes of the nonces generated for the symmetric encryptors
+const NonceSize = 8
+
+// defaultNonce is the fixed nonce used by Encryptor.Encrypt and Encryptor.Decrypt
+const defaultNonce = 123456789
+
+// DefaultNonce returns the fixed nonce used by Encrypt and Decrypt, it must
+// not be used for more than one message per key
+func DefaultNonce() []byte {
+	nonce := make([]byte, NonceSize)
+	binary.BigEndian.PutUint64(nonce, defaultNonce)
+	return nonce
+}
+
+// NewNonce samples a fresh random nonce of NonceSize bytes
+func NewNonce() []byte {
+	nonce := make([]byte, NonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		panic("Failed to sample a random nonce!")
+	}
+	return nonce
+}
+
+// CounterBytes returns the 8-byte big endian encoding of a block counter
+func CounterBytes(counter uint64) []byte {
+	ctr := make([]byte, 8)
+	binary.BigEndian.PutUint64(ctr, counter)
+	return ctr
+}
+
+// nonceBytesPerElement returns the number of nonce bytes packed into a field element,
+// it is zero for moduli below 256 which carry the nonce bit by bit
+func nonceBytesPerElement(modulus uint64) int {
+	return (bits.Len64(modulus) - 1) / 8
+}
+
+// nonceBitsPerElement returns the number of nonce bits packed into a field element of a small modulus,
+// it fails with ErrInvalidParameters for moduli below 2 which cannot carry a bit
+func nonceBitsPerElement(modulus uint64) (int, error) {
+	n := bits.Len64(modulus) - 1
+	if n <= 0 {
+		return 0, fmt.Errorf("%w: modulus %d is too small to carry a nonce", ErrInvalidParameters, modulus)
+	}
+	return n, nil
+}
+
+// NonceElements returns the number of field elements used to carry a NonceSize nonce
+func NonceElements(modulus uint64) (int, error) {
+	if n := nonceBytesPerElement(modulus); n > 0 {
+		return (NonceSize + n - 1) / n, nil
+	}
+	n, err := nonceBitsPerElement(modulus)
+	if err != nil {
+		return 0, err
+	}
+	return (8*NonceSize + n - 1) / n, nil
+}
+
+// NonceToElements packs the nonce into field elements smaller than the modulus
+func NonceToElements(nonce []byte, modulus uint64) ([]uint64, error) {
+	size, err := NonceElements(modulus)
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]uint64, size)
+	if n := nonceBytesPerElement(modulus); n > 0 {
+		for i := range elements {
+			for j := 0; j < n && i*n+j < len(nonce); j++ {
+				elements[i] |= uint64(nonce[i*n+j]) << (8 * j)
+			}
+		}
+		return elements, nil
+	}
+	n, _ := nonceBitsPerElement(modulus)
+	for k := 0; k < 8*NonceSize && k/8 < len(nonce); k++ {
+		elements[k/n] |= uint64(nonce[k/8]>>(k%8)&1) << (k % n)
+	}
+	return elements, nil
+}
+
+// ElementsToNonce unpacks a NonceSize nonce from the field elements produced by NonceToElements,
+// it fails with ErrCiphertextLength when there are fewer than NonceElements elements
+func ElementsToNonce(elements []uint64, modulus uint64) ([]byte, error) {
+	size, err := NonceElements(modulus)
+	if err != nil {
+		return nil, err
+	}
+	if len(elements) < size {
+		return nil, fmt.Errorf("%w: got %d elements, a nonce takes %d", ErrCiphertextLength, len(elements), size)
+	}
+	nonce := make([]byte, NonceSize)
+	if n := nonceBytesPerElement(modulus); n > 0 {
+		for i := range nonce {
+			nonce[i] = byte(elements[i/n] >> (8 * (i % n)))
+		}
+		return nonce, nil
+	}
+	n, _ := nonceBitsPerElement(modulus)
+	for k := 0; k < 8*NonceSize; k++ {
+		nonce[k/8] |= byte(elements[k/n]>>(k%n)&1) << (k % 8)
+	}
+	return nonce, nil
+}
+
+// BlockNonces returns nonce||CounterBytes(counter+i) for numBlock consecutive blocks,
+// these are the per-block nonces of the HHE evaluators: flat block i of a sym
+// ciphertext is masked by keystream[i], i.e. column i of the HHE data matrix
+func BlockNonces(nonce []byte, counter uint64, numBlock int) [][]byte {
+	nonces := make([][]byte, numBlock)
+	for i := range nonces {
+		nonces[i] = append(append(make([]byte, 0, len(nonce)+8), nonce...), CounterBytes(counter+uint64(i))...)
+	}
+	return nonces
+}
@@ -0,0 +1,51 @@
+package HHESoK
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Workers returns the size of a worker pool, workers <= 0 uses one worker per CPU
+func Workers(workers int) int {
+	if workers <= 0 {
+		return runtime.NumCPU()
+	}
+	return workers
+}
+
+// ParallelRange splits [0, n) into contiguous ranges, one per worker, and calls run(first, last)
+// on every range in its own goroutine. It returns when all the ranges are done, a worker that
+// needs private state allocates it at the start of run
+func ParallelRange(n, workers int, run func(first, last int)) {
+	_ = ParallelRangeErr(n, workers, func(first, last int) error {
+		run(first, last)
+		return nil
+	})
+}
+
+// ParallelRangeErr is ParallelRange for a run that can fail, it returns the error of the first
+// failed range in the order of the ranges, the other ranges still run to their end
+func ParallelRangeErr(n, workers int, run func(first, last int) error) error {
+	if workers > n {
+		workers = n
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, max(workers, 0))
+	for w := 0; w < workers; w++ {
+		first := w * n / workers
+		last := (w + 1) * n / workers
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			errs[w] = run(first, last)
+		}(w)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -74,11 +74,11 @@ func (l logger) PrintHeader(header string) {
 	fmt.Println(fmt.Sprintf("=== ----\t\t\t %s \t\t\t---- ===", header))
 }
 
-// HandleError checks the error and throws a fatal log if the error isn't nil
+// HandleError checks the error and panics if the error isn't nil, it backs the Must* wrappers
+// of the error returning APIs
 func HandleError(err error) {
 	if err != nil {
-		fmt.Printf("|-> Error: %s\n", err.Error())
-		panic("=== Panic\n ")
+		panic(err)
 	}
 }
 
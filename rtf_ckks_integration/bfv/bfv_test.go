@@ -860,3 +860,42 @@ func testMarshalRotKey(testctx *testContext, t *testing.T) {
 
 	})
 }
+
+func TestCoeffEncoder(t *testing.T) {
+
+	params := DefaultParams[PN12QP109].Copy()
+
+	for _, plainModulus := range []uint64{2, params.t} {
+
+		p, err := NewParametersFromModuli(params.logN, params.Moduli(), plainModulus)
+		require.NoError(t, err)
+
+		t.Run(testString(fmt.Sprintf("CoeffEncoder/T=%d/", plainModulus), p), func(t *testing.T) {
+
+			kgen := NewKeyGenerator(p)
+			sk := kgen.GenSecretKey()
+			encoder := NewCoeffEncoder(p)
+			encryptor := NewEncryptorFromSk(p, sk)
+			decryptor := NewDecryptor(p, sk)
+
+			ptRt := NewPlaintextRingT(p)
+			for i := range ptRt.value.Coeffs[0] {
+				ptRt.value.Coeffs[0][i] = utils.RandUint64() % plainModulus
+			}
+
+			pt := NewPlaintext(p)
+			encoder.ScaleUp(ptRt, pt)
+
+			have := NewPlaintextRingT(p)
+			encoder.ScaleDown(decryptor.DecryptNew(encryptor.EncryptNew(pt)), have)
+			require.Equal(t, ptRt.value.Coeffs[0], have.value.Coeffs[0])
+		})
+	}
+
+	t.Run("Encoder/T=2/", func(t *testing.T) {
+		p, err := NewParametersFromModuli(params.logN, params.Moduli(), 2)
+		require.NoError(t, err)
+		// t = 2 does not allow the NTT of R_t, the slot encoder must refuse it
+		require.Panics(t, func() { NewEncoder(p) })
+	})
+}
@@ -0,0 +1,55 @@
+package bfv
+
+import (
+	"HHESoK/rtf_ckks_integration/ring"
+)
+
+// CoeffEncoder is an interface for the coefficient embedding of the messages, it scales a PlaintextRingT
+// up to a Plaintext and back without the slot encoding. Unlike the Encoder, it does not need the NTT of
+// R_t and supports any plaintext modulus, e.g. t = 2.
+type CoeffEncoder interface {
+	ScaleUp(*PlaintextRingT, *Plaintext)
+	ScaleDown(pt *Plaintext, ptRt *PlaintextRingT)
+}
+
+// coeffEncoder is a structure that stores the parameters to scale the coefficients of a plaintext.
+type coeffEncoder struct {
+	ringQ     *ring.Ring
+	scaler    ring.Scaler
+	deltaMont []uint64
+}
+
+// NewCoeffEncoder creates a new CoeffEncoder from the provided parameters.
+func NewCoeffEncoder(params *Parameters) CoeffEncoder {
+
+	var ringQ *ring.Ring
+	var err error
+
+	if ringQ, err = ring.NewRing(params.N(), params.qi); err != nil {
+		panic(err)
+	}
+
+	// The RNS scaler reduces modulo t with the Montgomery arithmetic, which needs an odd t.
+	var scaler ring.Scaler
+	if params.t&1 == 1 {
+		scaler = ring.NewRNSScaler(params.t, ringQ)
+	} else {
+		scaler = ring.NewSimpleScaler(params.t, ringQ)
+	}
+
+	return &coeffEncoder{
+		ringQ:     ringQ,
+		scaler:    scaler,
+		deltaMont: GenLiftParams(ringQ, params.t),
+	}
+}
+
+// ScaleUp transforms a PlaintextRingT (R_t) into a Plaintext (R_q) by scaling up the coefficient by Q/t.
+func (encoder *coeffEncoder) ScaleUp(ptRt *PlaintextRingT, pt *Plaintext) {
+	scaleUp(encoder.ringQ, encoder.deltaMont, ptRt.value, pt.value)
+}
+
+// ScaleDown transforms a Plaintext (R_q) into a PlaintextRingT (R_t) by scaling down the coefficient by t/Q and rounding.
+func (encoder *coeffEncoder) ScaleDown(pt *Plaintext, ptRt *PlaintextRingT) {
+	encoder.scaler.DivByQOverTRounded(pt.value, ptRt.value)
+}
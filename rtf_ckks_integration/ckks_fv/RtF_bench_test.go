@@ -256,13 +256,18 @@ func benchmarkRtFHera(b *testing.B, name string, numRound int, paramIndex int, r
 		fvEncoder.FVScaleUp(plainCKKSRingTs[s], plaintexts[s])
 	}
 
-	hera = NewMFVHera(numRound, params, fvEncoder, fvEncryptor, fvEvaluator, heraModDown[0])
-	kCt := hera.EncKey(key)
+	hera = MustNewMFVHera(numRound, params, fvEncoder, fvEncryptor, fvEvaluator, heraModDown[0])
+	kCt, err := hera.EncKey(key)
+	if err != nil {
+		panic(err)
+	}
 
 	// FV Keystream
 	benchOffLat := fmt.Sprintf("RtF HERA Offline Latency")
 	b.Run(benchOffLat, func(b *testing.B) {
-		fvKeystreams = hera.Crypt(nonces, kCt, heraModDown)
+		if fvKeystreams, err = hera.Crypt(nonces, kCt, heraModDown); err != nil {
+			panic(err)
+		}
 		for i := 0; i < 1; i++ {
 			fvKeystreams[i] = fvEvaluator.SlotsToCoeffs(fvKeystreams[i], stcModDown)
 			fvEvaluator.ModSwitchMany(fvKeystreams[i], fvKeystreams[i], fvKeystreams[i].Level())
@@ -294,9 +299,13 @@ func benchmarkRtFHera(b *testing.B, name string, numRound int, paramIndex int, r
 		// CAUTION: the scale of the ciphertext MUST be equal (or very close) to params.Scale
 		// To equalize the scale, the function evaluator.SetScale(ciphertext, parameters.Scale) can be used at the expense of one level.
 		if fullCoeffs {
-			ctBoot, _ = hbtp.HalfBoot(ciphertext, false)
+			if ctBoot, _, err = hbtp.HalfBoot(ciphertext, false); err != nil {
+				panic(err)
+			}
 		} else {
-			ctBoot, _ = hbtp.HalfBoot(ciphertext, true)
+			if ctBoot, _, err = hbtp.HalfBoot(ciphertext, true); err != nil {
+				panic(err)
+			}
 		}
 	})
 	valuesWant := make([]complex128, params.Slots())
@@ -436,12 +445,17 @@ func benchmarkRtFRubato(b *testing.B, rubatoParam int) {
 	}
 
 	// FV Keystream
-	rubato = NewMFVRubato(rubatoParam, params, fvEncoder, fvEncryptor, fvEvaluator, rubatoModDown[0])
-	kCt := rubato.EncKey(key)
+	rubato = MustNewMFVRubato(rubatoParam, params, fvEncoder, fvEncryptor, fvEvaluator, rubatoModDown[0])
+	kCt, err := rubato.EncKey(key)
+	if err != nil {
+		panic(err)
+	}
 
 	benchOffLat := fmt.Sprintf("RtF Rubato Offline Latency")
 	b.Run(benchOffLat, func(b *testing.B) {
-		fvKeystreams = rubato.Crypt(nonces, counter, kCt, rubatoModDown)
+		if fvKeystreams, err = rubato.Crypt(nonces, counter, kCt, rubatoModDown); err != nil {
+			panic(err)
+		}
 		for i := 0; i < 1; i++ {
 			fvKeystreams[i] = fvEvaluator.SlotsToCoeffs(fvKeystreams[i], stcModDown)
 			fvEvaluator.ModSwitchMany(fvKeystreams[i], fvKeystreams[i], fvKeystreams[i].Level())
@@ -472,7 +486,9 @@ func benchmarkRtFRubato(b *testing.B, rubatoParam int) {
 		// Difference from the bootstrapping is that the last StC is missing.
 		// CAUTION: the scale of the ciphertext MUST be equal (or very close) to params.Scale
 		// To equalize the scale, the function evaluator.SetScale(ciphertext, parameters.Scale) can be used at the expense of one level.
-		ctBoot, _ = hbtp.HalfBoot(ciphertext, false)
+		if ctBoot, _, err = hbtp.HalfBoot(ciphertext, false); err != nil {
+			panic(err)
+		}
 	})
 	valuesWant := make([]complex128, params.Slots())
 	for i := 0; i < params.Slots(); i++ {
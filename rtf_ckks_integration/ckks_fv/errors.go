@@ -0,0 +1,28 @@
+package ckks_fv
+
+import "errors"
+
+// Errors returned by the MFV ciphers and the half-bootstrapping, the returned errors wrap one
+// of them with the details of the failure and are matched with errors.Is
+var (
+	// ErrInvalidParameters reports parameters that cannot instantiate an MFV cipher or a planner
+	ErrInvalidParameters = errors.New("invalid parameters")
+	// ErrKeyLength reports a key of the wrong number of elements
+	ErrKeyLength = errors.New("invalid key length")
+	// ErrSlotCapacity reports nonces that do not match the FV slots
+	ErrSlotCapacity = errors.New("insufficient slot capacity")
+	// ErrInsufficientLevel reports a ciphertext without enough moduli left for the evaluation
+	ErrInsufficientLevel = errors.New("insufficient level")
+	// ErrScaleMismatch reports a ciphertext whose scale cannot be brought to the one expected
+	// by the half-bootstrapping
+	ErrScaleMismatch = errors.New("scale mismatch")
+	// ErrMissingGaloisKey reports an evaluation that needs a rotation key that was not generated
+	ErrMissingGaloisKey = errors.New("missing Galois key")
+)
+
+// handleError panics if err isn't nil, it backs the Must* wrappers of the error returning APIs
+func handleError(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,42 @@
+package ckks_fv
+
+import "fmt"
+
+// checkInitModDown checks that nbInitModDown moduli can be dropped from the fresh ciphertexts
+func checkInitModDown(params *Parameters, nbInitModDown int) error {
+	if nbInitModDown < 0 || nbInitModDown > params.MaxLevel() {
+		return fmt.Errorf("%w: %d moduli dropped from the states, only %d available", ErrInsufficientLevel,
+			nbInitModDown, params.MaxLevel())
+	}
+	return nil
+}
+
+// checkModDown checks that modDown has numRound+1 entries starting with the nbInitModDown of
+// the states and does not drop more than the moduli of the fresh ciphertexts
+func checkModDown(params *Parameters, modDown []int, numRound, nbInitModDown int) error {
+	if len(modDown) != numRound+1 {
+		return fmt.Errorf("%w: mod-down of %d entries, want %d", ErrInvalidParameters, len(modDown), numRound+1)
+	}
+	if modDown[0] != nbInitModDown {
+		return fmt.Errorf("%w: nbInitModDown expected %d but %d given", ErrInvalidParameters, nbInitModDown, modDown[0])
+	}
+	dropped := 0
+	for _, n := range modDown {
+		dropped += n
+	}
+	if dropped > params.MaxLevel() {
+		return fmt.Errorf("%w: the mod-down drops %d moduli, only %d available", ErrInsufficientLevel, dropped, params.MaxLevel())
+	}
+	return nil
+}
+
+// checkCryptInput checks that there is one nonce per FV slot and one ciphertext per key element
+func checkCryptInput(params *Parameters, nonce [][]byte, kCt []*Ciphertext, keySize int) error {
+	if len(nonce) != params.FVSlots() {
+		return fmt.Errorf("%w: got %d nonces for %d slots", ErrSlotCapacity, len(nonce), params.FVSlots())
+	}
+	if len(kCt) != keySize {
+		return fmt.Errorf("%w: got %d encrypted key elements, want %d", ErrKeyLength, len(kCt), keySize)
+	}
+	return nil
+}
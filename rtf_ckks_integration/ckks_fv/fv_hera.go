@@ -7,11 +7,11 @@ import (
 )
 
 type MFVHera interface {
-	Crypt(nonce [][]byte, kCt []*Ciphertext, heraModDown []int) []*Ciphertext
-	CryptNoModSwitch(nonce [][]byte, kCt []*Ciphertext) []*Ciphertext
-	CryptAutoModSwitch(nonce [][]byte, kCt []*Ciphertext, noiseEstimator MFVNoiseEstimator) (res []*Ciphertext, heraModDown []int)
-	Reset(nbInitModDown int)
-	EncKey(key []uint64) (res []*Ciphertext)
+	Crypt(nonce [][]byte, kCt []*Ciphertext, heraModDown []int) ([]*Ciphertext, error)
+	CryptNoModSwitch(nonce [][]byte, kCt []*Ciphertext) ([]*Ciphertext, error)
+	CryptAutoModSwitch(nonce [][]byte, kCt []*Ciphertext, noiseEstimator MFVNoiseEstimator) (res []*Ciphertext, heraModDown []int, err error)
+	Reset(nbInitModDown int) error
+	EncKey(key []uint64) (res []*Ciphertext, err error)
 }
 
 type mfvHera struct {
@@ -32,7 +32,13 @@ type mfvHera struct {
 	xof  []sha3.ShakeHash
 }
 
-func NewMFVHera(numRound int, params *Parameters, encoder MFVEncoder, encryptor MFVEncryptor, evaluator MFVEvaluator, nbInitModDown int) MFVHera {
+func NewMFVHera(numRound int, params *Parameters, encoder MFVEncoder, encryptor MFVEncryptor, evaluator MFVEvaluator, nbInitModDown int) (MFVHera, error) {
+	if numRound < 1 {
+		return nil, fmt.Errorf("%w: HERA with %d rounds", ErrInvalidParameters, numRound)
+	}
+	if err := checkInitModDown(params, nbInitModDown); err != nil {
+		return nil, err
+	}
 	hera := new(mfvHera)
 
 	hera.numRound = numRound
@@ -73,10 +79,20 @@ func NewMFVHera(numRound int, params *Parameters, encoder MFVEncoder, encryptor
 			evaluator.ModSwitchMany(hera.stCt[i], hera.stCt[i], nbInitModDown)
 		}
 	}
+	return hera, nil
+}
+
+// MustNewMFVHera is NewMFVHera panicking on invalid parameters
+func MustNewMFVHera(numRound int, params *Parameters, encoder MFVEncoder, encryptor MFVEncryptor, evaluator MFVEvaluator, nbInitModDown int) MFVHera {
+	hera, err := NewMFVHera(numRound, params, encoder, encryptor, evaluator, nbInitModDown)
+	handleError(err)
 	return hera
 }
 
-func (hera *mfvHera) Reset(nbInitModDown int) {
+func (hera *mfvHera) Reset(nbInitModDown int) error {
+	if err := checkInitModDown(hera.params, nbInitModDown); err != nil {
+		return err
+	}
 	// Precompute Initial States
 	hera.nbInitModDown = nbInitModDown
 	state := make([]uint64, hera.slots)
@@ -93,6 +109,7 @@ func (hera *mfvHera) Reset(nbInitModDown int) {
 			hera.evaluator.ModSwitchMany(hera.stCt[i], hera.stCt[i], nbInitModDown)
 		}
 	}
+	return nil
 }
 
 // Compute Round Constants
@@ -190,7 +207,10 @@ func (hera *mfvHera) modSwitch(nbSwitch int) {
 }
 
 // CryptNoModSwitch Compute ciphertexts without modulus switching
-func (hera *mfvHera) CryptNoModSwitch(nonce [][]byte, kCt []*Ciphertext) []*Ciphertext {
+func (hera *mfvHera) CryptNoModSwitch(nonce [][]byte, kCt []*Ciphertext) ([]*Ciphertext, error) {
+	if err := checkCryptInput(hera.params, nonce, kCt, 16); err != nil {
+		return nil, err
+	}
 	for st := 0; st < 16; st++ {
 		hera.mkCt[st] = kCt[st].CopyNew().Ciphertext()
 	}
@@ -206,11 +226,14 @@ func (hera *mfvHera) CryptNoModSwitch(nonce [][]byte, kCt []*Ciphertext) []*Ciph
 	hera.cube()
 	hera.linLayer()
 	hera.addRoundKey(hera.numRound, true)
-	return hera.stCt
+	return hera.stCt, nil
 }
 
 // CryptAutoModSwitch Compute ciphertexts with automatic modulus switching
-func (hera *mfvHera) CryptAutoModSwitch(nonce [][]byte, kCt []*Ciphertext, noiseEstimator MFVNoiseEstimator) ([]*Ciphertext, []int) {
+func (hera *mfvHera) CryptAutoModSwitch(nonce [][]byte, kCt []*Ciphertext, noiseEstimator MFVNoiseEstimator) ([]*Ciphertext, []int, error) {
+	if err := checkCryptInput(hera.params, nonce, kCt, 16); err != nil {
+		return nil, nil, err
+	}
 	heraModDown := make([]int, hera.numRound+1)
 	heraModDown[0] = hera.nbInitModDown
 	for st := 0; st < 16; st++ {
@@ -230,14 +253,16 @@ func (hera *mfvHera) CryptAutoModSwitch(nonce [][]byte, kCt []*Ciphertext, noise
 	hera.modSwitchAuto(hera.numRound, noiseEstimator, heraModDown)
 	hera.linLayer()
 	hera.addRoundKey(hera.numRound, true)
-	return hera.stCt, heraModDown
+	return hera.stCt, heraModDown, nil
 }
 
 // Crypt Compute ciphertexts with modulus switching as given in heraModDown
-func (hera *mfvHera) Crypt(nonce [][]byte, kCt []*Ciphertext, heraModDown []int) []*Ciphertext {
-	if heraModDown[0] != hera.nbInitModDown {
-		errorString := fmt.Sprintf("nbInitModDown expected %d but %d given", hera.nbInitModDown, heraModDown[0])
-		panic(errorString)
+func (hera *mfvHera) Crypt(nonce [][]byte, kCt []*Ciphertext, heraModDown []int) ([]*Ciphertext, error) {
+	if err := checkCryptInput(hera.params, nonce, kCt, 16); err != nil {
+		return nil, err
+	}
+	if err := checkModDown(hera.params, heraModDown, hera.numRound, hera.nbInitModDown); err != nil {
+		return nil, err
 	}
 
 	for st := 0; st < 16; st++ {
@@ -257,7 +282,7 @@ func (hera *mfvHera) Crypt(nonce [][]byte, kCt []*Ciphertext, heraModDown []int)
 	hera.modSwitch(heraModDown[hera.numRound])
 	hera.linLayer()
 	hera.addRoundKey(hera.numRound, true)
-	return hera.stCt
+	return hera.stCt, nil
 }
 
 func (hera *mfvHera) addRoundKey(round int, reduce bool) {
@@ -349,7 +374,10 @@ func (hera *mfvHera) cube() {
 	}
 }
 
-func (hera *mfvHera) EncKey(key []uint64) (res []*Ciphertext) {
+func (hera *mfvHera) EncKey(key []uint64) (res []*Ciphertext, err error) {
+	if len(key) != 16 {
+		return nil, fmt.Errorf("%w: got %d elements, want 16", ErrKeyLength, len(key))
+	}
 	slots := hera.slots
 	res = make([]*Ciphertext, 16)
 
@@ -69,11 +69,11 @@ var RubatoParams = []RubatoParam{
 }
 
 type MFVRubato interface {
-	Crypt(nonce [][]byte, counter []byte, kCt []*Ciphertext, rubatoModDown []int) []*Ciphertext
-	CryptNoModSwitch(nonce [][]byte, counter []byte, kCt []*Ciphertext) []*Ciphertext
-	CryptAutoModSwitch(nonce [][]byte, counter []byte, kCt []*Ciphertext, noiseEstimator MFVNoiseEstimator) (res []*Ciphertext, rubatoModDown []int)
-	Reset(nbInitModDown int)
-	EncKey(key []uint64) (res []*Ciphertext)
+	Crypt(nonce [][]byte, counter []byte, kCt []*Ciphertext, rubatoModDown []int) ([]*Ciphertext, error)
+	CryptNoModSwitch(nonce [][]byte, counter []byte, kCt []*Ciphertext) ([]*Ciphertext, error)
+	CryptAutoModSwitch(nonce [][]byte, counter []byte, kCt []*Ciphertext, noiseEstimator MFVNoiseEstimator) (res []*Ciphertext, rubatoModDown []int, err error)
+	Reset(nbInitModDown int) error
+	EncKey(key []uint64) (res []*Ciphertext, err error)
 }
 
 type mfvRubato struct {
@@ -96,7 +96,13 @@ type mfvRubato struct {
 	xof  []sha3.ShakeHash
 }
 
-func NewMFVRubato(rubatoParam int, params *Parameters, encoder MFVEncoder, encryptor MFVEncryptor, evaluator MFVEvaluator, nbInitModDown int) MFVRubato {
+func NewMFVRubato(rubatoParam int, params *Parameters, encoder MFVEncoder, encryptor MFVEncryptor, evaluator MFVEvaluator, nbInitModDown int) (MFVRubato, error) {
+	if rubatoParam < 0 || rubatoParam >= len(RubatoParams) {
+		return nil, fmt.Errorf("%w: Rubato parameter index %d, want < %d", ErrInvalidParameters, rubatoParam, len(RubatoParams))
+	}
+	if err := checkInitModDown(params, nbInitModDown); err != nil {
+		return nil, err
+	}
 	rubato := new(mfvRubato)
 
 	rubato.rubatoParam = rubatoParam
@@ -139,10 +145,20 @@ func NewMFVRubato(rubatoParam int, params *Parameters, encoder MFVEncoder, encry
 			evaluator.ModSwitchMany(rubato.stCt[i], rubato.stCt[i], nbInitModDown)
 		}
 	}
+	return rubato, nil
+}
+
+// MustNewMFVRubato is NewMFVRubato panicking on invalid parameters
+func MustNewMFVRubato(rubatoParam int, params *Parameters, encoder MFVEncoder, encryptor MFVEncryptor, evaluator MFVEvaluator, nbInitModDown int) MFVRubato {
+	rubato, err := NewMFVRubato(rubatoParam, params, encoder, encryptor, evaluator, nbInitModDown)
+	handleError(err)
 	return rubato
 }
 
-func (rubato *mfvRubato) Reset(nbInitModDown int) {
+func (rubato *mfvRubato) Reset(nbInitModDown int) error {
+	if err := checkInitModDown(rubato.params, nbInitModDown); err != nil {
+		return err
+	}
 	// Precompute Initial States
 	rubato.nbInitModDown = nbInitModDown
 	state := make([]uint64, rubato.slots)
@@ -159,6 +175,7 @@ func (rubato *mfvRubato) Reset(nbInitModDown int) {
 			rubato.evaluator.ModSwitchMany(rubato.stCt[i], rubato.stCt[i], nbInitModDown)
 		}
 	}
+	return nil
 }
 
 // Compute Round Constants
@@ -257,7 +274,10 @@ func (rubato *mfvRubato) modSwitch(nbSwitch int) {
 }
 
 // Compute ciphertexts without modulus switching
-func (rubato *mfvRubato) CryptNoModSwitch(nonce [][]byte, counter []byte, kCt []*Ciphertext) []*Ciphertext {
+func (rubato *mfvRubato) CryptNoModSwitch(nonce [][]byte, counter []byte, kCt []*Ciphertext) ([]*Ciphertext, error) {
+	if err := checkCryptInput(rubato.params, nonce, kCt, rubato.blocksize); err != nil {
+		return nil, err
+	}
 	for i := 0; i < rubato.blocksize; i++ {
 		rubato.mkCt[i] = kCt[i].CopyNew().Ciphertext()
 	}
@@ -273,11 +293,14 @@ func (rubato *mfvRubato) CryptNoModSwitch(nonce [][]byte, counter []byte, kCt []
 	rubato.feistel()
 	rubato.finLinLayer()
 	rubato.finAddRoundKey(rubato.blocksize - 4)
-	return rubato.stCt
+	return rubato.stCt, nil
 }
 
 // Compute ciphertexts with automatic modulus switching
-func (rubato *mfvRubato) CryptAutoModSwitch(nonce [][]byte, counter []byte, kCt []*Ciphertext, noiseEstimator MFVNoiseEstimator) ([]*Ciphertext, []int) {
+func (rubato *mfvRubato) CryptAutoModSwitch(nonce [][]byte, counter []byte, kCt []*Ciphertext, noiseEstimator MFVNoiseEstimator) ([]*Ciphertext, []int, error) {
+	if err := checkCryptInput(rubato.params, nonce, kCt, rubato.blocksize); err != nil {
+		return nil, nil, err
+	}
 	rubatoModDown := make([]int, rubato.numRound+1)
 	rubatoModDown[0] = rubato.nbInitModDown
 	for i := 0; i < rubato.blocksize; i++ {
@@ -297,15 +320,17 @@ func (rubato *mfvRubato) CryptAutoModSwitch(nonce [][]byte, counter []byte, kCt
 	rubato.modSwitchAuto(rubato.numRound, noiseEstimator, rubatoModDown)
 	rubato.finLinLayer()
 	rubato.finAddRoundKey(rubato.blocksize - 4)
-	return rubato.stCt, rubatoModDown
+	return rubato.stCt, rubatoModDown, nil
 }
 
 // Crypt compute ciphertexts with modulus switching as given in rubatoModDown
 // using the homomorphically encrypted secret key `kCt`, `nonce`, `counter`
-func (rubato *mfvRubato) Crypt(nonce [][]byte, counter []byte, kCt []*Ciphertext, rubatoModDown []int) []*Ciphertext {
-	if rubatoModDown[0] != rubato.nbInitModDown {
-		errorString := fmt.Sprintf("nbInitModDown expected %d but %d given", rubato.nbInitModDown, rubatoModDown[0])
-		panic(errorString)
+func (rubato *mfvRubato) Crypt(nonce [][]byte, counter []byte, kCt []*Ciphertext, rubatoModDown []int) ([]*Ciphertext, error) {
+	if err := checkCryptInput(rubato.params, nonce, kCt, rubato.blocksize); err != nil {
+		return nil, err
+	}
+	if err := checkModDown(rubato.params, rubatoModDown, rubato.numRound, rubato.nbInitModDown); err != nil {
+		return nil, err
 	}
 
 	for i := 0; i < rubato.blocksize; i++ {
@@ -325,7 +350,7 @@ func (rubato *mfvRubato) Crypt(nonce [][]byte, counter []byte, kCt []*Ciphertext
 	rubato.modSwitch(rubatoModDown[rubato.numRound])
 	rubato.finLinLayer()
 	rubato.finAddRoundKey(rubato.blocksize - 4)
-	return rubato.stCt
+	return rubato.stCt, nil
 }
 
 func (rubato *mfvRubato) addRoundKey(round int, reduce bool) {
@@ -741,7 +766,10 @@ func (rubato *mfvRubato) feistel() {
 	}
 }
 
-func (rubato *mfvRubato) EncKey(key []uint64) (res []*Ciphertext) {
+func (rubato *mfvRubato) EncKey(key []uint64) (res []*Ciphertext, err error) {
+	if len(key) != rubato.blocksize {
+		return nil, fmt.Errorf("%w: got %d elements, want %d", ErrKeyLength, len(key), rubato.blocksize)
+	}
 	slots := rubato.slots
 	res = make([]*Ciphertext, rubato.blocksize)
 
@@ -1,6 +1,7 @@
 package ckks_fv
 
 import (
+	"fmt"
 	"math"
 
 	"HHESoK/rtf_ckks_integration/ring"
@@ -10,7 +11,7 @@ import (
 // If the input ciphertext level is zero, the input scale must be an exact power of two smaller or equal to round(Q0/2^{10}).
 // If the input ciphertext is at level one or more, the input scale does not need to be an exact power of two as one level
 // can be used to do a scale matching.
-func (hbtp *HalfBootstrapper) HalfBoot(ct *Ciphertext, repack bool) (ct0, ct1 *Ciphertext) {
+func (hbtp *HalfBootstrapper) HalfBoot(ct *Ciphertext, repack bool) (ct0, ct1 *Ciphertext, err error) {
 
 	//var t time.Time
 	// var ct0, ct1 *Ciphertext
@@ -41,7 +42,7 @@ func (hbtp *HalfBootstrapper) HalfBoot(ct *Ciphertext, repack bool) (ct0, ct1 *C
 		// and does an integer constant mult by round((Q0/Delta_m)/ctscle)
 
 		if hbtp.prescale < ct.Scale() {
-			panic("ciphetext scale > Q[0]/(Q[0]/Delta_m)")
+			return nil, nil, fmt.Errorf("%w: ciphertext at level 0 with scale > Q[0]/(Q[0]/Delta_m)", ErrScaleMismatch)
 		}
 		hbtp.ckksEvaluator.ScaleUp(ct, math.Round(hbtp.prescale/ct.Scale()), ct)
 	}
@@ -79,22 +80,22 @@ func (hbtp *HalfBootstrapper) HalfBoot(ct *Ciphertext, repack bool) (ct0, ct1 *C
 
 	// Part 3 : Fix scale using diffScaleAfterEvalSine
 	hbtp.ckksEvaluator.MultByConst(ct0, hbtp.diffScaleAfterSineEval, ct0)
-	if err := hbtp.ckksEvaluator.RescaleMany(ct0, 1, ct0); err != nil {
-		panic(err)
+	if err = hbtp.ckksEvaluator.RescaleMany(ct0, 1, ct0); err != nil {
+		return nil, nil, err
 	}
 	// Rounds to the nearest power of two
 	ct0.SetScale(math.Exp2(math.Round(math.Log2(ct0.Scale()))))
 
 	if ct1 != nil {
 		hbtp.ckksEvaluator.MultByConst(ct1, hbtp.diffScaleAfterSineEval, ct1)
-		if err := hbtp.ckksEvaluator.RescaleMany(ct1, 1, ct1); err != nil {
-			panic(err)
+		if err = hbtp.ckksEvaluator.RescaleMany(ct1, 1, ct1); err != nil {
+			return nil, nil, err
 		}
 		// Rounds to the nearest power of two
 		ct1.SetScale(math.Exp2(math.Round(math.Log2(ct1.Scale()))))
 	}
 
-	return ct0, ct1
+	return ct0, ct1, nil
 }
 
 func (hbtp *HalfBootstrapper) subSum(ct *Ciphertext) *Ciphertext {
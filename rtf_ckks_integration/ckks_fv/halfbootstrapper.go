@@ -104,7 +104,7 @@ func (hbtp *HalfBootstrapper) CheckKeys() (err error) {
 	}
 
 	if len(rotMissing) != 0 {
-		return fmt.Errorf("rotation key(s) missing: %d", rotMissing)
+		return fmt.Errorf("%w: rotation key(s) missing: %d", ErrMissingGaloisKey, rotMissing)
 	}
 
 	return nil
@@ -352,6 +352,7 @@ func (eval *mfvEvaluator) MultiplyByDiabMatrixNaive(vecNTT, res *Ciphertext, mat
 
 	// levelQ := eval.params.QiCount() - 1
 	levelQ := vecNTT.Level()
+	noise := eval.noise.diagMatrix(eval.noise.noise(vecNTT), levelQ, len(matrix.Vec))
 	levelP := eval.params.PiCount() - 1
 
 	QiOverF := eval.params.QiOverflowMargin(levelQ)
@@ -450,6 +451,7 @@ func (eval *mfvEvaluator) MultiplyByDiabMatrixNaive(vecNTT, res *Ciphertext, mat
 
 	ringQ.InvNTTLvl(levelQ, res.value[0], res.value[0])
 	ringQ.InvNTTLvl(levelQ, res.value[1], res.value[1])
+	res.noise = noise
 }
 
 func (eval *ckksEvaluator) MultiplyByDiabMatrixNaive(vec, res *Ciphertext, matrix *PtDiagMatrix, c2QiQDecomp, c2QiPDecomp []*ring.Poly) {
@@ -568,6 +570,7 @@ func (eval *mfvEvaluator) MultiplyByDiabMatrixBSGS(vecNTT, res *Ciphertext, matr
 	ringP := eval.ringP
 
 	levelQ := vecNTT.Level()
+	noise := eval.noise.diagMatrix(eval.noise.noise(vecNTT), levelQ, len(matrix.Vec))
 	levelP := eval.params.PiCount() - 1
 
 	QiOverF := eval.params.QiOverflowMargin(levelQ)
@@ -809,6 +812,7 @@ func (eval *mfvEvaluator) MultiplyByDiabMatrixBSGS(vecNTT, res *Ciphertext, matr
 
 	ringQ.InvNTTLvl(levelQ, res.value[0], res.value[0])
 	ringQ.InvNTTLvl(levelQ, res.value[1], res.value[1])
+	res.noise = noise
 }
 
 func (eval *ckksEvaluator) MultiplyByDiabMatrixBSGS(vec, res *Ciphertext, matrix *PtDiagMatrix, c2QiQDecomp, c2QiPDecomp []*ring.Poly) {
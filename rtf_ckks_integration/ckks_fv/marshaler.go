@@ -0,0 +1,101 @@
+package ckks_fv
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"HHESoK/rtf_ckks_integration/ring"
+)
+
+// GetDataLen returns the length in bytes of the target Ciphertext.
+func (ciphertext *Ciphertext) GetDataLen(WithMetaData bool) (dataLen int) {
+	// MetaData is :
+	// 1 byte : Degree
+	// 9 byte : Scale
+	// 1 byte : isNTT
+	// 8 byte : noise bound tracked by the MFV evaluator
+	if WithMetaData {
+		dataLen += 19
+	}
+
+	for _, el := range ciphertext.value {
+		dataLen += el.GetDataLen(WithMetaData)
+	}
+
+	return dataLen
+}
+
+// MarshalBinary encodes a Ciphertext on a byte slice, with the noise bound tracked by the MFV
+// evaluator so that NewMFVNoiseBoundEstimator does not take the decoded ciphertext as fresh.
+func (ciphertext *Ciphertext) MarshalBinary() (data []byte, err error) {
+
+	data = make([]byte, ciphertext.GetDataLen(true))
+
+	data[0] = uint8(ciphertext.Degree() + 1)
+
+	binary.LittleEndian.PutUint64(data[1:9], math.Float64bits(ciphertext.Scale()))
+
+	if ciphertext.isNTT {
+		data[10] = 1
+	}
+
+	binary.LittleEndian.PutUint64(data[11:19], math.Float64bits(ciphertext.noise))
+
+	var pointer, inc int
+
+	pointer = 19
+
+	for _, el := range ciphertext.value {
+
+		if inc, err = el.WriteTo(data[pointer:]); err != nil {
+			return nil, err
+		}
+
+		pointer += inc
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a previously marshaled Ciphertext on the target Ciphertext.
+func (ciphertext *Ciphertext) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < 19 { // cf. ciphertext.GetDataLen()
+		return errors.New("too small bytearray")
+	}
+
+	ciphertext.Element = new(Element)
+
+	ciphertext.value = make([]*ring.Poly, uint8(data[0]))
+
+	ciphertext.scale = math.Float64frombits(binary.LittleEndian.Uint64(data[1:9]))
+
+	if uint8(data[10]) == 1 {
+		ciphertext.isNTT = true
+	}
+
+	ciphertext.noise = math.Float64frombits(binary.LittleEndian.Uint64(data[11:19]))
+	if math.IsNaN(ciphertext.noise) || math.IsInf(ciphertext.noise, 0) || ciphertext.noise < 0 {
+		return errors.New("invalid noise bound")
+	}
+
+	var pointer, inc int
+	pointer = 19
+
+	for i := range ciphertext.value {
+
+		ciphertext.value[i] = new(ring.Poly)
+
+		if inc, err = ciphertext.value[i].DecodePolyNew(data[pointer:]); err != nil {
+			return err
+		}
+
+		pointer += inc
+	}
+
+	if pointer != len(data) {
+		return errors.New("remaining unparsed data")
+	}
+
+	return nil
+}
@@ -87,6 +87,8 @@ type mfvEvaluatorBase struct {
 	pHalf        *big.Int
 
 	deltasMont [][]uint64
+
+	noise *noiseModel
 }
 
 func newMFVEvaluatorPrecomp(params *Parameters) *mfvEvaluatorBase {
@@ -136,6 +138,7 @@ func newMFVEvaluatorPrecomp(params *Parameters) *mfvEvaluatorBase {
 		}
 		ev.decomposer = ring.NewDecomposer(ev.ringQ.Modulus, ev.ringP.Modulus)
 	}
+	ev.noise = newNoiseModel(params)
 	return ev
 }
 
@@ -279,8 +282,10 @@ func (eval *mfvEvaluator) Add(op0, op1 Operand, ctOut *Ciphertext) {
 	}
 
 	level := op0.Level()
+	noise := eval.noise.add(eval.noise.noise(op0), eval.noise.noise(op1))
 	el0, el1, elOut := eval.getElemAndCheckBinary(op0, op1, ctOut, utils.MaxInt(op0.Degree(), op1.Degree()), true)
 	eval.evaluateInPlaceBinaryLvl(level, el0, el1, elOut, eval.ringQ.AddLvl)
+	ctOut.noise = noise
 }
 
 // AddNew adds op0 to op1 and creates a new element ctOut to store the result.
@@ -301,8 +306,10 @@ func (eval *mfvEvaluator) AddNoMod(op0, op1 Operand, ctOut *Ciphertext) {
 	if op1.Level() != level || ctOut.Level() != level {
 		panic("cannot AddNoMod: inputs and output should have the same level")
 	}
+	noise := eval.noise.add(eval.noise.noise(op0), eval.noise.noise(op1))
 	el0, el1, elOut := eval.getElemAndCheckBinary(op0, op1, ctOut, utils.MaxInt(op0.Degree(), op1.Degree()), true)
 	eval.evaluateInPlaceBinaryLvl(level, el0, el1, elOut, eval.ringQ.AddNoModLvl)
+	ctOut.noise = noise
 }
 
 // AddNoModNew adds op0 to op1 without modular reduction and creates a new element ctOut to store the result.
@@ -323,6 +330,7 @@ func (eval *mfvEvaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
 	if op1.Level() != level || ctOut.Level() != level {
 		panic("cannot Sub: inputs and output should have the same level")
 	}
+	noise := eval.noise.add(eval.noise.noise(op0), eval.noise.noise(op1))
 	el0, el1, elOut := eval.getElemAndCheckBinary(op0, op1, ctOut, utils.MaxInt(op0.Degree(), op1.Degree()), true)
 	eval.evaluateInPlaceBinaryLvl(level, el0, el1, elOut, eval.ringQ.SubLvl)
 
@@ -331,6 +339,7 @@ func (eval *mfvEvaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) {
 			eval.ringQ.NegLvl(level, ctOut.Value()[i], ctOut.Value()[i])
 		}
 	}
+	ctOut.noise = noise
 }
 
 // SubNew subtracts op1 from op0 and creates a new element ctOut to store the result.
@@ -352,6 +361,7 @@ func (eval *mfvEvaluator) SubNoMod(op0, op1 Operand, ctOut *Ciphertext) {
 		panic("cannot SubNoMod: inputs and output should have the same level")
 	}
 
+	noise := eval.noise.add(eval.noise.noise(op0), eval.noise.noise(op1))
 	el0, el1, elOut := eval.getElemAndCheckBinary(op0, op1, ctOut, utils.MaxInt(op0.Degree(), op1.Degree()), true)
 
 	eval.evaluateInPlaceBinaryLvl(level, el0, el1, elOut, eval.ringQ.SubNoModLvl)
@@ -361,6 +371,7 @@ func (eval *mfvEvaluator) SubNoMod(op0, op1 Operand, ctOut *Ciphertext) {
 			eval.ringQ.NegLvl(level, ctOut.Value()[i], ctOut.Value()[i])
 		}
 	}
+	ctOut.noise = noise
 }
 
 // SubNoModNew subtracts op1 from op0 without modular reduction and creates a new element ctOut to store the result.
@@ -382,8 +393,10 @@ func (eval *mfvEvaluator) Neg(op Operand, ctOut *Ciphertext) {
 	}
 
 	level := op.Level()
+	noise := eval.noise.noise(op)
 	el0, elOut := eval.getElemAndCheckUnary(op, ctOut, op.Degree())
 	evaluateInPlaceUnaryLvl(level, el0, elOut, eval.ringQ.NegLvl)
+	ctOut.noise = noise
 }
 
 // NegNew negates op and creates a new element to store the result.
@@ -401,8 +414,10 @@ func (eval *mfvEvaluator) Reduce(op Operand, ctOut *Ciphertext) {
 	}
 
 	level := op.Level()
+	noise := eval.noise.noise(op)
 	el0, elOut := eval.getElemAndCheckUnary(op, ctOut, op.Degree())
 	evaluateInPlaceUnaryLvl(level, el0, elOut, eval.ringQ.ReduceLvl)
+	ctOut.noise = noise
 }
 
 // ReduceNew applies a modular reduction to op and creates a new element ctOut to store the result.
@@ -420,9 +435,11 @@ func (eval *mfvEvaluator) MulScalar(op Operand, scalar uint64, ctOut *Ciphertext
 	}
 
 	level := op.Level()
+	noise := eval.noise.mulScalar(eval.noise.noise(op), scalar)
 	el0, elOut := eval.getElemAndCheckUnary(op, ctOut, op.Degree())
 	fun := func(lvl int, el, elOut *ring.Poly) { eval.ringQ.MulScalarLvl(lvl, el, scalar, elOut) }
 	evaluateInPlaceUnaryLvl(level, el0, elOut, fun)
+	ctOut.noise = noise
 }
 
 // MulScalarNew multiplies op by a uint64 scalar and creates a new element ctOut to store the result.
@@ -633,17 +650,21 @@ func (eval *mfvEvaluator) quantize(ctOut *Element) {
 // Mul multiplies op0 by op1 and returns the result in ctOut.
 func (eval *mfvEvaluator) Mul(op0 *Ciphertext, op1 Operand, ctOut *Ciphertext) {
 	el0, el1, elOut := eval.getElemAndCheckBinary(op0, op1, ctOut, op0.Degree()+op1.Degree(), false)
+	var noise float64
 	switch op1 := op1.(type) {
 	case *PlaintextMul:
+		noise = eval.noise.mulPlain(eval.noise.noise(op0))
 		eval.mulPlaintextMul(op0, op1, ctOut)
 	case *PlaintextRingT:
+		noise = eval.noise.mulPlain(eval.noise.noise(op0))
 		eval.mulPlaintextRingT(op0, op1, ctOut)
 	case *Plaintext, *Ciphertext:
+		noise = eval.noise.tensor(eval.noise.noise(op0), eval.noise.noise(op1), op0.Level())
 		eval.tensorAndRescale(el0, el1, elOut)
 	default:
 		panic(fmt.Errorf("invalid operand type for Mul: %T", op1))
 	}
-
+	ctOut.noise = noise
 }
 
 func (eval *mfvEvaluator) mulPlaintextMul(ct0 *Ciphertext, ptRt *PlaintextMul, ctOut *Ciphertext) {
@@ -722,6 +743,7 @@ func (eval *mfvEvaluator) relinearize(ct0 *Ciphertext, ctOut *Ciphertext) {
 		panic("cannot relinearize: input and output should have the same level")
 	}
 	level := ct0.Level()
+	noise := eval.noise.add(eval.noise.noise(ct0), eval.noise.keySwitch(level))
 
 	if ctOut != ct0 {
 		eval.ringQ.CopyLvl(level, ct0.value[0], ctOut.value[0])
@@ -735,6 +757,7 @@ func (eval *mfvEvaluator) relinearize(ct0 *Ciphertext, ctOut *Ciphertext) {
 	}
 
 	ctOut.SetValue(ctOut.value[:2])
+	ctOut.noise = noise
 }
 
 // Relinearize relinearizes the ciphertext ct0 of degree > 1 until it is of degree 1, and returns the result in cOut.
@@ -796,10 +819,12 @@ func (eval *mfvEvaluator) SwitchKeys(ct0 *Ciphertext, switchKey *SwitchingKey, c
 		panic("cannot SwitchKeys: input and output must be of degree 1 to allow key switching")
 	}
 
+	noise := eval.noise.add(eval.noise.noise(ct0), eval.noise.keySwitch(level))
 	eval.switchKeysInPlace(ct0.value[1], &switchKey.SwitchingKey, eval.poolQKS[1], eval.poolQKS[2])
 
 	eval.ringQ.AddLvl(level, ct0.value[0], eval.poolQKS[1], ctOut.value[0])
 	eval.ringQ.CopyLvl(level, eval.poolQKS[2], ctOut.value[1])
+	ctOut.noise = noise
 }
 
 // SwitchKeysNew applies the key-switching procedure to the ciphertext ct0 and creates a new ciphertext to store the result. It requires as an additional input a valid switching-key:
@@ -911,12 +936,14 @@ func (eval *mfvEvaluator) InnerSum(ct0 *Ciphertext, ctOut *Ciphertext) {
 func (eval *mfvEvaluator) permute(ct0 *Ciphertext, generator uint64, switchKey *rlwe.SwitchingKey, ctOut *Ciphertext) {
 
 	level := ct0.Level()
+	noise := eval.noise.add(eval.noise.noise(ct0), eval.noise.keySwitch(level))
 	eval.switchKeysInPlace(ct0.value[1], switchKey, eval.poolQKS[1], eval.poolQKS[2])
 
 	eval.ringQ.AddLvl(level, eval.poolQKS[1], ct0.value[0], eval.poolQKS[1])
 
 	eval.ringQ.PermuteLvl(level, eval.poolQKS[1], generator, ctOut.value[0])
 	eval.ringQ.PermuteLvl(level, eval.poolQKS[2], generator, ctOut.value[1])
+	ctOut.noise = noise
 }
 
 // switchKeys applies the general key-switching procedure of the form [c0 + cx*evakey[0], c1 + cx*evakey[1]]
@@ -1327,11 +1354,13 @@ func (eval *mfvEvaluator) ModSwitch(ct0 *Ciphertext, ctOut *Ciphertext) {
 		panic("cannot ModSwitch: input and output should have the same degree")
 	}
 
+	noise := eval.noise.modSwitch(eval.noise.noise(ct0), level, 1)
 	ringQ := eval.ringQ
 	for i := range ct0.value {
 		ringQ.DivRoundByLastModulus(ct0.value[i], ctOut.value[i])
 		ctOut.value[i].Coeffs = ctOut.value[i].Coeffs[:level]
 	}
+	ctOut.noise = noise
 }
 
 // ModSwitchMany switches modulus of ct0 nbModSwitch levels down and returns the result in ctOut
@@ -1349,11 +1378,13 @@ func (eval *mfvEvaluator) ModSwitchMany(ct0, ctOut *Ciphertext, nbModSwitch int)
 		panic("cannot ModSwitchMany: input and output should have the same degree")
 	}
 
+	noise := eval.noise.modSwitch(eval.noise.noise(ct0), level, nbModSwitch)
 	ringQ := eval.ringQ
 	for i := range ct0.value {
 		ringQ.DivRoundByLastModulusMany(ct0.value[i], ctOut.value[i], nbModSwitch)
 		ctOut.value[i].Coeffs = ctOut.value[i].Coeffs[:level+1-nbModSwitch]
 	}
+	ctOut.noise = noise
 }
 
 // TransformToNTT transforms ct0 into NTT form and returns the result in ctOut
@@ -1372,6 +1403,7 @@ func (eval *mfvEvaluator) TransformToNTT(ct0 *Ciphertext, ctOut *Ciphertext) {
 	}
 
 	ctOut.SetIsNTT(true)
+	ctOut.noise = ct0.noise
 }
 
 // SlotsToCoeffs returns ctOut whose coefficients are data stored in slots of ct
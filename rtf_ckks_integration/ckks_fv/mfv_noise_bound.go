@@ -0,0 +1,169 @@
+package ckks_fv
+
+import (
+	"math"
+)
+
+// noiseBoundTail is the ratio between the bound on a random noise and its standard deviation
+const noiseBoundTail = 6
+
+// noiseModel is the noise growth of the MFV operations. The noise of a ciphertext is tracked
+// in its Element as log2 of a bound on the infinity norm of e, where
+// c0 + c1*s = floor(Q/t)*m + e (mod Q). The messages and plaintexts are taken in [0, t) so
+// their products and carries are bounded in the worst case, the noise of the keys and of the
+// roundings multiplied by the secret key are bounded by noiseBoundTail standard deviations.
+// The secret key is assumed ternary with at most 2N/3 non zero coefficients as sampled by
+// GenSecretKey, sparse keys only make the bound looser
+type noiseModel struct {
+	logN      float64
+	logT      float64
+	logQi     []float64
+	logP      float64
+	alpha     int
+	logSigma  float64 // standard deviation of the Gaussian errors
+	keyVar    float64 // variance of the coefficients of the secret key
+	fresh     float64 // noise of a fresh public key encryption
+	rounding  float64 // bound on (c0 + c1*s)/Q for c0, c1 in [0, Q)
+	plaintext float64 // noise of a plaintext operand
+}
+
+func newNoiseModel(params *Parameters) *noiseModel {
+	n := float64(params.N())
+	keyVar := 2.0 / 3
+	sigma := params.Sigma()
+
+	logQi := make([]float64, params.QiCount())
+	for i, qi := range params.Qi() {
+		logQi[i] = math.Log2(float64(qi))
+	}
+	logP := 0.0
+	for _, pi := range params.Pi() {
+		logP += math.Log2(float64(pi))
+	}
+
+	return &noiseModel{
+		logN:     float64(params.LogN()),
+		logT:     math.Log2(float64(params.PlainModulus())),
+		logQi:    logQi,
+		logP:     logP,
+		alpha:    params.Alpha(),
+		logSigma: math.Log2(sigma),
+		keyVar:   keyVar,
+		// u*e + e0 + e1*s with a ternary u of variance 1/2
+		fresh:     math.Log2(noiseBoundTail * sigma * math.Sqrt(1+n/2+n*keyVar)),
+		rounding:  math.Log2(1 + noiseBoundTail*math.Sqrt(n*keyVar/3)),
+		plaintext: 0,
+	}
+}
+
+// logAdd returns log2(2^a + 2^b)
+func logAdd(a, b float64) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	return a + math.Log2(1+math.Exp2(b-a))
+}
+
+// logQ returns log2 of the modulus of the given level
+func (model *noiseModel) logQ(level int) (logQ float64) {
+	for _, logQi := range model.logQi[:level+1] {
+		logQ += logQi
+	}
+	return
+}
+
+// noise returns the noise of op, a fresh encryption is assumed for the ciphertexts whose noise
+// is not tracked
+func (model *noiseModel) noise(op Operand) float64 {
+	el := op.El()
+	if el.Degree() == 0 {
+		return model.plaintext
+	}
+	if el.noise == 0 {
+		return model.fresh
+	}
+	return el.noise
+}
+
+// keySwitch returns the noise added by a key switching at the given level: the digits of the
+// decomposition in basis Q_alpha multiplied by the noise of the key and divided by P, and the
+// rounding of the division
+func (model *noiseModel) keySwitch(level int) float64 {
+	beta := (level + model.alpha) / model.alpha
+	logDigit := 0.0
+	for i := 0; i < beta; i++ {
+		logQAlpha := 0.0
+		for _, logQi := range model.logQi[i*model.alpha : min((i+1)*model.alpha, level+1)] {
+			logQAlpha += logQi
+		}
+		logDigit = math.Max(logDigit, logQAlpha)
+	}
+	std := 0.5*(math.Log2(float64(beta))+model.logN-math.Log2(3)) + logDigit + model.logSigma
+	return logAdd(math.Log2(noiseBoundTail)+std-model.logP, model.rounding)
+}
+
+// add returns the noise of the sum of the noises a and b, with the carry of the messages
+func (model *noiseModel) add(a, b float64) float64 {
+	return logAdd(logAdd(a, b), model.logT)
+}
+
+// mulScalar returns the noise a multiplied by scalar, with the carries of the messages
+func (model *noiseModel) mulScalar(a float64, scalar uint64) float64 {
+	if scalar == 0 {
+		return model.plaintext
+	}
+	return math.Log2(float64(scalar)) + logAdd(a, model.logT)
+}
+
+// mulPlain returns the noise a multiplied by a plaintext polynomial, with the carries of the
+// product of the message by the plaintext
+func (model *noiseModel) mulPlain(a float64) float64 {
+	return model.logN + model.logT + logAdd(a, model.logT)
+}
+
+// tensor returns the noise of the product of the noises a and b scaled by t/Q at the given
+// level: the noises multiplied by the messages and by the overflow of c0 + c1*s over Q, the
+// carries of the product of the messages, the product of the noises and the rounding
+func (model *noiseModel) tensor(a, b float64, level int) float64 {
+	noise := model.logN + model.logT + logAdd(a, b) + logAdd(0, model.rounding)
+	noise = logAdd(noise, model.logN+2*model.logT)
+	noise = logAdd(noise, model.logN+model.logT+a+b-model.logQ(level))
+	return logAdd(noise, 2*model.rounding+1)
+}
+
+// modSwitch returns the noise a after dropping nbModSwitch moduli from the given level, with
+// the rounding and the difference of floor(Q/t) between the levels
+func (model *noiseModel) modSwitch(a float64, level, nbModSwitch int) float64 {
+	dropped := model.logQ(level) - model.logQ(level-nbModSwitch)
+	return logAdd(logAdd(a-dropped, model.rounding), model.logT)
+}
+
+// diagMatrix returns the noise a after the product by a plaintext matrix of nbDiag diagonals
+// at the given level, each diagonal rotated by a key switching
+func (model *noiseModel) diagMatrix(a float64, level, nbDiag int) float64 {
+	ks := model.keySwitch(level)
+	noise := math.Log2(float64(nbDiag)) + model.mulPlain(logAdd(a, ks))
+	return logAdd(noise, ks)
+}
+
+type mfvNoiseBoundEstimator struct {
+	model *noiseModel
+}
+
+// NewMFVNoiseBoundEstimator creates a noise estimator which does not use the secret key: the
+// MFV evaluator tracks a heuristic upper bound on the noise of its outputs, the bound is kept
+// by Copy, CopyNew and MarshalBinary, the ciphertexts which were not produced by the evaluator
+// are assumed freshly encrypted with a public key
+func NewMFVNoiseBoundEstimator(params *Parameters) MFVNoiseEstimator {
+	return &mfvNoiseBoundEstimator{model: newNoiseModel(params)}
+}
+
+// InvariantNoiseBudget returns the noise budget of ciphertext computed from the noise tracked
+// by the evaluator, it is lower than the budget measured with the secret key
+func (est *mfvNoiseBoundEstimator) InvariantNoiseBudget(ciphertext *Ciphertext) int {
+	model := est.model
+	level := ciphertext.Level()
+	// t*e and the difference between t*floor(Q/t) and Q times the message
+	logNorm := logAdd(model.logT+model.noise(ciphertext), 2*model.logT)
+	return max(int(math.Floor(model.logQ(level)-logNorm-1)), 0)
+}
@@ -0,0 +1,195 @@
+package ckks_fv
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+// noiseBoundContext holds the keys and evaluators of the noise bound tests
+type noiseBoundContext struct {
+	params    *Parameters
+	encoder   MFVEncoder
+	encryptor MFVEncryptor
+	evaluator MFVEvaluator
+	skEst     MFVNoiseEstimator
+	boundEst  MFVNoiseEstimator
+	model     *noiseModel
+	nonces    [][]byte
+	counter   []byte
+}
+
+func newNoiseBoundContext(t *testing.T, hbtpParams *HalfBootParameters, plainModulus uint64) *noiseBoundContext {
+	params, err := testHalfBootParams(hbtpParams).Params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	params.SetPlainModulus(plainModulus)
+	params.SetLogFVSlots(params.LogN())
+
+	kgen := NewKeyGenerator(params)
+	sk, pk := kgen.GenKeyPairSparse(hbtpParams.H)
+	encoder := NewMFVEncoder(params)
+	pDcds := encoder.GenSlotToCoeffMatFV(2)
+	rotKeys := kgen.GenRotationKeysForRotations(kgen.GenRotationIndexesForSlotsToCoeffsMat(pDcds), true, sk)
+
+	ctx := &noiseBoundContext{
+		params:    params,
+		encoder:   encoder,
+		encryptor: NewMFVEncryptorFromPk(params, pk),
+		evaluator: NewMFVEvaluator(params, EvaluationKey{Rlk: kgen.GenRelinearizationKey(sk), Rtks: rotKeys}, pDcds),
+		skEst:     NewMFVNoiseEstimator(params, sk),
+		boundEst:  NewMFVNoiseBoundEstimator(params),
+		model:     newNoiseModel(params),
+		nonces:    make([][]byte, params.FVSlots()),
+		counter:   make([]byte, 64),
+	}
+	for i := range ctx.nonces {
+		ctx.nonces[i] = make([]byte, 64)
+		if _, err = rand.Read(ctx.nonces[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = rand.Read(ctx.counter); err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
+
+// noiseBoundSlack is the gap allowed between the bound and secret key budgets of a fresh
+// ciphertext, the bound assumes messages of size t
+const noiseBoundSlack = 32
+
+// checkBound checks that the budget of the bound estimator is at most the budget measured with
+// the secret key, and that the gap is at most a quarter of the consumed budget since the
+// products by the messages are bounded in the worst case
+func (ctx *noiseBoundContext) checkBound(t *testing.T, name string, ct *Ciphertext) {
+	t.Helper()
+	want := ctx.skEst.InvariantNoiseBudget(ct)
+	got := ctx.boundEst.InvariantNoiseBudget(ct)
+	t.Logf("%s: level %d, bound budget %d, secret key budget %d", name, ct.Level(), got, want)
+	if got > want {
+		t.Errorf("%s: bound budget %d above the secret key budget %d", name, got, want)
+	}
+	consumed := int(ctx.model.logQ(ct.Level())) - want
+	if maxGap := noiseBoundSlack + consumed/4; want > 0 && want-got > maxGap {
+		t.Errorf("%s: bound budget %d more than %d bits below the secret key budget %d", name, got, maxGap, want)
+	}
+}
+
+func TestNoiseBoundHera(t *testing.T) {
+	ctx := newNoiseBoundContext(t, RtFHeraParams[0], RtFHeraParams[0].PlainModulus)
+	key := make([]uint64, 16)
+	for i := range key {
+		key[i] = uint64(i + 1)
+	}
+
+	for _, auto := range []bool{false, true} {
+		hera := MustNewMFVHera(4, ctx.params, ctx.encoder, ctx.encryptor, ctx.evaluator, 0)
+		kCt, err := hera.EncKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx.checkBound(t, "key", kCt[0])
+		var stCt []*Ciphertext
+		if auto {
+			// the schedule is searched without the secret key
+			stCt, _, err = hera.CryptAutoModSwitch(ctx.nonces, kCt, ctx.boundEst)
+		} else {
+			stCt, err = hera.CryptNoModSwitch(ctx.nonces, kCt)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range stCt {
+			ctx.checkBound(t, "key stream", stCt[i])
+		}
+		var ksSlot *Ciphertext
+		if auto {
+			ksSlot, _ = ctx.evaluator.SlotsToCoeffsAutoModSwitch(stCt[0], ctx.boundEst)
+		} else {
+			ksSlot = ctx.evaluator.SlotsToCoeffsNoModSwitch(stCt[0])
+		}
+		ctx.checkBound(t, "SlotsToCoeffs", ksSlot)
+	}
+}
+
+func TestNoiseBoundRubato(t *testing.T) {
+	rubatoParam := RUBATO128M
+	ctx := newNoiseBoundContext(t, RtFRubatoParams[0], RubatoParams[rubatoParam].PlainModulus)
+	key := make([]uint64, RubatoParams[rubatoParam].Blocksize)
+	for i := range key {
+		key[i] = uint64(i + 1)
+	}
+
+	for _, auto := range []bool{false, true} {
+		rubato := MustNewMFVRubato(rubatoParam, ctx.params, ctx.encoder, ctx.encryptor, ctx.evaluator, 0)
+		kCt, err := rubato.EncKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var stCt []*Ciphertext
+		if auto {
+			stCt, _, err = rubato.CryptAutoModSwitch(ctx.nonces, ctx.counter, kCt, ctx.boundEst)
+		} else {
+			stCt, err = rubato.CryptNoModSwitch(ctx.nonces, ctx.counter, kCt)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range stCt[:len(key)-4] {
+			ctx.checkBound(t, "key stream", stCt[i])
+		}
+		var ksSlot *Ciphertext
+		if auto {
+			ksSlot, _ = ctx.evaluator.SlotsToCoeffsAutoModSwitch(stCt[0], ctx.boundEst)
+		} else {
+			ksSlot = ctx.evaluator.SlotsToCoeffsNoModSwitch(stCt[0])
+		}
+		ctx.checkBound(t, "SlotsToCoeffs", ksSlot)
+	}
+}
+
+func TestNoiseBoundMarshal(t *testing.T) {
+	ctx := newNoiseBoundContext(t, RtFHeraParams[0], RtFHeraParams[0].PlainModulus)
+	key := make([]uint64, 16)
+	for i := range key {
+		key[i] = uint64(i + 1)
+	}
+	hera := MustNewMFVHera(4, ctx.params, ctx.encoder, ctx.encryptor, ctx.evaluator, 0)
+	kCt, err := hera.EncKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stCt, err := hera.CryptNoModSwitch(ctx.nonces, kCt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ctx.boundEst.InvariantNoiseBudget(stCt[0])
+	if fresh := ctx.boundEst.InvariantNoiseBudget(kCt[0]); want >= fresh {
+		t.Fatalf("evaluated budget %d, want below the fresh budget %d", want, fresh)
+	}
+
+	data, err := stCt[0].MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := new(Ciphertext)
+	if err = ct.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if got := ctx.boundEst.InvariantNoiseBudget(ct); got != want {
+		t.Errorf("unmarshaled budget %d, want %d", got, want)
+	}
+	if got := ctx.boundEst.InvariantNoiseBudget(&Ciphertext{ct.CopyNew()}); got != want {
+		t.Errorf("copied budget %d, want %d", got, want)
+	}
+	if again, err := ct.MarshalBinary(); err != nil || !bytes.Equal(again, data) {
+		t.Errorf("unmarshaled ciphertext differs from the original, %v", err)
+	}
+
+	// the bound is part of the metadata, a ciphertext without it is refused
+	if err = new(Ciphertext).UnmarshalBinary(data[:11]); err == nil {
+		t.Error("truncated ciphertext unmarshaled")
+	}
+}
@@ -0,0 +1,326 @@
+package ckks_fv
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// DefaultModDownMargin is the noise budget in bits kept above the moduli dropped from the
+// fresh states, it is the margin of the ModDownParams tables
+const DefaultModDownMargin = 40
+
+// Names of the ciphers of a ModDownCipher
+const (
+	CipherHera   = "hera"
+	CipherRubato = "rubato"
+)
+
+// ModDownCipher is the cipher whose key stream is searched by a ModDownPlanner, NumRound is
+// the number of rounds of HERA and RubatoParam the index in RubatoParams of Rubato
+type ModDownCipher struct {
+	Name        string
+	NumRound    int `json:",omitempty"`
+	RubatoParam int `json:",omitempty"`
+}
+
+// HeraCipher returns the HERA cipher with numRound rounds
+func HeraCipher(numRound int) ModDownCipher {
+	return ModDownCipher{Name: CipherHera, NumRound: numRound}
+}
+
+// RubatoCipher returns the Rubato cipher of RubatoParams[rubatoParam]
+func RubatoCipher(rubatoParam int) ModDownCipher {
+	return ModDownCipher{Name: CipherRubato, RubatoParam: rubatoParam}
+}
+
+// ModDownPlanner searches the modulus switching indices of the RtF key stream: the key stream
+// is evaluated without modulus switching to find how many moduli can be dropped from the fresh
+// states, then with CryptAutoModSwitch and SlotsToCoeffsAutoModSwitch, which drop moduli as
+// long as the noise estimated with the secret key allows it.
+// Margin is the noise budget in bits kept above the dropped moduli of the fresh states,
+// FullCoeffs uses the N coefficients for the data instead of 2*Slots and CacheDir, if not
+// empty, is the directory where the results are stored and read back by Plan
+type ModDownPlanner struct {
+	hbtpParams *HalfBootParameters
+	cipher     ModDownCipher
+	radix      int
+
+	Margin     int
+	FullCoeffs bool
+	CacheDir   string
+}
+
+// NewModDownPlanner creates a planner of the key stream of cipher with the parameters
+// hbtpParams and the SlotsToCoeffs matrices factorized with radix, with full coefficients
+// and DefaultModDownMargin
+func NewModDownPlanner(hbtpParams *HalfBootParameters, cipher ModDownCipher, radix int) *ModDownPlanner {
+	return &ModDownPlanner{
+		hbtpParams: hbtpParams,
+		cipher:     cipher,
+		radix:      radix,
+		Margin:     DefaultModDownMargin,
+		FullCoeffs: true,
+	}
+}
+
+// plannerContext holds the keys and evaluators of one search
+type plannerContext struct {
+	params    *Parameters
+	encoder   MFVEncoder
+	encryptor MFVEncryptor
+	decryptor MFVDecryptor
+	evaluator MFVEvaluator
+	estimator MFVNoiseEstimator
+
+	key     []uint64
+	nonces  [][]byte
+	counter []byte
+}
+
+// Plan returns the modulus switching indices of the key stream, from the cache if CacheDir
+// holds the result of the same search
+func (p *ModDownPlanner) Plan() (modDown ModDownParams, err error) {
+	if err = p.check(); err != nil {
+		return
+	}
+	var path string
+	if p.CacheDir != "" {
+		if path, err = p.cachePath(); err != nil {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			if err = json.Unmarshal(data, &modDown); err != nil {
+				return modDown, fmt.Errorf("cannot read the cached mod-down %s: %w", path, err)
+			}
+			return modDown, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return modDown, err
+		}
+	}
+
+	if modDown, err = p.search(); err != nil {
+		return
+	}
+
+	if path != "" {
+		data, err := json.MarshalIndent(modDown, "", "  ")
+		if err != nil {
+			return modDown, err
+		}
+		if err = os.MkdirAll(p.CacheDir, 0o755); err != nil {
+			return modDown, err
+		}
+		if err = os.WriteFile(path, data, 0o644); err != nil {
+			return modDown, err
+		}
+	}
+	return modDown, nil
+}
+
+func (p *ModDownPlanner) check() error {
+	if p.hbtpParams == nil {
+		return fmt.Errorf("%w: no half-bootstrapping parameters", ErrInvalidParameters)
+	}
+	if p.radix < 0 || p.Margin < 0 {
+		return fmt.Errorf("%w: radix %d and margin %d", ErrInvalidParameters, p.radix, p.Margin)
+	}
+	switch p.cipher.Name {
+	case CipherHera:
+		if p.cipher.NumRound < 1 {
+			return fmt.Errorf("%w: HERA with %d rounds", ErrInvalidParameters, p.cipher.NumRound)
+		}
+	case CipherRubato:
+		if p.cipher.RubatoParam < 0 || p.cipher.RubatoParam >= len(RubatoParams) {
+			return fmt.Errorf("%w: Rubato parameter index %d", ErrInvalidParameters, p.cipher.RubatoParam)
+		}
+	default:
+		return fmt.Errorf("%w: unknown cipher %q", ErrInvalidParameters, p.cipher.Name)
+	}
+	return nil
+}
+
+// cachePath returns the file of the search, named after a hash of all its inputs
+func (p *ModDownPlanner) cachePath() (string, error) {
+	key, err := json.Marshal(struct {
+		HalfBoot   *HalfBootParameters
+		Cipher     ModDownCipher
+		Radix      int
+		Margin     int
+		FullCoeffs bool
+	}{p.hbtpParams, p.cipher, p.radix, p.Margin, p.FullCoeffs})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(key)
+	return filepath.Join(p.CacheDir, p.cipher.Name+"-"+hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// search evaluates the key stream under a fresh key pair, the key stream with the returned
+// indices is checked against the key stream without modulus switching
+func (p *ModDownPlanner) search() (modDown ModDownParams, err error) {
+	ctx, err := p.newContext()
+	if err != nil {
+		return
+	}
+	params := ctx.params
+
+	stCt, _, err := p.crypt(ctx, 0, nil)
+	if err != nil {
+		return
+	}
+	want := make([][]uint64, len(stCt))
+	minBudget := math.MaxInt
+	for i := range stCt {
+		ksSlot := ctx.evaluator.SlotsToCoeffsNoModSwitch(stCt[i])
+		minBudget = min(minBudget, ctx.estimator.InvariantNoiseBudget(ksSlot))
+		want[i] = p.decrypt(ctx, ksSlot)
+	}
+	if minBudget <= 0 {
+		return modDown, fmt.Errorf("%w: the key stream has no noise budget left without modulus switching", ErrInsufficientLevel)
+	}
+
+	logQi := make([]int, params.QiCount())
+	for i, qi := range params.Qi() {
+		logQi[i] = int(math.Round(math.Log2(float64(qi))))
+	}
+	nbInitModDown := initModDown(logQi, minBudget, p.Margin)
+
+	stCt, modDown.CipherModDown, err = p.crypt(ctx, nbInitModDown, ctx.estimator)
+	if err != nil {
+		return
+	}
+	_, modDown.StCModDown = ctx.evaluator.SlotsToCoeffsAutoModSwitch(stCt[0], ctx.estimator)
+	for i := range stCt {
+		ksSlot := ctx.evaluator.SlotsToCoeffs(stCt[i], modDown.StCModDown)
+		got := p.decrypt(ctx, ksSlot)
+		for j := range want[i] {
+			if got[j] != want[i][j] {
+				return modDown, fmt.Errorf("%w: decryption failure of key stream element %d with the mod-down %v %v",
+					ErrInsufficientLevel, i, modDown.CipherModDown, modDown.StCModDown)
+			}
+		}
+	}
+	return modDown, nil
+}
+
+// initModDown returns the number of moduli dropped from the fresh states: moduli are dropped
+// from the top as long as the moduli dropped and the next one stay margin bits below the
+// budget of the key stream evaluated without modulus switching
+func initModDown(logQi []int, minBudget, margin int) (nbInitModDown int) {
+	qiCount := len(logQi)
+	cutBits := logQi[qiCount-1]
+	for cutBits+margin < minBudget && nbInitModDown+2 < qiCount {
+		nbInitModDown++
+		cutBits += logQi[qiCount-nbInitModDown-1]
+	}
+	return
+}
+
+// newContext generates the keys, the evaluators and a random input of the key stream
+func (p *ModDownPlanner) newContext() (ctx *plannerContext, err error) {
+	ctx = new(plannerContext)
+	if ctx.params, err = p.hbtpParams.Params(); err != nil {
+		return nil, err
+	}
+	params := ctx.params
+
+	keySize := 16
+	if p.cipher.Name == CipherRubato {
+		keySize = RubatoParams[p.cipher.RubatoParam].Blocksize
+		params.SetPlainModulus(RubatoParams[p.cipher.RubatoParam].PlainModulus)
+	}
+	if p.FullCoeffs {
+		params.SetLogFVSlots(params.LogN())
+	} else {
+		params.SetLogFVSlots(params.LogSlots())
+	}
+
+	kgen := NewKeyGenerator(params)
+	sk, pk := kgen.GenKeyPairSparse(p.hbtpParams.H)
+	ctx.encoder = NewMFVEncoder(params)
+	ctx.encryptor = NewMFVEncryptorFromPk(params, pk)
+	ctx.decryptor = NewMFVDecryptor(params, sk)
+	ctx.estimator = NewMFVNoiseEstimator(params, sk)
+
+	pDcds := ctx.encoder.GenSlotToCoeffMatFV(p.radix)
+	rotKeys := kgen.GenRotationKeysForRotations(kgen.GenRotationIndexesForSlotsToCoeffsMat(pDcds), true, sk)
+	ctx.evaluator = NewMFVEvaluator(params, EvaluationKey{Rlk: kgen.GenRelinearizationKey(sk), Rtks: rotKeys}, pDcds)
+
+	ctx.key = make([]uint64, keySize)
+	for i := range ctx.key {
+		ctx.key[i] = uint64(i + 1)
+	}
+	ctx.nonces = make([][]byte, params.FVSlots())
+	for i := range ctx.nonces {
+		ctx.nonces[i] = make([]byte, 64)
+		if _, err = rand.Read(ctx.nonces[i]); err != nil {
+			return nil, err
+		}
+	}
+	ctx.counter = make([]byte, 64)
+	if _, err = rand.Read(ctx.counter); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// crypt returns the output elements of the key stream of the states and key dropped by
+// nbInitModDown moduli, with the automatic modulus switching if estimator is not nil
+func (p *ModDownPlanner) crypt(ctx *plannerContext, nbInitModDown int, estimator MFVNoiseEstimator) (stCt []*Ciphertext, cipherModDown []int, err error) {
+	switch p.cipher.Name {
+	case CipherHera:
+		var hera MFVHera
+		var kCt []*Ciphertext
+		if hera, err = NewMFVHera(p.cipher.NumRound, ctx.params, ctx.encoder, ctx.encryptor, ctx.evaluator, nbInitModDown); err != nil {
+			return
+		}
+		if kCt, err = hera.EncKey(ctx.key); err != nil {
+			return
+		}
+		if estimator == nil {
+			stCt, err = hera.CryptNoModSwitch(ctx.nonces, kCt)
+		} else {
+			stCt, cipherModDown, err = hera.CryptAutoModSwitch(ctx.nonces, kCt, estimator)
+		}
+		return
+	default:
+		var rubato MFVRubato
+		var kCt []*Ciphertext
+		if rubato, err = NewMFVRubato(p.cipher.RubatoParam, ctx.params, ctx.encoder, ctx.encryptor, ctx.evaluator, nbInitModDown); err != nil {
+			return
+		}
+		if kCt, err = rubato.EncKey(ctx.key); err != nil {
+			return
+		}
+		if estimator == nil {
+			stCt, err = rubato.CryptNoModSwitch(ctx.nonces, ctx.counter, kCt)
+		} else {
+			stCt, cipherModDown, err = rubato.CryptAutoModSwitch(ctx.nonces, ctx.counter, kCt, estimator)
+		}
+		if err != nil {
+			return
+		}
+		// the last 4 elements of the Rubato state are truncated
+		return stCt[:len(ctx.key)-4], cipherModDown, nil
+	}
+}
+
+// decrypt switches ksSlot to level 0 and returns its coefficients modulo the plaintext modulus
+func (p *ModDownPlanner) decrypt(ctx *plannerContext, ksSlot *Ciphertext) []uint64 {
+	if ksSlot.Level() > 0 {
+		ctx.evaluator.ModSwitchMany(ksSlot, ksSlot, ksSlot.Level())
+	}
+	ksCoef := NewPlaintextRingT(ctx.params)
+	ctx.encoder.DecodeRingT(ctx.decryptor.DecryptNew(ksSlot), ksCoef)
+	return append([]uint64(nil), ksCoef.Element.Value()[0].Coeffs[0]...)
+}
@@ -0,0 +1,244 @@
+package ckks_fv
+
+import (
+	"HHESoK/rtf_ckks_integration/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestInitModDown(t *testing.T) {
+	logQi := []int{60, 40, 40, 40, 50}
+	for _, tc := range []struct {
+		budget, margin, want int
+	}{
+		{0, 40, 0},
+		{90, 40, 0},
+		{91, 40, 1},
+		{131, 40, 2},
+		// the first modulus is never dropped
+		{1000, 0, 3},
+	} {
+		if got := initModDown(logQi, tc.budget, tc.margin); got != tc.want {
+			t.Errorf("initModDown(%v, %d, %d) = %d, want %d", logQi, tc.budget, tc.margin, got, tc.want)
+		}
+	}
+}
+
+// checkPlainKeyStream evaluates the key stream of a fresh key pair with modDown and compares
+// every FV slot with the plain cipher, the slot j is the coefficient of bit-reversed index j
+func checkPlainKeyStream(t *testing.T, planner *ModDownPlanner, modDown ModDownParams) {
+	t.Helper()
+	ctx, err := planner.newContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := ctx.params
+	keystream := make([][]uint64, params.FVSlots())
+	var stCt []*Ciphertext
+	if planner.cipher.Name == CipherHera {
+		hera, err := NewMFVHera(planner.cipher.NumRound, params, ctx.encoder, ctx.encryptor, ctx.evaluator, modDown.CipherModDown[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		kCt, err := hera.EncKey(ctx.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stCt, err = hera.Crypt(ctx.nonces, kCt, modDown.CipherModDown); err != nil {
+			t.Fatal(err)
+		}
+		for j := range keystream {
+			keystream[j] = plainHera(planner.cipher.NumRound, ctx.nonces[j], ctx.key, params.PlainModulus())
+		}
+	} else {
+		rubatoParams := RubatoParams[planner.cipher.RubatoParam]
+		rubato, err := NewMFVRubato(planner.cipher.RubatoParam, params, ctx.encoder, ctx.encryptor, ctx.evaluator, modDown.CipherModDown[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		kCt, err := rubato.EncKey(ctx.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stCt, err = rubato.Crypt(ctx.nonces, ctx.counter, kCt, modDown.CipherModDown); err != nil {
+			t.Fatal(err)
+		}
+		// the last 4 elements of the Rubato state are truncated
+		stCt = stCt[:len(ctx.key)-4]
+		for j := range keystream {
+			keystream[j] = plainRubato(rubatoParams.Blocksize, rubatoParams.NumRound, ctx.nonces[j], ctx.counter, ctx.key, params.PlainModulus(), -1)
+		}
+	}
+
+	logN := uint64(params.LogN())
+	for i := range stCt {
+		coeffs := planner.decrypt(ctx, ctx.evaluator.SlotsToCoeffs(stCt[i], modDown.StCModDown))
+		for j := range keystream {
+			if got := coeffs[utils.BitReverse64(uint64(j), logN)]; got != keystream[j][i] {
+				t.Fatalf("key stream element %d of slot %d with the mod-down %v %v: got %d, want %d",
+					i, j, modDown.CipherModDown, modDown.StCModDown, got, keystream[j][i])
+			}
+		}
+	}
+}
+
+// testLogN is the ring degree of the planner test parameters, the sets are not secure
+const testLogN = 11
+
+// testHalfBootParams returns a copy of hbtpParams on a ring of degree 2^testLogN
+func testHalfBootParams(hbtpParams *HalfBootParameters) *HalfBootParameters {
+	params := *hbtpParams
+	params.LogN = testLogN
+	params.LogSlots = testLogN - 1
+	return &params
+}
+
+func TestModDownPlanner(t *testing.T) {
+	for name, planner := range map[string]*ModDownPlanner{
+		"Hera":   NewModDownPlanner(testHalfBootParams(RtFHeraParams[0]), HeraCipher(4), 2),
+		"Rubato": NewModDownPlanner(testHalfBootParams(RtFRubatoParams[0]), RubatoCipher(RUBATO128M), 2),
+	} {
+		t.Run(name, func(t *testing.T) {
+			planner.CacheDir = t.TempDir()
+			// the key stream is decrypted with the schedule found by Plan
+			modDown, err := planner.Plan()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if modDown.CipherModDown[0] == 0 {
+				t.Errorf("no modulus dropped from the fresh states: %v", modDown.CipherModDown)
+			}
+			checkPlainKeyStream(t, planner, modDown)
+			cached, err := planner.Plan()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cached, modDown) {
+				t.Errorf("cached mod-down %v, want %v", cached, modDown)
+			}
+		})
+	}
+}
+
+// modDownTolerance is the number of moduli the planned schedules may drop less than the tables,
+// the noise of the search depends on the random nonces and key pair
+const modDownTolerance = 1
+
+// dropped returns the number of moduli dropped by the key stream and by SlotsToCoeffs
+func dropped(modDown ModDownParams) (cipher, stc int) {
+	for _, n := range modDown.CipherModDown {
+		cipher += n
+	}
+	for _, n := range modDown.StCModDown {
+		stc += n
+	}
+	return
+}
+
+func TestModDownPlannerTables(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the search on the RtF parameters in short mode")
+	}
+	type tableCase struct {
+		planner *ModDownPlanner
+		want    ModDownParams
+	}
+	cases := map[string]tableCase{}
+	// the radices and coefficients of the RtF benchmarks, the sparse parameters use LogSlots FV slots
+	for _, hera := range []struct {
+		numRound int
+		table    []ModDownParams
+		radix    []int
+	}{
+		{4, HeraModDownParams80, []int{2, 0, 2, 0}},
+		{5, HeraModDownParams128, []int{2, 0, 2, 2}},
+	} {
+		for i, hbtpParams := range RtFHeraParams {
+			planner := NewModDownPlanner(hbtpParams, HeraCipher(hera.numRound), hera.radix[i])
+			planner.FullCoeffs = i%2 == 0
+			cases[fmt.Sprintf("Hera%d/%d", hera.numRound, i)] = tableCase{planner, hera.table[i]}
+		}
+	}
+	for i := range RubatoParams {
+		cases[fmt.Sprintf("Rubato/%d", i)] = tableCase{NewModDownPlanner(RtFRubatoParams[0], RubatoCipher(i), 2), RubatoModDownParams[i]}
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.planner.Plan()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkPlainKeyStream(t, tc.planner, got)
+			if len(got.CipherModDown) != len(tc.want.CipherModDown) || len(got.StCModDown) != len(tc.want.StCModDown) {
+				t.Fatalf("mod-down %v %v, want the lengths of %v %v", got.CipherModDown, got.StCModDown,
+					tc.want.CipherModDown, tc.want.StCModDown)
+			}
+			gotCipher, gotStC := dropped(got)
+			wantCipher, wantStC := dropped(tc.want)
+			if gotCipher+gotStC+modDownTolerance < wantCipher+wantStC {
+				t.Errorf("planned %v %v drops %d moduli, the table %v %v drops %d", got.CipherModDown, got.StCModDown,
+					gotCipher+gotStC, tc.want.CipherModDown, tc.want.StCModDown, wantCipher+wantStC)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Logf("planned %v %v, table %v %v", got.CipherModDown, got.StCModDown, tc.want.CipherModDown, tc.want.StCModDown)
+			}
+		})
+	}
+}
+
+func TestModDownPlannerCache(t *testing.T) {
+	planner := NewModDownPlanner(RtFHeraParams[0], HeraCipher(4), 2)
+	planner.CacheDir = t.TempDir()
+
+	// a cached result is returned without evaluating the key stream
+	want := HeraModDownParams80[0]
+	path, err := planner.cachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := planner.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Plan() = %v, want %v", got, want)
+	}
+
+	// every input of the search is part of the cache key
+	other := *planner
+	other.Margin++
+	otherPath, err := other.cachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherPath == path {
+		t.Errorf("same cache file %s for margins %d and %d", path, planner.Margin, other.Margin)
+	}
+}
+
+func TestModDownPlannerErrors(t *testing.T) {
+	for name, planner := range map[string]*ModDownPlanner{
+		"nil parameters":  NewModDownPlanner(nil, HeraCipher(4), 2),
+		"negative radix":  NewModDownPlanner(RtFHeraParams[0], HeraCipher(4), -1),
+		"no round":        NewModDownPlanner(RtFHeraParams[0], HeraCipher(0), 2),
+		"rubato index":    NewModDownPlanner(RtFRubatoParams[0], RubatoCipher(len(RubatoParams)), 2),
+		"unknown cipher":  NewModDownPlanner(RtFHeraParams[0], ModDownCipher{Name: "pasta"}, 2),
+		"negative margin": {hbtpParams: RtFHeraParams[0], cipher: HeraCipher(4), Margin: -1},
+	} {
+		if _, err := planner.Plan(); !errors.Is(err, ErrInvalidParameters) {
+			t.Errorf("%s: got error %v, want %v", name, err, ErrInvalidParameters)
+		}
+	}
+}
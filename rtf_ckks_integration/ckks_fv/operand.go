@@ -20,6 +20,7 @@ type Element struct {
 	value []*ring.Poly
 	scale float64
 	isNTT bool
+	noise float64 // log2 of the noise bound tracked by the MFV evaluator, 0 if not tracked, kept by the copies and MarshalBinary
 }
 
 // NewElement returns a new Element with zero values.
@@ -149,6 +150,7 @@ func (el *Element) Copy(ctxCopy *Element) {
 func (el *Element) CopyParams(Element *Element) {
 	el.SetScale(Element.Scale())
 	el.SetIsNTT(Element.IsNTT())
+	el.noise = Element.noise
 }
 
 // El sets the target element type to Element.
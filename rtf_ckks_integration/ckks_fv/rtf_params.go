@@ -2,7 +2,7 @@ package ckks_fv
 
 /* ModDown Parameters*/
 // ModDownParams denotes optimized modulus switching indices for given RtF parameters
-// (See github.com/smilecjf/lattigo/v2/examples/ckks_fv/main for an example)
+// (See ModDownPlanner to search them for other parameters)
 // StcModDownParams assumes that the decoding matrix is factorized with radix 2.
 
 type ModDownParams struct {
@@ -4,7 +4,6 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math"
-	"os"
 
 	"HHESoK/rtf_ckks_integration/ckks_fv"
 	"HHESoK/rtf_ckks_integration/ring"
@@ -14,155 +13,20 @@ import (
 
 // findHeraModDown(4, 0, 2, false)
 func findHeraModDown(numRound int, paramIndex int, radix int, fullCoeffs bool) {
-	var err error
-
-	var kgen ckks_fv.KeyGenerator
-	var fvEncoder ckks_fv.MFVEncoder
-	var sk *ckks_fv.SecretKey
-	var pk *ckks_fv.PublicKey
-	var fvEncryptor ckks_fv.MFVEncryptor
-	var fvDecryptor ckks_fv.MFVDecryptor
-	var fvEvaluator ckks_fv.MFVEvaluator
-	var fvNoiseEstimator ckks_fv.MFVNoiseEstimator
-	var hera ckks_fv.MFVHera
-
-	var nonces [][]byte
-	var key []uint64
-	var stCt []*ckks_fv.Ciphertext
-	var keystream [][]uint64
-
-	var heraModDown []int
-	var stcModDown []int
-
 	// RtF parameters
 	// Four sets of parameters (index 0 to 3) ensuring 128 bit of security
 	// are available in github.com/smilecjf/lattigo/v2/ckks_fv/rtf_params
-	// LogSlots is hardcoded in the parameters, but can be changed from 4 to 15.
-	// When changing logSlots make sure that the number of levels allocated to CtS is
-	// smaller or equal to logSlots.
+	// fullCoeffs denotes whether full coefficients are used for data encoding
+	planner := ckks_fv.NewModDownPlanner(ckks_fv.RtFHeraParams[paramIndex], ckks_fv.HeraCipher(numRound), radix)
+	planner.FullCoeffs = fullCoeffs
 
-	hbtpParams := ckks_fv.RtFHeraParams[paramIndex]
-	params, err := hbtpParams.Params()
+	modDown, err := planner.Plan()
 	if err != nil {
 		panic(err)
 	}
 
-	// fullCoeffs denotes whether full coefficients are used for data encoding
-	if fullCoeffs {
-		params.SetLogFVSlots(params.LogN())
-	} else {
-		params.SetLogFVSlots(params.LogSlots())
-	}
-
-	// Scheme context and keys
-	kgen = ckks_fv.NewKeyGenerator(params)
-
-	sk, pk = kgen.GenKeyPairSparse(hbtpParams.H)
-
-	fvEncoder = ckks_fv.NewMFVEncoder(params)
-
-	fvEncryptor = ckks_fv.NewMFVEncryptorFromPk(params, pk)
-	fvDecryptor = ckks_fv.NewMFVDecryptor(params, sk)
-	fvNoiseEstimator = ckks_fv.NewMFVNoiseEstimator(params, sk)
-
-	pDcds := fvEncoder.GenSlotToCoeffMatFV(radix)
-	rotations := kgen.GenRotationIndexesForSlotsToCoeffsMat(pDcds)
-	rotkeys := kgen.GenRotationKeysForRotations(rotations, true, sk)
-	rlk := kgen.GenRelinearizationKey(sk)
-
-	fvEvaluator = ckks_fv.NewMFVEvaluator(params, ckks_fv.EvaluationKey{Rlk: rlk, Rtks: rotkeys}, pDcds)
-
-	// Generating data set
-	key = make([]uint64, 16)
-	for i := 0; i < 16; i++ {
-		key[i] = uint64(i + 1) // Use (1, ..., 16) for testing
-	}
-
-	nonces = make([][]byte, params.FVSlots())
-	for i := 0; i < params.FVSlots(); i++ {
-		nonces[i] = make([]byte, 64)
-		rand.Read(nonces[i])
-	}
-
-	keystream = make([][]uint64, params.FVSlots())
-	for i := 0; i < params.FVSlots(); i++ {
-		keystream[i] = plainHera(numRound, nonces[i], key, params.PlainModulus())
-	}
-
-	// Find proper nbInitModDown value for fvHera
-	fmt.Println("=========== Start to find nbInitModDown ===========")
-	hera = ckks_fv.NewMFVHera(numRound, params, fvEncoder, fvEncryptor, fvEvaluator, 0)
-	heKey := hera.EncKey(key)
-	stCt = hera.CryptNoModSwitch(nonces, heKey)
-
-	invBudgets := make([]int, 16)
-	minInvBudget := int((^uint(0)) >> 1) // MaxInt
-	for i := 0; i < 16; i++ {
-		ksSlot := fvEvaluator.SlotsToCoeffsNoModSwitch(stCt[i])
-
-		invBudgets[i] = fvNoiseEstimator.InvariantNoiseBudget(ksSlot)
-		if invBudgets[i] < minInvBudget {
-			minInvBudget = invBudgets[i]
-		}
-		fvEvaluator.ModSwitchMany(ksSlot, ksSlot, ksSlot.Level())
-
-		ksCt := fvDecryptor.DecryptNew(ksSlot)
-		ksCoef := ckks_fv.NewPlaintextRingT(params)
-		fvEncoder.DecodeRingT(ksCt, ksCoef)
-
-		for j := 0; j < params.FVSlots(); j++ {
-			br_j := utils.BitReverse64(uint64(j), uint64(params.LogN()))
-
-			if ksCoef.Element.Value()[0].Coeffs[0][br_j] != keystream[j][i] {
-				fmt.Printf("[-] Validity failed")
-				os.Exit(0)
-			}
-		}
-	}
-	fmt.Printf("Budget info : min %d in %v\n", minInvBudget, invBudgets)
-
-	qi := params.Qi()
-	qiCount := params.QiCount()
-	logQi := make([]int, qiCount)
-	for i := 0; i < qiCount; i++ {
-		logQi[i] = int(math.Round(math.Log2(float64(qi[i]))))
-	}
-
-	nbInitModDown := 0
-	cutBits := logQi[qiCount-1]
-	for cutBits+40 < minInvBudget { // if minInvBudget is too close to cutBits, decryption can be failed
-		nbInitModDown++
-		cutBits += logQi[qiCount-nbInitModDown-1]
-	}
-	fmt.Printf("Preferred nbInitModDown = %d\n\n", nbInitModDown)
-
-	fmt.Println("=========== Start to find HeraModDown & StcModDown ===========")
-	hera = ckks_fv.NewMFVHera(numRound, params, fvEncoder, fvEncryptor, fvEvaluator, nbInitModDown)
-	heKey = hera.EncKey(key)
-	stCt, heraModDown = hera.CryptAutoModSwitch(nonces, heKey, fvNoiseEstimator)
-	_, stcModDown = fvEvaluator.SlotsToCoeffsAutoModSwitch(stCt[0], fvNoiseEstimator)
-	for i := 0; i < 16; i++ {
-		ksSlot := fvEvaluator.SlotsToCoeffs(stCt[i], stcModDown)
-		if ksSlot.Level() > 0 {
-			fvEvaluator.ModSwitchMany(ksSlot, ksSlot, ksSlot.Level())
-		}
-
-		ksCt := fvDecryptor.DecryptNew(ksSlot)
-		ksCoef := ckks_fv.NewPlaintextRingT(params)
-		fvEncoder.DecodeRingT(ksCt, ksCoef)
-
-		for j := 0; j < params.FVSlots(); j++ {
-			br_j := utils.BitReverse64(uint64(j), uint64(params.LogN()))
-
-			if ksCoef.Element.Value()[0].Coeffs[0][br_j] != keystream[j][i] {
-				fmt.Printf("[-] Validity failed")
-				os.Exit(0)
-			}
-		}
-	}
-
-	fmt.Printf("Hera modDown : %v\n", heraModDown)
-	fmt.Printf("SlotsToCoeffs modDown : %v\n", stcModDown)
+	fmt.Printf("Hera modDown : %v\n", modDown.CipherModDown)
+	fmt.Printf("SlotsToCoeffs modDown : %v\n", modDown.StCModDown)
 }
 
 func plainHera(roundNum int, nonce []byte, key []uint64, plainModulus uint64) (state []uint64) {
@@ -530,11 +394,16 @@ func testFVRubato(rubatoParam int) {
 
 	// Evaluate the Rubato keystream
 	fmt.Println("Evaluating HE keystream...")
-	rubato = ckks_fv.NewMFVRubato(rubatoParam, params, fvEncoder, fvEncryptor, fvEvaluator, 0)
-	hekey := rubato.EncKey(key)
+	rubato = ckks_fv.MustNewMFVRubato(rubatoParam, params, fvEncoder, fvEncryptor, fvEvaluator, 0)
+	hekey, err := rubato.EncKey(key)
+	if err != nil {
+		panic(err)
+	}
 	budget := fvNoiseEstimator.InvariantNoiseBudget(hekey[0])
 	fmt.Printf("Initial noise budget: %d\n", budget)
-	keystreamCt = rubato.CryptNoModSwitch(nonces, counter, hekey)
+	if keystreamCt, err = rubato.CryptNoModSwitch(nonces, counter, hekey); err != nil {
+		panic(err)
+	}
 	budget = fvNoiseEstimator.InvariantNoiseBudget(keystreamCt[0])
 	fmt.Printf("Output noise budget: %d\n", budget)
 
@@ -730,11 +599,16 @@ func testRtFRubatoModDown(rubatoParam int, paramIndex int, radix int, fullCoeffs
 		fvEncoder.FVScaleUp(plainCKKSRingTs[s], plaintexts[s])
 	}
 
-	rubato = ckks_fv.NewMFVRubato(rubatoParam, params, fvEncoder, fvEncryptor, fvEvaluator, rubatoModDown[0])
-	kCt := rubato.EncKey(key)
+	rubato = ckks_fv.MustNewMFVRubato(rubatoParam, params, fvEncoder, fvEncryptor, fvEvaluator, rubatoModDown[0])
+	kCt, err := rubato.EncKey(key)
+	if err != nil {
+		panic(err)
+	}
 
 	// FV Keystream
-	fvKeystreams = rubato.CryptNoModSwitch(nonces, counter, kCt)
+	if fvKeystreams, err = rubato.CryptNoModSwitch(nonces, counter, kCt); err != nil {
+		panic(err)
+	}
 	for i := 0; i < outputsize; i++ {
 		fvKeystreams[i] = fvEvaluator.SlotsToCoeffs(fvKeystreams[i], stcModDown)
 		fvEvaluator.ModSwitchMany(fvKeystreams[i], fvKeystreams[i], fvKeystreams[i].Level())
@@ -756,9 +630,13 @@ func testRtFRubatoModDown(rubatoParam int, paramIndex int, radix int, fullCoeffs
 		// CAUTION: the scale of the ciphertext MUST be equal (or very close) to params.Scale
 		// To equalize the scale, the function evaluator.SetScale(ciphertext, parameters.Scale) can be used at the expense of one level.
 		if fullCoeffs {
-			ctBoot, _ = hbtp.HalfBoot(ciphertext, false)
+			if ctBoot, _, err = hbtp.HalfBoot(ciphertext, false); err != nil {
+				panic(err)
+			}
 		} else {
-			ctBoot, _ = hbtp.HalfBoot(ciphertext, true)
+			if ctBoot, _, err = hbtp.HalfBoot(ciphertext, true); err != nil {
+				panic(err)
+			}
 		}
 
 		valuesWant := make([]complex128, params.Slots())
@@ -789,157 +667,18 @@ func printDebug(params *ckks_fv.Parameters, ciphertext *ckks_fv.Ciphertext, valu
 }
 
 func findRubatoModDown(rubatoParam int, radix int) {
-	var err error
-
-	var kgen ckks_fv.KeyGenerator
-	var fvEncoder ckks_fv.MFVEncoder
-	var sk *ckks_fv.SecretKey
-	var pk *ckks_fv.PublicKey
-	var fvEncryptor ckks_fv.MFVEncryptor
-	var fvDecryptor ckks_fv.MFVDecryptor
-	var fvEvaluator ckks_fv.MFVEvaluator
-	var fvNoiseEstimator ckks_fv.MFVNoiseEstimator
-	var rubato ckks_fv.MFVRubato
-
-	var nonces [][]byte
-	var key []uint64
-	var stCt []*ckks_fv.Ciphertext
-	var keystream [][]uint64
-
-	var rubatoModDown []int
-	var stcModDown []int
-
-	// Rubato parameter
-	blocksize := ckks_fv.RubatoParams[rubatoParam].Blocksize
-	numRound := ckks_fv.RubatoParams[rubatoParam].NumRound
-	plainModulus := ckks_fv.RubatoParams[rubatoParam].PlainModulus
-
 	// RtF Rubato parameters
 	// Four sets of parameters (index 0 to 1) ensuring 128 bit of security
 	// are available in github.com/smilecjf/lattigo/v2/ckks_fv/rtf_params
-	// LogSlots is hardcoded in the parameters, but can be changed from 4 to 15.
-	// When changing logSlots make sure that the number of levels allocated to CtS is
-	// smaller or equal to logSlots.
+	planner := ckks_fv.NewModDownPlanner(ckks_fv.RtFRubatoParams[0], ckks_fv.RubatoCipher(rubatoParam), radix)
 
-	hbtpParams := ckks_fv.RtFRubatoParams[0]
-	params, err := hbtpParams.Params()
+	modDown, err := planner.Plan()
 	if err != nil {
 		panic(err)
 	}
-	params.SetPlainModulus(plainModulus)
-	params.SetLogFVSlots(params.LogN())
-
-	// Scheme context and keys
-	kgen = ckks_fv.NewKeyGenerator(params)
-	sk, pk = kgen.GenKeyPairSparse(hbtpParams.H)
-
-	fvEncoder = ckks_fv.NewMFVEncoder(params)
-
-	fvEncryptor = ckks_fv.NewMFVEncryptorFromPk(params, pk)
-	fvDecryptor = ckks_fv.NewMFVDecryptor(params, sk)
-	fvNoiseEstimator = ckks_fv.NewMFVNoiseEstimator(params, sk)
-
-	pDcds := fvEncoder.GenSlotToCoeffMatFV(radix)
-	rotations := kgen.GenRotationIndexesForSlotsToCoeffsMat(pDcds)
-	rotkeys := kgen.GenRotationKeysForRotations(rotations, true, sk)
-	rlk := kgen.GenRelinearizationKey(sk)
-
-	fvEvaluator = ckks_fv.NewMFVEvaluator(params, ckks_fv.EvaluationKey{Rlk: rlk, Rtks: rotkeys}, pDcds)
-
-	// Generating data set
-	key = make([]uint64, blocksize)
-	for i := 0; i < blocksize; i++ {
-		key[i] = uint64(i + 1) // Use (1, ..., 16) for testing
-	}
-
-	nonces = make([][]byte, params.FVSlots())
-	for i := 0; i < params.FVSlots(); i++ {
-		nonces[i] = make([]byte, 64)
-		rand.Read(nonces[i])
-	}
-	counter := make([]byte, 64)
-	rand.Read(counter)
-
-	keystream = make([][]uint64, params.FVSlots())
-	for i := 0; i < params.FVSlots(); i++ {
-		keystream[i] = plainRubato(blocksize, numRound, nonces[i], counter, key, params.PlainModulus(), -1)
-	}
-	outputsize := blocksize - 4
-
-	// Find proper nbInitModDown value for fvHera
-	fmt.Println("=========== Start to find nbInitModDown ===========")
-	rubato = ckks_fv.NewMFVRubato(rubatoParam, params, fvEncoder, fvEncryptor, fvEvaluator, 0)
-	heKey := rubato.EncKey(key)
-	stCt = rubato.CryptNoModSwitch(nonces, counter, heKey)
-
-	invBudgets := make([]int, outputsize)
-	minInvBudget := int((^uint(0)) >> 1) // MaxInt
-	for i := 0; i < outputsize; i++ {
-		ksSlot := fvEvaluator.SlotsToCoeffsNoModSwitch(stCt[i])
-
-		invBudgets[i] = fvNoiseEstimator.InvariantNoiseBudget(ksSlot)
-		if invBudgets[i] < minInvBudget {
-			minInvBudget = invBudgets[i]
-		}
-		fvEvaluator.ModSwitchMany(ksSlot, ksSlot, ksSlot.Level())
-
-		ksCt := fvDecryptor.DecryptNew(ksSlot)
-		ksCoef := ckks_fv.NewPlaintextRingT(params)
-		fvEncoder.DecodeRingT(ksCt, ksCoef)
-
-		for j := 0; j < params.FVSlots(); j++ {
-			br_j := utils.BitReverse64(uint64(j), uint64(params.LogN()))
-
-			if ksCoef.Element.Value()[0].Coeffs[0][br_j] != keystream[j][i] {
-				fmt.Printf("[-] Validity failed")
-				os.Exit(0)
-			}
-		}
-	}
-	fmt.Printf("Budget info : min %d in %v\n", minInvBudget, invBudgets)
-
-	qi := params.Qi()
-	qiCount := params.QiCount()
-	logQi := make([]int, qiCount)
-	for i := 0; i < qiCount; i++ {
-		logQi[i] = int(math.Round(math.Log2(float64(qi[i]))))
-	}
-
-	nbInitModDown := 0
-	cutBits := logQi[qiCount-1]
-	for cutBits+40 < minInvBudget { // if minInvBudget is too close to cutBits, decryption can be failed
-		nbInitModDown++
-		cutBits += logQi[qiCount-nbInitModDown-1]
-	}
-	fmt.Printf("Preferred nbInitModDown = %d\n\n", nbInitModDown)
-
-	fmt.Println("=========== Start to find RubatoModDown & StcModDown ===========")
-	rubato = ckks_fv.NewMFVRubato(rubatoParam, params, fvEncoder, fvEncryptor, fvEvaluator, nbInitModDown)
-	heKey = rubato.EncKey(key)
-	stCt, rubatoModDown = rubato.CryptAutoModSwitch(nonces, counter, heKey, fvNoiseEstimator)
-	_, stcModDown = fvEvaluator.SlotsToCoeffsAutoModSwitch(stCt[0], fvNoiseEstimator)
-	for i := 0; i < outputsize; i++ {
-		ksSlot := fvEvaluator.SlotsToCoeffs(stCt[i], stcModDown)
-		if ksSlot.Level() > 0 {
-			fvEvaluator.ModSwitchMany(ksSlot, ksSlot, ksSlot.Level())
-		}
-
-		ksCt := fvDecryptor.DecryptNew(ksSlot)
-		ksCoef := ckks_fv.NewPlaintextRingT(params)
-		fvEncoder.DecodeRingT(ksCt, ksCoef)
-
-		for j := 0; j < params.FVSlots(); j++ {
-			br_j := utils.BitReverse64(uint64(j), uint64(params.LogN()))
-
-			if ksCoef.Element.Value()[0].Coeffs[0][br_j] != keystream[j][i] {
-				fmt.Printf("[-] Validity failed")
-				os.Exit(0)
-			}
-		}
-	}
 
-	fmt.Printf("Rubato modDown : %v\n", rubatoModDown)
-	fmt.Printf("SlotsToCoeffs modDown : %v\n", stcModDown)
+	fmt.Printf("Rubato modDown : %v\n", modDown.CipherModDown)
+	fmt.Printf("SlotsToCoeffs modDown : %v\n", modDown.StCModDown)
 }
 
 func main() {
@@ -249,6 +249,8 @@ func (ss *SimpleScaler) reconstructThenScale(p1, p2 *Poly) {
 		if ss.r.Lsh(ss.r, 1).CmpAbs(ss.ringQ.ModulusBigint) != -1 {
 			coeff.Add(coeff, ss.one)
 		}
+		// round(t*x/Q) reaches t for x close to Q
+		coeff.Mod(coeff, ss.tBI)
 
 		for j := range p2.Coeffs {
 			p2.Coeffs[j][i] = coeff.Uint64()
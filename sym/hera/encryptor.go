@@ -1,107 +1,50 @@
 package hera
 
-import (
-	"HHESoK"
-	"encoding/binary"
-	"math"
-)
+import "HHESoK"
 
-type Encryptor interface {
-	Encrypt(plaintext HHESoK.Plaintext) HHESoK.Ciphertext
-	Decrypt(ciphertext HHESoK.Ciphertext) HHESoK.Plaintext
-	KeyStream(size int) HHESoK.Matrix
-}
+type Encryptor = HHESoK.Encryptor
 
 type encryptor struct {
 	her hera
 }
 
-// Encrypt plaintext
+// Encrypt plaintext using the fixed default nonce
 func (enc encryptor) Encrypt(plaintext HHESoK.Plaintext) HHESoK.Ciphertext {
-	logger := HHESoK.NewLogger(HHESoK.DEBUG)
-	var size = len(plaintext)
-	var modulus = enc.her.params.GetModulus()
-	var blockSize = enc.her.params.GetBlockSize()
-	var numBlock = int(math.Ceil(float64(size / blockSize)))
-	logger.PrintFormatted("Number of Block: %d", numBlock)
-
-	// Nonce and Counter
-	nonces := make([][]byte, numBlock)
-	// set nonce up to blockSize
-	n := 123456789
-	for i := 0; i < numBlock; i++ {
-		nonces[i] = make([]byte, 8)
-		binary.BigEndian.PutUint64(nonces[i], uint64(i+n))
-	}
-
-	ciphertext := make(HHESoK.Ciphertext, size)
-	copy(ciphertext, plaintext)
-
-	for i := 0; i < numBlock; i++ {
-		z := make(HHESoK.Block, blockSize)
-		copy(z, enc.her.KeyStream(nonces[i]))
-		ciphertext[i] = (ciphertext[i] + z[i]) % modulus
-	}
-
-	return ciphertext
+	return enc.EncryptWithNonce(HHESoK.DefaultNonce(), plaintext)
 }
 
-// Decrypt ciphertext
+// Decrypt ciphertext using the fixed default nonce
 func (enc encryptor) Decrypt(ciphertext HHESoK.Ciphertext) HHESoK.Plaintext {
-	logger := HHESoK.NewLogger(HHESoK.DEBUG)
-
-	var size = len(ciphertext)
-	var modulus = enc.her.params.GetModulus()
-	var blockSize = enc.her.params.GetBlockSize()
-	var numBlock = int(math.Ceil(float64(size / blockSize)))
-	logger.PrintFormatted("Number of Block: %d", numBlock)
-
-	// Nonce and Counter
-	nonces := make([][]byte, numBlock)
-	// set nonce up to blockSize
-	n := 123456789
-	for i := 0; i < numBlock; i++ {
-		nonces[i] = make([]byte, 8)
-		binary.BigEndian.PutUint64(nonces[i], uint64(i+n))
-	}
-
-	plaintext := make(HHESoK.Plaintext, size)
-	copy(plaintext, ciphertext)
-
-	for i := 0; i < numBlock; i++ {
-		z := make(HHESoK.Block, blockSize)
-		copy(z, enc.her.KeyStream(nonces[i]))
-
-		if z[i] > plaintext[i] {
-			plaintext[i] += modulus
-		}
-		plaintext[i] = plaintext[i] - z[i]
-	}
+	return enc.DecryptWithNonce(HHESoK.DefaultNonce(), ciphertext)
+}
 
-	return plaintext
+// EncryptWithNonce encrypts plaintext under the given nonce, starting from block counter 0
+func (enc encryptor) EncryptWithNonce(nonce []byte, plaintext HHESoK.Plaintext) HHESoK.Ciphertext {
+	return enc.EncryptWithCounter(nonce, 0, plaintext)
 }
 
-// KeyStream takes len(plaintext) as input and generate a KeyStream
-func (enc encryptor) KeyStream(size int) (keyStream HHESoK.Matrix) {
-	logger := HHESoK.NewLogger(HHESoK.DEBUG)
+// DecryptWithNonce decrypts ciphertext under the given nonce, starting from block counter 0
+func (enc encryptor) DecryptWithNonce(nonce []byte, ciphertext HHESoK.Ciphertext) HHESoK.Plaintext {
+	return enc.DecryptWithCounter(nonce, 0, ciphertext)
+}
 
-	blockSize := enc.her.params.GetBlockSize()
-	numBlock := int(math.Ceil(float64(size / blockSize)))
-	logger.PrintFormatted("Number of Block: %d", numBlock)
+// EncryptWithCounter encrypts plaintext under the given nonce, block i uses counter+i
+func (enc encryptor) EncryptWithCounter(nonce []byte, counter uint64, plaintext HHESoK.Plaintext) HHESoK.Ciphertext {
+	return HHESoK.CTREncrypt(&enc.her, nonce, counter, plaintext)
+}
 
-	nonces := make([][]byte, numBlock)
-	// set nonce up to blockSize
-	n := 123456789
-	for i := 0; i < numBlock; i++ {
-		nonces[i] = make([]byte, 8)
-		binary.BigEndian.PutUint64(nonces[i], uint64(i+n))
-	}
+// DecryptWithCounter decrypts ciphertext under the given nonce, block i uses counter+i
+func (enc encryptor) DecryptWithCounter(nonce []byte, counter uint64, ciphertext HHESoK.Ciphertext) HHESoK.Plaintext {
+	return HHESoK.CTRDecrypt(&enc.her, nonce, counter, ciphertext)
+}
 
-	// generate key stream
-	keyStream = make(HHESoK.Matrix, numBlock)
-	for i := 0; i < numBlock; i++ {
-		copy(keyStream[i], enc.her.KeyStream(nonces[i]))
-	}
+// EncryptRandomNonce encrypts plaintext under a fresh random nonce,
+// the nonce is prepended to the ciphertext as HHESoK.NonceElements field elements
+func (enc encryptor) EncryptRandomNonce(plaintext HHESoK.Plaintext) (HHESoK.Ciphertext, error) {
+	return HHESoK.CTREncryptRandomNonce(&enc.her, plaintext)
+}
 
-	return
+// DecryptRandomNonce decrypts a ciphertext produced by EncryptRandomNonce
+func (enc encryptor) DecryptRandomNonce(ciphertext HHESoK.Ciphertext) (HHESoK.Plaintext, error) {
+	return HHESoK.CTRDecryptRandomNonce(&enc.her, ciphertext)
 }
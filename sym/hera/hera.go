@@ -3,12 +3,12 @@ package hera
 import (
 	"HHESoK"
 	"HHESoK/rtf_ckks_integration/ckks_fv"
+	"fmt"
 	"golang.org/x/crypto/sha3"
 )
 
 type Hera interface {
-	NewEncryptor() Encryptor
-	KeyStream(nonce []byte) HHESoK.Block
+	HHESoK.SymmetricCipher
 }
 
 type hera struct {
@@ -18,33 +18,80 @@ type hera struct {
 	state     HHESoK.Block
 	rcs       HHESoK.Matrix
 	p         uint64
+	mod       HHESoK.Modulus
+	keyMForm  HHESoK.Block
 }
 
-// NewHera return a new instance of Hera cipher
-func NewHera(secretKey HHESoK.Key, params Parameter) Hera {
+// NewHera returns a new instance of HERA cipher, the secret key holds block size elements
+func NewHera(secretKey HHESoK.Key, params Parameter) (Hera, error) {
+	// the round constants are sampled from at least one bit, which rules out Z_2
+	if params.GetModulus() < 3 || params.GetBlockSize() == 0 {
+		return nil, fmt.Errorf("%w: HERA block size %d, modulus %d", HHESoK.ErrInvalidParameters, params.GetBlockSize(), params.GetModulus())
+	}
 	if len(secretKey) != params.GetBlockSize() {
-		panic("Invalid Key Length!")
+		return nil, fmt.Errorf("%w: got %d elements, want %d", HHESoK.ErrKeyLength, len(secretKey), params.GetBlockSize())
 	}
 
 	state := make(HHESoK.Block, params.GetBlockSize())
+	mod, err := HHESoK.NewModulus(params.GetModulus())
+	if err != nil {
+		return nil, err
+	}
+	// the key multiplies every round constant, keep it in the Montgomery domain
+	keyMForm := make(HHESoK.Block, len(secretKey))
+	for i := range secretKey {
+		keyMForm[i] = mod.MForm(mod.Reduce(secretKey[i]))
+	}
 	her := &hera{
 		params:    params,
 		shake:     nil,
 		secretKey: secretKey,
 		state:     state,
 		p:         params.GetModulus(),
+		mod:       mod,
+		keyMForm:  keyMForm,
 		rcs:       nil,
 	}
+	return her, nil
+}
+
+// MustNewHera is NewHera panicking on invalid parameters or key
+func MustNewHera(secretKey HHESoK.Key, params Parameter) Hera {
+	her, err := NewHera(secretKey, params)
+	HHESoK.HandleError(err)
 	return her
 }
 
+// ShallowCopy returns a new instance sharing the secret key and parameters,
+// with fresh internal states that can be used on another goroutine
+func (her *hera) ShallowCopy() HHESoK.SymmetricCipher {
+	return MustNewHera(her.secretKey, her.params)
+}
+
 func (her *hera) NewEncryptor() Encryptor {
 	return &encryptor{her: *her}
 }
 
-func (her *hera) KeyStream(nonce []byte) (ks HHESoK.Block) {
+// GetKeySize returns the secret key size, equal to the block size
+func (her *hera) GetKeySize() int {
+	return her.params.GetBlockSize()
+}
+
+// GetKeyStreamSize returns the key stream size of one block, equal to the block size
+func (her *hera) GetKeyStreamSize() int {
+	return her.params.GetBlockSize()
+}
+
+// GetModulus returns the plaintext modulus
+func (her *hera) GetModulus() uint64 {
+	return her.params.GetModulus()
+}
+
+// KeyStream returns a vector of [BlockSize][uint64] elements as key stream,
+// the counter is absorbed after the nonce and can be nil as in the RtF framework
+func (her *hera) KeyStream(nonce []byte, counter []byte) (ks HHESoK.Block) {
 	// init Shake256
-	her.initShake(nonce)
+	her.initShake(nonce, counter)
 	// init state with values between 1 and BlockSize
 	her.initState()
 	her.generateRCs()
@@ -74,16 +121,15 @@ func (her *hera) initState() {
 	}
 }
 
-func (her *hera) initShake(nonce []byte) {
+func (her *hera) initShake(nonce []byte, counter []byte) {
 	shake := sha3.NewShake256()
-	if _, err := shake.Write(nonce); err != nil {
-		panic("Failed to init SHAKE128!")
-	}
+	// writes to a SHAKE state never fail
+	_, _ = shake.Write(nonce)
+	_, _ = shake.Write(counter)
 	her.shake = shake
 }
 
 func (her *hera) generateRCs() {
-	key := her.secretKey
 	p := her.params.GetModulus()
 	rounds := her.params.GetRounds()
 	blockSize := her.params.GetBlockSize()
@@ -93,7 +139,7 @@ func (her *hera) generateRCs() {
 	for r := 0; r <= rounds; r++ {
 		rcs[r] = make([]uint64, blockSize)
 		for i := 0; i < blockSize; i++ {
-			rcs[r][i] = ckks_fv.SampleZqx(her.shake, p) * key[i] % p
+			rcs[r][i] = her.mod.MulMForm(ckks_fv.SampleZqx(her.shake, p), her.keyMForm[i])
 		}
 	}
 	her.rcs = rcs
@@ -101,43 +147,40 @@ func (her *hera) generateRCs() {
 
 func (her *hera) keySchedule(r int) {
 	for i := 0; i < her.params.GetBlockSize(); i++ {
-		her.state[i] = (her.state[i] + her.rcs[r][i]) % her.params.GetModulus()
+		her.state[i] = her.mod.Add(her.state[i], her.rcs[r][i])
 	}
 }
 
 func (her *hera) mixColumns() {
-	p := her.params.GetModulus()
 	for col := 0; col < 4; col++ {
 		y0 := 2*her.state[col] + 3*her.state[col+4] + 1*her.state[col+8] + 1*her.state[col+12]
 		y1 := 2*her.state[col+4] + 3*her.state[col+8] + 1*her.state[col+12] + 1*her.state[col]
 		y2 := 2*her.state[col+8] + 3*her.state[col+12] + 1*her.state[col] + 1*her.state[col+4]
 		y3 := 2*her.state[col+12] + 3*her.state[col] + 1*her.state[col+4] + 1*her.state[col+8]
 
-		her.state[col] = y0 % p
-		her.state[col+4] = y1 % p
-		her.state[col+8] = y2 % p
-		her.state[col+12] = y3 % p
+		her.state[col] = her.mod.Reduce(y0)
+		her.state[col+4] = her.mod.Reduce(y1)
+		her.state[col+8] = her.mod.Reduce(y2)
+		her.state[col+12] = her.mod.Reduce(y3)
 	}
 }
 
 func (her *hera) mixRows() {
-	p := her.params.GetModulus()
 	for row := 0; row < 4; row++ {
 		y0 := 2*her.state[4*row] + 3*her.state[4*row+1] + 1*her.state[4*row+2] + 1*her.state[4*row+3]
 		y1 := 2*her.state[4*row+1] + 3*her.state[4*row+2] + 1*her.state[4*row+3] + 1*her.state[4*row]
 		y2 := 2*her.state[4*row+2] + 3*her.state[4*row+3] + 1*her.state[4*row] + 1*her.state[4*row+1]
 		y3 := 2*her.state[4*row+3] + 3*her.state[4*row] + 1*her.state[4*row+1] + 1*her.state[4*row+2]
 
-		her.state[4*row] = y0 % p
-		her.state[4*row+1] = y1 % p
-		her.state[4*row+2] = y2 % p
-		her.state[4*row+3] = y3 % p
+		her.state[4*row] = her.mod.Reduce(y0)
+		her.state[4*row+1] = her.mod.Reduce(y1)
+		her.state[4*row+2] = her.mod.Reduce(y2)
+		her.state[4*row+3] = her.mod.Reduce(y3)
 	}
 }
 
 func (her *hera) sBoxCube() {
-	p := her.params.GetModulus()
 	for i := 0; i < her.params.GetBlockSize(); i++ {
-		her.state[i] = (her.state[i] * her.state[i] % p) * her.state[i] % p
+		her.state[i] = her.mod.Mul(her.mod.Mul(her.state[i], her.state[i]), her.state[i])
 	}
 }
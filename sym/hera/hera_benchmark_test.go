@@ -24,7 +24,14 @@ func benchmarkHera(tc *TestContext, b *testing.B) {
 
 	b.Run("HERA/NewHera", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			heraCipher = NewHera(tc.Key, tc.Params)
+			heraCipher = MustNewHera(tc.Key, tc.Params)
+		}
+	})
+
+	b.Run("HERA/KeyStream", func(b *testing.B) {
+		nonce := HHESoK.DefaultNonce()
+		for i := 0; i < b.N; i++ {
+			heraCipher.KeyStream(nonce, HHESoK.CounterBytes(uint64(i)))
 		}
 	})
 
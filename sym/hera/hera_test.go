@@ -15,7 +15,7 @@ func TestHera(t *testing.T) {
 	logger := HHESoK.NewLogger(HHESoK.DEBUG)
 	for _, tc := range TestVector {
 		fmt.Println(testString("HERA", tc.Params))
-		heraCipher := NewHera(tc.Key, tc.Params)
+		heraCipher := MustNewHera(tc.Key, tc.Params)
 		encryptor := heraCipher.NewEncryptor()
 		var ciphertext HHESoK.Ciphertext
 
@@ -0,0 +1,52 @@
+package sym
+
+import (
+	"HHESoK"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// benchSize is the number of elements of a sensor batch encrypted by the clients
+const benchSize = 1000000
+
+var benchCiphers = []struct{ name, paramSet string }{
+	{"pasta4", "17"},
+	{"hera", "128af"},
+	{"rubato", "128s"},
+}
+
+func BenchmarkKeyStreamGenerator(b *testing.B) {
+	for _, bc := range benchCiphers {
+		entry, err := Lookup(bc.name, bc.paramSet)
+		if err != nil {
+			b.Fatal(err)
+		}
+		probe, err := entry.New(make(HHESoK.Key, entry.KeySize))
+		if err != nil {
+			b.Fatal(err)
+		}
+		key := HHESoK.Key(randomVector(entry.KeySize, probe.GetModulus()))
+		cipher, err := entry.New(key)
+		if err != nil {
+			b.Fatal(err)
+		}
+		nonce := HHESoK.NewNonce()
+		buf := make(HHESoK.Block, benchSize)
+
+		workerCounts := []int{1}
+		if runtime.NumCPU() > 1 {
+			workerCounts = append(workerCounts, runtime.NumCPU())
+		}
+
+		for _, workers := range workerCounts {
+			gen := HHESoK.NewKeyStreamGenerator(cipher, workers)
+			b.Run(fmt.Sprintf("%s/%s/Size=%d/Workers=%d", bc.name, bc.paramSet, benchSize, workers), func(b *testing.B) {
+				b.SetBytes(8 * benchSize)
+				for i := 0; i < b.N; i++ {
+					gen.Fill(buf, nonce, 0)
+				}
+			})
+		}
+	}
+}
@@ -1,73 +1,50 @@
 package pasta
 
-import (
-	"HHESoK"
-	"encoding/binary"
-	"math"
-)
+import "HHESoK"
 
-type Encryptor interface {
-	Encrypt(plaintext HHESoK.Plaintext) HHESoK.Ciphertext
-	Decrypt(ciphertext HHESoK.Ciphertext) HHESoK.Plaintext
-}
+type Encryptor = HHESoK.Encryptor
 
 type encryptor struct {
 	pas pasta
 }
 
-// Encrypt plaintext vector
+// Encrypt plaintext vector using the fixed default nonce
 func (enc encryptor) Encrypt(plaintext HHESoK.Plaintext) HHESoK.Ciphertext {
-	logger := HHESoK.NewLogger(HHESoK.DEBUG)
-	var size = uint64(len(plaintext))
-	var modulus = enc.pas.params.GetModulus()
-	var blockSize = uint64(enc.pas.params.GetBlockSize())
-	var numBlock = uint64(math.Ceil(float64(size / blockSize)))
-	logger.PrintFormatted("Number of Block: %d", numBlock)
-
-	nonce := make([]byte, 8)
-	binary.BigEndian.PutUint64(nonce, uint64(123456789))
-	counter := make([]byte, 8)
-
-	ciphertext := make(HHESoK.Ciphertext, size)
-	copy(ciphertext, plaintext)
+	return enc.EncryptWithNonce(HHESoK.DefaultNonce(), plaintext)
+}
 
-	for b := uint64(0); b < numBlock; b++ {
-		binary.BigEndian.PutUint64(counter, b)
-		keyStream := enc.pas.KeyStream(nonce, counter)
-		for i := b * blockSize; i < (b+1)*blockSize && i < size; i++ {
-			ciphertext[i] = (ciphertext[i] + keyStream[i-b*blockSize]) % modulus
-		}
-	}
+// Decrypt ciphertext vector using the fixed default nonce
+func (enc encryptor) Decrypt(ciphertext HHESoK.Ciphertext) HHESoK.Plaintext {
+	return enc.DecryptWithNonce(HHESoK.DefaultNonce(), ciphertext)
+}
 
-	return ciphertext
+// EncryptWithNonce encrypts plaintext vector under the given nonce, starting from block counter 0
+func (enc encryptor) EncryptWithNonce(nonce []byte, plaintext HHESoK.Plaintext) HHESoK.Ciphertext {
+	return enc.EncryptWithCounter(nonce, 0, plaintext)
 }
 
-// Decrypt ciphertext vector
-func (enc encryptor) Decrypt(ciphertext HHESoK.Ciphertext) HHESoK.Plaintext {
-	logger := HHESoK.NewLogger(HHESoK.DEBUG)
-	var size = uint64(len(ciphertext))
-	var modulus = enc.pas.params.GetModulus()
-	var blockSize = uint64(enc.pas.params.GetBlockSize())
-	var numBlock = uint64(math.Ceil(float64(size / blockSize)))
-	logger.PrintFormatted("Number of Block: %d", numBlock)
+// DecryptWithNonce decrypts ciphertext vector under the given nonce, starting from block counter 0
+func (enc encryptor) DecryptWithNonce(nonce []byte, ciphertext HHESoK.Ciphertext) HHESoK.Plaintext {
+	return enc.DecryptWithCounter(nonce, 0, ciphertext)
+}
 
-	plaintext := make(HHESoK.Plaintext, size)
-	copy(plaintext, ciphertext)
+// EncryptWithCounter encrypts plaintext vector under the given nonce, block b uses counter+b
+func (enc encryptor) EncryptWithCounter(nonce []byte, counter uint64, plaintext HHESoK.Plaintext) HHESoK.Ciphertext {
+	return HHESoK.CTREncrypt(&enc.pas, nonce, counter, plaintext)
+}
 
-	nonce := make([]byte, 8)
-	binary.BigEndian.PutUint64(nonce, uint64(123456789))
-	counter := make([]byte, 8)
+// DecryptWithCounter decrypts ciphertext vector under the given nonce, block b uses counter+b
+func (enc encryptor) DecryptWithCounter(nonce []byte, counter uint64, ciphertext HHESoK.Ciphertext) HHESoK.Plaintext {
+	return HHESoK.CTRDecrypt(&enc.pas, nonce, counter, ciphertext)
+}
 
-	for b := uint64(0); b < numBlock; b++ {
-		binary.BigEndian.PutUint64(counter, b)
-		keyStream := enc.pas.KeyStream(nonce, counter)
-		for i := b * blockSize; i < (b+1)*blockSize && i < size; i++ {
-			if keyStream[i-b*blockSize] > plaintext[i] {
-				plaintext[i] += modulus
-			}
-			plaintext[i] = plaintext[i] - keyStream[i-b*blockSize]
-		}
-	}
+// EncryptRandomNonce encrypts plaintext vector under a fresh random nonce,
+// the nonce is prepended to the ciphertext as HHESoK.NonceElements field elements
+func (enc encryptor) EncryptRandomNonce(plaintext HHESoK.Plaintext) (HHESoK.Ciphertext, error) {
+	return HHESoK.CTREncryptRandomNonce(&enc.pas, plaintext)
+}
 
-	return plaintext
+// DecryptRandomNonce decrypts a ciphertext produced by EncryptRandomNonce
+func (enc encryptor) DecryptRandomNonce(ciphertext HHESoK.Ciphertext) (HHESoK.Plaintext, error) {
+	return HHESoK.CTRDecryptRandomNonce(&enc.pas, ciphertext)
 }
@@ -3,10 +3,10 @@ package pasta
 // Parameter for Pasta cipher
 // note: Plaintext and Ciphertext size are both equal in PASTA, we merge both as BlockSize
 type Parameter struct {
-	KeySize   int
-	BlockSize int
-	Rounds    int
-	Modulus   uint64
+	KeySize   int    `json:"keySize" yaml:"keySize"`
+	BlockSize int    `json:"blockSize" yaml:"blockSize"`
+	Rounds    int    `json:"rounds" yaml:"rounds"`
+	Modulus   uint64 `json:"modulus" yaml:"modulus"`
 }
 
 // GetKeySize returns the secret key size in bits
@@ -3,13 +3,12 @@ package pasta
 import (
 	"HHESoK"
 	"encoding/binary"
+	"fmt"
 	"golang.org/x/crypto/sha3"
-	"math/big"
 )
 
 type Pasta interface {
-	NewEncryptor() Encryptor
-	KeyStream(nonce []byte, counter []byte) HHESoK.Block
+	HHESoK.SymmetricCipher
 }
 
 type pasta struct {
@@ -19,13 +18,17 @@ type pasta struct {
 	state1       HHESoK.Block
 	state2       HHESoK.Block
 	p            uint64
+	mod          HHESoK.Modulus
 	maxPrimeSize uint64
 }
 
-// NewPasta return a new instance of pasta cipher
-func NewPasta(secretKey HHESoK.Key, params Parameter) Pasta {
+// NewPasta returns a new instance of PASTA cipher, the secret key holds key size elements
+func NewPasta(secretKey HHESoK.Key, params Parameter) (Pasta, error) {
+	if params.GetModulus() < 2 || params.GetBlockSize() == 0 {
+		return nil, fmt.Errorf("%w: PASTA block size %d, modulus %d", HHESoK.ErrInvalidParameters, params.GetBlockSize(), params.GetModulus())
+	}
 	if len(secretKey) != params.GetKeySize() {
-		panic("Invalid Key Length!")
+		return nil, fmt.Errorf("%w: got %d elements, want %d", HHESoK.ErrKeyLength, len(secretKey), params.GetKeySize())
 	}
 
 	mps := uint64(0) // max prime size
@@ -40,6 +43,11 @@ func NewPasta(secretKey HHESoK.Key, params Parameter) Pasta {
 	// set mps to the maximum value that can be represented with mps bits
 	mps = (1 << mps) - 1
 
+	mod, err := HHESoK.NewModulus(params.GetModulus())
+	if err != nil {
+		return nil, err
+	}
+
 	// init empty states
 	state1 := make(HHESoK.Block, params.GetBlockSize())
 	state2 := make(HHESoK.Block, params.GetBlockSize())
@@ -52,8 +60,16 @@ func NewPasta(secretKey HHESoK.Key, params Parameter) Pasta {
 		state1:       state1,
 		state2:       state2,
 		p:            params.GetModulus(),
+		mod:          mod,
 		maxPrimeSize: mps,
 	}
+	return pas, nil
+}
+
+// MustNewPasta is NewPasta panicking on invalid parameters or key
+func MustNewPasta(secretKey HHESoK.Key, params Parameter) Pasta {
+	pas, err := NewPasta(secretKey, params)
+	HHESoK.HandleError(err)
 	return pas
 }
 
@@ -61,6 +77,21 @@ func (pas *pasta) NewEncryptor() Encryptor {
 	return &encryptor{pas: *pas}
 }
 
+// GetKeySize returns the secret key size
+func (pas *pasta) GetKeySize() int {
+	return pas.params.GetKeySize()
+}
+
+// GetKeyStreamSize returns the key stream size of one block, equal to the block size
+func (pas *pasta) GetKeyStreamSize() int {
+	return pas.params.GetBlockSize()
+}
+
+// GetModulus returns the plaintext modulus
+func (pas *pasta) GetModulus() uint64 {
+	return pas.params.GetModulus()
+}
+
 // prepareOneBlock prepare the first block and call preProcess function
 func (pas *pasta) prepareOneBlock() {
 	nonce := make([]byte, 8)
@@ -126,43 +157,20 @@ func (pas *pasta) round(r int) {
 
 // sBoxCube state[i] := (state[i] ^ 3)
 func (pas *pasta) sBoxCube(state *HHESoK.Block) {
-	modulus := new(big.Int).SetUint64(pas.params.GetModulus())
 	for i := 0; i < pas.params.GetBlockSize(); i++ {
-		// square = state ^ 2 (mod p)
-		curState := new(big.Int).SetUint64((*state)[i])
-		square := new(big.Int).Mul(curState, curState)
-		square.Mod(square, modulus)
-
-		// cube = square * state (mod p)
-		cube := square.Mul(square, curState)
-		cube.Mod(cube, modulus)
-
-		(*state)[i] = cube.Uint64()
+		// cube = state ^ 2 * state (mod p)
+		square := pas.mod.Mul((*state)[i], (*state)[i])
+		(*state)[i] = pas.mod.Mul(square, (*state)[i])
 	}
 }
 
 // sBoxFeistel state[i] := {i = 0; state[i];state[i] + (state[i-1] ^ 2)}
 func (pas *pasta) sBoxFeistel(state *HHESoK.Block) {
-	ps := pas.params.GetBlockSize()
-	modulus := new(big.Int).SetUint64(pas.params.GetModulus())
-
-	nState := make(HHESoK.Block, ps)
-	nState[0] = (*state)[0]
-
-	for i := 1; i < ps; i++ {
-		// square = state[i-1] ^ 2 (mod p)
-		prevState := new(big.Int).SetUint64((*state)[i-1])
-		square := new(big.Int).Mul(prevState, prevState)
-		square.Mod(square, modulus)
-		curState := new(big.Int).SetUint64((*state)[i])
-		//square = square + state[i] (mod p)
-		square.Add(square, curState)
-		square.Mod(square, modulus)
-		// new state = square
-		nState[i] = square.Uint64()
+	// going backwards keeps state[i-1] unchanged until state[i] is updated
+	for i := pas.params.GetBlockSize() - 1; i > 0; i-- {
+		square := pas.mod.Mul((*state)[i-1], (*state)[i-1])
+		(*state)[i] = pas.mod.Add((*state)[i], square)
 	}
-
-	*state = nState
 }
 
 // linearLayer
@@ -181,23 +189,17 @@ func (pas *pasta) linearLayer() {
 // requires storage of two row in the matrix
 func (pas *pasta) matmul(state *HHESoK.Block) {
 	ps := pas.params.GetBlockSize()
-	modulus := new(big.Int).SetUint64(pas.params.GetModulus())
 	newState := make(HHESoK.Block, ps)
 	rand := pas.getRandomVector(false)
-	var currentRow = rand
+	currentRow := make(HHESoK.Block, ps)
+	copy(currentRow, rand)
 
 	for i := 0; i < ps; i++ {
 		for j := 0; j < ps; j++ {
-			matMulVal := new(big.Int).Mul(
-				big.NewInt(int64(currentRow[j])),
-				big.NewInt(int64((*state)[j])),
-			)
-			matMulVal.Mod(matMulVal, modulus)
-
-			newState[i] = (newState[i] + matMulVal.Uint64()) % pas.params.Modulus
+			newState[i] = pas.mod.Add(newState[i], pas.mod.Mul(currentRow[j], (*state)[j]))
 		}
 		if i != (ps - 1) {
-			currentRow = pas.calculateRow(currentRow, rand)
+			pas.nextRow(currentRow, rand)
 		}
 	}
 
@@ -206,17 +208,8 @@ func (pas *pasta) matmul(state *HHESoK.Block) {
 
 // addRC add state with a random field element
 func (pas *pasta) addRC(state *HHESoK.Block) {
-	ps := pas.params.GetBlockSize()
-	modulus := new(big.Int).SetUint64(pas.params.GetModulus())
-
-	for i := 0; i < ps; i++ {
-		randElement := new(big.Int).SetUint64(pas.generateRandomFieldElement(true))
-		curState := new(big.Int).SetUint64((*state)[i])
-
-		curState.Add(curState, randElement)
-		curState.Mod(curState, modulus)
-
-		(*state)[i] = curState.Uint64()
+	for i := 0; i < pas.params.GetBlockSize(); i++ {
+		(*state)[i] = pas.mod.Add((*state)[i], pas.generateRandomFieldElement(true))
 	}
 }
 
@@ -227,44 +220,23 @@ func (pas *pasta) addRC(state *HHESoK.Block) {
 
 // mix add the state1 and state2
 func (pas *pasta) mix() {
-	ps := pas.params.GetBlockSize()
-	modulus := new(big.Int).SetUint64(pas.params.GetModulus())
-
-	// allocate memory for the two state
-	st1 := new(big.Int)
-	st2 := new(big.Int)
-
 	// adding states
-	for i := 0; i < ps; i++ {
-		st1.SetUint64(pas.state1[i])
-		st2.SetUint64(pas.state2[i])
-
+	for i := 0; i < pas.params.GetBlockSize(); i++ {
 		// (state1[i] + state2[i]) % pas.p
-		sum := new(big.Int).Add(st1, st2)
-		sum.Mod(sum, modulus)
-
+		sum := pas.mod.Add(pas.state1[i], pas.state2[i])
 		//state1[i] = (state1[i] + sum) % pas.p
-		sSt1 := new(big.Int).Add(sum, st1)
-		sSt1.Mod(sSt1, modulus)
-
+		pas.state1[i] = pas.mod.Add(pas.state1[i], sum)
 		//state2[i] = (state2[i] + sum) % pas.p
-		sSt2 := new(big.Int).Add(sum, st2)
-		sSt2.Mod(sSt2, modulus)
-
-		pas.state1[i] = sSt1.Uint64()
-		pas.state2[i] = sSt2.Uint64()
+		pas.state2[i] = pas.mod.Add(pas.state2[i], sum)
 	}
 }
 
 // InitShake function get nonce and counter and combine them as seed for SHAKE128
 func (pas *pasta) initShake(nonce []byte, counter []byte) {
 	shake := sha3.NewShake128()
-	if _, err := shake.Write(nonce); err != nil {
-		panic("Failed to init SHAKE128!")
-	}
-	if _, err := shake.Write(counter); err != nil {
-		panic("Failed to init SHAKE128!")
-	}
+	// writes to a SHAKE state never fail
+	_, _ = shake.Write(nonce)
+	_, _ = shake.Write(counter)
 	pas.shake = shake
 }
 
@@ -272,9 +244,8 @@ func (pas *pasta) initShake(nonce []byte, counter []byte) {
 func (pas *pasta) generateRandomFieldElement(allowZero bool) uint64 {
 	var randomByte [8]byte
 	for {
-		if _, err := pas.shake.Read(randomByte[:]); err != nil {
-			panic("SHAKE128 squeeze failed")
-		}
+		// reads from a SHAKE state never fail
+		_, _ = pas.shake.Read(randomByte[:])
 
 		fieldElement := binary.BigEndian.Uint64(randomByte[:]) & pas.maxPrimeSize
 
@@ -336,36 +307,34 @@ func (pas *pasta) getRcVector(size int) HHESoK.Block {
 
 // calculateRow
 func (pas *pasta) calculateRow(previousRow, firstRow HHESoK.Block) HHESoK.Block {
-	ps := pas.params.GetBlockSize()
-	modulus := new(big.Int).SetUint64(pas.params.GetModulus())
-	output := make(HHESoK.Block, ps)
-	// =======================================
-	pRow := new(big.Int).SetUint64(previousRow[ps-1])
-
-	for j := 0; j < ps; j++ {
-		fRow := new(big.Int).SetUint64(firstRow[j])
-		temp := new(big.Int).Mul(fRow, pRow)
-		temp.Mod(temp, modulus)
-		// update the index row and add the value to the temp
-		if j > 0 {
-			indexRow := new(big.Int).SetUint64(previousRow[j-1])
-			temp.Add(temp, indexRow)
-			temp.Mod(temp, modulus)
-		}
+	output := make(HHESoK.Block, pas.params.GetBlockSize())
+	copy(output, previousRow)
+	pas.nextRow(output, firstRow)
+	return output
+}
 
-		output[j] = temp.Uint64()
+// nextRow overwrites row with the next row of the sequential matrix in place
+func (pas *pasta) nextRow(row, firstRow HHESoK.Block) {
+	ps := pas.params.GetBlockSize()
+	pRow := row[ps-1]
+	// going backwards keeps row[j-1] unchanged until row[j] is updated
+	for j := ps - 1; j > 0; j-- {
+		row[j] = pas.mod.Add(pas.mod.Mul(firstRow[j], pRow), row[j-1])
 	}
-	return output
+	row[0] = pas.mod.Mul(firstRow[0], pRow)
 }
 
-func (pas *pasta) ShallowCopy() Pasta {
+// ShallowCopy returns a new instance sharing the secret key and parameters,
+// with fresh internal states that can be used on another goroutine
+func (pas *pasta) ShallowCopy() HHESoK.SymmetricCipher {
 	return &pasta{
 		p:            pas.p,
 		secretKey:    pas.secretKey,
+		mod:          pas.mod,
 		maxPrimeSize: pas.maxPrimeSize,
-		shake:        pas.shake,
+		shake:        nil,
 		params:       pas.params,
-		state1:       pas.state1,
-		state2:       pas.state2,
+		state1:       make(HHESoK.Block, pas.params.GetBlockSize()),
+		state2:       make(HHESoK.Block, pas.params.GetBlockSize()),
 	}
 }
@@ -30,7 +30,14 @@ func benchmarkPasta(tc *TestContext, b *testing.B) {
 
 	b.Run("Pasta/NewPasta", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			pastaCipher = NewPasta(tc.Key, tc.Params)
+			pastaCipher = MustNewPasta(tc.Key, tc.Params)
+		}
+	})
+
+	b.Run("Pasta/KeyStream", func(b *testing.B) {
+		nonce := HHESoK.DefaultNonce()
+		for i := 0; i < b.N; i++ {
+			pastaCipher.KeyStream(nonce, HHESoK.CounterBytes(uint64(i)))
 		}
 	})
 
@@ -16,7 +16,7 @@ func TestPasta3(t *testing.T) {
 	logger := HHESoK.NewLogger(HHESoK.DEBUG)
 	for _, tc := range pasta3TestVector {
 		fmt.Println(testString("PASTA", tc.Params))
-		pastaCipher := NewPasta(tc.Key, tc.Params)
+		pastaCipher := MustNewPasta(tc.Key, tc.Params)
 		encryptor := pastaCipher.NewEncryptor()
 		var ciphertext HHESoK.Ciphertext
 
@@ -51,7 +51,7 @@ func TestPasta4(t *testing.T) {
 	logger := HHESoK.NewLogger(HHESoK.DEBUG)
 	for _, tc := range pasta4TestVector {
 		fmt.Println(testString("PASTA", tc.Params))
-		pastaCipher := NewPasta(tc.Key, tc.Params)
+		pastaCipher := MustNewPasta(tc.Key, tc.Params)
 		encryptor := pastaCipher.NewEncryptor()
 		var ciphertext HHESoK.Ciphertext
 
@@ -81,3 +81,51 @@ func TestPasta4(t *testing.T) {
 		})
 	}
 }
+
+func TestPastaCounter(t *testing.T) {
+	for _, tc := range append(pasta3TestVector, pasta4TestVector...) {
+		t.Run(testString("PastaCounter", tc.Params), func(t *testing.T) {
+			encryptor := MustNewPasta(tc.Key, tc.Params).NewEncryptor()
+			bs := tc.Params.GetBlockSize()
+			nonce := HHESoK.NewNonce()
+
+			// three blocks of plaintext, the last one is partial
+			plaintext := make(HHESoK.Plaintext, 0, 3*bs)
+			for len(plaintext) < 3*bs-1 {
+				plaintext = append(plaintext, tc.Plaintext...)
+			}
+			plaintext = plaintext[:3*bs-1]
+
+			ciphertext := encryptor.EncryptWithNonce(nonce, plaintext)
+			// starting at block offset 1 gives the same key stream as the tail of the message
+			tail := encryptor.EncryptWithCounter(nonce, 1, plaintext[bs:2*bs])
+			if !reflect.DeepEqual(tail, ciphertext[bs:2*bs]) {
+				t.Fatal("counter mode with block offset 1 does not match the second block")
+			}
+			if !reflect.DeepEqual(plaintext, encryptor.DecryptWithCounter(nonce, 0, ciphertext)) {
+				t.Fatal("decryption failure with a caller supplied nonce")
+			}
+
+			// every element of the partial last block at a non-zero start counter is masked by
+			// the key stream of its block
+			const start = 5
+			pas := MustNewPasta(tc.Key, tc.Params)
+			shifted := encryptor.EncryptWithCounter(nonce, start, plaintext)
+			if len(shifted) != len(plaintext) {
+				t.Fatalf("got %d elements, want %d", len(shifted), len(plaintext))
+			}
+			modulus := tc.Params.GetModulus()
+			for b := 0; b*bs < len(plaintext); b++ {
+				keyStream := pas.KeyStream(nonce, HHESoK.CounterBytes(uint64(start+b)))
+				for i := b * bs; i < (b+1)*bs && i < len(plaintext); i++ {
+					if want := (plaintext[i] + keyStream[i-b*bs]) % modulus; shifted[i] != want {
+						t.Fatalf("element %d of block %d: got %d, want %d", i-b*bs, b, shifted[i], want)
+					}
+				}
+			}
+			if !reflect.DeepEqual(tc.ExpCipherText, encryptor.EncryptWithNonce(HHESoK.DefaultNonce(), tc.Plaintext)) {
+				t.Fatal("the default nonce does not reproduce the test vector")
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package sym
+
+import (
+	"HHESoK"
+	"HHESoK/rtf_ckks_integration/ckks_fv"
+	"HHESoK/sym/hera"
+	"HHESoK/sym/pasta"
+	"HHESoK/sym/rubato"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Constructor builds a cipher instance from a secret key
+type Constructor func(key HHESoK.Key) (HHESoK.SymmetricCipher, error)
+
+// Entry describes a registered cipher and one of its parameter sets
+// NoiseBound is the largest absolute noise the encryption adds to a key stream element, it is
+// zero for every cipher except Rubato, whose KeyStream is noiseless
+type Entry struct {
+	Name       string
+	ParamSet   string
+	KeySize    int
+	NoiseBound uint64
+	New        Constructor
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Entry)
+)
+
+func init() {
+	// PASTA-3 and PASTA-4 with 17-bit, 33-bit and 60-bit plaintext moduli
+	for _, mod := range []struct {
+		name    string
+		modulus uint64
+	}{
+		{"17", 65537},
+		{"33", 8088322049},
+		{"60", 1096486890805657601},
+	} {
+		registerPasta("pasta3", mod.name, pasta.Parameter{KeySize: 256, BlockSize: 128, Rounds: 3, Modulus: mod.modulus})
+		registerPasta("pasta4", mod.name, pasta.Parameter{KeySize: 64, BlockSize: 32, Rounds: 4, Modulus: mod.modulus})
+	}
+
+	// HERA with 4 rounds (80-bit) and 5 rounds (128-bit) for every RtF parameter
+	for _, sec := range []struct {
+		name   string
+		rounds int
+	}{
+		{"80", 4},
+		{"128", 5},
+	} {
+		for index, fv := range []string{"f", "s", "af", "as"} {
+			registerHera(sec.name+fv, hera.Parameter{
+				BlockSize: 16,
+				Modulus:   ckks_fv.RtFHeraParams[index].PlainModulus,
+				Rounds:    sec.rounds,
+			})
+		}
+	}
+
+	// Rubato parameter sets as defined in ckks_fv.RubatoParams
+	for index, name := range []string{"80s", "80m", "80l", "128s", "128m", "128l"} {
+		rp := ckks_fv.RubatoParams[index]
+		registerRubato(name, rubato.Parameter{
+			BlockSize: rp.Blocksize,
+			Modulus:   rp.PlainModulus,
+			Rounds:    rp.NumRound,
+			Sigma:     rp.Sigma,
+		})
+	}
+}
+
+func registerPasta(name, paramSet string, params pasta.Parameter) {
+	Register(Entry{
+		Name:     name,
+		ParamSet: paramSet,
+		KeySize:  params.GetKeySize(),
+		New: func(key HHESoK.Key) (HHESoK.SymmetricCipher, error) {
+			return pasta.NewPasta(key, params)
+		},
+	})
+}
+
+func registerHera(paramSet string, params hera.Parameter) {
+	Register(Entry{
+		Name:     "hera",
+		ParamSet: paramSet,
+		KeySize:  params.GetBlockSize(),
+		New: func(key HHESoK.Key) (HHESoK.SymmetricCipher, error) {
+			return hera.NewHera(key, params)
+		},
+	})
+}
+
+func registerRubato(paramSet string, params rubato.Parameter) {
+	Register(Entry{
+		Name:       "rubato",
+		ParamSet:   paramSet,
+		KeySize:    params.GetBlockSize(),
+		NoiseBound: uint64(6 * params.GetSigma()),
+		New: func(key HHESoK.Key) (HHESoK.SymmetricCipher, error) {
+			return rubato.NewRubato(key, params)
+		},
+	})
+}
+
+func registryKey(name, paramSet string) string {
+	return strings.ToLower(name) + "/" + strings.ToLower(paramSet)
+}
+
+// Register adds a cipher parameter set to the registry, replacing any
+// previous entry with the same name and parameter set
+func Register(entry Entry) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[registryKey(entry.Name, entry.ParamSet)] = entry
+}
+
+// Lookup returns the registry entry for the given cipher name and parameter set
+func Lookup(name, paramSet string) (Entry, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	entry, ok := registry[registryKey(name, paramSet)]
+	if !ok {
+		return Entry{}, fmt.Errorf("sym: unknown cipher %q with parameter set %q", name, paramSet)
+	}
+	return entry, nil
+}
+
+// NewCipher builds the cipher registered under name and paramSet with the given secret key
+func NewCipher(name, paramSet string, key HHESoK.Key) (HHESoK.SymmetricCipher, error) {
+	entry, err := Lookup(name, paramSet)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != entry.KeySize {
+		return nil, fmt.Errorf("sym: %s/%s: %w: got %d elements, want %d",
+			entry.Name, entry.ParamSet, HHESoK.ErrKeyLength, len(key), entry.KeySize)
+	}
+	return entry.New(key)
+}
+
+// Entries returns every registered entry sorted by name and parameter set
+func Entries() []Entry {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	keys := make([]string, 0, len(registry))
+	for k := range registry {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	entries := make([]Entry, len(keys))
+	for i, k := range keys {
+		entries[i] = registry[k]
+	}
+	return entries
+}
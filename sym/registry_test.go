@@ -0,0 +1,226 @@
+package sym
+
+import (
+	"HHESoK"
+	"HHESoK/rtf_ckks_integration/utils"
+	"HHESoK/sym/pasta"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testString(opName string, entry Entry) string {
+	return fmt.Sprintf("%s/Cipher=%s/ParamSet=%s/KeySize=%d", opName, entry.Name, entry.ParamSet, entry.KeySize)
+}
+
+// randomVector samples a vector with elements in [0, modulus)
+func randomVector(size int, modulus uint64) []uint64 {
+	vec := make([]uint64, size)
+	for i := range vec {
+		vec[i] = utils.RandUint64() % modulus
+	}
+	return vec
+}
+
+// distance returns the absolute difference between a and b in Z_modulus
+func distance(a, b, modulus uint64) uint64 {
+	d := (a + modulus - b) % modulus
+	if d > modulus/2 {
+		d = modulus - d
+	}
+	return d
+}
+
+func TestRegistry(t *testing.T) {
+	t.Run("UnknownCipher", func(t *testing.T) {
+		_, err := NewCipher("aes", "128", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("CaseInsensitive", func(t *testing.T) {
+		_, err := Lookup("PASTA3", "17")
+		require.NoError(t, err)
+		_, err = Lookup("Rubato", "128S")
+		require.NoError(t, err)
+	})
+
+	t.Run("InvalidKeyLength", func(t *testing.T) {
+		_, err := NewCipher("pasta4", "17", make(HHESoK.Key, 3))
+		require.ErrorIs(t, err, HHESoK.ErrKeyLength)
+	})
+
+	t.Run("MatchesDirectConstruction", func(t *testing.T) {
+		params := pasta.Parameter{KeySize: 64, BlockSize: 32, Rounds: 4, Modulus: 65537}
+		key := HHESoK.Key(randomVector(params.GetKeySize(), params.GetModulus()))
+		nonce := []byte{0, 0, 0, 0, 0x07, 0x5b, 0xcd, 0x15}
+		counter := make([]byte, 8)
+
+		cipher, err := NewCipher("pasta4", "17", key)
+		require.NoError(t, err)
+		want := pasta.MustNewPasta(key, params).KeyStream(nonce, counter)
+		require.Equal(t, want, cipher.KeyStream(nonce, counter))
+	})
+}
+
+// TestConformance runs the shared SymmetricCipher suite against every registered cipher
+func TestConformance(t *testing.T) {
+	for _, entry := range Entries() {
+		t.Run(testString("Conformance", entry), func(t *testing.T) {
+			testConformance(t, entry)
+		})
+	}
+}
+
+func testConformance(t *testing.T, entry Entry) {
+	probe, err := entry.New(make(HHESoK.Key, entry.KeySize))
+	require.NoError(t, err)
+	modulus := probe.GetModulus()
+	key := HHESoK.Key(randomVector(entry.KeySize, modulus))
+
+	cipher, err := NewCipher(entry.Name, entry.ParamSet, key)
+	require.NoError(t, err)
+
+	ksSize := cipher.GetKeyStreamSize()
+	nonce := make([]byte, 8)
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(nonce, 123456789)
+
+	t.Run("Sizes", func(t *testing.T) {
+		assert.Equal(t, entry.KeySize, cipher.GetKeySize())
+		assert.Greater(t, ksSize, 0)
+		assert.LessOrEqual(t, ksSize, cipher.GetKeySize())
+	})
+
+	ks0 := make(HHESoK.Block, ksSize)
+	copy(ks0, cipher.KeyStream(nonce, counter))
+
+	t.Run("KeyStreamRange", func(t *testing.T) {
+		for _, z := range ks0 {
+			assert.Less(t, z, modulus)
+		}
+	})
+
+	t.Run("KeyStreamDeterministic", func(t *testing.T) {
+		ks1 := cipher.KeyStream(nonce, counter)
+		require.Len(t, ks1, ksSize)
+		require.Equal(t, ks0, ks1)
+	})
+
+	t.Run("KeyStreamDomainSeparation", func(t *testing.T) {
+		otherCounter := make([]byte, 8)
+		binary.BigEndian.PutUint64(otherCounter, 1)
+		otherNonce := make([]byte, 8)
+		binary.BigEndian.PutUint64(otherNonce, 987654321)
+		assert.NotEqual(t, ks0, cipher.KeyStream(nonce, otherCounter))
+		assert.NotEqual(t, ks0, cipher.KeyStream(otherNonce, counter))
+	})
+
+	t.Run("EncryptDecrypt", func(t *testing.T) {
+		encryptor := cipher.NewEncryptor()
+		plaintext := HHESoK.Plaintext(randomVector(2*ksSize, modulus))
+		ciphertext := encryptor.Encrypt(plaintext)
+		require.Len(t, ciphertext, len(plaintext))
+		decrypted := encryptor.Decrypt(ciphertext)
+		require.Len(t, decrypted, len(plaintext))
+		for i := range plaintext {
+			assert.LessOrEqual(t, distance(plaintext[i], decrypted[i], modulus), entry.NoiseBound)
+		}
+	})
+
+	t.Run("EncryptDecryptWithNonce", func(t *testing.T) {
+		encryptor := cipher.NewEncryptor()
+		plaintext := HHESoK.Plaintext(randomVector(2*ksSize, modulus))
+		userNonce := HHESoK.NewNonce()
+		ciphertext := encryptor.EncryptWithNonce(userNonce, plaintext)
+		assert.NotEqual(t, encryptor.EncryptWithNonce(HHESoK.NewNonce(), plaintext), ciphertext)
+		decrypted := encryptor.DecryptWithNonce(userNonce, ciphertext)
+		for i := range plaintext {
+			assert.LessOrEqual(t, distance(plaintext[i], decrypted[i], modulus), entry.NoiseBound)
+		}
+	})
+
+	t.Run("CounterMode", func(t *testing.T) {
+		// every block, including the partial last one, is masked by the full key stream of its counter
+		encryptor := cipher.NewEncryptor()
+		plaintext := HHESoK.Plaintext(randomVector(3*ksSize-1, modulus))
+		nonce := HHESoK.NewNonce()
+		counter := uint64(7)
+		ciphertext := encryptor.EncryptWithCounter(nonce, counter, plaintext)
+		require.Len(t, ciphertext, len(plaintext))
+		var ks HHESoK.Block
+		for i := range plaintext {
+			b := i / ksSize
+			if i%ksSize == 0 {
+				ks = cipher.KeyStream(nonce, HHESoK.CounterBytes(counter+uint64(b)))
+			}
+			mask := (ciphertext[i] + modulus - plaintext[i]) % modulus
+			assert.LessOrEqual(t, distance(mask, ks[i-b*ksSize], modulus), entry.NoiseBound)
+		}
+		// the second block alone, encrypted from counter+1, matches the second block of the message
+		second := encryptor.EncryptWithCounter(nonce, counter+1, plaintext[ksSize:2*ksSize])
+		for i := range second {
+			assert.LessOrEqual(t, distance(second[i], ciphertext[ksSize+i], modulus), 2*entry.NoiseBound)
+		}
+	})
+
+	t.Run("ParallelKeyStream", func(t *testing.T) {
+		// the worker pool must reproduce the sequential CTR mode for any pool size
+		plaintext := HHESoK.Plaintext(randomVector(9*ksSize-3, modulus))
+		userNonce := HHESoK.NewNonce()
+		counter := uint64(5)
+		sequential := cipher.NewEncryptor().EncryptWithCounter(userNonce, counter, plaintext)
+		keyStream := make(HHESoK.Block, 0, 9*ksSize)
+		for b := 0; b < 9; b++ {
+			keyStream = append(keyStream, cipher.KeyStream(userNonce, HHESoK.CounterBytes(counter+uint64(b)))...)
+		}
+		for _, workers := range []int{1, 2, 4, 16} {
+			gen := HHESoK.NewKeyStreamGenerator(cipher, workers)
+			require.Equal(t, keyStream[:len(plaintext)], gen.KeyStream(userNonce, counter, len(plaintext)))
+			ciphertext := gen.Encrypt(userNonce, counter, plaintext)
+			require.Len(t, ciphertext, len(plaintext))
+			for i := range plaintext {
+				assert.LessOrEqual(t, distance(sequential[i], ciphertext[i], modulus), 2*entry.NoiseBound)
+			}
+			decrypted := gen.Decrypt(userNonce, counter, ciphertext)
+			for i := range plaintext {
+				assert.LessOrEqual(t, distance(plaintext[i], decrypted[i], modulus), entry.NoiseBound)
+			}
+		}
+	})
+
+	t.Run("EncryptDecryptRandomNonce", func(t *testing.T) {
+		encryptor := cipher.NewEncryptor()
+		plaintext := HHESoK.Plaintext(randomVector(2*ksSize, modulus))
+		ciphertext, err := encryptor.EncryptRandomNonce(plaintext)
+		require.NoError(t, err)
+		nonceElements, err := HHESoK.NonceElements(modulus)
+		require.NoError(t, err)
+		require.Len(t, ciphertext, nonceElements+len(plaintext))
+		for _, c := range ciphertext {
+			assert.Less(t, c, modulus)
+		}
+		decrypted, err := encryptor.DecryptRandomNonce(ciphertext)
+		require.NoError(t, err)
+		require.Len(t, decrypted, len(plaintext))
+		for i := range plaintext {
+			assert.LessOrEqual(t, distance(plaintext[i], decrypted[i], modulus), entry.NoiseBound)
+		}
+		_, err = encryptor.DecryptRandomNonce(ciphertext[:nonceElements-1])
+		require.ErrorIs(t, err, HHESoK.ErrCiphertextLength)
+	})
+
+	t.Run("NonceElements", func(t *testing.T) {
+		userNonce := HHESoK.NewNonce()
+		elements, err := HHESoK.NonceToElements(userNonce, modulus)
+		require.NoError(t, err)
+		nonceElements, err := HHESoK.NonceElements(modulus)
+		require.NoError(t, err)
+		require.Len(t, elements, nonceElements)
+		nonce, err := HHESoK.ElementsToNonce(elements, modulus)
+		require.NoError(t, err)
+		require.Equal(t, userNonce, nonce)
+	})
+}
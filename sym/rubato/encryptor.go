@@ -1,83 +1,50 @@
 package rubato
 
-import (
-	"HHESoK"
-	"encoding/binary"
-	"math"
-)
+import "HHESoK"
 
-type Encryptor interface {
-	Encrypt(plaintext HHESoK.Plaintext) HHESoK.Ciphertext
-	Decrypt(ciphertext HHESoK.Ciphertext) HHESoK.Plaintext
-}
+type Encryptor = HHESoK.Encryptor
 
 type encryptor struct {
 	rub rubato
 }
 
-// Encrypt plaintext vector
+// Encrypt plaintext vector using the fixed default nonce
 func (enc encryptor) Encrypt(plaintext HHESoK.Plaintext) HHESoK.Ciphertext {
-	logger := HHESoK.NewLogger(HHESoK.DEBUG)
-	var size = len(plaintext)
-	var modulus = enc.rub.params.GetModulus()
-	var ksSize = enc.rub.params.GetBlockSize() - 4
-	var numBlock = int(math.Ceil(float64(size / ksSize)))
-	logger.PrintFormatted("Number of Block: %d", numBlock)
-
-	// Nonce and Counter
-	nonces := make([][]byte, numBlock)
-	// set nonce up to blockSize
-	n := 123456789
-	for i := 0; i < numBlock; i++ {
-		nonces[i] = make([]byte, 8)
-		binary.BigEndian.PutUint64(nonces[i], uint64(i+n))
-	}
-	counter := make([]byte, 8)
-
-	ciphertext := make(HHESoK.Ciphertext, size)
-	copy(ciphertext, plaintext)
+	return enc.EncryptWithNonce(HHESoK.DefaultNonce(), plaintext)
+}
 
-	for i := 0; i < numBlock; i++ {
-		z := make(HHESoK.Block, ksSize)
-		binary.BigEndian.PutUint64(counter, uint64(i+1))
-		copy(z, enc.rub.KeyStream(nonces[i], counter))
-		ciphertext[i] = (ciphertext[i] + z[i]) % modulus
-	}
+// Decrypt ciphertext vector using the fixed default nonce
+func (enc encryptor) Decrypt(ciphertext HHESoK.Ciphertext) HHESoK.Plaintext {
+	return enc.DecryptWithNonce(HHESoK.DefaultNonce(), ciphertext)
+}
 
-	return ciphertext
+// EncryptWithNonce encrypts plaintext vector under the given nonce, starting from block counter 0
+func (enc encryptor) EncryptWithNonce(nonce []byte, plaintext HHESoK.Plaintext) HHESoK.Ciphertext {
+	return enc.EncryptWithCounter(nonce, 0, plaintext)
 }
 
-// Decrypt ciphertext vector
-func (enc encryptor) Decrypt(ciphertext HHESoK.Ciphertext) HHESoK.Plaintext {
-	logger := HHESoK.NewLogger(HHESoK.DEBUG)
-	var size = len(ciphertext)
-	var modulus = enc.rub.params.GetModulus()
-	var ksSize = enc.rub.params.GetBlockSize() - 4
-	var numBlock = int(math.Ceil(float64(size / ksSize)))
-	logger.PrintFormatted("Number of Block: %d", numBlock)
+// DecryptWithNonce decrypts ciphertext vector under the given nonce, starting from block counter 0
+func (enc encryptor) DecryptWithNonce(nonce []byte, ciphertext HHESoK.Ciphertext) HHESoK.Plaintext {
+	return enc.DecryptWithCounter(nonce, 0, ciphertext)
+}
 
-	// Nonce and Counter
-	nonces := make([][]byte, numBlock)
-	// set nonce up to blockSize
-	n := 123456789
-	for i := 0; i < numBlock; i++ {
-		nonces[i] = make([]byte, 8)
-		binary.BigEndian.PutUint64(nonces[i], uint64(i+n))
-	}
-	counter := make([]byte, 8)
+// EncryptWithCounter encrypts plaintext vector under the given nonce, block i uses counter+i
+func (enc encryptor) EncryptWithCounter(nonce []byte, counter uint64, plaintext HHESoK.Plaintext) HHESoK.Ciphertext {
+	return HHESoK.CTREncrypt(&enc.rub, nonce, counter, plaintext)
+}
 
-	plaintext := make(HHESoK.Plaintext, size)
-	copy(plaintext, ciphertext)
+// DecryptWithCounter decrypts ciphertext vector under the given nonce, block i uses counter+i
+func (enc encryptor) DecryptWithCounter(nonce []byte, counter uint64, ciphertext HHESoK.Ciphertext) HHESoK.Plaintext {
+	return HHESoK.CTRDecrypt(&enc.rub, nonce, counter, ciphertext)
+}
 
-	for i := 0; i < numBlock; i++ {
-		z := make(HHESoK.Block, ksSize)
-		binary.BigEndian.PutUint64(counter, uint64(i+1))
-		copy(z, enc.rub.KeyStream(nonces[i], counter))
-		if z[i] > plaintext[i] {
-			plaintext[i] += modulus
-		}
-		plaintext[i] = plaintext[i] - z[i]
-	}
+// EncryptRandomNonce encrypts plaintext vector under a fresh random nonce,
+// the nonce is prepended to the ciphertext as HHESoK.NonceElements field elements
+func (enc encryptor) EncryptRandomNonce(plaintext HHESoK.Plaintext) (HHESoK.Ciphertext, error) {
+	return HHESoK.CTREncryptRandomNonce(&enc.rub, plaintext)
+}
 
-	return plaintext
+// DecryptRandomNonce decrypts a ciphertext produced by EncryptRandomNonce
+func (enc encryptor) DecryptRandomNonce(ciphertext HHESoK.Ciphertext) (HHESoK.Plaintext, error) {
+	return HHESoK.CTRDecryptRandomNonce(&enc.rub, ciphertext)
 }
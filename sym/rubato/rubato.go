@@ -5,12 +5,13 @@ import (
 	"HHESoK/rtf_ckks_integration/ckks_fv"
 	"HHESoK/rtf_ckks_integration/ring"
 	"HHESoK/rtf_ckks_integration/utils"
+	"fmt"
 	"golang.org/x/crypto/sha3"
 )
 
+// Rubato is a NoisyCipher, its KeyStream is noiseless and the encryption adds fresh Gaussian noise
 type Rubato interface {
-	NewEncryptor() Encryptor
-	KeyStream(nonce []byte, counter []byte) HHESoK.Block
+	HHESoK.NoisyCipher
 }
 
 type rubato struct {
@@ -20,46 +21,97 @@ type rubato struct {
 	state     HHESoK.Block
 	rcs       HHESoK.Matrix
 	p         uint64
+	mod       HHESoK.Modulus
+	keyMForm  HHESoK.Block
 	sampler   *ring.GaussianSampler
 }
 
-// NewRubato return a new instance of Rubato cipher
-func NewRubato(secretKey HHESoK.Key, params Parameter) Rubato {
+// NewRubato returns a new instance of Rubato cipher, the secret key holds block size elements
+func NewRubato(secretKey HHESoK.Key, params Parameter) (Rubato, error) {
+	if bs := params.GetBlockSize(); bs != 16 && bs != 36 && bs != 64 {
+		return nil, fmt.Errorf("%w: Rubato block size %d, want 16, 36 or 64", HHESoK.ErrInvalidParameters, bs)
+	}
+	// the round constants are sampled from at least one bit, which rules out Z_2
+	if params.GetModulus() < 3 {
+		return nil, fmt.Errorf("%w: Rubato modulus %d, want at least 3", HHESoK.ErrInvalidParameters, params.GetModulus())
+	}
 	if len(secretKey) != params.GetBlockSize() {
-		panic("Invalid Key Length!")
+		return nil, fmt.Errorf("%w: got %d elements, want %d", HHESoK.ErrKeyLength, len(secretKey), params.GetBlockSize())
 	}
 
 	state := make(HHESoK.Block, params.GetBlockSize())
+	mod, err := HHESoK.NewModulus(params.GetModulus())
+	if err != nil {
+		return nil, err
+	}
+	// the key multiplies every round constant, keep it in the Montgomery domain
+	keyMForm := make(HHESoK.Block, len(secretKey))
+	for i := range secretKey {
+		keyMForm[i] = mod.MForm(mod.Reduce(secretKey[i]))
+	}
+	prng, err := utils.NewPRNG()
+	if err != nil {
+		return nil, err
+	}
 	rub := &rubato{
 		params:    params,
 		shake:     nil,
 		secretKey: secretKey,
 		state:     state,
 		p:         params.GetModulus(),
+		mod:       mod,
+		keyMForm:  keyMForm,
 		rcs:       nil,
-		sampler:   nil,
+		sampler:   ring.NewGaussianSampler(prng),
 	}
+	return rub, nil
+}
+
+// MustNewRubato is NewRubato panicking on invalid parameters or key
+func MustNewRubato(secretKey HHESoK.Key, params Parameter) Rubato {
+	rub, err := NewRubato(secretKey, params)
+	HHESoK.HandleError(err)
 	return rub
 }
 
+// ShallowCopy returns a new instance sharing the secret key and parameters,
+// with fresh internal states that can be used on another goroutine
+func (rub *rubato) ShallowCopy() HHESoK.SymmetricCipher {
+	return MustNewRubato(rub.secretKey, rub.params)
+}
+
 func (rub *rubato) NewEncryptor() Encryptor {
 	return &encryptor{rub: *rub}
 }
 
-// KeyStream returns a vector of [BlockSize - 4][uint64] elements as key stream
+// GetKeySize returns the secret key size, equal to the block size
+func (rub *rubato) GetKeySize() int {
+	return rub.params.GetBlockSize()
+}
+
+// GetKeyStreamSize returns the key stream size of one block, Rubato truncates the last 4 elements
+func (rub *rubato) GetKeyStreamSize() int {
+	return rub.params.GetBlockSize() - 4
+}
+
+// GetModulus returns the plaintext modulus
+func (rub *rubato) GetModulus() uint64 {
+	return rub.params.GetModulus()
+}
+
+// KeyStream returns a vector of [BlockSize - 4][uint64] elements as key stream, without the
+// Gaussian noise that AddNoise samples for every encryption
 func (rub *rubato) KeyStream(nonce []byte, counter []byte) (ks HHESoK.Block) {
-	p := rub.params.GetModulus()
 	rounds := rub.params.GetRounds()
 	blockSize := rub.params.GetBlockSize()
 
 	rub.initShake(nonce, counter)
 	rub.initState()
-	rub.initGuSampler()
 	rub.generateRCs()
 
 	// Initial AddRoundKey
 	for i := 0; i < blockSize; i++ {
-		rub.state[i] = (rub.state[i] + rub.rcs[0][i]) % p
+		rub.state[i] = rub.mod.Add(rub.state[i], rub.rcs[0][i])
 	}
 
 	// Round Functions
@@ -67,7 +119,7 @@ func (rub *rubato) KeyStream(nonce []byte, counter []byte) (ks HHESoK.Block) {
 		rub.linearLayer()
 		rub.sBoxFeistel()
 		for i := 0; i < blockSize; i++ {
-			rub.state[i] = (rub.state[i] + rub.rcs[r][i]) % p
+			rub.state[i] = rub.mod.Add(rub.state[i], rub.rcs[r][i])
 		}
 	}
 
@@ -75,13 +127,8 @@ func (rub *rubato) KeyStream(nonce []byte, counter []byte) (ks HHESoK.Block) {
 	rub.linearLayer()
 	rub.sBoxFeistel()
 	rub.linearLayer()
-	// adding this noise will change the key randomly !!
-	// cause lost very small part of plaintext
-	if rub.params.GetSigma() > 0 {
-		rub.addGaussianNoise()
-	}
 	for i := 0; i < blockSize; i++ {
-		rub.state[i] = (rub.state[i] + rub.rcs[rounds][i]) % p
+		rub.state[i] = rub.mod.Add(rub.state[i], rub.rcs[rounds][i])
 	}
 	ks = rub.state[0 : blockSize-4]
 	return
@@ -95,25 +142,13 @@ func (rub *rubato) initState() {
 
 func (rub *rubato) initShake(nonce []byte, counter []byte) {
 	shake := sha3.NewShake256()
-	if _, err := shake.Write(nonce); err != nil {
-		panic("Failed to init SHAKE128!")
-	}
-	if _, err := shake.Write(counter); err != nil {
-		panic("Failed to init SHAKE128!")
-	}
+	// writes to a SHAKE state never fail
+	_, _ = shake.Write(nonce)
+	_, _ = shake.Write(counter)
 	rub.shake = shake
 }
 
-func (rub *rubato) initGuSampler() {
-	prng, err := utils.NewPRNG()
-	if err != nil {
-		panic(err)
-	}
-	rub.sampler = ring.NewGaussianSampler(prng)
-}
-
 func (rub *rubato) generateRCs() {
-	key := rub.secretKey
 	blockSize := rub.params.GetBlockSize()
 	p := rub.params.GetModulus()
 	rounds := rub.params.GetRounds()
@@ -122,7 +157,7 @@ func (rub *rubato) generateRCs() {
 	for r := 0; r <= rounds; r++ {
 		rcs[r] = make([]uint64, blockSize)
 		for i := 0; i < blockSize; i++ {
-			rcs[r][i] = ckks_fv.SampleZqx(rub.shake, p) * key[i] % p
+			rcs[r][i] = rub.mod.MulMForm(ckks_fv.SampleZqx(rub.shake, p), rub.keyMForm[i])
 		}
 	}
 	rub.rcs = rcs
@@ -130,10 +165,11 @@ func (rub *rubato) generateRCs() {
 
 func (rub *rubato) linearLayer() {
 	blockSize := len(rub.state)
-	p := rub.params.GetModulus()
 	buf := make(HHESoK.Block, blockSize)
 
-	if blockSize == 16 {
+	// NewRubato only accepts the block sizes 16, 36 and 64
+	switch blockSize {
+	case 16:
 		// MixColumns
 		for row := 0; row < 4; row++ {
 			for col := 0; col < 4; col++ {
@@ -141,7 +177,7 @@ func (rub *rubato) linearLayer() {
 				buf[row*4+col] += 3 * rub.state[((row+1)%4)*4+col]
 				buf[row*4+col] += rub.state[((row+2)%4)*4+col]
 				buf[row*4+col] += rub.state[((row+3)%4)*4+col]
-				buf[row*4+col] %= p
+				buf[row*4+col] = rub.mod.Reduce(buf[row*4+col])
 			}
 		}
 		// MixRows
@@ -151,10 +187,10 @@ func (rub *rubato) linearLayer() {
 				rub.state[row*4+col] += 3 * buf[row*4+(col+1)%4]
 				rub.state[row*4+col] += buf[row*4+(col+2)%4]
 				rub.state[row*4+col] += buf[row*4+(col+3)%4]
-				rub.state[row*4+col] %= p
+				rub.state[row*4+col] = rub.mod.Reduce(rub.state[row*4+col])
 			}
 		}
-	} else if blockSize == 36 {
+	case 36:
 		// MixColumns
 		for row := 0; row < 6; row++ {
 			for col := 0; col < 6; col++ {
@@ -164,7 +200,7 @@ func (rub *rubato) linearLayer() {
 				buf[row*6+col] += 3 * rub.state[((row+3)%6)*6+col]
 				buf[row*6+col] += rub.state[((row+4)%6)*6+col]
 				buf[row*6+col] += rub.state[((row+5)%6)*6+col]
-				buf[row*6+col] %= p
+				buf[row*6+col] = rub.mod.Reduce(buf[row*6+col])
 			}
 		}
 		// MixRows
@@ -176,10 +212,10 @@ func (rub *rubato) linearLayer() {
 				rub.state[row*6+col] += 3 * buf[row*6+(col+3)%6]
 				rub.state[row*6+col] += buf[row*6+(col+4)%6]
 				rub.state[row*6+col] += buf[row*6+(col+5)%6]
-				rub.state[row*6+col] %= p
+				rub.state[row*6+col] = rub.mod.Reduce(rub.state[row*6+col])
 			}
 		}
-	} else if blockSize == 64 {
+	case 64:
 		// MixColumns
 		for row := 0; row < 8; row++ {
 			for col := 0; col < 8; col++ {
@@ -191,7 +227,7 @@ func (rub *rubato) linearLayer() {
 				buf[row*8+col] += 2 * rub.state[((row+5)%8)*8+col]
 				buf[row*8+col] += rub.state[((row+6)%8)*8+col]
 				buf[row*8+col] += rub.state[((row+7)%8)*8+col]
-				buf[row*8+col] %= p
+				buf[row*8+col] = rub.mod.Reduce(buf[row*8+col])
 			}
 		}
 		// MixRows
@@ -205,16 +241,13 @@ func (rub *rubato) linearLayer() {
 				rub.state[row*8+col] += 2 * buf[row*8+(col+5)%8]
 				rub.state[row*8+col] += buf[row*8+(col+6)%8]
 				rub.state[row*8+col] += buf[row*8+(col+7)%8]
-				rub.state[row*8+col] %= p
+				rub.state[row*8+col] = rub.mod.Reduce(rub.state[row*8+col])
 			}
 		}
-	} else {
-		panic("Invalid block size!")
 	}
 }
 
 func (rub *rubato) sBoxFeistel() {
-	p := rub.params.GetModulus()
 	blockSize := rub.params.GetBlockSize()
 	buf := make(HHESoK.Block, blockSize)
 
@@ -223,11 +256,17 @@ func (rub *rubato) sBoxFeistel() {
 	}
 
 	for i := 1; i < blockSize; i++ {
-		rub.state[i] = (buf[i] + buf[i-1]*buf[i-1]) % p
+		rub.state[i] = rub.mod.Add(buf[i], rub.mod.Mul(buf[i-1], buf[i-1]))
 	}
 }
 
-func (rub *rubato) addGaussianNoise() {
+// AddNoise adds fresh Gaussian noise of standard deviation sigma, bounded by 6 sigma, to every
+// element of vec. The reference adds it to the state before the last round constants, which is
+// the same as adding it to the masked vector
+func (rub *rubato) AddNoise(vec []uint64) {
+	if rub.params.GetSigma() <= 0 {
+		return
+	}
 	bound := int(6 * rub.params.GetSigma())
-	rub.sampler.AGN(rub.state, rub.p, rub.params.GetSigma(), bound)
+	rub.sampler.AGN(vec, rub.p, rub.params.GetSigma(), bound)
 }
@@ -24,7 +24,14 @@ func benchmarkRubato(tc *TestContext, b *testing.B) {
 
 	b.Run("Rubato/NewRubato", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			rubatoCipher = NewRubato(tc.Key, tc.Params)
+			rubatoCipher = MustNewRubato(tc.Key, tc.Params)
+		}
+	})
+
+	b.Run("Rubato/KeyStream", func(b *testing.B) {
+		nonce := HHESoK.DefaultNonce()
+		for i := 0; i < b.N; i++ {
+			rubatoCipher.KeyStream(nonce, HHESoK.CounterBytes(uint64(i)))
 		}
 	})
 
@@ -3,6 +3,7 @@ package rubato
 import (
 	"HHESoK"
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -15,7 +16,7 @@ func TestRubato(t *testing.T) {
 	logger := HHESoK.NewLogger(HHESoK.DEBUG)
 	for _, tc := range TestsVector {
 		fmt.Println(testString("Rubato", tc.Params))
-		rubatoCipher := NewRubato(tc.Key, tc.Params)
+		rubatoCipher := MustNewRubato(tc.Key, tc.Params)
 		encryptor := rubatoCipher.NewEncryptor()
 		var ciphertext HHESoK.Ciphertext
 
@@ -25,10 +26,47 @@ func TestRubato(t *testing.T) {
 		})
 
 		t.Run("RubatoDecryptionTest", func(t *testing.T) {
-			encryptor.Decrypt(ciphertext)
+			decrypted := encryptor.Decrypt(ciphertext)
+			modulus, bound := tc.Params.GetModulus(), uint64(6*tc.Params.GetSigma())
+			for i := range tc.Plaintext {
+				if d := (decrypted[i] + modulus - tc.Plaintext[i]) % modulus; d > bound && modulus-d > bound {
+					t.Fatalf("element %d: error %d outside [-%d, %d]", i, d, bound, bound)
+				}
+			}
 		})
 
 		logger.PrintDataLen(tc.Key)
 		logger.PrintDataLen(ciphertext)
 	}
 }
+
+// TestRubatoNoise checks that the key stream is the same for every instance under the same key
+// and that the encryption adds fresh noise within 6 sigma of it
+func TestRubatoNoise(t *testing.T) {
+	tc := TestsVector[0]
+	nonce := HHESoK.NewNonce()
+	modulus := tc.Params.GetModulus()
+	bound := uint64(6 * tc.Params.GetSigma())
+
+	rub := MustNewRubato(tc.Key, tc.Params)
+	ks := append(HHESoK.Block(nil), rub.KeyStream(nonce, HHESoK.CounterBytes(0))...)
+	if again := MustNewRubato(tc.Key, tc.Params).KeyStream(nonce, HHESoK.CounterBytes(0)); !reflect.DeepEqual(ks, again) {
+		t.Fatal("two instances give different key streams under the same key, nonce and counter")
+	}
+
+	plaintext := make(HHESoK.Plaintext, len(ks))
+	encryptor := rub.NewEncryptor()
+	ct0 := encryptor.EncryptWithNonce(nonce, plaintext)
+	ct1 := encryptor.EncryptWithNonce(nonce, plaintext)
+	for i := range ks {
+		for _, ct := range []HHESoK.Ciphertext{ct0, ct1} {
+			d := (ct[i] + modulus - ks[i]) % modulus
+			if d > bound && modulus-d > bound {
+				t.Fatalf("element %d: noise %d outside [-%d, %d]", i, d, bound, bound)
+			}
+		}
+	}
+	if reflect.DeepEqual(ct0, ct1) {
+		t.Fatal("two encryptions under the same nonce have the same noise")
+	}
+}